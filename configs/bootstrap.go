@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -28,6 +29,9 @@ func NewApp(ctx context.Context, dbString string, r chi.Router) *App {
 
 	SetupRouter(r, serviceCfg)
 
+	serviceCfg.OrderService.StartExpiryWorker(time.Minute)
+	serviceCfg.DeviceService.StartQuoteExpiryWorker(ctx, time.Minute)
+
 	return &App{
 		Router:  r,
 		Repo:    repoCfg,
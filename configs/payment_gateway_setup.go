@@ -0,0 +1,31 @@
+package configs
+
+import (
+	"backEnd-RingoTechLife/internal/payment"
+	"log"
+	"os"
+)
+
+// newPaymentGateway pilih implementasi payment gateway dari env.
+// PAYMENT_GATEWAY=fake (default) buat lokal, selain itu pake RestGateway
+// dengan PAYMENT_GATEWAY_URL, PAYMENT_GATEWAY_SERVER_KEY & PAYMENT_GATEWAY_CALLBACK_SECRET.
+func newPaymentGateway() payment.PaymentGateway {
+
+	name := os.Getenv("PAYMENT_GATEWAY")
+	secret := os.Getenv("PAYMENT_GATEWAY_CALLBACK_SECRET")
+
+	if name == "" || name == "fake" {
+		if secret == "" {
+			secret = "fake-callback-secret"
+		}
+		log.Println("payment gateway: pakai fake gateway")
+		return payment.NewFakeGateway(secret)
+	}
+
+	return payment.NewRestGateway(
+		name,
+		os.Getenv("PAYMENT_GATEWAY_URL"),
+		os.Getenv("PAYMENT_GATEWAY_SERVER_KEY"),
+		secret,
+	)
+}
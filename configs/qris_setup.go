@@ -0,0 +1,30 @@
+package configs
+
+import (
+	"backEnd-RingoTechLife/internal/qris"
+	"log"
+	"os"
+)
+
+// newQRISMerchant baca QRIS statis merchant dari QRIS_MERCHANT_PAYLOAD.
+// payload nya divalidasi pas startup, kalau rusak server ga jalan biar
+// ga ada QR yang salah kekirim ke customer.
+func newQRISMerchant() *qris.Payload {
+
+	raw := os.Getenv("QRIS_MERCHANT_PAYLOAD")
+	if raw == "" {
+		log.Println("QRIS_MERCHANT_PAYLOAD kosong, pembayaran QRIS dimatikan")
+		return nil
+	}
+
+	payload, err := qris.Parse(raw)
+	if err != nil {
+		panic("QRIS_MERCHANT_PAYLOAD tidak valid: " + err.Error())
+	}
+
+	if payload.IsDynamic() {
+		panic("QRIS_MERCHANT_PAYLOAD harus QRIS statis (tag 01 = 11)")
+	}
+
+	return &payload
+}
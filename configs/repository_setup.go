@@ -1,14 +1,21 @@
 package configs
 
 import (
+	"backEnd-RingoTechLife/internal/cart"
 	"backEnd-RingoTechLife/internal/category"
+	"backEnd-RingoTechLife/internal/inventory"
 	"backEnd-RingoTechLife/internal/order"
 	"backEnd-RingoTechLife/internal/payment"
 	"backEnd-RingoTechLife/internal/productimage"
 	"backEnd-RingoTechLife/internal/products"
+	"backEnd-RingoTechLife/internal/productunit"
+	"backEnd-RingoTechLife/internal/refund"
 	"backEnd-RingoTechLife/internal/review"
+	"backEnd-RingoTechLife/internal/rma"
 	"backEnd-RingoTechLife/internal/servicerequest"
+	"backEnd-RingoTechLife/internal/shipping"
 	"backEnd-RingoTechLife/internal/user"
+	"backEnd-RingoTechLife/internal/voucher"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -22,6 +29,14 @@ type RepositoryConfigs struct {
 	OrderRepository         *order.OrderRepositoryImpl
 	PaymentRepository       *payment.PaymentRepositoryImpl
 	DeviceRequestRepository *servicerequest.ServiceRequestRepository
+	CartRepository          *cart.CartRepositoryImpl
+	AddressRepository       *user.AddressRepositoryImpl
+	ShippingRepository      *shipping.ShippingRepositoryImpl
+	VoucherRepository       *voucher.VoucherRepositoryImpl
+	RefundRepository        *refund.RefundRepositoryImpl
+	ReturnRepository        *rma.ReturnRepositoryImpl
+	InventoryRepository     *inventory.InventoryRepositoryImpl
+	ProductUnitRepository   *productunit.ProductUnitRepositoryImpl
 }
 
 func NewRepositoryConfigs(pool *pgxpool.Pool) *RepositoryConfigs {
@@ -34,6 +49,14 @@ func NewRepositoryConfigs(pool *pgxpool.Pool) *RepositoryConfigs {
 	orderRepo := order.NewOrderRepository(pool)
 	paymentRepo := payment.NewPaymentRepository(pool)
 	ServiceRequestRepo := servicerequest.NewServiceRequestRepository(pool)
+	cartRepo := cart.NewCartRepository(pool)
+	addressRepo := user.NewAddressRepository(pool)
+	shippingRepo := shipping.NewShippingRepository(pool)
+	voucherRepo := voucher.NewVoucherRepository(pool)
+	refundRepo := refund.NewRefundRepository(pool)
+	returnRepo := rma.NewReturnRepository(pool)
+	inventoryRepo := inventory.NewInventoryRepository(pool)
+	productUnitRepo := productunit.NewProductUnitRepository(pool)
 
 	return &RepositoryConfigs{
 		UserRepository:          userRepo,
@@ -44,6 +67,14 @@ func NewRepositoryConfigs(pool *pgxpool.Pool) *RepositoryConfigs {
 		OrderRepository:         orderRepo,
 		PaymentRepository:       paymentRepo,
 		DeviceRequestRepository: ServiceRequestRepo,
+		CartRepository:          cartRepo,
+		AddressRepository:       addressRepo,
+		ShippingRepository:      shippingRepo,
+		VoucherRepository:       voucherRepo,
+		RefundRepository:        refundRepo,
+		ReturnRepository:        returnRepo,
+		InventoryRepository:     inventoryRepo,
+		ProductUnitRepository:   productUnitRepo,
 	}
 
 }
@@ -2,14 +2,21 @@ package configs
 
 import (
 	"backEnd-RingoTechLife/internal/auth"
+	"backEnd-RingoTechLife/internal/cart"
 	"backEnd-RingoTechLife/internal/category"
 	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/inventory"
 	"backEnd-RingoTechLife/internal/order"
 	"backEnd-RingoTechLife/internal/payment"
 	"backEnd-RingoTechLife/internal/products"
+	"backEnd-RingoTechLife/internal/productunit"
+	"backEnd-RingoTechLife/internal/refund"
 	"backEnd-RingoTechLife/internal/review"
+	"backEnd-RingoTechLife/internal/rma"
 	"backEnd-RingoTechLife/internal/servicerequest"
+	"backEnd-RingoTechLife/internal/shipping"
 	"backEnd-RingoTechLife/internal/user"
+	"backEnd-RingoTechLife/internal/voucher"
 	"backEnd-RingoTechLife/pkg"
 	"encoding/json"
 	"log"
@@ -29,18 +36,26 @@ func SetupRouter(r chi.Router, svcCfg *ServiceConfigs) {
 	validator := validator.New()
 	validator.RegisterValidation("phoneID", pkg.PhoneID)
 	validator.RegisterValidation("slug", pkg.SlugValidator)
+	validator.RegisterValidation("imei", pkg.IMEIValidator)
 
 	// ==== form decoder
 	decoder := form.NewDecoder()
 
 	authHandler := auth.NewAuthHandler(svcCfg.AuthService, validator)
-	userHandler := user.NewUserHandler(svcCfg.UserService, decoder, validator)
+	userHandler := user.NewUserHandler(svcCfg.UserService, svcCfg.AddressService, decoder, validator)
 	categoryHandler := category.NewCategoryHandler(svcCfg.CategoryService, validator)
 	productHandler := products.NewProductsHandler(svcCfg.ProductService, decoder, validator)
 	reviewHandler := review.NewReviewHandler(svcCfg.ReviewService, validator)
 	orderHandler := order.NewOrderHandler(svcCfg.OrderService, validator)
 	paymentHandler := payment.NewPaymentHandler(svcCfg.PaymentService, decoder, validator)
 	deviceServiceHandler := servicerequest.NewServiceRequestHandler(svcCfg.DeviceService, decoder, validator)
+	cartHandler := cart.NewCartHandler(svcCfg.CartService, validator)
+	shippingHandler := shipping.NewShippingHandler(svcCfg.ShippingService, validator)
+	voucherHandler := voucher.NewVoucherHandler(svcCfg.VoucherService, validator)
+	refundHandler := refund.NewRefundHandler(svcCfg.RefundService, decoder, validator)
+	returnHandler := rma.NewReturnHandler(svcCfg.ReturnService, decoder, validator)
+	inventoryHandler := inventory.NewInventoryHandler(svcCfg.InventoryService)
+	productUnitHandler := productunit.NewProductUnitHandler(svcCfg.ProductUnitService, validator)
 
 	fileServer := http.FileServer(http.Dir(svcCfg.ServerStorage.Public))
 
@@ -76,6 +91,13 @@ func SetupRouter(r chi.Router, svcCfg *ServiceConfigs) {
 		orderHandler.SetUpRoute(r)
 		paymentHandler.SetupRoute(r)
 		deviceServiceHandler.SetUpRoute(r)
+		cartHandler.SetUpRoute(r)
+		shippingHandler.SetUpRoute(r)
+		voucherHandler.SetUpRoute(r)
+		refundHandler.SetUpRoute(r)
+		returnHandler.SetUpRoute(r)
+		inventoryHandler.SetUpRoute(r)
+		productUnitHandler.SetUpRoute(r)
 	})
 
 	r.Handle("/uploads/public/*", http.StripPrefix("/uploads/public/", fileServer))
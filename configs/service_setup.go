@@ -2,28 +2,43 @@ package configs
 
 import (
 	"backEnd-RingoTechLife/internal/auth"
+	"backEnd-RingoTechLife/internal/cart"
 	"backEnd-RingoTechLife/internal/category"
+	"backEnd-RingoTechLife/internal/inventory"
 	"backEnd-RingoTechLife/internal/order"
 	"backEnd-RingoTechLife/internal/payment"
 	"backEnd-RingoTechLife/internal/productimage"
 	"backEnd-RingoTechLife/internal/products"
+	"backEnd-RingoTechLife/internal/productunit"
+	"backEnd-RingoTechLife/internal/refund"
 	"backEnd-RingoTechLife/internal/review"
+	"backEnd-RingoTechLife/internal/rma"
 	"backEnd-RingoTechLife/internal/servicerequest"
+	"backEnd-RingoTechLife/internal/shipping"
 	"backEnd-RingoTechLife/internal/storage"
 	"backEnd-RingoTechLife/internal/user"
+	"backEnd-RingoTechLife/internal/voucher"
 	"context"
 )
 
 type ServiceConfigs struct {
-	AuthService     *auth.AuthService
-	UserService     *user.UserService
-	ServerStorage   *storage.FileStorage
-	CategoryService *category.CategoryService
-	ProductService  *products.ProductsService
-	ReviewService   *review.ReviewService
-	OrderService    *order.OrderService
-	PaymentService  *payment.PayementService
-	DeviceService   *servicerequest.DeviceService
+	AuthService        *auth.AuthService
+	UserService        *user.UserService
+	ServerStorage      *storage.FileStorage
+	CategoryService    *category.CategoryService
+	ProductService     *products.ProductsService
+	ReviewService      *review.ReviewService
+	OrderService       *order.OrderService
+	PaymentService     *payment.PayementService
+	DeviceService      *servicerequest.DeviceService
+	CartService        *cart.CartService
+	AddressService     *user.AddressService
+	ShippingService    *shipping.ShippingService
+	VoucherService     *voucher.VoucherService
+	RefundService      *refund.RefundService
+	ReturnService      *rma.ReturnService
+	InventoryService   *inventory.InventoryService
+	ProductUnitService *productunit.ProductUnitService
 }
 
 func NewServiceConfigs(rcf *RepositoryConfigs, serverStorage *storage.FileStorage) *ServiceConfigs {
@@ -31,26 +46,42 @@ func NewServiceConfigs(rcf *RepositoryConfigs, serverStorage *storage.FileStorag
 	serviceContext := context.Background()
 
 	userSvc := user.NewUserService(rcf.UserRepository, serverStorage)
+	addressSvc := user.NewAddressService(rcf.AddressRepository)
+	shippingSvc := shipping.NewShippingService(rcf.ShippingRepository, shipping.NewTableRateProvider(rcf.ShippingRepository))
+	voucherSvc := voucher.NewVoucherService(rcf.VoucherRepository)
 	authSvc := auth.NewAuthService(userSvc)
 	categorySvc := category.NewCategoryService(rcf.CategoryRepository)
 	productImageSvc := productimage.NewProductImageService(rcf.ProductImageRepository, serverStorage)
 	productSvc := products.NewProductsService(rcf.ProductsRepository, serverStorage, productImageSvc)
 	reviewsSvc := review.NewReviewService(rcf.ReviewRepository)
-	orderSvc := order.NewOrderService(rcf.OrderRepository, productSvc, serviceContext)
-	paymentSvc := payment.NewPaymentService(rcf.PaymentRepository, serverStorage, orderSvc)
+	orderSvc := order.NewOrderService(rcf.OrderRepository, productSvc, addressSvc, shippingSvc, newStoreIdentity(), serviceContext)
+	paymentSvc := payment.NewPaymentService(rcf.PaymentRepository, serverStorage, orderSvc, newPaymentGateway(), newQRISMerchant())
 
 	deviceServiceSvc := servicerequest.NewDeviceService(rcf.DeviceRequestRepository, *serverStorage, orderSvc)
+	refundSvc := refund.NewRefundService(rcf.RefundRepository, serverStorage)
+	returnSvc := rma.NewReturnService(rcf.ReturnRepository, serverStorage)
+	inventorySvc := inventory.NewInventoryService(rcf.InventoryRepository)
+	productUnitSvc := productunit.NewProductUnitService(rcf.ProductUnitRepository)
+	cartSvc := cart.NewCartService(rcf.CartRepository, productSvc, orderSvc, addressSvc, shippingSvc, voucherSvc)
 
 	return &ServiceConfigs{
-		AuthService:     authSvc,
-		UserService:     userSvc,
-		ServerStorage:   serverStorage,
-		CategoryService: categorySvc,
-		ProductService:  productSvc,
-		ReviewService:   reviewsSvc,
-		OrderService:    orderSvc,
-		PaymentService:  paymentSvc,
-		DeviceService:   deviceServiceSvc,
+		AuthService:        authSvc,
+		UserService:        userSvc,
+		ServerStorage:      serverStorage,
+		CategoryService:    categorySvc,
+		ProductService:     productSvc,
+		ReviewService:      reviewsSvc,
+		OrderService:       orderSvc,
+		PaymentService:     paymentSvc,
+		DeviceService:      deviceServiceSvc,
+		CartService:        cartSvc,
+		AddressService:     addressSvc,
+		ShippingService:    shippingSvc,
+		VoucherService:     voucherSvc,
+		RefundService:      refundSvc,
+		ReturnService:      returnSvc,
+		InventoryService:   inventorySvc,
+		ProductUnitService: productUnitSvc,
 	}
 
 }
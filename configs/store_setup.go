@@ -0,0 +1,23 @@
+package configs
+
+import (
+	"backEnd-RingoTechLife/internal/invoice"
+	"os"
+)
+
+// newStoreIdentity identitas toko buat kop invoice & kwitansi, dari env STORE_*
+func newStoreIdentity() invoice.Store {
+
+	name := os.Getenv("STORE_NAME")
+	if name == "" {
+		name = "RingoTechLife"
+	}
+
+	return invoice.Store{
+		Name:    name,
+		Address: os.Getenv("STORE_ADDRESS"),
+		Phone:   os.Getenv("STORE_PHONE"),
+		Email:   os.Getenv("STORE_EMAIL"),
+		TaxID:   os.Getenv("STORE_TAX_ID"),
+	}
+}
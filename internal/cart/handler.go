@@ -0,0 +1,294 @@
+package cart
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/middleware"
+	"backEnd-RingoTechLife/pkg"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type CartHandler struct {
+	cartService *CartService
+	validator   *validator.Validate
+}
+
+func NewCartHandler(csvc *CartService, vld *validator.Validate) *CartHandler {
+	return &CartHandler{
+		cartService: csvc,
+		validator:   vld,
+	}
+}
+
+func (ch *CartHandler) GetMyCartHandler(w http.ResponseWriter, r *http.Request) {
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, err := ch.cartService.GetCart(r.Context(), userId)
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (ch *CartHandler) AddItemHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.AddCartItemRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data keranjang dengan benar!")
+		return
+	}
+
+	if err := ch.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	productId, err := uuid.Parse(req.ProductId)
+	if err != nil {
+		pkg.JSONError(w, 400, "product id tidak valid!")
+		return
+	}
+
+	var variantId *uuid.UUID
+	if req.VariantId != nil {
+		parsed, err := uuid.Parse(*req.VariantId)
+		if err != nil {
+			pkg.JSONError(w, 400, "variant id tidak valid!")
+			return
+		}
+		variantId = &parsed
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, addErr := ch.cartService.AddItem(r.Context(), userId, productId, variantId, req.Quantity)
+	if addErr != nil {
+		pkg.JSONError(w, addErr.Code, addErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menambahkan ke keranjang", data)
+}
+
+func (ch *CartHandler) UpdateItemHandler(w http.ResponseWriter, r *http.Request) {
+
+	itemId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.UpdateCartItemRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data keranjang dengan benar!")
+		return
+	}
+
+	if err := ch.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, updateErr := ch.cartService.UpdateItem(r.Context(), userId, itemId, req.Quantity)
+	if updateErr != nil {
+		pkg.JSONError(w, updateErr.Code, updateErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengupdate keranjang", data)
+}
+
+func (ch *CartHandler) RemoveItemHandler(w http.ResponseWriter, r *http.Request) {
+
+	itemId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, delErr := ch.cartService.RemoveItem(r.Context(), userId, itemId)
+	if delErr != nil {
+		pkg.JSONError(w, delErr.Code, delErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menghapus item dari keranjang", data)
+}
+
+func (ch *CartHandler) ClearHandler(w http.ResponseWriter, r *http.Request) {
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	if err := ch.cartService.Clear(r.Context(), userId); err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengosongkan keranjang", nil)
+}
+
+func (ch *CartHandler) ShippingQuoteHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.ShippingQuoteRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data alamat dengan benar!")
+		return
+	}
+
+	if err := ch.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	var addressId *uuid.UUID
+	if req.AddressId != nil {
+		parsed, err := uuid.Parse(*req.AddressId)
+		if err != nil {
+			pkg.JSONError(w, 400, "address id tidak valid!")
+			return
+		}
+		addressId = &parsed
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, quoteErr := ch.cartService.QuoteShipping(r.Context(), userId, addressId)
+	if quoteErr != nil {
+		pkg.JSONError(w, quoteErr.Code, quoteErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menghitung ongkir", data)
+}
+
+func (ch *CartHandler) PreviewHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.CheckoutPreviewRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data checkout dengan benar!")
+		return
+	}
+
+	if err := ch.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	var addressId *uuid.UUID
+	if req.AddressId != nil {
+		parsed, err := uuid.Parse(*req.AddressId)
+		if err != nil {
+			pkg.JSONError(w, 400, "address id tidak valid!")
+			return
+		}
+		addressId = &parsed
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, previewErr := ch.cartService.Preview(r.Context(), userId, addressId, req.VoucherCode)
+	if previewErr != nil {
+		pkg.JSONError(w, previewErr.Code, previewErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menghitung rincian checkout", data)
+}
+
+func (ch *CartHandler) CheckoutHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.CheckoutRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data checkout dengan benar!")
+		return
+	}
+
+	if err := ch.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	var notes string
+	if req.Notes != nil {
+		notes = *req.Notes
+	}
+
+	var addressId *uuid.UUID
+	if req.AddressId != nil {
+		parsed, err := uuid.Parse(*req.AddressId)
+		if err != nil {
+			pkg.JSONError(w, 400, "address id tidak valid!")
+			return
+		}
+		addressId = &parsed
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	result, failedLines, checkoutErr := ch.cartService.Checkout(r.Context(), userId, notes, addressId, req.VoucherCode)
+	if checkoutErr != nil {
+		if len(failedLines) != 0 {
+			pkg.JSONError(w, checkoutErr.Code, map[string]any{
+				"message":      checkoutErr.Message,
+				"failed_items": failedLines,
+			})
+			return
+		}
+		pkg.JSONError(w, checkoutErr.Code, checkoutErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil membuat order", result)
+}
+
+func (ch *CartHandler) SetUpRoute(router chi.Router) {
+
+	router.Route("/cart", func(r chi.Router) {
+		r.Use(httprate.Limit(
+			50,
+			time.Minute,
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				errorRes := common.NewErrorResponse(http.StatusTooManyRequests, "Terlalu banyak request, coba lagi nanti")
+				errorResJson, _ := json.Marshal(errorRes)
+				w.Write(errorResJson)
+			}),
+		))
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware(middleware.RoleAdmin, middleware.RoleUser))
+
+		r.Get("/my-cart", ch.GetMyCartHandler)
+		r.Post("/add", ch.AddItemHandler)
+		r.Put("/update/{id}", ch.UpdateItemHandler)
+		r.Delete("/delete/{id}", ch.RemoveItemHandler)
+		r.Delete("/clear", ch.ClearHandler)
+		r.Post("/shipping-quote", ch.ShippingQuoteHandler)
+		r.Post("/preview", ch.PreviewHandler)
+		r.Post("/checkout", ch.CheckoutHandler)
+	})
+}
@@ -0,0 +1,267 @@
+package cart
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrCartItemNotFound    = errors.New("item keranjang tidak ditemukan!")
+	ErrCartProductNotFound = errors.New("produk tidak ditemukan!")
+)
+
+type CartRepositoryInterface interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.CartItem, error)
+	GetItemByID(ctx context.Context, userID uuid.UUID, itemID uuid.UUID) (model.CartItem, error)
+	GetItemByProduct(ctx context.Context, userID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID) (model.CartItem, error)
+	AddItem(ctx context.Context, userID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID, quantity int) (*model.CartItem, error)
+	UpdateQuantity(ctx context.Context, userID uuid.UUID, itemID uuid.UUID, quantity int) error
+	RemoveItem(ctx context.Context, userID uuid.UUID, itemID uuid.UUID) error
+	Clear(ctx context.Context, userID uuid.UUID) error
+}
+
+type CartRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewCartRepository(pool *pgxpool.Pool) *CartRepositoryImpl {
+	return &CartRepositoryImpl{
+		db: pool,
+	}
+}
+
+// varian yang dinonaktifin dianggap status produk nya inactive biar ketahan di validasi checkout
+const cartItemDetailQuery = `
+	SELECT
+		ci.id, ci.user_id, ci.product_id, ci.variant_id, ci.quantity,
+		p.name,
+		(
+			SELECT string_agg(pov.value, ' / ' ORDER BY po.position, po.name)
+			FROM product_variant_values pvv
+			JOIN product_option_values pov ON pov.id = pvv.option_value_id
+			JOIN product_options po ON po.id = pov.option_id
+			WHERE pvv.variant_id = v.id
+		) AS variant_name,
+		ci.variant_id IS NULL AND EXISTS (
+			SELECT 1 FROM product_variants WHERE product_id = p.id
+		) AS needs_variant,
+		p.slug,
+		COALESCE(v.sku, p.sku),
+		COALESCE(v.price, p.price),
+		CASE WHEN p.is_bundle THEN bundle_available_stock(p.id)
+		     ELSE COALESCE(v.stock - v.reserved_stock, p.stock - p.reserved_stock) END,
+		COALESCE(v.weight, p.weight),
+		CASE WHEN v.id IS NOT NULL AND NOT v.is_active THEN 'inactive' ELSE p.status END,
+		COALESCE(
+			(SELECT vi.image_url FROM product_images vi WHERE vi.id = v.image_id),
+			(
+				SELECT pi.image_url
+				FROM product_images pi
+				WHERE pi.product_id = p.id
+				ORDER BY pi.is_primary DESC, pi.display_order ASC
+				LIMIT 1
+			)
+		) AS product_image,
+		ci.created_at, ci.updated_at
+	FROM cart_items ci
+	INNER JOIN products p ON p.id = ci.product_id
+	LEFT JOIN product_variants v ON v.id = ci.variant_id
+`
+
+func (r *CartRepositoryImpl) GetByUserID(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]model.CartItem, error) {
+	query := cartItemDetailQuery + `
+	WHERE ci.user_id = $1
+	ORDER BY ci.created_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.CartItem, 0)
+	for rows.Next() {
+		item, err := scanCartItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cart item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *CartRepositoryImpl) GetItemByID(
+	ctx context.Context,
+	userID uuid.UUID,
+	itemID uuid.UUID,
+) (model.CartItem, error) {
+	query := cartItemDetailQuery + `
+	WHERE ci.user_id = $1 AND ci.id = $2
+	`
+
+	item, err := scanCartItem(r.db.QueryRow(ctx, query, userID, itemID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.CartItem{}, ErrCartItemNotFound
+		}
+		return model.CartItem{}, err
+	}
+	return item, nil
+}
+
+func (r *CartRepositoryImpl) GetItemByProduct(
+	ctx context.Context,
+	userID uuid.UUID,
+	productID uuid.UUID,
+	variantID *uuid.UUID,
+) (model.CartItem, error) {
+	query := cartItemDetailQuery + `
+	WHERE ci.user_id = $1 AND ci.product_id = $2
+	  AND ci.variant_id IS NOT DISTINCT FROM $3
+	`
+
+	item, err := scanCartItem(r.db.QueryRow(ctx, query, userID, productID, variantID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.CartItem{}, ErrCartItemNotFound
+		}
+		return model.CartItem{}, err
+	}
+	return item, nil
+}
+
+// AddItem nambah produk (+ varian) ke keranjang, kalau kombinasi nya udah ada quantity nya ditambah
+func (r *CartRepositoryImpl) AddItem(
+	ctx context.Context,
+	userID uuid.UUID,
+	productID uuid.UUID,
+	variantID *uuid.UUID,
+	quantity int,
+) (*model.CartItem, error) {
+	query := `
+		INSERT INTO cart_items (user_id, product_id, variant_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid))
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity,
+		              updated_at = NOW()
+		RETURNING id, quantity, created_at, updated_at
+	`
+
+	item := model.CartItem{
+		UserID:    userID,
+		ProductID: productID,
+		VariantID: variantID,
+	}
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, userID, productID, variantID, quantity).
+			Scan(&item.ID, &item.Quantity, &item.CreatedAt, &item.UpdatedAt)
+	})
+
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok {
+			if pgErr.Code == "23503" {
+				return nil, ErrCartProductNotFound
+			}
+		}
+		return nil, fmt.Errorf("failed to add cart item: %w", err)
+	}
+
+	return &item, nil
+}
+
+func (r *CartRepositoryImpl) UpdateQuantity(
+	ctx context.Context,
+	userID uuid.UUID,
+	itemID uuid.UUID,
+	quantity int,
+) error {
+	query := `
+		UPDATE cart_items
+		SET quantity = $1, updated_at = NOW()
+		WHERE id = $2 AND user_id = $3
+	`
+
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, query, quantity, itemID, userID)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return ErrCartItemNotFound
+		}
+		return nil
+	})
+}
+
+func (r *CartRepositoryImpl) RemoveItem(
+	ctx context.Context,
+	userID uuid.UUID,
+	itemID uuid.UUID,
+) error {
+	query := `DELETE FROM cart_items WHERE id = $1 AND user_id = $2`
+
+	res, err := r.db.Exec(ctx, query, itemID, userID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (r *CartRepositoryImpl) Clear(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM cart_items WHERE user_id = $1`
+
+	_, err := r.db.Exec(ctx, query, userID)
+	return err
+}
+
+type scannable interface {
+	Scan(dest ...any) error
+}
+
+func scanCartItem(row scannable) (model.CartItem, error) {
+	var item model.CartItem
+	err := row.Scan(
+		&item.ID,
+		&item.UserID,
+		&item.ProductID,
+		&item.VariantID,
+		&item.Quantity,
+		&item.ProductName,
+		&item.VariantName,
+		&item.NeedsVariant,
+		&item.ProductSlug,
+		&item.ProductSKU,
+		&item.ProductPrice,
+		&item.ProductStock,
+		&item.ProductWeight,
+		&item.ProductStatus,
+		&item.ProductImage,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		return model.CartItem{}, err
+	}
+	item.Subtotal = item.ProductPrice.Mul(item.Quantity)
+	return item, nil
+}
@@ -0,0 +1,340 @@
+package cart
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/order"
+	"backEnd-RingoTechLife/internal/products"
+	"backEnd-RingoTechLife/internal/shipping"
+	"backEnd-RingoTechLife/internal/user"
+	"backEnd-RingoTechLife/internal/voucher"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+type CartService struct {
+	cartRepo        CartRepositoryInterface
+	productService  *products.ProductsService
+	orderService    *order.OrderService
+	addressService  *user.AddressService
+	shippingService *shipping.ShippingService
+	voucherService  *voucher.VoucherService
+}
+
+func NewCartService(repo *CartRepositoryImpl, psvc *products.ProductsService, osvc *order.OrderService, asvc *user.AddressService, shsvc *shipping.ShippingService, vsvc *voucher.VoucherService) *CartService {
+	return &CartService{
+		cartRepo:        repo,
+		productService:  psvc,
+		orderService:    osvc,
+		addressService:  asvc,
+		shippingService: shsvc,
+		voucherService:  vsvc,
+	}
+}
+
+func (c *CartService) GetCart(ctx context.Context, userId uuid.UUID) (model.Cart, *common.ErrorResponse) {
+
+	items, err := c.cartRepo.GetByUserID(ctx, userId)
+	if err != nil {
+		return model.Cart{}, common.NewErrorResponse(500, "gagal mengambil data keranjang! "+err.Error())
+	}
+
+	return buildCart(userId, items), nil
+}
+
+func (c *CartService) AddItem(ctx context.Context, userId uuid.UUID, productId uuid.UUID, variantId *uuid.UUID, q int) (model.Cart, *common.ErrorResponse) {
+
+	productData, getErr := c.productService.GetById(ctx, productId)
+	if getErr != nil {
+		return model.Cart{}, getErr
+	}
+
+	if productData.Status != model.ProductsStatusActive {
+		return model.Cart{}, common.NewErrorResponse(404, "produk tidak ditemukan! tidak dapat ditambahkan ke keranjang")
+	}
+
+	variant, variantErr := products.SelectVariant(productData, variantId)
+	if variantErr != nil {
+		return model.Cart{}, variantErr
+	}
+
+	available := productData.AvailableStock
+	if variant != nil {
+		available = variant.AvailableStock
+	}
+
+	// quantity yang udah ada di keranjang ikut dihitung
+	existing, err := c.cartRepo.GetItemByProduct(ctx, userId, productId, variantId)
+	if err != nil && !errors.Is(err, ErrCartItemNotFound) {
+		return model.Cart{}, common.NewErrorResponse(500, "gagal mengambil data keranjang! "+err.Error())
+	}
+
+	if existing.Quantity+q > available {
+		return model.Cart{}, common.NewErrorResponse(409, "Stok tidak mencukupi!")
+	}
+
+	_, err = c.cartRepo.AddItem(ctx, userId, productId, variantId, q)
+	if err != nil {
+		if errors.Is(err, ErrCartProductNotFound) {
+			return model.Cart{}, common.NewErrorResponse(404, err.Error())
+		}
+		return model.Cart{}, common.NewErrorResponse(500, "gagal menambahkan ke keranjang! "+err.Error())
+	}
+
+	return c.GetCart(ctx, userId)
+}
+
+func (c *CartService) UpdateItem(ctx context.Context, userId uuid.UUID, itemId uuid.UUID, q int) (model.Cart, *common.ErrorResponse) {
+
+	item, err := c.cartRepo.GetItemByID(ctx, userId, itemId)
+	if err != nil {
+		if errors.Is(err, ErrCartItemNotFound) {
+			return model.Cart{}, common.NewErrorResponse(404, err.Error())
+		}
+		return model.Cart{}, common.NewErrorResponse(500, "gagal mengambil data keranjang! "+err.Error())
+	}
+
+	if q > item.ProductStock {
+		return model.Cart{}, common.NewErrorResponse(409, "Stok tidak mencukupi!")
+	}
+
+	err = c.cartRepo.UpdateQuantity(ctx, userId, itemId, q)
+	if err != nil {
+		if errors.Is(err, ErrCartItemNotFound) {
+			return model.Cart{}, common.NewErrorResponse(404, err.Error())
+		}
+		return model.Cart{}, common.NewErrorResponse(500, "gagal mengupdate keranjang! "+err.Error())
+	}
+
+	return c.GetCart(ctx, userId)
+}
+
+func (c *CartService) RemoveItem(ctx context.Context, userId uuid.UUID, itemId uuid.UUID) (model.Cart, *common.ErrorResponse) {
+
+	err := c.cartRepo.RemoveItem(ctx, userId, itemId)
+	if err != nil {
+		if errors.Is(err, ErrCartItemNotFound) {
+			return model.Cart{}, common.NewErrorResponse(404, err.Error())
+		}
+		return model.Cart{}, common.NewErrorResponse(500, "gagal menghapus item keranjang! "+err.Error())
+	}
+
+	return c.GetCart(ctx, userId)
+}
+
+func (c *CartService) Clear(ctx context.Context, userId uuid.UUID) *common.ErrorResponse {
+
+	if err := c.cartRepo.Clear(ctx, userId); err != nil {
+		return common.NewErrorResponse(500, "gagal mengosongkan keranjang! "+err.Error())
+	}
+	return nil
+}
+
+// QuoteShipping preview ongkir isi keranjang ke alamat yang dipilih (atau alamat default)
+func (c *CartService) QuoteShipping(ctx context.Context, userId uuid.UUID, addressId *uuid.UUID) (model.ShippingQuote, *common.ErrorResponse) {
+
+	cart, getErr := c.GetCart(ctx, userId)
+	if getErr != nil {
+		return model.ShippingQuote{}, getErr
+	}
+
+	if len(cart.Items) == 0 {
+		return model.ShippingQuote{}, common.NewErrorResponse(400, "keranjang kamu masih kosong!")
+	}
+
+	shippingAddress, addrErr := c.addressService.ResolveShippingAddress(ctx, userId, addressId)
+	if addrErr != nil {
+		return model.ShippingQuote{}, addrErr
+	}
+
+	return c.shippingService.Quote(ctx, *shippingAddress, cart.TotalWeight)
+}
+
+// Preview rincian harga checkout (subtotal, diskon voucher, ongkir, total)
+// tanpa bikin order dan tanpa make kuota voucher
+func (c *CartService) Preview(ctx context.Context, userId uuid.UUID, addressId *uuid.UUID, voucherCode *string) (dto.CheckoutPreview, *common.ErrorResponse) {
+
+	cart, getErr := c.GetCart(ctx, userId)
+	if getErr != nil {
+		return dto.CheckoutPreview{}, getErr
+	}
+
+	if len(cart.Items) == 0 {
+		return dto.CheckoutPreview{}, common.NewErrorResponse(400, "keranjang kamu masih kosong!")
+	}
+
+	shippingAddress, addrErr := c.addressService.ResolveShippingAddress(ctx, userId, addressId)
+	if addrErr != nil {
+		return dto.CheckoutPreview{}, addrErr
+	}
+
+	quote, quoteErr := c.shippingService.Quote(ctx, *shippingAddress, cart.TotalWeight)
+	if quoteErr != nil {
+		return dto.CheckoutPreview{}, quoteErr
+	}
+
+	preview := dto.CheckoutPreview{
+		Subtotal:     cart.Subtotal,
+		ShippingCost: quote.Cost,
+		WeightGram:   cart.TotalWeight,
+	}
+
+	if voucherCode != nil {
+		applied, voucherErr := c.voucherService.Preview(ctx, *voucherCode, userId, cartToOrderItems(cart.Items))
+		if voucherErr != nil {
+			return dto.CheckoutPreview{}, voucherErr
+		}
+		preview.Discount = applied.Discount
+		preview.VoucherCode = &applied.Code
+	}
+
+	preview.TotalAmount = preview.Subtotal - preview.Discount + preview.ShippingCost
+	return preview, nil
+}
+
+// Checkout ngubah semua isi keranjang jadi satu order.
+// setiap baris dicek ulang stok & status produknya, kalau ada yang gagal
+// semua baris yang gagal dikembaliin biar user tau harus benerin yang mana.
+func (c *CartService) Checkout(ctx context.Context, userId uuid.UUID, notes string, addressId *uuid.UUID, voucherCode *string) (*model.Order, []dto.CheckoutLineError, *common.ErrorResponse) {
+
+	items, err := c.cartRepo.GetByUserID(ctx, userId)
+	if err != nil {
+		return nil, nil, common.NewErrorResponse(500, "gagal mengambil data keranjang! "+err.Error())
+	}
+
+	if len(items) == 0 {
+		return nil, nil, common.NewErrorResponse(400, "keranjang kamu masih kosong!")
+	}
+
+	shippingAddress, addrErr := c.addressService.ResolveShippingAddress(ctx, userId, addressId)
+	if addrErr != nil {
+		return nil, nil, addrErr
+	}
+
+	failedLines := make([]dto.CheckoutLineError, 0)
+	orderItems := make([]model.OrderItem, len(items))
+	cartItemIds := make([]uuid.UUID, len(items))
+
+	for i, item := range items {
+		if lineErr := validateCartLine(item); lineErr != nil {
+			failedLines = append(failedLines, *lineErr)
+		}
+
+		orderItems[i] = cartItemToOrderItem(item)
+		cartItemIds[i] = item.ID
+	}
+
+	if len(failedLines) != 0 {
+		return nil, failedLines, common.NewErrorResponse(409, "beberapa item di keranjang tidak dapat di checkout!")
+	}
+
+	quote, quoteErr := c.shippingService.Quote(ctx, *shippingAddress, buildCart(userId, items).TotalWeight)
+	if quoteErr != nil {
+		return nil, nil, quoteErr
+	}
+
+	result, err := c.orderService.CreateOrderFromCart(ctx, userId, notes, shippingAddress, quote.Cost, voucherCode, orderItems, cartItemIds)
+	if err != nil {
+		// stok bisa aja keburu dibeli orang lain diantara validasi sama insert
+		if stockErr, ok := errors.AsType[*order.InsufficientStockError](err); ok {
+			for _, item := range items {
+				if item.ProductID == stockErr.ProductID && sameVariant(item.VariantID, stockErr.VariantID) {
+					failedLines = append(failedLines, newCheckoutLineError(item, "stok tidak mencukupi"))
+				}
+			}
+			return nil, failedLines, common.NewErrorResponse(409, "beberapa item di keranjang tidak dapat di checkout!")
+		}
+
+		if errors.Is(err, voucher.ErrVoucherInvalid) {
+			return nil, nil, common.NewErrorResponse(422, err.Error())
+		}
+
+		if errors.Is(err, order.ErrNoUniqueCode) {
+			return nil, nil, common.NewErrorResponse(503, err.Error())
+		}
+
+		return nil, nil, common.NewErrorResponse(500, "gagal membuat order! "+err.Error())
+	}
+
+	return result, nil, nil
+}
+
+func cartItemToOrderItem(item model.CartItem) model.OrderItem {
+	return model.OrderItem{
+		ProductID:       item.ProductID,
+		VariantID:       item.VariantID,
+		VariantName:     item.VariantName,
+		ProductName:     item.ProductName,
+		ProductSKU:      item.ProductSKU,
+		PriceAtPurchase: item.ProductPrice,
+		Quantity:        item.Quantity,
+		Subtotal:        item.Subtotal,
+	}
+}
+
+func cartToOrderItems(items []model.CartItem) []model.OrderItem {
+	orderItems := make([]model.OrderItem, len(items))
+	for i, item := range items {
+		orderItems[i] = cartItemToOrderItem(item)
+	}
+	return orderItems
+}
+
+func validateCartLine(item model.CartItem) *dto.CheckoutLineError {
+
+	if item.ProductStatus != model.ProductsStatusActive {
+		lineErr := newCheckoutLineError(item, "produk sudah tidak tersedia")
+		return &lineErr
+	}
+
+	if item.NeedsVariant {
+		lineErr := newCheckoutLineError(item, "pilih varian produk dulu")
+		return &lineErr
+	}
+
+	if item.Quantity > item.ProductStock {
+		lineErr := newCheckoutLineError(item, "stok tidak mencukupi")
+		return &lineErr
+	}
+
+	return nil
+}
+
+func sameVariant(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func newCheckoutLineError(item model.CartItem, reason string) dto.CheckoutLineError {
+	return dto.CheckoutLineError{
+		CartItemID:  item.ID,
+		ProductID:   item.ProductID,
+		ProductName: item.ProductName,
+		Requested:   item.Quantity,
+		Available:   item.ProductStock,
+		Reason:      reason,
+	}
+}
+
+func buildCart(userId uuid.UUID, items []model.CartItem) model.Cart {
+	cart := model.Cart{
+		UserID: userId,
+		Items:  items,
+	}
+
+	for _, item := range items {
+		cart.TotalQuantity += item.Quantity
+		cart.Subtotal += item.Subtotal
+		// produk yang beratnya belum diisi admin dianggap 0 gram
+		if item.ProductWeight != nil {
+			cart.TotalWeight += *item.ProductWeight * item.Quantity
+		}
+	}
+
+	return cart
+}
@@ -0,0 +1,24 @@
+package dto
+
+type CreateAddressRequest struct {
+	Label         *string `json:"label" validate:"omitempty,max=50"`
+	RecipientName string  `json:"recipient_name" validate:"required,min=3,max=100"`
+	PhoneNumber   string  `json:"phone_number" validate:"required,phoneID"`
+	Province      string  `json:"province" validate:"required,max=100"`
+	City          string  `json:"city" validate:"required,max=100"`
+	District      string  `json:"district" validate:"required,max=100"`
+	PostalCode    string  `json:"postal_code" validate:"required,numeric,len=5"`
+	AddressLine   string  `json:"address_line" validate:"required,max=255"`
+	IsDefault     bool    `json:"is_default"`
+}
+
+type UpdateAddressRequest struct {
+	Label         *string `json:"label" validate:"omitempty,max=50"`
+	RecipientName *string `json:"recipient_name" validate:"omitempty,min=3,max=100"`
+	PhoneNumber   *string `json:"phone_number" validate:"omitempty,phoneID"`
+	Province      *string `json:"province" validate:"omitempty,max=100"`
+	City          *string `json:"city" validate:"omitempty,max=100"`
+	District      *string `json:"district" validate:"omitempty,max=100"`
+	PostalCode    *string `json:"postal_code" validate:"omitempty,numeric,len=5"`
+	AddressLine   *string `json:"address_line" validate:"omitempty,max=255"`
+}
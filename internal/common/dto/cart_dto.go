@@ -0,0 +1,29 @@
+package dto
+
+import "github.com/google/uuid"
+
+type AddCartItemRequest struct {
+	ProductId string  `json:"product_id" validate:"required,uuid"`
+	VariantId *string `json:"variant_id" validate:"omitempty,uuid"`
+	Quantity  int     `json:"quantity" validate:"required,min=1,max=1000"`
+}
+
+type UpdateCartItemRequest struct {
+	Quantity int `json:"quantity" validate:"required,min=1,max=1000"`
+}
+
+type CheckoutRequest struct {
+	Notes       *string `json:"order_notes" validate:"omitempty"`
+	AddressId   *string `json:"address_id" validate:"omitempty,uuid"`
+	VoucherCode *string `json:"voucher_code" validate:"omitempty,max=50"`
+}
+
+// CheckoutLineError nunjukin baris keranjang mana yang bikin checkout gagal
+type CheckoutLineError struct {
+	CartItemID  uuid.UUID `json:"cart_item_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Requested   int       `json:"requested_quantity"`
+	Available   int       `json:"available_stock"`
+	Reason      string    `json:"reason"`
+}
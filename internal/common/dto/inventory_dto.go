@@ -0,0 +1,22 @@
+package dto
+
+import "github.com/google/uuid"
+
+// StockDiscrepancy produk yang stok nya ga sama dengan hasil hitung ulang ledger,
+// atau reserved_stock nya ga sama dengan jumlah item order yang masih nunggu bayar
+type StockDiscrepancy struct {
+	ProductID        uuid.UUID `json:"product_id"`
+	ProductName      string    `json:"product_name"`
+	Stock            int       `json:"stock"`
+	LedgerStock      int       `json:"ledger_stock"`
+	Difference       int       `json:"difference"`
+	ReservedStock    int       `json:"reserved_stock"`
+	OpenReservations int       `json:"open_reservations"`
+	VariantStock     *int      `json:"variant_stock,omitempty"`
+}
+
+type StockConsistencyReport struct {
+	CheckedProducts int                `json:"checked_products"`
+	Consistent      bool               `json:"consistent"`
+	Discrepancies   []StockDiscrepancy `json:"discrepancies"`
+}
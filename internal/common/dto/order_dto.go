@@ -1,12 +1,27 @@
 package dto
 
 type CreateOrderRequest struct {
-	ProductId string  `json:"product_id" validate:"required,uuid"`
-	Quantity  int     `json:"product_quantity" validate:"required,min=1,max=1000"`
-	Notes     *string `json:"order_notes" validate:"omitempty"`
+	ProductId   string  `json:"product_id" validate:"required,uuid"`
+	VariantId   *string `json:"variant_id" validate:"omitempty,uuid"`
+	Quantity    int     `json:"product_quantity" validate:"required,min=1,max=1000"`
+	Notes       *string `json:"order_notes" validate:"omitempty"`
+	AddressId   *string `json:"address_id" validate:"omitempty,uuid"`
+	VoucherCode *string `json:"voucher_code" validate:"omitempty,max=50"`
 }
 
 type UpdateStatusOrder struct {
-	OrderId string `json:"order_id" validate:"required,uuid"`
-	Status  string `json:"status" validate:"required,oneof=pending waiting_confirmation confirmed cancelled"`
+	OrderId string  `json:"order_id" validate:"required,uuid"`
+	Status  string  `json:"status" validate:"required,oneof=pending waiting_confirmation confirmed packed shipped delivered completed cancelled"`
+	Note    *string `json:"note" validate:"omitempty,max=255"`
+}
+
+type ShipOrderRequest struct {
+	Courier        string  `json:"courier" validate:"required,max=100"`
+	TrackingNumber string  `json:"tracking_number" validate:"required,max=100"`
+	Note           *string `json:"note" validate:"omitempty,max=255"`
+}
+
+// OrderNoteRequest body opsional buat endpoint progres order (pack, deliver, terima)
+type OrderNoteRequest struct {
+	Note *string `json:"note" validate:"omitempty,max=255"`
 }
@@ -1,6 +1,10 @@
 package dto
 
-import "mime/multipart"
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"mime/multipart"
+	"time"
+)
 
 type SubmitPaymentRequest struct {
 	OrderId    string `form:"payment_order_id" validate:"required,uuid"`
@@ -11,3 +15,79 @@ type UpdatePaymentStatusRequest struct {
 	PaymentId string  `json:"payment_id" validate:"required,uuid"`
 	Note      *string `json:"notes"`
 }
+
+// ReconcileStatementRequest upload mutasi rekening (CSV).
+// kolom pake index mulai dari 0, kalau diisi nimpa default dari preset bank.
+// type_column -1 berarti mutasi nya ga punya kolom jenis transaksi.
+type ReconcileStatementRequest struct {
+	Bank              string  `form:"bank" validate:"omitempty,oneof=bca mandiri bri bni"`
+	DateColumn        *int    `form:"date_column" validate:"omitempty,min=0"`
+	DateLayout        *string `form:"date_layout" validate:"omitempty"`
+	DescriptionColumn *int    `form:"description_column" validate:"omitempty,min=0"`
+	AmountColumn      *int    `form:"amount_column" validate:"omitempty,min=0"`
+	TypeColumn        *int    `form:"type_column" validate:"omitempty,min=-1"`
+	CreditMarker      *string `form:"credit_marker" validate:"omitempty,max=10"`
+	Delimiter         *string `form:"delimiter" validate:"omitempty,len=1"`
+	DecimalSeparator  *string `form:"decimal_separator" validate:"omitempty,oneof=. 0x2C"`
+	WindowDays        *int    `form:"window_days" validate:"omitempty,min=0,max=30"`
+	Statement         *multipart.FileHeader
+}
+
+type BulkApprovePaymentRequest struct {
+	PaymentIds []string `json:"payment_ids" validate:"required,min=1,max=200,dive,uuid"`
+	Note       *string  `json:"notes"`
+}
+
+type ReconciliationLine struct {
+	LineNumber  int         `json:"line_number"`
+	Date        time.Time   `json:"date"`
+	Description string      `json:"description"`
+	Amount      model.Money `json:"amount"`
+	PaymentIds  []string    `json:"payment_ids"`
+	OrderIds    []string    `json:"order_ids"`
+	Reason      string      `json:"reason,omitempty"`
+}
+
+type ReconciliationSummary struct {
+	CreditLines int `json:"credit_lines"`
+	Matched     int `json:"matched"`
+	Ambiguous   int `json:"ambiguous"`
+	Unmatched   int `json:"unmatched"`
+	Skipped     int `json:"skipped"`
+}
+
+type ReconciliationReport struct {
+	Summary   ReconciliationSummary `json:"summary"`
+	Matched   []ReconciliationLine  `json:"matched"`
+	Ambiguous []ReconciliationLine  `json:"ambiguous"`
+	Unmatched []ReconciliationLine  `json:"unmatched"`
+}
+
+type BulkApproveResult struct {
+	PaymentId string `json:"payment_id"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message,omitempty"`
+}
+
+type CreateChargeRequest struct {
+	OrderId  string `json:"order_id" validate:"required,uuid"`
+	Method   string `json:"method" validate:"required,oneof=virtual_account qris"`
+	BankCode string `json:"bank_code" validate:"omitempty,oneof=bca bni bri mandiri permata"`
+}
+
+type SimulateChargeRequest struct {
+	Status string `json:"status" validate:"required,oneof=paid expired failed"`
+}
+
+type ResolveGatewayEventRequest struct {
+	Note string `json:"notes" validate:"required,max=500"`
+}
+
+type QRISPaymentResponse struct {
+	OrderId   string      `json:"order_id"`
+	PaymentId string      `json:"payment_id"`
+	Amount    model.Money `json:"amount"`
+	Payload   string      `json:"payload"`
+	Image     string      `json:"image"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+
+	"github.com/google/uuid"
+)
+
+type BundleItemRequest struct {
+	ProductId string  `json:"product_id" validate:"required,uuid"`
+	VariantId *string `json:"variant_id" validate:"omitempty,uuid"`
+	Quantity  int     `json:"quantity" validate:"required,min=1,max=100"`
+}
+
+// SetBundleRequest isi bundle diganti semua sama items yang dikirim,
+// harga bundle nya tetep pake harga produk
+type SetBundleRequest struct {
+	Items []BundleItemRequest `json:"items" validate:"required,min=1,max=20,dive"`
+}
+
+type ProductBundleResponse struct {
+	BundleID       uuid.UUID          `json:"bundle_id"`
+	BundleName     string             `json:"bundle_name"`
+	BundlePrice    model.Money        `json:"bundle_price"`
+	RegularPrice   model.Money        `json:"regular_price"`
+	Savings        model.Money        `json:"savings"`
+	AvailableStock int                `json:"available_stock"`
+	Items          []model.BundleItem `json:"items"`
+}
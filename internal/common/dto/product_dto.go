@@ -10,36 +10,36 @@ import (
 )
 
 type CreateProductRequest struct {
-	CategoryId     *string `form:"product_category_id" validate:"omitempty"`
-	Name           string  `form:"product_name" validate:"required,min=3,alphanumspace"`
-	Slug           string  `form:"product_slug" validate:"required,max=150,slug"`
-	Description    *string `form:"product_description" validate:"omitempty"`
-	Brand          *string `form:"product_brand" validate:"omitempty,max=100"`
-	Condition      string  `form:"product_condition" validate:"required,oneof=new used refurbished"`
-	Sku            string  `form:"product_sku" validate:"required"`
-	Price          float32 `form:"product_price" validate:"required"`
-	Stock          int     `form:"product_initial_stock" validate:"required"`
-	Specifications *string `form:"product_specification" validate:"omitempty,json"`
-	Status         string  `form:"product_status" validate:"required,oneof=draft active inactive out_of_stock"`
-	IsFeatured     *bool   `form:"product_featured" validate:"omitempty"`
-	Weight         *int    `form:"product_weight" validate:"omitempty,min=1"`
+	CategoryId     *string     `form:"product_category_id" validate:"omitempty"`
+	Name           string      `form:"product_name" validate:"required,min=3,alphanumspace"`
+	Slug           string      `form:"product_slug" validate:"required,max=150,slug"`
+	Description    *string     `form:"product_description" validate:"omitempty"`
+	Brand          *string     `form:"product_brand" validate:"omitempty,max=100"`
+	Condition      string      `form:"product_condition" validate:"required,oneof=new used refurbished"`
+	Sku            string      `form:"product_sku" validate:"required"`
+	Price          model.Money `form:"product_price" validate:"required,min=1"`
+	Stock          int         `form:"product_initial_stock" validate:"min=0"`
+	Specifications *string     `form:"product_specification" validate:"omitempty,json"`
+	Status         string      `form:"product_status" validate:"required,oneof=draft active inactive out_of_stock"`
+	IsFeatured     *bool       `form:"product_featured" validate:"omitempty"`
+	Weight         *int        `form:"product_weight" validate:"omitempty,min=1"`
 	ProductImages  []*multipart.FileHeader
 }
 
 type UpdateProductsRequest struct {
-	CategoryId        *string  `form:"product_category_id" validate:"omitempty,uuid"`
-	Name              *string  `form:"product_name" validate:"omitempty,min=3,alphanumspace"`
-	Slug              *string  `form:"product_slug" validate:"omitempty,max=150,slug"`
-	Description       *string  `form:"product_description" validate:"omitempty"`
-	Brand             *string  `form:"product_brand" validate:"omitempty,max=100"`
-	Condition         *string  `form:"product_condition" validate:"omitempty,oneof=new used refurbished"`
-	Sku               *string  `form:"product_sku" validate:"omitempty"`
-	Price             *float32 `form:"product_price" validate:"omitempty"`
-	Stock             *int     `form:"product_initial_stock" validate:"omitempty"`
-	Specifications    *string  `form:"product_specification" validate:"omitempty,json"`
-	Status            *string  `form:"product_status" validate:"omitempty,oneof=draft active inactive out_of_stock"`
-	IsFeatured        *bool    `form:"product_featured" validate:"omitempty"`
-	Weight            *int     `form:"product_weight" validate:"omitempty,min=1"`
+	CategoryId        *string      `form:"product_category_id" validate:"omitempty,uuid"`
+	Name              *string      `form:"product_name" validate:"omitempty,min=3,alphanumspace"`
+	Slug              *string      `form:"product_slug" validate:"omitempty,max=150,slug"`
+	Description       *string      `form:"product_description" validate:"omitempty"`
+	Brand             *string      `form:"product_brand" validate:"omitempty,max=100"`
+	Condition         *string      `form:"product_condition" validate:"omitempty,oneof=new used refurbished"`
+	Sku               *string      `form:"product_sku" validate:"omitempty"`
+	Price             *model.Money `form:"product_price" validate:"omitempty,min=1"`
+	Stock             *int         `form:"product_initial_stock" validate:"omitempty"`
+	Specifications    *string      `form:"product_specification" validate:"omitempty,json"`
+	Status            *string      `form:"product_status" validate:"omitempty,oneof=draft active inactive out_of_stock"`
+	IsFeatured        *bool        `form:"product_featured" validate:"omitempty"`
+	Weight            *int         `form:"product_weight" validate:"omitempty,min=1"`
 	NewProductImages  []*multipart.FileHeader
 	DeletedImage      []string `form:"product_deleted_image"`
 	UpdatedImage      []string `form:"product_updated_image_id"`
@@ -54,17 +54,25 @@ type ProductDetailResponse struct {
 	Description    *string                `json:"product_description"`
 	Brand          *string                `json:"product_brand"`
 	Condition      model.ProductCondition `json:"product_condition"`
-	Price          float64                `json:"product_price"`
+	Price          model.Money            `json:"product_price"`
 	Stock          int                    `json:"product_stock"`
+	ReservedStock  int                    `json:"product_reserved_stock"`
+	AvailableStock int                    `json:"product_available_stock"`
 	SKU            *string                `json:"product_sku"`
 	Specifications model.JSONB            `json:"product_specification"`
 	Status         model.ProductStatus    `json:"product_status"`
 	IsFeatured     bool                   `json:"product_is_featured"`
 	Weight         *int                   `json:"product_weight"`
 	Images         []model.ProductImage   `json:"product_images"`
-	Category       model.Category         `json:"category"`
-	CreatedAt      time.Time              `json:"product_created_at"`
-	Reviews        []ReviewDetail         `json:"reviews"` // tambah ini
+	Options        []model.ProductOption  `json:"product_options"`
+	Variants       []model.ProductVariant `json:"product_variants"`
+	IsBundle       bool                   `json:"product_is_bundle"`
+	BundleItems    []model.BundleItem     `json:"product_bundle_items,omitempty"`
+	// total harga komponen kalau dibeli satuan, buat nampilin hemat nya
+	BundleRegularPrice *model.Money   `json:"product_bundle_regular_price,omitempty"`
+	Category           model.Category `json:"category"`
+	CreatedAt          time.Time      `json:"product_created_at"`
+	Reviews            []ReviewDetail `json:"reviews"` // tambah ini
 }
 
 func NewProductFromCreateRequest(req CreateProductRequest) (model.Product, error) {
@@ -99,7 +107,7 @@ func NewProductFromCreateRequest(req CreateProductRequest) (model.Product, error
 		Description:    req.Description,
 		Brand:          req.Brand,
 		Condition:      model.ProductCondition(req.Condition),
-		Price:          float64(req.Price),
+		Price:          req.Price,
 		Stock:          req.Stock,
 		SKU:            &req.Sku, // belum ada di request
 		Specifications: specs,
@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProductUnitRequest struct {
+	SerialNumber  string  `json:"serial_number" validate:"required,max=100"`
+	IMEI          *string `json:"imei" validate:"omitempty,imei"`
+	ConditionNote *string `json:"condition_note" validate:"omitempty,max=500"`
+}
+
+// AddProductUnitsRequest daftarin beberapa unit sekaligus buat satu produk
+type AddProductUnitsRequest struct {
+	ProductId string               `json:"product_id" validate:"required,uuid"`
+	VariantId *string              `json:"variant_id" validate:"omitempty,uuid"`
+	Units     []ProductUnitRequest `json:"units" validate:"required,min=1,max=100,dive"`
+}
+
+type UpdateProductUnitRequest struct {
+	ConditionNote *string `json:"condition_note" validate:"omitempty,max=500"`
+	Status        *string `json:"status" validate:"omitempty,oneof=in_stock returned"`
+}
+
+type AssignProductUnitRequest struct {
+	UnitId      string `json:"unit_id" validate:"required,uuid"`
+	OrderItemId string `json:"order_item_id" validate:"required,uuid"`
+}
+
+// UnitSaleLookup hasil cari serial / IMEI: unitnya + order & customer yang terakhir dapet unit itu
+type UnitSaleLookup struct {
+	Unit          model.ProductUnit  `json:"unit"`
+	ProductName   string             `json:"product_name"`
+	OrderID       *uuid.UUID         `json:"order_id,omitempty"`
+	OrderItemID   *uuid.UUID         `json:"order_item_id,omitempty"`
+	OrderStatus   *model.OrderStatus `json:"order_status,omitempty"`
+	OrderedAt     *time.Time         `json:"ordered_at,omitempty"`
+	CustomerID    *uuid.UUID         `json:"customer_id,omitempty"`
+	CustomerName  *string            `json:"customer_name,omitempty"`
+	CustomerEmail *string            `json:"customer_email,omitempty"`
+	CustomerPhone *string            `json:"customer_phone,omitempty"`
+}
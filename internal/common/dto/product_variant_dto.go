@@ -0,0 +1,31 @@
+package dto
+
+import "backEnd-RingoTechLife/internal/common/model"
+
+// CreateProductVariantRequest options isinya nama opsi -> nilai, misal {"Kapasitas": "128GB", "Warna": "Hitam"}.
+// semua varian satu produk harus pake nama opsi yang sama
+type CreateProductVariantRequest struct {
+	Sku      string            `json:"sku" validate:"required,max=100"`
+	Price    model.Money       `json:"price" validate:"required,min=1"`
+	Stock    int               `json:"stock" validate:"min=0"`
+	Weight   *int              `json:"weight" validate:"omitempty,min=1"`
+	ImageId  *string           `json:"image_id" validate:"omitempty,uuid"`
+	IsActive *bool             `json:"is_active" validate:"omitempty"`
+	Options  map[string]string `json:"options" validate:"required,min=1,max=3,dive,keys,required,max=50,endkeys,required,max=100"`
+}
+
+// UpdateProductVariantRequest kombinasi opsi nya ga bisa diubah, kalau salah hapus terus bikin ulang.
+// image_id diisi string kosong buat ngelepas gambar varian
+type UpdateProductVariantRequest struct {
+	Sku      *string      `json:"sku" validate:"omitempty,max=100"`
+	Price    *model.Money `json:"price" validate:"omitempty,min=1"`
+	Stock    *int         `json:"stock" validate:"omitempty,min=0"`
+	Weight   *int         `json:"weight" validate:"omitempty,min=1"`
+	ImageId  *string      `json:"image_id" validate:"omitempty,uuid|len=0"`
+	IsActive *bool        `json:"is_active" validate:"omitempty"`
+}
+
+type ProductVariantsResponse struct {
+	Options  []model.ProductOption  `json:"options"`
+	Variants []model.ProductVariant `json:"variants"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "mime/multipart"
+
+// CreateRefundRequest Items kosong berarti refund penuh (semua sisa dana & barang)
+type CreateRefundRequest struct {
+	OrderId string              `json:"order_id" validate:"required,uuid"`
+	Reason  string              `json:"reason" validate:"required,min=5,max=500"`
+	Items   []RefundItemRequest `json:"items" validate:"omitempty,dive"`
+}
+
+type RefundItemRequest struct {
+	OrderItemId string `json:"order_item_id" validate:"required,uuid"`
+	Quantity    int    `json:"quantity" validate:"required,min=1"`
+}
+
+// ApproveRefundRequest RestockItemIds isinya id refund item yang stoknya mau dibalikin
+type ApproveRefundRequest struct {
+	Note           *string  `json:"notes" validate:"omitempty,max=500"`
+	RestockItemIds []string `json:"restock_item_ids" validate:"omitempty,dive,uuid"`
+}
+
+type RejectRefundRequest struct {
+	Note string `json:"notes" validate:"required,min=3,max=500"`
+}
+
+type CompleteRefundRequest struct {
+	Note       *string `form:"notes" validate:"omitempty,max=500"`
+	ProofImage *multipart.FileHeader
+}
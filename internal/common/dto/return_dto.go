@@ -0,0 +1,43 @@
+package dto
+
+import "mime/multipart"
+
+// CreateReturnRequest dikirim multipart, items nya pake format
+// items[0].order_item_id / items[0].quantity, fotonya di field "photos"
+type CreateReturnRequest struct {
+	OrderId string              `form:"order_id" validate:"required,uuid"`
+	Reason  string              `form:"reason" validate:"required,min=10,max=1000"`
+	Items   []ReturnItemRequest `form:"items" validate:"required,min=1,dive"`
+	Photos  []*multipart.FileHeader
+}
+
+type ReturnItemRequest struct {
+	OrderItemId string `form:"order_item_id" validate:"required,uuid"`
+	Quantity    int    `form:"quantity" validate:"required,min=1"`
+}
+
+type ApproveReturnRequest struct {
+	Note *string `json:"notes" validate:"omitempty,max=500"`
+}
+
+type RejectReturnRequest struct {
+	Note string `json:"notes" validate:"required,min=3,max=500"`
+}
+
+type ShipReturnRequest struct {
+	Courier        string `json:"courier" validate:"required,max=50"`
+	TrackingNumber string `json:"tracking_number" validate:"required,max=100"`
+}
+
+type InspectReturnRequest struct {
+	Result string `json:"result" validate:"required,oneof=defect_confirmed no_defect_found"`
+	Note   string `json:"notes" validate:"required,min=3,max=1000"`
+}
+
+// ResolveReturnRequest data kurir pengganti cuma kepake buat resolution replacement
+type ResolveReturnRequest struct {
+	Resolution                string  `json:"resolution" validate:"required,oneof=refund replacement repair"`
+	Note                      *string `json:"notes" validate:"omitempty,max=500"`
+	ReplacementCourier        *string `json:"replacement_courier" validate:"omitempty,max=50"`
+	ReplacementTrackingNumber *string `json:"replacement_tracking_number" validate:"omitempty,max=100"`
+}
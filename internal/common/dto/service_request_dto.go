@@ -1,6 +1,12 @@
 package dto
 
-import "mime/multipart"
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"mime/multipart"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // internal/dto/service_request_dto.go
 
@@ -10,14 +16,28 @@ type CreateServiceRequestDTO struct {
 	DeviceBrand        *string `form:"device_brand"         validate:"omitempty,max=100"`
 	DeviceModel        *string `form:"device_model"         validate:"omitempty,max=150"`
 	ProblemDescription string  `form:"problem_description"  validate:"required"`
-	ProductPictures    []*multipart.FileHeader
+	// jadwal antar perangkat (opsional), slot_start dari GET /device-service/appointment/slots
+	AppointmentAt   *string `form:"appointment_at"       validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ProductPictures []*multipart.FileHeader
 }
 
-// PATCH /admin/service-requests/:id/quote — admin kasih penawaran
+// PATCH /admin/service-requests/:id/quote — admin kasih penawaran / revisi penawaran.
+// Items kosong = penawaran lama satu harga, dijadiin satu baris jasa senilai QuotedPrice
 type AdminQuoteServiceRequestDTO struct {
-	QuotedPrice       float64 `json:"quoted_price"        validate:"required,min=0"`
-	EstimatedDuration int     `json:"estimated_duration"  validate:"required,min=1"`
-	AdminNote         *string `json:"admin_note"          validate:"omitempty"`
+	QuotedPrice       model.Money    `json:"quoted_price"        validate:"omitempty,min=0"`
+	EstimatedDuration int            `json:"estimated_duration"  validate:"required,min=1"`
+	AdminNote         *string        `json:"admin_note"          validate:"omitempty"`
+	Items             []QuoteItemDTO `json:"items"               validate:"omitempty,max=30,dive"`
+}
+
+// satu baris penawaran. sparepart yang diambil dari produk toko boleh ngosongin
+// deskripsi / harga, nanti diisi nama & harga produk nya
+type QuoteItemDTO struct {
+	Type        string       `json:"type"        validate:"required,oneof=labour part diagnostic"`
+	ProductId   *string      `json:"product_id"  validate:"omitempty,uuid"`
+	Description string       `json:"description" validate:"omitempty,max=255"`
+	Quantity    int          `json:"quantity"    validate:"required,min=1"`
+	UnitPrice   *model.Money `json:"unit_price"  validate:"omitempty,min=0"`
 }
 
 // PATCH /admin/service-requests/:id/reject — admin reject
@@ -25,7 +45,73 @@ type AdminRejectServiceRequestDTO struct {
 	AdminNote string `json:"admin_note" validate:"required"`
 }
 
-// PATCH /service-requests/:id/decision — user acc atau reject penawaran
+// PATCH /service-requests/:id/decision — user acc atau reject penawaran.
+// QuoteVersion wajib revisi yang lagi pending, biar user ga acc harga yang udah diganti
 type UserDecisionDTO struct {
-	Accept bool `json:"accept"`
+	Accept       bool `json:"accept"`
+	QuoteVersion int  `json:"quote_version" validate:"required,min=1"`
+}
+
+// PUT /device-service/repair-status/:id — admin update progres perbaikan
+type UpdateRepairStatusDTO struct {
+	Status string  `json:"status" validate:"required,oneof=device_received diagnosing waiting_parts in_repair quality_check ready_for_pickup picked_up returned"`
+	Note   *string `json:"note"   validate:"omitempty,max=1000"`
+}
+
+// PUT /device-service/assign/:id — admin assign / pindahin request ke teknisi
+type AssignTechnicianDTO struct {
+	TechnicianId string  `json:"technician_id" validate:"required,uuid"`
+	Note         *string `json:"note"          validate:"omitempty,max=1000"`
+}
+
+// GET /device-service/workload — jumlah request servis yang masih jalan per teknisi,
+// ByStatus key nya status perbaikan (atau status request kalau perbaikan belum mulai)
+type TechnicianWorkload struct {
+	TechnicianID   uuid.UUID      `json:"technician_id"`
+	TechnicianName string         `json:"technician_name"`
+	Total          int            `json:"total"`
+	ByStatus       map[string]int `json:"by_status"`
+}
+
+// POST /device-service/appointment/:id — user booking jadwal antar perangkat
+type BookAppointmentDTO struct {
+	AppointmentAt string `json:"appointment_at" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// PUT /device-service/appointment-status/:id — admin nutup booking.
+// completed = perangkat udah dianter, no_show = customer ga dateng
+type UpdateAppointmentStatusDTO struct {
+	Status string  `json:"status" validate:"required,oneof=completed no_show"`
+	Note   *string `json:"note"   validate:"omitempty,max=1000"`
+}
+
+// PUT /device-service/appointment/settings — admin atur jam buka & kapasitas.
+// OpeningHours nimpa semua jam buka, hari yang ga dikirim berarti tutup
+type AppointmentSettingsDTO struct {
+	SlotMinutes      int              `json:"slot_minutes"       validate:"required,min=15,max=480"`
+	CapacityPerSlot  int              `json:"capacity_per_slot"  validate:"required,min=1,max=100"`
+	BookingDaysAhead int              `json:"booking_days_ahead" validate:"required,min=1,max=90"`
+	UTCOffsetMinutes *int             `json:"utc_offset_minutes" validate:"omitempty,min=-720,max=840"`
+	OpeningHours     []OpeningHourDTO `json:"opening_hours"      validate:"max=7,dive"`
+}
+
+type OpeningHourDTO struct {
+	Weekday   *int   `json:"weekday"    validate:"required,min=0,max=6"`
+	OpenTime  string `json:"open_time"  validate:"required,datetime=15:04"`
+	CloseTime string `json:"close_time" validate:"required,datetime=15:04"`
+}
+
+// POST /device-service/appointment/holidays
+type HolidayDTO struct {
+	Date string  `json:"date" validate:"required,datetime=2006-01-02"`
+	Note *string `json:"note" validate:"omitempty,max=255"`
+}
+
+// GET /device-service/appointment/slots — slot yang masih bisa dibooking
+type AppointmentSlot struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Capacity  int       `json:"capacity"`
+	Booked    int       `json:"booked"`
+	Available int       `json:"available"`
 }
@@ -0,0 +1,23 @@
+package dto
+
+import "backEnd-RingoTechLife/internal/common/model"
+
+type ShippingZoneRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
+
+type ShippingRegionRequest struct {
+	Province string  `json:"province" validate:"required,max=100"`
+	City     *string `json:"city" validate:"omitempty,max=100"`
+}
+
+type ShippingRateRequest struct {
+	MinWeight  int         `json:"min_weight" validate:"min=0"`
+	MaxWeight  *int        `json:"max_weight" validate:"omitempty,gtfield=MinWeight"`
+	Price      model.Money `json:"price" validate:"min=0"`
+	ExtraPerKg model.Money `json:"extra_per_kg" validate:"min=0"`
+}
+
+type ShippingQuoteRequest struct {
+	AddressId *string `json:"address_id" validate:"omitempty,uuid"`
+}
@@ -20,7 +20,7 @@ type UpdateUserRequest struct {
 	Email                *string   `validate:"omitempty,email" form:"email"`
 	Password             *string   `validate:"omitempty,min=8" form:"password"`
 	PhoneNumber          *string   `validate:"omitempty,phoneID" form:"phone_number"`
-	Role                 *string   `validate:"omitempty" form:"role"`
+	Role                 *string   `validate:"omitempty,oneof=ADMIN USER TECHNICIAN" form:"role"`
 	ProfilePicture       *multipart.FileHeader
 	DeleteProfilePicture *bool `validate:"omitempty" form:"delete_profile_picture"`
 }
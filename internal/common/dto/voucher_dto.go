@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"time"
+)
+
+type VoucherRequest struct {
+	Code          string       `json:"code" validate:"required,min=3,max=50,alphanum"`
+	Description   *string      `json:"description" validate:"omitempty,max=255"`
+	DiscountType  string       `json:"discount_type" validate:"required,oneof=percentage fixed"`
+	DiscountValue int64        `json:"discount_value" validate:"required,gt=0"`
+	MinSpend      model.Money  `json:"min_spend" validate:"min=0"`
+	MaxDiscount   *model.Money `json:"max_discount" validate:"omitempty,gt=0"`
+	StartsAt      time.Time    `json:"starts_at" validate:"required"`
+	EndsAt        time.Time    `json:"ends_at" validate:"required,gtfield=StartsAt"`
+	UsageLimit    *int         `json:"usage_limit" validate:"omitempty,min=1"`
+	PerUserLimit  *int         `json:"per_user_limit" validate:"omitempty,min=1"`
+	IsActive      *bool        `json:"is_active" validate:"omitempty"`
+	ProductIds    []string     `json:"product_ids" validate:"omitempty,dive,uuid"`
+	CategoryIds   []string     `json:"category_ids" validate:"omitempty,dive,uuid"`
+}
+
+// CheckoutPreview rincian harga sebelum checkout (subtotal, diskon, ongkir)
+type CheckoutPreview struct {
+	Subtotal     model.Money `json:"subtotal"`
+	Discount     model.Money `json:"discount_amount"`
+	VoucherCode  *string     `json:"voucher_code,omitempty"`
+	ShippingCost model.Money `json:"shipping_cost"`
+	WeightGram   int         `json:"weight_gram"`
+	TotalAmount  model.Money `json:"total_amount"`
+}
+
+type CheckoutPreviewRequest struct {
+	AddressId   *string `json:"address_id" validate:"omitempty,uuid"`
+	VoucherCode *string `json:"voucher_code" validate:"omitempty,max=50"`
+}
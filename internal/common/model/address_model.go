@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserAddress satu alamat di buku alamat user, tiap user cuma boleh punya satu default
+type UserAddress struct {
+	ID            uuid.UUID `json:"id"`
+	UserID        uuid.UUID `json:"user_id"`
+	Label         *string   `json:"label,omitempty"`
+	RecipientName string    `json:"recipient_name"`
+	PhoneNumber   string    `json:"phone_number"`
+	Province      string    `json:"province"`
+	City          string    `json:"city"`
+	District      string    `json:"district"`
+	PostalCode    string    `json:"postal_code"`
+	AddressLine   string    `json:"address_line"`
+	IsDefault     bool      `json:"is_default"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ShippingAddress salinan alamat yang disimpen di order pas checkout.
+// sengaja dipisah dari UserAddress biar edit/hapus alamat ga ngubah order lama.
+type ShippingAddress struct {
+	RecipientName string `json:"recipient_name"`
+	PhoneNumber   string `json:"phone_number"`
+	Province      string `json:"province"`
+	City          string `json:"city"`
+	District      string `json:"district"`
+	PostalCode    string `json:"postal_code"`
+	AddressLine   string `json:"address_line"`
+}
+
+func (a UserAddress) ToShippingAddress() *ShippingAddress {
+	return &ShippingAddress{
+		RecipientName: a.RecipientName,
+		PhoneNumber:   a.PhoneNumber,
+		Province:      a.Province,
+		City:          a.City,
+		District:      a.District,
+		PostalCode:    a.PostalCode,
+		AddressLine:   a.AddressLine,
+	}
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CartItem satu baris di keranjang user.
+// field Product* diisi dari join ke tabel products, bukan kolom cart_items.
+// kalau ada varian, harga / sku / stok / berat nya pake punya varian.
+type CartItem struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	ProductID uuid.UUID  `json:"product_id"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty"`
+	Quantity  int        `json:"quantity"`
+
+	ProductName string  `json:"product_name"`
+	VariantName *string `json:"variant_name,omitempty"`
+	// produk nya punya varian tapi baris ini belum milih varian (misal ditambah sebelum ada varian)
+	NeedsVariant  bool          `json:"needs_variant"`
+	ProductSlug   string        `json:"product_slug"`
+	ProductSKU    *string       `json:"product_sku,omitempty"`
+	ProductPrice  Money         `json:"product_price"`
+	ProductStock  int           `json:"product_stock"`
+	ProductWeight *int          `json:"product_weight,omitempty"`
+	ProductStatus ProductStatus `json:"product_status"`
+	ProductImage  *string       `json:"product_image,omitempty"`
+	Subtotal      Money         `json:"subtotal"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Cart struct {
+	UserID        uuid.UUID  `json:"user_id"`
+	Items         []CartItem `json:"items"`
+	TotalQuantity int        `json:"total_quantity"`
+	TotalWeight   int        `json:"total_weight"`
+	Subtotal      Money      `json:"subtotal"`
+}
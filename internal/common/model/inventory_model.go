@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InventoryReason string
+
+const (
+	InventoryReasonSale       InventoryReason = "sale"
+	InventoryReasonCancel     InventoryReason = "cancel"
+	InventoryReasonRestock    InventoryReason = "restock"
+	InventoryReasonAdjustment InventoryReason = "adjustment"
+	InventoryReasonReturn     InventoryReason = "return"
+)
+
+// InventoryMovement satu baris ledger stok (append-only).
+// stok produk = jumlah Delta semua movement nya, StockAfter stok setelah movement ini.
+type InventoryMovement struct {
+	ID         uuid.UUID  `json:"id"`
+	ProductID  uuid.UUID  `json:"product_id"`
+	VariantID  *uuid.UUID `json:"variant_id,omitempty"`
+	Delta      int        `json:"delta"`
+	StockAfter int        `json:"stock_after"`
+	// stok varian setelah movement ini, StockAfter tetep stok total produk
+	VariantStockAfter *int            `json:"variant_stock_after,omitempty"`
+	Reason            InventoryReason `json:"reason"`
+	ReferenceType     *string         `json:"reference_type,omitempty"`
+	ReferenceID       *uuid.UUID      `json:"reference_id,omitempty"`
+	ActorID           *uuid.UUID      `json:"actor_id,omitempty"`
+	ActorName         *string         `json:"actor_name,omitempty"`
+	Note              *string         `json:"note,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
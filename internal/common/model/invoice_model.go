@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invoice nomor invoice yang udah keluar buat satu order. Snapshot isinya
+// dokumen invoice (JSON) yang dibekukan pas pertama kali dibuat, admin bisa
+// bikin ulang (Revision naik) tapi nomornya tetep.
+type Invoice struct {
+	ID            uuid.UUID  `json:"id"`
+	OrderID       uuid.UUID  `json:"order_id"`
+	InvoiceNumber string     `json:"invoice_number"`
+	Period        string     `json:"period"`
+	Sequence      int        `json:"sequence"`
+	Revision      int        `json:"revision"`
+	Snapshot      []byte     `json:"-"`
+	IssuedAt      time.Time  `json:"issued_at"`
+	GeneratedAt   *time.Time `json:"generated_at,omitempty"`
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money nominal rupiah dalam satuan terkecil (rupiah utuh, ga pake sen).
+// disimpen sebagai BIGINT di database dan dikirim sebagai angka bulat di JSON,
+// jadi harga laptop puluhan juta ga kena pembulatan float lagi.
+type Money int64
+
+// Mul buat harga satuan x quantity
+func (m Money) Mul(q int) Money {
+	return m * Money(q)
+}
+
+// Percent ngambil p persen dari nominal, dibulatkan ke bawah
+func (m Money) Percent(p int64) Money {
+	return m * Money(p) / 100
+}
+
+func (m Money) Int64() int64 {
+	return int64(m)
+}
+
+func (m Money) String() string {
+	return strconv.FormatInt(int64(m), 10)
+}
+
+// Scan implements sql.Scanner interface
+func (m *Money) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v)
+	case int32:
+		*m = Money(v)
+	case []byte:
+		return m.parse(string(v))
+	case string:
+		return m.parse(v)
+	default:
+		return fmt.Errorf("money: tipe %T tidak didukung", value)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer interface
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON nerima angka bulat atau string angka ("15000000").
+// angka pecahan ditolak biar ga ada pembulatan diam-diam.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(raw, `"`) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		raw = s
+	}
+
+	return m.parse(raw)
+}
+
+func (m *Money) parse(raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	// kolom NUMERIC lama kebaca "15000.00", masih diterima selama ga ada pecahan
+	if whole, frac, ok := strings.Cut(raw, "."); ok {
+		if strings.Trim(frac, "0") != "" {
+			return fmt.Errorf("money: nominal %q harus bilangan bulat", raw)
+		}
+		raw = whole
+	}
+
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return fmt.Errorf("money: nominal %q tidak valid", raw)
+	}
+	*m = Money(v)
+	return nil
+}
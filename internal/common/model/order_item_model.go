@@ -6,16 +6,33 @@ import (
 	"github.com/google/uuid"
 )
 
+// OrderItemTypeProduct item barang biasa, selain ini baris jasa dari
+// penawaran servis (labour / part / diagnostic) yang ga nyentuh stok
+const OrderItemTypeProduct = "product"
+
 type OrderItem struct {
-	ID        uuid.UUID `json:"id"`
-	OrderID   uuid.UUID `json:"order_id"`
+	ID       uuid.UUID `json:"id"`
+	OrderID  uuid.UUID `json:"order_id"`
+	ItemType string    `json:"item_type"`
+
+	// uuid.Nil buat baris jasa yang ga nyambung ke produk
 	ProductID uuid.UUID `json:"product_id"`
 
+	// snapshot varian yang dibeli, nil buat produk tanpa varian
+	VariantID   *uuid.UUID `json:"variant_id,omitempty"`
+	VariantName *string    `json:"variant_name,omitempty"`
+
 	ProductName     string  `json:"product_name"`
 	ProductSKU      *string `json:"product_sku,omitempty"`
-	PriceAtPurchase float64 `json:"price_at_purchase"`
+	PriceAtPurchase Money   `json:"price_at_purchase"`
 	Quantity        int     `json:"quantity"`
-	Subtotal        float64 `json:"subtotal"`
+	Subtotal        Money   `json:"subtotal"`
+
+	// isi bundle waktu dibeli, kosong buat produk biasa
+	BundleComponents []BundleComponent `json:"bundle_components,omitempty"`
+
+	// serial unit fisik yang dipasang ke item ini (cuma produk yang dilacak per unit)
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
 
 	CreatedAt time.Time `json:"created_at"`
 }
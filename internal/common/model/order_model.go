@@ -1,6 +1,7 @@
 package model
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +15,11 @@ var AllowedOrderStatus = map[string]bool{
 	string(OrderStatusWaitingConfirmation): true,
 	string(OrderStatusCancelled):           true,
 	string(OrderStatusConfirmed):           true,
+	string(OrderStatusPacked):              true,
+	string(OrderStatusShipped):             true,
+	string(OrderStatusDelivered):           true,
+	string(OrderStatusCompleted):           true,
+	string(OrderStatusRefunded):            true,
 }
 
 const (
@@ -21,16 +27,48 @@ const (
 	OrderStatusWaitingConfirmation OrderStatus = "waiting_confirmation"
 	OrderStatusConfirmed           OrderStatus = "confirmed"
 	OrderStatusCancelled           OrderStatus = "cancelled"
+	OrderStatusPacked              OrderStatus = "packed"
+	OrderStatusShipped             OrderStatus = "shipped"
+	OrderStatusDelivered           OrderStatus = "delivered"
+	OrderStatusCompleted           OrderStatus = "completed"
+	OrderStatusRefunded            OrderStatus = "refunded"
 )
 
+// OrderStatusTransitions tabel perpindahan status order yang diizinkan.
+// semua perubahan status order wajib lewat tabel ini.
+// confirmed -> completed cuma buat order tanpa barang (order service request),
+// order produk harus lewat packed -> shipped -> delivered dulu.
+// refunded cuma dipasang sama subsistem refund pas seluruh pembayaran udah dikembaliin.
+var OrderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:             {OrderStatusWaitingConfirmation, OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusWaitingConfirmation: {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed:           {OrderStatusPacked, OrderStatusCompleted, OrderStatusRefunded},
+	OrderStatusPacked:              {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:             {OrderStatusDelivered, OrderStatusRefunded},
+	OrderStatusDelivered:           {OrderStatusCompleted, OrderStatusRefunded},
+	OrderStatusCompleted:           {OrderStatusRefunded},
+	OrderStatusCancelled:           {},
+	OrderStatusRefunded:            {},
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return slices.Contains(OrderStatusTransitions[s], next)
+}
+
 type Order struct {
 	ID     uuid.UUID   `json:"id"`
 	UserID uuid.UUID   `json:"user_id"`
 	Status OrderStatus `json:"status"`
 
-	Subtotal    float64 `json:"subtotal"`
-	TotalAmount float64 `json:"total_amount"`
-	Notes       *string `json:"notes,omitempty"`
+	Subtotal       Money   `json:"subtotal"`
+	DiscountAmount Money   `json:"discount_amount"`
+	VoucherCode    *string `json:"voucher_code,omitempty"`
+	ShippingCost   Money   `json:"shipping_cost"`
+	TotalAmount    Money   `json:"total_amount"`
+	Notes          *string `json:"notes,omitempty"`
+
+	// nil buat order tanpa barang (service request)
+	ShippingAddress *ShippingAddress `json:"shipping_address,omitempty"`
 
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
@@ -38,7 +76,34 @@ type Order struct {
 	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
 	ExpiresAt   time.Time  `json:"expires_at"`
 
-	Items    []OrderItem `json:"items,omitempty"`
-	Payment  *Payment    `json:"payment,omitempty"`
-	UserData User        `json:"user"`
+	Items         []OrderItem          `json:"items,omitempty"`
+	Payment       *Payment             `json:"payment,omitempty"`
+	UserData      User                 `json:"user"`
+	Shipment      *Shipment            `json:"shipment,omitempty"`
+	StatusHistory []OrderStatusHistory `json:"status_history,omitempty"`
+}
+
+// Shipment data pengiriman order produk, dibuat pas admin nandain order dikirim
+type Shipment struct {
+	ID             uuid.UUID  `json:"id"`
+	OrderID        uuid.UUID  `json:"order_id"`
+	Courier        string     `json:"courier"`
+	TrackingNumber string     `json:"tracking_number"`
+	ShippedAt      *time.Time `json:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// OrderStatusHistory satu baris timeline perubahan status order.
+// FromStatus nil berarti order baru dibuat, ActorID nil berarti dari sistem.
+type OrderStatusHistory struct {
+	ID         uuid.UUID    `json:"id"`
+	OrderID    uuid.UUID    `json:"order_id"`
+	FromStatus *OrderStatus `json:"from_status"`
+	ToStatus   OrderStatus  `json:"to_status"`
+	ActorID    *uuid.UUID   `json:"actor_id"`
+	ActorName  *string      `json:"actor_name"`
+	Note       *string      `json:"note"`
+	CreatedAt  time.Time    `json:"created_at"`
 }
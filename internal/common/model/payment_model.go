@@ -6,18 +6,44 @@ import (
 	"github.com/google/uuid"
 )
 
+type PaymentMethod string
+
+const (
+	PaymentMethodManualTransfer PaymentMethod = "manual_transfer"
+	PaymentMethodVirtualAccount PaymentMethod = "virtual_account"
+	PaymentMethodQRIS           PaymentMethod = "qris"
+)
+
 const (
 	PaymentStatusUnpaid    PaymentStatus = "unpaid"
 	PaymentStatusSubmitted PaymentStatus = "submitted"
 	PaymentStatusApproved  PaymentStatus = "approved"
 	PaymentStatusRejected  PaymentStatus = "rejected"
+
+	// status setelah approved kalau sebagian / seluruh dana dikembaliin
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
 )
 
 type Payment struct {
 	ID      uuid.UUID     `json:"id"`
 	OrderID uuid.UUID     `json:"order_id"`
 	Status  PaymentStatus `json:"status"`
-	Amount  float64       `json:"amount"`
+	Amount  Money         `json:"amount"`
+
+	// UniqueCode kode unik (1-999) yang udah ditambahin ke Amount,
+	// biar transferan bisa dicocokin ke satu order aja
+	UniqueCode int `json:"unique_code"`
+
+	// total refund yang udah selesai ditransfer balik, ga boleh lebih dari Amount
+	RefundedAmount Money `json:"refunded_amount"`
+
+	// diisi kalau pembayaran lewat payment gateway (VA / QRIS)
+	Method          PaymentMethod `json:"method"`
+	GatewayName     *string       `json:"gateway_name,omitempty"`
+	GatewayChargeID *string       `json:"gateway_charge_id,omitempty"`
+	VANumber        *string       `json:"va_number,omitempty"`
+	QRString        *string       `json:"qr_string,omitempty"`
 
 	ProofImage *string    `json:"proof_image,omitempty"`
 	AdminNote  *string    `json:"admin_note,omitempty"`
@@ -28,3 +54,45 @@ type Payment struct {
 	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
 	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
 }
+
+// GatewayEventOutcome hasil proses callback payment gateway
+type GatewayEventOutcome string
+
+const (
+	GatewayEventProcessed GatewayEventOutcome = "processed"
+	// callback yang bukan lunas, atau lunas buat payment yang udah approved
+	GatewayEventIgnored GatewayEventOutcome = "ignored"
+
+	// uang nya udah masuk tapi ga bisa diproses otomatis, perlu dicek admin
+	// (refund / approve manual)
+	GatewayEventAmountMismatch GatewayEventOutcome = "amount_mismatch"
+	GatewayEventOrderNotOpen   GatewayEventOutcome = "order_not_open"
+	GatewayEventUnknownCharge  GatewayEventOutcome = "unknown_charge"
+)
+
+func (o GatewayEventOutcome) NeedsReview() bool {
+	switch o {
+	case GatewayEventAmountMismatch, GatewayEventOrderNotOpen, GatewayEventUnknownCharge:
+		return true
+	}
+	return false
+}
+
+// PaymentGatewayEvent callback gateway yang udah dicatet
+type PaymentGatewayEvent struct {
+	ID          uuid.UUID           `json:"id"`
+	GatewayName string              `json:"gateway_name"`
+	EventID     string              `json:"event_id"`
+	ChargeID    string              `json:"charge_id"`
+	Status      string              `json:"status"`
+	Amount      *Money              `json:"amount,omitempty"`
+	Outcome     GatewayEventOutcome `json:"outcome"`
+	Detail      *string             `json:"detail,omitempty"`
+	PaymentID   *uuid.UUID          `json:"payment_id,omitempty"`
+	OrderID     *uuid.UUID          `json:"order_id,omitempty"`
+	ReceivedAt  time.Time           `json:"received_at"`
+
+	ResolvedBy     *uuid.UUID `json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	ResolutionNote *string    `json:"resolution_note,omitempty"`
+}
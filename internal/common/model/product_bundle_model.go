@@ -0,0 +1,28 @@
+package model
+
+import "github.com/google/uuid"
+
+// BundleItem satu komponen produk bundle. Price & AvailableStock punya komponen nya
+// (atau varian nya kalau komponen nya produk bervarian), Quantity jumlah per satu bundle
+type BundleItem struct {
+	ID             uuid.UUID  `json:"id"`
+	ProductID      uuid.UUID  `json:"product_id"`
+	VariantID      *uuid.UUID `json:"variant_id,omitempty"`
+	ProductName    string     `json:"product_name"`
+	ProductSlug    string     `json:"product_slug"`
+	VariantName    *string    `json:"variant_name,omitempty"`
+	Price          Money      `json:"price"`
+	AvailableStock int        `json:"available_stock"`
+	Quantity       int        `json:"quantity"`
+}
+
+// BundleComponent snapshot komponen bundle yang disimpen di item order,
+// Quantity per satu bundle (total = Quantity x quantity item order)
+type BundleComponent struct {
+	ProductID   uuid.UUID  `json:"product_id"`
+	VariantID   *uuid.UUID `json:"variant_id,omitempty"`
+	ProductName string     `json:"product_name"`
+	VariantName *string    `json:"variant_name,omitempty"`
+	ProductSKU  *string    `json:"product_sku,omitempty"`
+	Quantity    int        `json:"quantity"`
+}
@@ -25,6 +25,9 @@ const (
 	ProductStatusDraft       ProductStatus = "draft"
 )
 
+// Stock stok fisik (on-hand), ReservedStock yang lagi ditahan order belum dibayar,
+// AvailableStock = Stock - ReservedStock, ini yang boleh dijual.
+// produk bundle ga nyimpen stok sendiri, AvailableStock nya diturunin dari komponen
 type Product struct {
 	ID             uuid.UUID        `json:"product_id"`
 	CategoryID     *uuid.UUID       `json:"product_category_id"`
@@ -33,14 +36,20 @@ type Product struct {
 	Description    *string          `json:"product_description"`
 	Brand          *string          `json:"product_brand"`
 	Condition      ProductCondition `json:"product_condition"`
-	Price          float64          `json:"product_price"`
+	Price          Money            `json:"product_price"`
 	Stock          int              `json:"product_stock"`
+	ReservedStock  int              `json:"product_reserved_stock"`
+	AvailableStock int              `json:"product_available_stock"`
 	SKU            *string          `json:"product_sku"`
 	Specifications JSONB            `json:"product_specification"`
 	Status         ProductStatus    `json:"product_status"`
 	IsFeatured     bool             `json:"product_is_featured"`
 	Weight         *int             `json:"product_weight"`
 	Images         []ProductImage   `json:"product_images"`
+	VariantCount   int              `json:"product_variant_count"`
+	IsBundle       bool             `json:"product_is_bundle"`
+	PriceMin       *Money           `json:"product_price_min,omitempty"`
+	PriceMax       *Money           `json:"product_price_max,omitempty"`
 	Category       *Category        `json:"category"`
 	CreatedAt      time.Time        `json:"product_created_at"`
 }
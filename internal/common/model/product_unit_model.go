@@ -0,0 +1,48 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProductUnitStatus string
+
+const (
+	ProductUnitInStock  ProductUnitStatus = "in_stock"
+	ProductUnitReserved ProductUnitStatus = "reserved"
+	ProductUnitSold     ProductUnitStatus = "sold"
+	ProductUnitReturned ProductUnitStatus = "returned"
+)
+
+// ProductUnitManualTransitions perubahan status unit yang boleh dilakuin admin manual.
+// reserved & sold cuma dipasang alur order (reservasi pas checkout, sold pas payment di approve)
+var ProductUnitManualTransitions = map[ProductUnitStatus][]ProductUnitStatus{
+	ProductUnitInStock:  {ProductUnitReturned},
+	ProductUnitSold:     {ProductUnitReturned},
+	ProductUnitReturned: {ProductUnitInStock},
+}
+
+func (s ProductUnitStatus) CanManuallyMoveTo(next ProductUnitStatus) bool {
+	return slices.Contains(ProductUnitManualTransitions[s], next)
+}
+
+// ProductUnit satu unit fisik produk (hp, laptop) yang dilacak per serial / IMEI.
+// OrderItemID nunjuk ke item order terakhir yang dapet unit ini,
+// tetep disimpen pas unitnya returned biar riwayat penjualannya ga ilang
+type ProductUnit struct {
+	ID            uuid.UUID         `json:"id"`
+	ProductID     uuid.UUID         `json:"product_id"`
+	VariantID     *uuid.UUID        `json:"variant_id,omitempty"`
+	SerialNumber  string            `json:"serial_number"`
+	IMEI          *string           `json:"imei,omitempty"`
+	ConditionNote *string           `json:"condition_note,omitempty"`
+	Status        ProductUnitStatus `json:"status"`
+	OrderItemID   *uuid.UUID        `json:"order_item_id,omitempty"`
+	ReservedAt    *time.Time        `json:"reserved_at,omitempty"`
+	SoldAt        *time.Time        `json:"sold_at,omitempty"`
+	ReturnedAt    *time.Time        `json:"returned_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductOption tipe opsi varian satu produk (misal "Kapasitas", "Warna") + nilai nya
+type ProductOption struct {
+	ID        uuid.UUID            `json:"id"`
+	ProductID uuid.UUID            `json:"product_id"`
+	Name      string               `json:"name"`
+	Position  int                  `json:"position"`
+	Values    []ProductOptionValue `json:"values"`
+}
+
+type ProductOptionValue struct {
+	ID       uuid.UUID `json:"id"`
+	OptionID uuid.UUID `json:"option_id"`
+	Value    string    `json:"value"`
+	Position int       `json:"position"`
+}
+
+// ProductVariant satu kombinasi opsi yang bisa dibeli, punya sku, harga, stok & berat sendiri.
+// Weight nil berarti ikut berat produk nya
+type ProductVariant struct {
+	ID             uuid.UUID         `json:"id"`
+	ProductID      uuid.UUID         `json:"product_id"`
+	SKU            string            `json:"sku"`
+	Name           string            `json:"name"`
+	Price          Money             `json:"price"`
+	Stock          int               `json:"stock"`
+	ReservedStock  int               `json:"reserved_stock"`
+	AvailableStock int               `json:"available_stock"`
+	Weight         *int              `json:"weight,omitempty"`
+	ImageID        *uuid.UUID        `json:"image_id,omitempty"`
+	ImageURL       *string           `json:"image_url,omitempty"`
+	IsActive       bool              `json:"is_active"`
+	Options        map[string]string `json:"options"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefundStatus string
+
+const (
+	RefundStatusRequested RefundStatus = "requested"
+	RefundStatusApproved  RefundStatus = "approved"
+	RefundStatusRejected  RefundStatus = "rejected"
+	RefundStatusCompleted RefundStatus = "completed"
+)
+
+// Refund pengembalian dana buat order yang pembayarannya udah di approve.
+// Items kosong ga mungkin, refund penuh tetep dipecah per item sisa
+// (kecuali order tanpa barang kayak order service request).
+type Refund struct {
+	ID        uuid.UUID    `json:"id"`
+	OrderID   uuid.UUID    `json:"order_id"`
+	PaymentID uuid.UUID    `json:"payment_id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	Status    RefundStatus `json:"status"`
+	IsFull    bool         `json:"is_full"`
+	Amount    Money        `json:"amount"`
+	Reason    string       `json:"reason"`
+
+	AdminNote  *string    `json:"admin_note,omitempty"`
+	ProofImage *string    `json:"proof_image,omitempty"`
+	ReviewedBy *uuid.UUID `json:"reviewed_by,omitempty"`
+
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	Items []RefundItem `json:"items"`
+}
+
+// RefundItem satu baris order item yang direfund.
+// Restock diputusin admin pas approve, stoknya dibalikin pas refund selesai.
+type RefundItem struct {
+	ID          uuid.UUID `json:"id"`
+	RefundID    uuid.UUID `json:"refund_id"`
+	OrderItemID uuid.UUID `json:"order_item_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Quantity    int       `json:"quantity"`
+	Amount      Money     `json:"amount"`
+	Restock     bool      `json:"restock"`
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReturnStatus string
+
+// alur retur: requested -> approved -> shipped -> received -> inspected -> resolved.
+// rejected bisa dari requested (ga layak retur) atau inspected (ga ketemu cacat),
+// cancelled cuma bisa dari user sebelum barang dikirim balik.
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	ReturnStatusApproved  ReturnStatus = "approved"
+	ReturnStatusRejected  ReturnStatus = "rejected"
+	ReturnStatusCancelled ReturnStatus = "cancelled"
+	ReturnStatusShipped   ReturnStatus = "shipped"
+	ReturnStatusReceived  ReturnStatus = "received"
+	ReturnStatusInspected ReturnStatus = "inspected"
+	ReturnStatusResolved  ReturnStatus = "resolved"
+)
+
+type ReturnInspectionResult string
+
+const (
+	InspectionDefectConfirmed ReturnInspectionResult = "defect_confirmed"
+	InspectionNoDefectFound   ReturnInspectionResult = "no_defect_found"
+)
+
+type ReturnResolution string
+
+const (
+	ReturnResolutionRefund      ReturnResolution = "refund"
+	ReturnResolutionReplacement ReturnResolution = "replacement"
+	ReturnResolutionRepair      ReturnResolution = "repair"
+)
+
+// ReturnRequest retur (RMA) barang cacat dari order yang udah sampai.
+// hasil akhirnya salah satu dari refund (RefundID), barang pengganti,
+// atau diubah jadi request servis (ServiceRequestID).
+type ReturnRequest struct {
+	ID      uuid.UUID    `json:"id"`
+	OrderID uuid.UUID    `json:"order_id"`
+	UserID  uuid.UUID    `json:"user_id"`
+	Status  ReturnStatus `json:"status"`
+	Reason  string       `json:"reason"`
+	Photos  []string     `json:"photos"`
+
+	AdminNote  *string    `json:"admin_note,omitempty"`
+	ReviewedBy *uuid.UUID `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+
+	// pengiriman barang dari customer ke toko
+	InboundCourier        *string    `json:"inbound_courier,omitempty"`
+	InboundTrackingNumber *string    `json:"inbound_tracking_number,omitempty"`
+	ShippedAt             *time.Time `json:"shipped_at,omitempty"`
+	ReceivedAt            *time.Time `json:"received_at,omitempty"`
+
+	InspectionResult *ReturnInspectionResult `json:"inspection_result,omitempty"`
+	InspectionNote   *string                 `json:"inspection_note,omitempty"`
+	InspectedBy      *uuid.UUID              `json:"inspected_by,omitempty"`
+	InspectedAt      *time.Time              `json:"inspected_at,omitempty"`
+
+	Resolution                *ReturnResolution `json:"resolution,omitempty"`
+	RefundID                  *uuid.UUID        `json:"refund_id,omitempty"`
+	ServiceRequestID          *uuid.UUID        `json:"service_request_id,omitempty"`
+	ReplacementCourier        *string           `json:"replacement_courier,omitempty"`
+	ReplacementTrackingNumber *string           `json:"replacement_tracking_number,omitempty"`
+	ResolvedBy                *uuid.UUID        `json:"resolved_by,omitempty"`
+	ResolvedAt                *time.Time        `json:"resolved_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Items []ReturnItem `json:"items"`
+}
+
+type ReturnItem struct {
+	ID          uuid.UUID `json:"id"`
+	ReturnID    uuid.UUID `json:"return_id"`
+	OrderItemID uuid.UUID `json:"order_item_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Quantity    int       `json:"quantity"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,40 @@ const (
 	StatusCancelled       ServiceRequestStatus = "cancelled"
 )
 
+// RepairStatus progres perbaikan perangkat, baru ada setelah request nya accepted
+type RepairStatus string
+
+const (
+	RepairStatusDeviceReceived RepairStatus = "device_received"
+	RepairStatusDiagnosing     RepairStatus = "diagnosing"
+	RepairStatusWaitingParts   RepairStatus = "waiting_parts"
+	RepairStatusInRepair       RepairStatus = "in_repair"
+	RepairStatusQualityCheck   RepairStatus = "quality_check"
+	RepairStatusReadyForPickup RepairStatus = "ready_for_pickup"
+	RepairStatusPickedUp       RepairStatus = "picked_up"
+	RepairStatusReturned       RepairStatus = "returned"
+)
+
+// RepairStatusTransitions tabel perpindahan status perbaikan yang diizinkan.
+// key "" = request yang baru di acc dan perangkat nya belum diterima.
+// returned = perangkat dibalikin ke customer tanpa selesai diperbaiki
+// (misal ga bisa diperbaiki), bisa dari status mana aja sebelum diambil.
+var RepairStatusTransitions = map[RepairStatus][]RepairStatus{
+	"":                         {RepairStatusDeviceReceived},
+	RepairStatusDeviceReceived: {RepairStatusDiagnosing, RepairStatusReturned},
+	RepairStatusDiagnosing:     {RepairStatusWaitingParts, RepairStatusInRepair, RepairStatusReturned},
+	RepairStatusWaitingParts:   {RepairStatusInRepair, RepairStatusReturned},
+	RepairStatusInRepair:       {RepairStatusWaitingParts, RepairStatusQualityCheck, RepairStatusReturned},
+	RepairStatusQualityCheck:   {RepairStatusInRepair, RepairStatusReadyForPickup, RepairStatusReturned},
+	RepairStatusReadyForPickup: {RepairStatusPickedUp, RepairStatusReturned},
+	RepairStatusPickedUp:       {},
+	RepairStatusReturned:       {},
+}
+
+func (s RepairStatus) CanTransitionTo(next RepairStatus) bool {
+	return slices.Contains(RepairStatusTransitions[s], next)
+}
+
 type ServiceRequest struct {
 	ID                 uuid.UUID            `json:"id"`
 	UserID             uuid.UUID            `json:"user_id"`
@@ -28,7 +63,7 @@ type ServiceRequest struct {
 	Photo2             *string              `json:"photo_2"`
 	Photo3             *string              `json:"photo_3"`
 	Status             ServiceRequestStatus `json:"status"`
-	QuotedPrice        *float64             `json:"quoted_price"`
+	QuotedPrice        *Money               `json:"quoted_price"`
 	EstimatedDuration  *int                 `json:"estimated_duration"`
 	AdminNote          *string              `json:"admin_note"`
 	QuotedBy           *uuid.UUID           `json:"quoted_by"`
@@ -37,5 +72,169 @@ type ServiceRequest struct {
 	UpdatedAt          time.Time            `json:"updated_at"`
 	QuotedAt           *time.Time           `json:"quoted_at"`
 	DecidedAt          *time.Time           `json:"decided_at"`
+	RepairStatus       *RepairStatus        `json:"repair_status"`
+	ReceivedAt         *time.Time           `json:"received_at"`
+	ReadyAt            *time.Time           `json:"ready_at"`
+	PickedUpAt         *time.Time           `json:"picked_up_at"`
+	AssignedTo         *uuid.UUID           `json:"assigned_to"`
+	AssignedToName     *string              `json:"assigned_to_name"`
+	AssignedAt         *time.Time           `json:"assigned_at"`
 	User               User                 `json:"user"`
+
+	Quotes        []ServiceQuote         `json:"quotes,omitempty"`
+	Parts         []ServiceRequestPart   `json:"parts,omitempty"`
+	StatusHistory []ServiceStatusHistory `json:"status_history,omitempty"`
+	Assignments   []ServiceAssignment    `json:"assignments,omitempty"`
+	Appointments  []ServiceAppointment   `json:"appointments,omitempty"`
+}
+
+// ServiceAssignment satu baris riwayat assign teknisi. FromTechnicianID nil = assign pertama
+type ServiceAssignment struct {
+	ID                 uuid.UUID  `json:"id"`
+	ServiceRequestID   uuid.UUID  `json:"service_request_id"`
+	FromTechnicianID   *uuid.UUID `json:"from_technician_id"`
+	FromTechnicianName *string    `json:"from_technician_name"`
+	ToTechnicianID     *uuid.UUID `json:"to_technician_id"`
+	ToTechnicianName   *string    `json:"to_technician_name"`
+	AssignedBy         *uuid.UUID `json:"assigned_by"`
+	AssignedByName     *string    `json:"assigned_by_name"`
+	Note               *string    `json:"note"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// ServiceStatusHistory satu baris timeline perbaikan, Note nya catatan teknisi
+// di langkah itu. FromStatus nil berarti langkah pertama (perangkat diterima).
+type ServiceStatusHistory struct {
+	ID               uuid.UUID     `json:"id"`
+	ServiceRequestID uuid.UUID     `json:"service_request_id"`
+	FromStatus       *RepairStatus `json:"from_status"`
+	ToStatus         RepairStatus  `json:"to_status"`
+	ActorID          *uuid.UUID    `json:"actor_id"`
+	ActorName        *string       `json:"actor_name"`
+	Note             *string       `json:"note"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+type QuoteStatus string
+
+const (
+	QuoteStatusPending    QuoteStatus = "pending"
+	QuoteStatusAccepted   QuoteStatus = "accepted"
+	QuoteStatusRejected   QuoteStatus = "rejected"
+	QuoteStatusSuperseded QuoteStatus = "superseded"
+	// ga diputusin user sampai lewat ExpiresAt
+	QuoteStatusExpired QuoteStatus = "expired"
+)
+
+type QuoteItemType string
+
+const (
+	QuoteItemLabour     QuoteItemType = "labour"
+	QuoteItemPart       QuoteItemType = "part"
+	QuoteItemDiagnostic QuoteItemType = "diagnostic"
+)
+
+// ServiceQuote satu revisi penawaran servis. revisi terbaru yang pending
+// yang diputusin user, revisi lama nya tetep disimpen buat riwayat.
+type ServiceQuote struct {
+	ID                uuid.UUID          `json:"id"`
+	ServiceRequestID  uuid.UUID          `json:"service_request_id"`
+	Version           int                `json:"version"`
+	Status            QuoteStatus        `json:"status"`
+	Subtotal          Money              `json:"subtotal"`
+	EstimatedDuration int                `json:"estimated_duration"`
+	Note              *string            `json:"note"`
+	CreatedBy         *uuid.UUID         `json:"created_by"`
+	CreatedAt         time.Time          `json:"created_at"`
+	ExpiresAt         *time.Time         `json:"expires_at"`
+	DecidedAt         *time.Time         `json:"decided_at"`
+	Items             []ServiceQuoteItem `json:"items"`
+}
+
+// ServiceQuoteItem baris penawaran, ProductID cuma ada buat sparepart yang diambil dari produk toko
+type ServiceQuoteItem struct {
+	ID          uuid.UUID     `json:"id"`
+	QuoteID     uuid.UUID     `json:"quote_id"`
+	ItemType    QuoteItemType `json:"item_type"`
+	ProductID   *uuid.UUID    `json:"product_id"`
+	Description string        `json:"description"`
+	Quantity    int           `json:"quantity"`
+	UnitPrice   Money         `json:"unit_price"`
+	Subtotal    Money         `json:"subtotal"`
+}
+
+type PartStatus string
+
+const (
+	PartStatusWaiting   PartStatus = "waiting"
+	PartStatusReserved  PartStatus = "reserved"
+	PartStatusCommitted PartStatus = "committed"
+	PartStatusReleased  PartStatus = "released"
+)
+
+// ServiceRequestPart sparepart dari stok toko yang dipake buat perbaikan,
+// asalnya dari baris part revisi penawaran yang nyambung ke produk
+type ServiceRequestPart struct {
+	ID               uuid.UUID  `json:"id"`
+	ServiceRequestID uuid.UUID  `json:"service_request_id"`
+	QuoteID          uuid.UUID  `json:"quote_id"`
+	QuoteItemID      *uuid.UUID `json:"quote_item_id"`
+	ProductID        uuid.UUID  `json:"product_id"`
+	ProductName      string     `json:"product_name"`
+	Quantity         int        `json:"quantity"`
+	Status           PartStatus `json:"status"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	CommittedAt      *time.Time `json:"committed_at"`
+}
+
+type AppointmentStatus string
+
+const (
+	AppointmentBooked    AppointmentStatus = "booked"
+	AppointmentCompleted AppointmentStatus = "completed"
+	AppointmentCancelled AppointmentStatus = "cancelled"
+	AppointmentNoShow    AppointmentStatus = "no_show"
+)
+
+// ServiceAppointment jadwal customer nganter perangkat ke toko
+type ServiceAppointment struct {
+	ID               uuid.UUID         `json:"id"`
+	ServiceRequestID uuid.UUID         `json:"service_request_id"`
+	UserID           uuid.UUID         `json:"user_id"`
+	SlotStart        time.Time         `json:"slot_start"`
+	SlotEnd          time.Time         `json:"slot_end"`
+	Status           AppointmentStatus `json:"status"`
+	Note             *string           `json:"note"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// AppointmentSettings pengaturan booking. jam buka & tanggal libur nya
+// dalam jam lokal toko (UTCOffsetMinutes dari UTC, WIB = 420)
+type AppointmentSettings struct {
+	SlotMinutes      int                  `json:"slot_minutes"`
+	CapacityPerSlot  int                  `json:"capacity_per_slot"`
+	BookingDaysAhead int                  `json:"booking_days_ahead"`
+	UTCOffsetMinutes int                  `json:"utc_offset_minutes"`
+	OpeningHours     []ServiceOpeningHour `json:"opening_hours"`
+	Holidays         []ServiceHoliday     `json:"holidays"`
+}
+
+// Location zona waktu toko
+func (s AppointmentSettings) Location() *time.Location {
+	return time.FixedZone("store", s.UTCOffsetMinutes*60)
+}
+
+// ServiceOpeningHour jam buka satu hari, Weekday 0 = minggu. jam nya format "15:04"
+type ServiceOpeningHour struct {
+	Weekday   int    `json:"weekday"`
+	OpenTime  string `json:"open_time"`
+	CloseTime string `json:"close_time"`
+}
+
+// ServiceHoliday tanggal toko tutup, format "2006-01-02"
+type ServiceHoliday struct {
+	Date string  `json:"date"`
+	Note *string `json:"note"`
 }
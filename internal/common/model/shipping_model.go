@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShippingZone kumpulan wilayah yang tarif ongkirnya sama
+type ShippingZone struct {
+	ID        uuid.UUID            `json:"id"`
+	Name      string               `json:"name"`
+	Regions   []ShippingZoneRegion `json:"regions"`
+	Rates     []ShippingRate       `json:"rates"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// ShippingZoneRegion wilayah yang masuk ke zona. City nil berarti satu provinsi.
+type ShippingZoneRegion struct {
+	ID        uuid.UUID `json:"id"`
+	ZoneID    uuid.UUID `json:"zone_id"`
+	Province  string    `json:"province"`
+	City      *string   `json:"city"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ShippingRate tarif satu bracket berat (gram) di sebuah zona.
+// MaxWeight nil berarti bracket terakhir, kelebihannya dihitung pake ExtraPerKg.
+type ShippingRate struct {
+	ID         uuid.UUID `json:"id"`
+	ZoneID     uuid.UUID `json:"zone_id"`
+	MinWeight  int       `json:"min_weight"`
+	MaxWeight  *int      `json:"max_weight"`
+	Price      Money     `json:"price"`
+	ExtraPerKg Money     `json:"extra_per_kg"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type ShippingQuote struct {
+	ZoneID     uuid.UUID `json:"zone_id"`
+	ZoneName   string    `json:"zone_name"`
+	WeightGram int       `json:"weight_gram"`
+	Cost       Money     `json:"cost"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type VoucherDiscountType string
+
+const (
+	VoucherDiscountPercentage VoucherDiscountType = "percentage"
+	VoucherDiscountFixed      VoucherDiscountType = "fixed"
+)
+
+// Voucher kode promo. DiscountValue itu persen (1-100) buat tipe percentage
+// dan nominal rupiah buat tipe fixed. kalau ProductIDs & CategoryIDs kosong berarti
+// berlaku buat semua produk, kalau diisi diskon cuma dihitung dari
+// item yang masuk salah satunya.
+type Voucher struct {
+	ID            uuid.UUID           `json:"id"`
+	Code          string              `json:"code"`
+	Description   *string             `json:"description,omitempty"`
+	DiscountType  VoucherDiscountType `json:"discount_type"`
+	DiscountValue int64               `json:"discount_value"`
+	MinSpend      Money               `json:"min_spend"`
+	MaxDiscount   *Money              `json:"max_discount,omitempty"`
+	StartsAt      time.Time           `json:"starts_at"`
+	EndsAt        time.Time           `json:"ends_at"`
+	UsageLimit    *int                `json:"usage_limit,omitempty"`
+	PerUserLimit  *int                `json:"per_user_limit,omitempty"`
+	UsedCount     int                 `json:"used_count"`
+	IsActive      bool                `json:"is_active"`
+	ProductIDs    []uuid.UUID         `json:"product_ids"`
+	CategoryIDs   []uuid.UUID         `json:"category_ids"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+// AppliedVoucher hasil validasi voucher terhadap isi order
+type AppliedVoucher struct {
+	VoucherID        uuid.UUID `json:"voucher_id"`
+	Code             string    `json:"code"`
+	EligibleSubtotal Money     `json:"eligible_subtotal"`
+	Discount         Money     `json:"discount"`
+}
@@ -0,0 +1,94 @@
+package inventory
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/middleware"
+	"backEnd-RingoTechLife/pkg"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	"github.com/google/uuid"
+)
+
+type InventoryHandler struct {
+	inventoryService *InventoryService
+}
+
+func NewInventoryHandler(svc *InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: svc,
+	}
+}
+
+func (ih *InventoryHandler) GetMovementsHandler(w http.ResponseWriter, r *http.Request) {
+
+	productId, err := uuid.Parse(chi.URLParam(r, "productId"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id produk tidak valid!")
+		return
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			pkg.JSONError(w, 400, "limit tidak valid!")
+			return
+		}
+	}
+
+	data, getErr := ih.inventoryService.GetMovements(r.Context(), productId, limit)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil riwayat stok", data)
+}
+
+func (ih *InventoryHandler) CheckConsistencyHandler(w http.ResponseWriter, r *http.Request) {
+
+	var productId *uuid.UUID
+	if raw := r.URL.Query().Get("product_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			pkg.JSONError(w, 400, "id produk tidak valid!")
+			return
+		}
+		productId = &id
+	}
+
+	data, err := ih.inventoryService.CheckConsistency(r.Context(), productId)
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengecek konsistensi stok", data)
+}
+
+func (ih *InventoryHandler) SetUpRoute(router chi.Router) {
+
+	router.Route("/inventory", func(r chi.Router) {
+		r.Use(httprate.Limit(
+			30,
+			time.Minute,
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				errorRes := common.NewErrorResponse(http.StatusTooManyRequests, "Terlalu banyak request, coba lagi nanti")
+				errorResJson, _ := json.Marshal(errorRes)
+				w.Write(errorResJson)
+			}),
+		))
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware(middleware.RoleAdmin))
+
+		r.Get("/movements/{productId}", ih.GetMovementsHandler)
+		r.Get("/check", ih.CheckConsistencyHandler)
+	})
+}
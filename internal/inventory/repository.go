@@ -0,0 +1,351 @@
+package inventory
+
+import (
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrProductNotFound = errors.New("produk tidak ditemukan")
+var ErrInsufficientStock = errors.New("stok produk tidak cukup")
+var ErrVariantNotFound = errors.New("varian produk tidak ditemukan")
+var ErrStockManagedByVariant = errors.New("produk ini punya varian, stok nya diatur per varian")
+var ErrStockManagedByBundle = errors.New("produk bundle ga punya stok sendiri, stok nya ngikut komponen")
+
+const (
+	RefOrder   = "order"
+	RefRefund  = "refund"
+	RefReturn  = "return"
+	RefProduct = "product"
+	RefVariant = "variant"
+	// sparepart yang dipake buat perbaikan, reference_id nya id service request
+	RefServiceRequest = "service_request"
+)
+
+// Movement perubahan stok yang mau dicatat
+type Movement struct {
+	ProductID     uuid.UUID
+	VariantID     *uuid.UUID // diisi kalau stoknya punya varian, stok produk tetep ikut berubah
+	Delta         int
+	Reserved      int // reservasi yang ikut dilepas, cuma dipake pas commit order
+	Reason        model.InventoryReason
+	ReferenceType string
+	ReferenceID   *uuid.UUID
+	ActorID       *uuid.UUID
+	Note          *string
+}
+
+// MoveTx satu-satunya jalan buat ngubah stok produk. stok diubah relatif
+// (ga boleh jadi lebih kecil dari stok yang lagi di reserve) dan baris ledger nya
+// ditulis di transaksi yang sama, jadi stok di tabel products selalu bisa
+// dijelasin dari inventory_movements.
+func MoveTx(ctx context.Context, tx pgx.Tx, m Movement) (int, error) {
+	if m.Delta == 0 {
+		return 0, nil
+	}
+
+	var stockAfter int
+	stockQuery := `
+		UPDATE products
+		SET stock = stock + $1,
+		    reserved_stock = reserved_stock - $3
+		WHERE id = $2 AND reserved_stock >= $3 AND stock + $1 >= reserved_stock - $3
+		RETURNING stock
+	`
+	err := tx.QueryRow(ctx, stockQuery, m.Delta, m.ProductID, m.Reserved).Scan(&stockAfter)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, stockError(ctx, tx, m.ProductID)
+		}
+		return 0, fmt.Errorf("failed to update stock: %w", err)
+	}
+
+	// stok produk yang punya varian = jumlah stok varian nya, jadi dua-dua nya diubah bareng
+	var variantStockAfter *int
+	if m.VariantID != nil {
+		variantQuery := `
+			UPDATE product_variants
+			SET stock = stock + $1,
+			    reserved_stock = reserved_stock - $3,
+			    updated_at = NOW()
+			WHERE id = $2 AND product_id = $4
+			  AND reserved_stock >= $3 AND stock + $1 >= reserved_stock - $3
+			RETURNING stock
+		`
+		var after int
+		err := tx.QueryRow(ctx, variantQuery, m.Delta, *m.VariantID, m.Reserved, m.ProductID).Scan(&after)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return 0, variantStockError(ctx, tx, m.ProductID, *m.VariantID)
+			}
+			return 0, fmt.Errorf("failed to update variant stock: %w", err)
+		}
+		variantStockAfter = &after
+	}
+
+	var refType *string
+	if m.ReferenceType != "" {
+		refType = &m.ReferenceType
+	}
+
+	ledgerQuery := `
+		INSERT INTO inventory_movements
+			(product_id, variant_id, delta, stock_after, variant_stock_after,
+			 reason, reference_type, reference_id, actor_id, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err = tx.Exec(ctx, ledgerQuery,
+		m.ProductID,
+		m.VariantID,
+		m.Delta,
+		stockAfter,
+		variantStockAfter,
+		m.Reason,
+		refType,
+		m.ReferenceID,
+		m.ActorID,
+		m.Note,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert inventory movement: %w", err)
+	}
+
+	return stockAfter, nil
+}
+
+// SetTx buat input stok absolut (form produk), dicatat sebagai selisihnya.
+// produk yang punya varian ga bisa diset langsung, harus lewat SetVariantTx,
+// produk bundle juga ga bisa karena stok nya diturunin dari komponen
+func SetTx(ctx context.Context, tx pgx.Tx, productID uuid.UUID, stock int, reason model.InventoryReason, actorID *uuid.UUID, note *string) (int, error) {
+	var current int
+	var hasVariants, isBundle bool
+	query := `
+		SELECT stock, EXISTS(SELECT 1 FROM product_variants WHERE product_id = products.id), is_bundle
+		FROM products
+		WHERE id = $1
+		FOR UPDATE
+	`
+	err := tx.QueryRow(ctx, query, productID).Scan(&current, &hasVariants, &isBundle)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrProductNotFound
+		}
+		return 0, err
+	}
+
+	if hasVariants {
+		return 0, ErrStockManagedByVariant
+	}
+	if isBundle && stock != current {
+		return 0, ErrStockManagedByBundle
+	}
+
+	if stock == current {
+		return current, nil
+	}
+
+	id := productID
+	return MoveTx(ctx, tx, Movement{
+		ProductID:     productID,
+		Delta:         stock - current,
+		Reason:        reason,
+		ReferenceType: RefProduct,
+		ReferenceID:   &id,
+		ActorID:       actorID,
+		Note:          note,
+	})
+}
+
+// SetVariantTx sama kayak SetTx tapi buat stok satu varian, balikin stok varian setelahnya
+func SetVariantTx(ctx context.Context, tx pgx.Tx, productID uuid.UUID, variantID uuid.UUID, stock int, reason model.InventoryReason, actorID *uuid.UUID, note *string) (int, error) {
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM products WHERE id = $1 FOR UPDATE`, productID); err != nil {
+		return 0, err
+	}
+
+	var current int
+	query := `SELECT stock FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE`
+	err := tx.QueryRow(ctx, query, variantID, productID).Scan(&current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrVariantNotFound
+		}
+		return 0, err
+	}
+
+	if stock == current {
+		return current, nil
+	}
+
+	id := variantID
+	_, err = MoveTx(ctx, tx, Movement{
+		ProductID:     productID,
+		VariantID:     &variantID,
+		Delta:         stock - current,
+		Reason:        reason,
+		ReferenceType: RefVariant,
+		ReferenceID:   &id,
+		ActorID:       actorID,
+		Note:          note,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return stock, nil
+}
+
+func variantStockError(ctx context.Context, tx pgx.Tx, productID uuid.UUID, variantID uuid.UUID) error {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM product_variants WHERE id = $1 AND product_id = $2)`
+	if err := tx.QueryRow(ctx, query, variantID, productID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrVariantNotFound
+	}
+	return ErrInsufficientStock
+}
+
+func stockError(ctx context.Context, tx pgx.Tx, productID uuid.UUID) error {
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)`, productID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrProductNotFound
+	}
+	return ErrInsufficientStock
+}
+
+type InventoryRepositoryInterface interface {
+	GetMovements(ctx context.Context, productID uuid.UUID, limit int) ([]model.InventoryMovement, error)
+	GetDiscrepancies(ctx context.Context, productID *uuid.UUID) (int, []dto.StockDiscrepancy, error)
+}
+
+type InventoryRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewInventoryRepository(pool *pgxpool.Pool) *InventoryRepositoryImpl {
+	return &InventoryRepositoryImpl{
+		db: pool,
+	}
+}
+
+func (r *InventoryRepositoryImpl) GetMovements(ctx context.Context, productID uuid.UUID, limit int) ([]model.InventoryMovement, error) {
+	var exists bool
+	if err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)`, productID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	query := `
+		SELECT m.id, m.product_id, m.variant_id, m.delta, m.stock_after, m.variant_stock_after,
+		       m.reason, m.reference_type, m.reference_id, m.actor_id, u.full_name, m.note, m.created_at
+		FROM inventory_movements m
+		LEFT JOIN users u ON u.id = m.actor_id
+		WHERE m.product_id = $1
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []model.InventoryMovement{}
+	for rows.Next() {
+		var m model.InventoryMovement
+		err := rows.Scan(
+			&m.ID,
+			&m.ProductID,
+			&m.VariantID,
+			&m.Delta,
+			&m.StockAfter,
+			&m.VariantStockAfter,
+			&m.Reason,
+			&m.ReferenceType,
+			&m.ReferenceID,
+			&m.ActorID,
+			&m.ActorName,
+			&m.Note,
+			&m.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan inventory movement: %w", err)
+		}
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
+
+// GetDiscrepancies ngitung ulang stok dari ledger terus dibandingin sama
+// kolom products.stock, reserved_stock juga dicocokin sama item order yang
+// stoknya belum di commit (item bundle dihitung ke komponen nya) plus sparepart
+// servis yang masih di reserve, produk bervarian
+// dicocokin juga sama total stok varian nya. productID nil berarti cek semua produk.
+func (r *InventoryRepositoryImpl) GetDiscrepancies(ctx context.Context, productID *uuid.UUID) (int, []dto.StockDiscrepancy, error) {
+	query := `
+		SELECT p.id, p.name, p.stock,
+		       COALESCE((SELECT SUM(m.delta) FROM inventory_movements m WHERE m.product_id = p.id), 0)::int,
+		       p.reserved_stock,
+		       COALESCE((
+		           SELECT SUM(r.quantity)
+		           FROM (
+		               SELECT oi.product_id, oi.quantity
+		               FROM order_items oi
+		               JOIN orders o ON o.id = oi.order_id
+		               WHERE oi.bundle_components IS NULL
+		                 AND oi.item_type = 'product'
+		                 AND NOT o.stock_committed
+		                 AND o.status IN ('pending', 'waiting_confirmation')
+		               UNION ALL
+		               SELECT (c->>'product_id')::uuid, oi.quantity * (c->>'quantity')::int
+		               FROM order_items oi
+		               JOIN orders o ON o.id = oi.order_id
+		               CROSS JOIN jsonb_array_elements(oi.bundle_components) c
+		               WHERE NOT o.stock_committed
+		                 AND o.status IN ('pending', 'waiting_confirmation')
+		               UNION ALL
+		               SELECT sp.product_id, sp.quantity
+		               FROM service_request_parts sp
+		               WHERE sp.status = 'reserved'
+		           ) r
+		           WHERE r.product_id = p.id
+		       ), 0)::int,
+		       (SELECT SUM(v.stock)::int FROM product_variants v WHERE v.product_id = p.id)
+		FROM products p
+		WHERE $1::uuid IS NULL OR p.id = $1
+		ORDER BY p.name
+	`
+	rows, err := r.db.Query(ctx, query, productID)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	checked := 0
+	discrepancies := []dto.StockDiscrepancy{}
+	for rows.Next() {
+		var d dto.StockDiscrepancy
+		err := rows.Scan(&d.ProductID, &d.ProductName, &d.Stock, &d.LedgerStock, &d.ReservedStock, &d.OpenReservations, &d.VariantStock)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to scan stock check: %w", err)
+		}
+		checked++
+		variantMismatch := d.VariantStock != nil && *d.VariantStock != d.Stock
+		if d.Stock != d.LedgerStock || d.ReservedStock != d.OpenReservations || variantMismatch {
+			d.Difference = d.Stock - d.LedgerStock
+			discrepancies = append(discrepancies, d)
+		}
+	}
+	return checked, discrepancies, rows.Err()
+}
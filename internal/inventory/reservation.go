@@ -0,0 +1,209 @@
+package inventory
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ShortageError stok yang bisa dijual (stock - reserved_stock) ga cukup buat produk ini
+type ShortageError struct {
+	ProductID uuid.UUID
+	VariantID *uuid.UUID
+}
+
+func (e *ShortageError) Error() string {
+	if e.VariantID != nil {
+		return fmt.Sprintf("insufficient available stock for product %s variant %s", e.ProductID, *e.VariantID)
+	}
+	return fmt.Sprintf("insufficient available stock for product %s", e.ProductID)
+}
+
+func (e *ShortageError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+// Line jumlah barang per produk (dan varian nya kalau ada) yang di reserve / dilepas
+type Line struct {
+	ProductID uuid.UUID
+	VariantID *uuid.UUID
+	Quantity  int
+}
+
+func (l Line) sameStock(o Line) bool {
+	if l.ProductID != o.ProductID {
+		return false
+	}
+	if l.VariantID == nil || o.VariantID == nil {
+		return l.VariantID == nil && o.VariantID == nil
+	}
+	return *l.VariantID == *o.VariantID
+}
+
+func variantKey(l Line) []byte {
+	if l.VariantID == nil {
+		return nil
+	}
+	return l.VariantID[:]
+}
+
+// ItemLines stok yang kepake satu item order. item bundle dipecah ke komponen nya
+// (quantity komponen x quantity item), item biasa jadi satu line
+func ItemLines(productID uuid.UUID, variantID *uuid.UUID, quantity int, components []model.BundleComponent) []Line {
+	if len(components) == 0 {
+		return []Line{{ProductID: productID, VariantID: variantID, Quantity: quantity}}
+	}
+
+	lines := make([]Line, len(components))
+	for i, c := range components {
+		lines[i] = Line{ProductID: c.ProductID, VariantID: c.VariantID, Quantity: c.Quantity * quantity}
+	}
+	return lines
+}
+
+// mergeLines gabungin produk / varian yang sama terus diurutin per id,
+// biar urutan lock baris products selalu sama dan ga deadlock
+func mergeLines(lines []Line) []Line {
+	merged := []Line{}
+	for _, l := range lines {
+		idx := slices.IndexFunc(merged, l.sameStock)
+		if idx < 0 {
+			merged = append(merged, l)
+			continue
+		}
+		merged[idx].Quantity += l.Quantity
+	}
+	slices.SortFunc(merged, func(a, b Line) int {
+		if c := slices.Compare(a.ProductID[:], b.ProductID[:]); c != 0 {
+			return c
+		}
+		return slices.Compare(variantKey(a), variantKey(b))
+	})
+	return merged
+}
+
+// ReserveTx nahan stok buat order yang belum dibayar. stok on-hand ga berubah
+// (jadi ga masuk ledger), cuma reserved_stock yang naik. baris produk di lock
+// dulu (FOR UPDATE) biar dua order barengan buat unit terakhir ga bisa lolos dua-duanya.
+// kalau line nya punya varian, stok varian nya juga dicek dan ikut di reserve.
+func ReserveTx(ctx context.Context, tx pgx.Tx, lines []Line) error {
+	for _, l := range mergeLines(lines) {
+		var stock, reserved int
+		err := tx.QueryRow(ctx, `
+			SELECT stock, reserved_stock
+			FROM products
+			WHERE id = $1
+			FOR UPDATE
+		`, l.ProductID).Scan(&stock, &reserved)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return &ShortageError{ProductID: l.ProductID}
+			}
+			return fmt.Errorf("failed to lock product: %w", err)
+		}
+
+		if stock-reserved < l.Quantity {
+			return &ShortageError{ProductID: l.ProductID, VariantID: l.VariantID}
+		}
+
+		if l.VariantID != nil {
+			if err := reserveVariantTx(ctx, tx, l); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE products
+			SET reserved_stock = reserved_stock + $1
+			WHERE id = $2
+		`, l.Quantity, l.ProductID)
+		if err != nil {
+			return fmt.Errorf("failed to reserve stock: %w", err)
+		}
+	}
+	return nil
+}
+
+func reserveVariantTx(ctx context.Context, tx pgx.Tx, l Line) error {
+	var stock, reserved int
+	var active bool
+	err := tx.QueryRow(ctx, `
+		SELECT stock, reserved_stock, is_active
+		FROM product_variants
+		WHERE id = $1 AND product_id = $2
+		FOR UPDATE
+	`, *l.VariantID, l.ProductID).Scan(&stock, &reserved, &active)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &ShortageError{ProductID: l.ProductID, VariantID: l.VariantID}
+		}
+		return fmt.Errorf("failed to lock product variant: %w", err)
+	}
+
+	if !active || stock-reserved < l.Quantity {
+		return &ShortageError{ProductID: l.ProductID, VariantID: l.VariantID}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE product_variants
+		SET reserved_stock = reserved_stock + $1
+		WHERE id = $2
+	`, l.Quantity, *l.VariantID)
+	if err != nil {
+		return fmt.Errorf("failed to reserve variant stock: %w", err)
+	}
+	return nil
+}
+
+// ReleaseTx ngelepas reservasi order yang batal / expired / payment nya ditolak
+func ReleaseTx(ctx context.Context, tx pgx.Tx, lines []Line) error {
+	for _, l := range mergeLines(lines) {
+		_, err := tx.Exec(ctx, `
+			UPDATE products
+			SET reserved_stock = reserved_stock - $1
+			WHERE id = $2
+		`, l.Quantity, l.ProductID)
+		if err != nil {
+			return fmt.Errorf("failed to release stock: %w", err)
+		}
+
+		if l.VariantID == nil {
+			continue
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE product_variants
+			SET reserved_stock = reserved_stock - $1
+			WHERE id = $2
+		`, l.Quantity, *l.VariantID)
+		if err != nil {
+			return fmt.Errorf("failed to release variant stock: %w", err)
+		}
+	}
+	return nil
+}
+
+// CommitTx reservasi jadi penjualan beneran (payment di approve):
+// stok on-hand sama reserved_stock turun bareng dan dicatat di ledger sebagai sale
+func CommitTx(ctx context.Context, tx pgx.Tx, lines []Line, referenceType string, referenceID uuid.UUID, actorID *uuid.UUID) error {
+	for _, l := range mergeLines(lines) {
+		_, err := MoveTx(ctx, tx, Movement{
+			ProductID:     l.ProductID,
+			VariantID:     l.VariantID,
+			Delta:         -l.Quantity,
+			Reserved:      l.Quantity,
+			Reason:        model.InventoryReasonSale,
+			ReferenceType: referenceType,
+			ReferenceID:   &referenceID,
+			ActorID:       actorID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to commit stock: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package inventory
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMovementLimit = 100
+	maxMovementLimit     = 500
+)
+
+type InventoryService struct {
+	inventoryRepo InventoryRepositoryInterface
+}
+
+func NewInventoryService(repo *InventoryRepositoryImpl) *InventoryService {
+	return &InventoryService{
+		inventoryRepo: repo,
+	}
+}
+
+func (s *InventoryService) GetMovements(ctx context.Context, productId uuid.UUID, limit int) ([]model.InventoryMovement, *common.ErrorResponse) {
+
+	if limit <= 0 {
+		limit = defaultMovementLimit
+	}
+	limit = min(limit, maxMovementLimit)
+
+	data, err := s.inventoryRepo.GetMovements(ctx, productId, limit)
+	if err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			return []model.InventoryMovement{}, common.NewErrorResponse(404, err.Error())
+		}
+		return []model.InventoryMovement{}, common.NewErrorResponse(500, "gagal mengambil riwayat stok! "+err.Error())
+	}
+	return data, nil
+}
+
+// CheckConsistency cuma laporan, stok yang selisih dibenerin manual lewat update produk
+// (yang nantinya kecatat juga sebagai adjustment)
+func (s *InventoryService) CheckConsistency(ctx context.Context, productId *uuid.UUID) (dto.StockConsistencyReport, *common.ErrorResponse) {
+
+	checked, discrepancies, err := s.inventoryRepo.GetDiscrepancies(ctx, productId)
+	if err != nil {
+		return dto.StockConsistencyReport{}, common.NewErrorResponse(500, "gagal mengecek stok! "+err.Error())
+	}
+
+	return dto.StockConsistencyReport{
+		CheckedProducts: checked,
+		Consistent:      len(discrepancies) == 0,
+		Discrepancies:   discrepancies,
+	}, nil
+}
@@ -0,0 +1,167 @@
+// Package invoice nyusun dokumen invoice & kwitansi order jadi PDF (pure Go, pake fpdf).
+// package ini cuma urusan dokumen & layout, penomoran dan snapshot data nya
+// diurus di package order biar nomornya keluar di transaksi yang sama
+// dengan konfirmasi order.
+package invoice
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	invoicePrefix = "INV"
+	receiptPrefix = "KWT"
+)
+
+// Location invoice pake tanggal WIB, periode penomoran juga ngikut WIB
+var Location = time.FixedZone("WIB", 7*60*60)
+
+// Store identitas toko yang dicetak di kop invoice
+type Store struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+	Email   string `json:"email"`
+	TaxID   string `json:"tax_id"`
+}
+
+type Party struct {
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+type Line struct {
+	Description string      `json:"description"`
+	SKU         string      `json:"sku,omitempty"`
+	Quantity    int         `json:"quantity"`
+	UnitPrice   model.Money `json:"unit_price"`
+	Total       model.Money `json:"total"`
+}
+
+type PaymentInfo struct {
+	Method     model.PaymentMethod `json:"method"`
+	Reference  string              `json:"reference,omitempty"`
+	Amount     model.Money         `json:"amount"`
+	UniqueCode int                 `json:"unique_code"`
+	PaidAt     *time.Time          `json:"paid_at,omitempty"`
+}
+
+// Document isi invoice. disimpen sebagai snapshot JSON pas pertama dibuat,
+// jadi invoice yang udah keluar ga berubah walaupun data produk / toko berubah
+type Document struct {
+	Number        string      `json:"number"`
+	ReceiptNumber string      `json:"receipt_number"`
+	Revision      int         `json:"revision"`
+	OrderID       string      `json:"order_id"`
+	IssuedAt      time.Time   `json:"issued_at"`
+	OrderedAt     time.Time   `json:"ordered_at"`
+	Store         Store       `json:"store"`
+	Customer      Party       `json:"customer"`
+	ShipTo        *Party      `json:"ship_to,omitempty"`
+	Lines         []Line      `json:"lines"`
+	Subtotal      model.Money `json:"subtotal"`
+	Discount      model.Money `json:"discount"`
+	VoucherCode   string      `json:"voucher_code,omitempty"`
+	ShippingCost  model.Money `json:"shipping_cost"`
+	Total         model.Money `json:"total"`
+	Notes         string      `json:"notes,omitempty"`
+
+	// diisi dari data payment terbaru pas kwitansi dicetak
+	Payment *PaymentInfo `json:"payment,omitempty"`
+}
+
+// Period periode penomoran invoice (YYYY-MM, WIB)
+func Period(t time.Time) string {
+	return t.In(Location).Format("2006-01")
+}
+
+// Number nomor invoice, contoh INV/2026/10/00042. urutannya reset tiap bulan.
+func Number(period string, sequence int) string {
+	return fmt.Sprintf("%s/%s/%05d", invoicePrefix, strings.ReplaceAll(period, "-", "/"), sequence)
+}
+
+// ReceiptNumber nomor kwitansi ngikut nomor invoice nya, jadi ikut gap-free juga
+func ReceiptNumber(invoiceNumber string) string {
+	return receiptPrefix + strings.TrimPrefix(invoiceNumber, invoicePrefix)
+}
+
+// FileName nama file PDF buat header Content-Disposition
+func FileName(number string) string {
+	return strings.ReplaceAll(number, "/", "-") + ".pdf"
+}
+
+var monthNames = [...]string{
+	"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+}
+
+func formatDate(t time.Time) string {
+	t = t.In(Location)
+	return fmt.Sprintf("%d %s %d", t.Day(), monthNames[t.Month()-1], t.Year())
+}
+
+func formatDateTime(t time.Time) string {
+	return formatDate(t) + " " + t.In(Location).Format("15:04") + " WIB"
+}
+
+// formatRupiah contoh Rp 1.250.000
+func formatRupiah(m model.Money) string {
+	n := m.Int64()
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	digits := fmt.Sprint(n)
+	var sb strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteRune(c)
+	}
+	return sign + "Rp " + sb.String()
+}
+
+var numberWords = [...]string{
+	"", "satu", "dua", "tiga", "empat", "lima",
+	"enam", "tujuh", "delapan", "sembilan", "sepuluh", "sebelas",
+}
+
+// terbilang nominal dalam kata buat kwitansi, contoh 1500 -> "seribu lima ratus"
+func terbilang(n int64) string {
+	switch {
+	case n < 12:
+		return numberWords[n]
+	case n < 20:
+		return terbilang(n-10) + " belas"
+	case n < 100:
+		return joinWords(terbilang(n/10)+" puluh", terbilang(n%10))
+	case n < 200:
+		return joinWords("seratus", terbilang(n-100))
+	case n < 1000:
+		return joinWords(terbilang(n/100)+" ratus", terbilang(n%100))
+	case n < 2000:
+		return joinWords("seribu", terbilang(n-1000))
+	case n < 1_000_000:
+		return joinWords(terbilang(n/1000)+" ribu", terbilang(n%1000))
+	case n < 1_000_000_000:
+		return joinWords(terbilang(n/1_000_000)+" juta", terbilang(n%1_000_000))
+	case n < 1_000_000_000_000:
+		return joinWords(terbilang(n/1_000_000_000)+" miliar", terbilang(n%1_000_000_000))
+	}
+	return joinWords(terbilang(n/1_000_000_000_000)+" triliun", terbilang(n%1_000_000_000_000))
+}
+
+func joinWords(a string, b string) string {
+	if b == "" {
+		return a
+	}
+	return a + " " + b
+}
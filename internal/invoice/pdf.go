@@ -0,0 +1,308 @@
+package invoice
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pageMargin   = 15.0
+	contentWidth = 210.0 - 2*pageMargin
+	lineHeight   = 5.0
+)
+
+// lebar kolom tabel item: no, deskripsi, qty, harga, jumlah
+var lineColumns = [...]float64{10, 90, 15, 32.5, 32.5}
+
+type document struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+}
+
+func newDocument(title string) *document {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("RingoTechLife", true)
+	pdf.AddPage()
+
+	// font bawaan fpdf cuma cp1252, teks utf-8 diterjemahin dulu
+	return &document{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+}
+
+func (d *document) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("gagal membuat PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *document) text(style string, size float64, w float64, txt string, align string) {
+	d.pdf.SetFont("Helvetica", style, size)
+	d.pdf.CellFormat(w, lineHeight, d.tr(txt), "", 0, align, false, 0, "")
+}
+
+func (d *document) line(style string, size float64, txt string, align string) {
+	d.text(style, size, contentWidth, txt, align)
+	d.pdf.Ln(lineHeight)
+}
+
+func (d *document) rule() {
+	y := d.pdf.GetY() + 2
+	d.pdf.SetDrawColor(180, 180, 180)
+	d.pdf.Line(pageMargin, y, pageMargin+contentWidth, y)
+	d.pdf.SetY(y + 3)
+}
+
+// header kop toko di kiri, judul dokumen & nomor di kanan
+func (d *document) header(doc Document, title string, number string, date string) {
+	top := d.pdf.GetY()
+
+	d.line("B", 14, doc.Store.Name, "L")
+	for _, info := range storeInfoLines(doc.Store) {
+		d.line("", 9, info, "L")
+	}
+	bottomLeft := d.pdf.GetY()
+
+	d.pdf.SetY(top)
+	d.line("B", 18, title, "R")
+	d.line("", 10, number, "R")
+	d.line("", 9, date, "R")
+	if doc.Revision > 1 {
+		d.line("I", 9, fmt.Sprintf("Revisi %d", doc.Revision), "R")
+	}
+
+	d.pdf.SetY(max(bottomLeft, d.pdf.GetY()))
+	d.rule()
+}
+
+func storeInfoLines(s Store) []string {
+	var lines []string
+	if s.Address != "" {
+		lines = append(lines, s.Address)
+	}
+
+	var contact []string
+	if s.Phone != "" {
+		contact = append(contact, "Telp. "+s.Phone)
+	}
+	if s.Email != "" {
+		contact = append(contact, s.Email)
+	}
+	if len(contact) > 0 {
+		lines = append(lines, strings.Join(contact, " | "))
+	}
+
+	if s.TaxID != "" {
+		lines = append(lines, "NPWP "+s.TaxID)
+	}
+	return lines
+}
+
+// parties blok "ditagihkan ke" dan "dikirim ke" sebelahan
+func (d *document) parties(doc Document) {
+	half := contentWidth / 2
+	top := d.pdf.GetY()
+
+	d.partyBlock(pageMargin, half, "Ditagihkan kepada", doc.Customer)
+	bottom := d.pdf.GetY()
+
+	if doc.ShipTo != nil {
+		d.pdf.SetY(top)
+		d.partyBlock(pageMargin+half, half, "Dikirim kepada", *doc.ShipTo)
+		bottom = max(bottom, d.pdf.GetY())
+	}
+
+	d.pdf.SetY(bottom + 3)
+}
+
+func (d *document) partyBlock(x float64, w float64, label string, p Party) {
+	d.pdf.SetX(x)
+	d.text("B", 9, w, label, "L")
+	d.pdf.Ln(lineHeight)
+
+	for _, txt := range []string{p.Name, p.Phone, p.Email} {
+		if txt == "" {
+			continue
+		}
+		d.pdf.SetX(x)
+		d.text("", 9, w, txt, "L")
+		d.pdf.Ln(lineHeight)
+	}
+
+	if p.Address != "" {
+		d.pdf.SetX(x)
+		d.pdf.SetFont("Helvetica", "", 9)
+		d.pdf.MultiCell(w-5, lineHeight, d.tr(p.Address), "", "L", false)
+	}
+}
+
+func (d *document) lineTable(lines []Line) {
+	headers := [...]string{"No", "Deskripsi", "Qty", "Harga", "Jumlah"}
+	aligns := [...]string{"C", "L", "C", "R", "R"}
+
+	d.pdf.SetFont("Helvetica", "B", 9)
+	d.pdf.SetFillColor(235, 235, 235)
+	for i, h := range headers {
+		d.pdf.CellFormat(lineColumns[i], 7, h, "1", 0, aligns[i], true, 0, "")
+	}
+	d.pdf.Ln(7)
+
+	d.pdf.SetFont("Helvetica", "", 9)
+	for i, l := range lines {
+		desc := l.Description
+		if l.SKU != "" {
+			desc += " (" + l.SKU + ")"
+		}
+
+		// deskripsi panjang dipecah ke beberapa baris, tinggi baris ngikut
+		descLines := d.pdf.SplitText(d.tr(desc), lineColumns[1]-2)
+		h := float64(max(len(descLines), 1)) * lineHeight
+
+		if d.pdf.GetY()+h > 297-pageMargin {
+			d.pdf.AddPage()
+		}
+
+		x, y := d.pdf.GetXY()
+		d.pdf.CellFormat(lineColumns[0], h, fmt.Sprint(i+1), "1", 0, "C", false, 0, "")
+		d.pdf.Rect(x+lineColumns[0], y, lineColumns[1], h, "D")
+		d.pdf.SetXY(x+lineColumns[0]+1, y)
+		d.pdf.MultiCell(lineColumns[1]-2, lineHeight, strings.Join(descLines, "\n"), "", "L", false)
+		d.pdf.SetXY(x+lineColumns[0]+lineColumns[1], y)
+		d.pdf.CellFormat(lineColumns[2], h, fmt.Sprint(l.Quantity), "1", 0, "C", false, 0, "")
+		d.pdf.CellFormat(lineColumns[3], h, formatRupiah(l.UnitPrice), "1", 0, "R", false, 0, "")
+		d.pdf.CellFormat(lineColumns[4], h, formatRupiah(l.Total), "1", 0, "R", false, 0, "")
+		d.pdf.Ln(h)
+	}
+}
+
+func (d *document) totalRow(label string, value string, bold bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	labelWidth := lineColumns[2] + lineColumns[3]
+	d.pdf.SetX(pageMargin + contentWidth - labelWidth - lineColumns[4])
+	d.text(style, 9, labelWidth, label, "R")
+	d.text(style, 9, lineColumns[4], value, "R")
+	d.pdf.Ln(lineHeight + 1)
+}
+
+// RenderInvoice bikin PDF invoice dari snapshot dokumen
+func RenderInvoice(doc Document) ([]byte, error) {
+	d := newDocument("Invoice " + doc.Number)
+
+	d.header(doc, "INVOICE", doc.Number, "Tanggal: "+formatDate(doc.IssuedAt))
+	d.line("", 9, "No. Order: "+doc.OrderID, "L")
+	d.line("", 9, "Tanggal order: "+formatDateTime(doc.OrderedAt), "L")
+	d.pdf.Ln(2)
+
+	d.parties(doc)
+	d.lineTable(doc.Lines)
+	d.pdf.Ln(2)
+
+	d.totalRow("Subtotal", formatRupiah(doc.Subtotal), false)
+	if doc.Discount > 0 {
+		label := "Diskon"
+		if doc.VoucherCode != "" {
+			label += " (" + doc.VoucherCode + ")"
+		}
+		d.totalRow(label, "-"+formatRupiah(doc.Discount), false)
+	}
+	if doc.ShippingCost > 0 {
+		d.totalRow("Ongkos kirim", formatRupiah(doc.ShippingCost), false)
+	}
+	d.totalRow("Total", formatRupiah(doc.Total), true)
+
+	if doc.Payment != nil {
+		d.pdf.Ln(3)
+		d.line("B", 9, "Pembayaran", "L")
+		d.line("", 9, paymentSummary(*doc.Payment), "L")
+	}
+
+	if doc.Notes != "" {
+		d.pdf.Ln(3)
+		d.line("B", 9, "Catatan", "L")
+		d.pdf.SetFont("Helvetica", "", 9)
+		d.pdf.MultiCell(contentWidth, lineHeight, d.tr(doc.Notes), "", "L", false)
+	}
+
+	d.pdf.Ln(6)
+	d.line("I", 8, "Invoice ini dibuat otomatis oleh sistem dan sah tanpa tanda tangan.", "C")
+
+	return d.bytes()
+}
+
+// RenderReceipt bikin PDF kwitansi, doc.Payment wajib ada
+func RenderReceipt(doc Document) ([]byte, error) {
+	if doc.Payment == nil {
+		return nil, fmt.Errorf("kwitansi butuh data pembayaran")
+	}
+	p := *doc.Payment
+
+	date := doc.IssuedAt
+	if p.PaidAt != nil {
+		date = *p.PaidAt
+	}
+
+	d := newDocument("Kwitansi " + doc.ReceiptNumber)
+	d.header(doc, "KWITANSI", doc.ReceiptNumber, "Tanggal: "+formatDate(date))
+
+	rows := [][2]string{
+		{"Telah diterima dari", doc.Customer.Name},
+		{"Uang sejumlah", formatRupiah(p.Amount)},
+		{"Terbilang", strings.TrimSpace(terbilang(p.Amount.Int64())) + " rupiah"},
+		{"Untuk pembayaran", fmt.Sprintf("Invoice %s (order %s)", doc.Number, doc.OrderID)},
+		{"Metode pembayaran", paymentSummary(p)},
+	}
+	if p.PaidAt != nil {
+		rows = append(rows, [2]string{"Dibayar pada", formatDateTime(*p.PaidAt)})
+	}
+
+	for _, row := range rows {
+		d.text("B", 10, 45, row[0], "L")
+		d.text("", 10, 5, ":", "L")
+		d.pdf.SetFont("Helvetica", "", 10)
+		d.pdf.MultiCell(contentWidth-50, lineHeight+1, d.tr(row[1]), "", "L", false)
+		d.pdf.Ln(1)
+	}
+
+	d.pdf.Ln(4)
+	d.rule()
+	d.line("B", 12, "LUNAS  "+formatRupiah(p.Amount), "R")
+
+	d.pdf.Ln(8)
+	d.line("I", 8, "Kwitansi ini dibuat otomatis oleh sistem dan sah tanpa tanda tangan.", "C")
+
+	return d.bytes()
+}
+
+func paymentSummary(p PaymentInfo) string {
+	summary := paymentMethodName(p.Method)
+	if p.Reference != "" {
+		summary += " - " + p.Reference
+	}
+	summary += ", " + formatRupiah(p.Amount)
+	if p.UniqueCode > 0 {
+		summary += fmt.Sprintf(" (termasuk kode unik %d)", p.UniqueCode)
+	}
+	return summary
+}
+
+func paymentMethodName(method model.PaymentMethod) string {
+	switch method {
+	case model.PaymentMethodManualTransfer:
+		return "Transfer bank"
+	case model.PaymentMethodVirtualAccount:
+		return "Virtual account"
+	case model.PaymentMethodQRIS:
+		return "QRIS"
+	}
+	return string(method)
+}
@@ -17,6 +17,9 @@ const (
 	RoleKey   contextKey = "role"
 	RoleAdmin string     = "ADMIN"
 	RoleUser  string     = "USER"
+
+	// teknisi servis, cuma bisa akses request servis yang di assign ke dia
+	RoleTechnician string = "TECHNICIAN"
 )
 
 func AuthMiddleware(next http.Handler) http.Handler {
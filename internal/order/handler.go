@@ -5,8 +5,11 @@ import (
 	"backEnd-RingoTechLife/internal/common/dto"
 	"backEnd-RingoTechLife/internal/middleware"
 	"backEnd-RingoTechLife/pkg"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -53,8 +56,29 @@ func (th *OrderHandler) CreateOrderHandler(w http.ResponseWriter, r *http.Reques
 	if order.Notes != nil {
 		notes = *order.Notes
 	}
+
+	var addressId *uuid.UUID
+	if order.AddressId != nil {
+		parsed, err := uuid.Parse(*order.AddressId)
+		if err != nil {
+			pkg.JSONError(w, 400, "address id tidak valid!")
+			return
+		}
+		addressId = &parsed
+	}
+
+	var variantId *uuid.UUID
+	if order.VariantId != nil {
+		parsed, err := uuid.Parse(*order.VariantId)
+		if err != nil {
+			pkg.JSONError(w, 400, "variant id tidak valid!")
+			return
+		}
+		variantId = &parsed
+	}
+
 	userId, _ := middleware.GetUserID(r.Context())
-	result, insertErr := th.orderService.CreateOneOrder(r.Context(), produtId, order.Quantity, userId, notes)
+	result, insertErr := th.orderService.CreateOneOrder(r.Context(), produtId, variantId, order.Quantity, userId, notes, addressId, order.VoucherCode)
 
 	if insertErr != nil {
 		pkg.JSONError(w, insertErr.Code, insertErr.Message)
@@ -142,13 +166,15 @@ func (th *OrderHandler) UpdateStatusHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	productId, err := uuid.Parse(updateOrder.OrderId)
+	orderId, err := uuid.Parse(updateOrder.OrderId)
 	if err != nil {
 		pkg.JSONError(w, 400, "id tidak valid!")
 		return
 	}
 
-	updateErr := th.orderService.UpdateOrderStatus(r.Context(), productId, updateOrder.Status)
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	updateErr := th.orderService.UpdateOrderStatus(r.Context(), orderId, updateOrder.Status, adminId, updateOrder.Note)
 
 	if updateErr != nil {
 		pkg.JSONError(w, updateErr.Code, updateErr.Message)
@@ -158,6 +184,138 @@ func (th *OrderHandler) UpdateStatusHandler(w http.ResponseWriter, r *http.Reque
 	pkg.JSONSuccess(w, 200, "berhasil mengupdate data!", updateOrder)
 }
 
+func (th *OrderHandler) PackOrderHandler(w http.ResponseWriter, r *http.Request) {
+	th.progressOrder(w, r, "berhasil menandai order sudah dikemas!", th.orderService.PackOrder)
+}
+
+func (th *OrderHandler) DeliverOrderHandler(w http.ResponseWriter, r *http.Request) {
+	th.progressOrder(w, r, "berhasil menandai order sudah sampai!", th.orderService.DeliverOrder)
+}
+
+func (th *OrderHandler) ConfirmReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	th.progressOrder(w, r, "terima kasih! pesanan sudah selesai", th.orderService.ConfirmReceipt)
+}
+
+func (th *OrderHandler) ShipOrderHandler(w http.ResponseWriter, r *http.Request) {
+
+	orderId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.ShipOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data pengiriman dengan benar!")
+		return
+	}
+
+	if err := th.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	shipErr := th.orderService.ShipOrder(r.Context(), orderId, req.Courier, req.TrackingNumber, adminId, req.Note)
+	if shipErr != nil {
+		pkg.JSONError(w, shipErr.Code, shipErr.Message)
+		return
+	}
+
+	th.writeOrder(w, r, orderId, "berhasil menandai order sudah dikirim!")
+}
+
+// progressOrder dipake endpoint progres order yang body nya cuma note opsional
+func (th *OrderHandler) progressOrder(
+	w http.ResponseWriter,
+	r *http.Request,
+	successMsg string,
+	action func(ctx context.Context, orderId uuid.UUID, actorId uuid.UUID, note *string) *common.ErrorResponse,
+) {
+
+	orderId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.OrderNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		pkg.JSONError(w, 400, "data yang kamu kirim tidak valid!")
+		return
+	}
+
+	if err := th.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	actorId, _ := middleware.GetUserID(r.Context())
+
+	if actionErr := action(r.Context(), orderId, actorId, req.Note); actionErr != nil {
+		pkg.JSONError(w, actionErr.Code, actionErr.Message)
+		return
+	}
+
+	th.writeOrder(w, r, orderId, successMsg)
+}
+
+func (th *OrderHandler) writeOrder(w http.ResponseWriter, r *http.Request, orderId uuid.UUID, successMsg string) {
+
+	userId, _ := middleware.GetUserID(r.Context())
+	role, _ := middleware.GetRole(r.Context())
+
+	data, getErr := th.orderService.GetByOrderId(r.Context(), orderId, userId, role)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, successMsg, data)
+}
+
+func (th *OrderHandler) GetInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	th.writePDF(w, r, th.orderService.GetInvoicePDF)
+}
+
+func (th *OrderHandler) GetReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	th.writePDF(w, r, th.orderService.GetReceiptPDF)
+}
+
+func (th *OrderHandler) RegenerateInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	th.writePDF(w, r, th.orderService.RegenerateInvoice)
+}
+
+func (th *OrderHandler) writePDF(
+	w http.ResponseWriter,
+	r *http.Request,
+	render func(context.Context, uuid.UUID, uuid.UUID, string) (PDFFile, *common.ErrorResponse),
+) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+	role, _ := middleware.GetRole(r.Context())
+
+	file, pdfErr := render(r.Context(), id, userId, role)
+	if pdfErr != nil {
+		pkg.JSONError(w, pdfErr.Code, pdfErr.Message)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.Name))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Content)
+}
+
 func (th *OrderHandler) SetUpRoute(router chi.Router) {
 
 	router.Route("/orders", func(r chi.Router) {
@@ -177,6 +335,9 @@ func (th *OrderHandler) SetUpRoute(router chi.Router) {
 		r.Post("/create-order", th.CreateOrderHandler)
 		r.Get("/my-orders", th.GetAllOfMyOrder)
 		r.Get("/id/{id}", th.GetOrderById)
+		r.Put("/confirm-receipt/{id}", th.ConfirmReceiptHandler)
+		r.Get("/invoice/{id}", th.GetInvoiceHandler)
+		r.Get("/receipt/{id}", th.GetReceiptHandler)
 
 		r.Group(func(adminRoute chi.Router) {
 			adminRoute.Use(middleware.RoleMiddleware(middleware.RoleAdmin))
@@ -184,6 +345,10 @@ func (th *OrderHandler) SetUpRoute(router chi.Router) {
 			adminRoute.Get("/get-all", th.GetAllOrderHandler)
 			adminRoute.Get("/status/{status}", th.GetAllOrdersByStatus)
 			adminRoute.Put("/update-status/", th.UpdateStatusHandler)
+			adminRoute.Put("/pack/{id}", th.PackOrderHandler)
+			adminRoute.Put("/ship/{id}", th.ShipOrderHandler)
+			adminRoute.Put("/deliver/{id}", th.DeliverOrderHandler)
+			adminRoute.Post("/invoice/regenerate/{id}", th.RegenerateInvoiceHandler)
 		})
 
 	})
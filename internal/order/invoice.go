@@ -0,0 +1,145 @@
+package order
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/invoice"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrInvoiceNotFound = errors.New("invoice tidak ditemukan")
+
+// issueInvoiceTx ngeluarin nomor invoice buat order kalau belum punya.
+// counter per bulan di-upsert (sekalian ngunci baris counter nya), jadi dua
+// konfirmasi barengan antri dan nomornya ga dobel. karena satu transaksi
+// sama konfirmasi order, kalau rollback nomornya ikut batal -> ga ada yang bolong.
+// baris order nya dikunci dulu biar dua request pertama barengan (misal download
+// invoice order lama) ga sama-sama ngerasa invoice nya belum ada
+func issueInvoiceTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID) error {
+	var locked uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT id FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoOrderFound
+		}
+		return fmt.Errorf("failed to lock order: %w", err)
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM invoices WHERE order_id = $1)`, orderID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check invoice: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	period := invoice.Period(time.Now())
+
+	var sequence int
+	counterQuery := `
+		INSERT INTO invoice_counters (period, last_number)
+		VALUES ($1, 1)
+		ON CONFLICT (period) DO UPDATE SET last_number = invoice_counters.last_number + 1
+		RETURNING last_number
+	`
+	if err := tx.QueryRow(ctx, counterQuery, period).Scan(&sequence); err != nil {
+		return fmt.Errorf("failed to allocate invoice number: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO invoices (order_id, invoice_number, period, sequence)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.Exec(ctx, insertQuery, orderID, invoice.Number(period, sequence), period, sequence); err != nil {
+		return fmt.Errorf("failed to insert invoice: %w", err)
+	}
+	return nil
+}
+
+// EnsureInvoice ngambil invoice order, order lama yang udah dikonfirmasi
+// sebelum ada fitur invoice dikasih nomor pas pertama kali diminta
+func (r *OrderRepositoryImpl) EnsureInvoice(ctx context.Context, orderID uuid.UUID) (model.Invoice, error) {
+	var inv model.Invoice
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := issueInvoiceTx(ctx, tx, orderID); err != nil {
+			return err
+		}
+
+		query := `SELECT ` + invoiceColumns + ` FROM invoices WHERE order_id = $1`
+		var err error
+		inv, err = scanInvoice(tx.QueryRow(ctx, query, orderID))
+		return err
+	})
+	return inv, err
+}
+
+// SaveInvoiceSnapshot nyimpen isi dokumen invoice. regenerate = true
+// berarti admin bikin ulang invoice yang udah pernah dicetak, revisinya naik.
+func (r *OrderRepositoryImpl) SaveInvoiceSnapshot(ctx context.Context, orderID uuid.UUID, snapshot []byte, regenerate bool) (model.Invoice, error) {
+	query := `
+		UPDATE invoices
+		SET snapshot = $2,
+		    revision = CASE WHEN $3 AND snapshot IS NOT NULL THEN revision + 1 ELSE revision END,
+		    generated_at = NOW()
+		WHERE order_id = $1
+		RETURNING ` + invoiceColumns
+
+	inv, err := scanInvoice(r.db.QueryRow(ctx, query, orderID, snapshot, regenerate))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Invoice{}, ErrInvoiceNotFound
+		}
+		return model.Invoice{}, err
+	}
+	return inv, nil
+}
+
+// GetServiceRequestByOrderID service request yang dibayar lewat order ini,
+// nil kalau order nya order produk biasa
+func (r *OrderRepositoryImpl) GetServiceRequestByOrderID(ctx context.Context, orderID uuid.UUID) (*model.ServiceRequest, error) {
+	query := `
+		SELECT id, device_type, device_brand, device_model, problem_description
+		FROM service_requests
+		WHERE order_id = $1
+		LIMIT 1
+	`
+	var sr model.ServiceRequest
+	err := r.db.QueryRow(ctx, query, orderID).Scan(
+		&sr.ID,
+		&sr.DeviceType,
+		&sr.DeviceBrand,
+		&sr.DeviceModel,
+		&sr.ProblemDescription,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sr, nil
+}
+
+const invoiceColumns = `id, order_id, invoice_number, period, sequence, revision, snapshot, issued_at, generated_at`
+
+func scanInvoice(row pgx.Row) (model.Invoice, error) {
+	var inv model.Invoice
+	err := row.Scan(
+		&inv.ID,
+		&inv.OrderID,
+		&inv.InvoiceNumber,
+		&inv.Period,
+		&inv.Sequence,
+		&inv.Revision,
+		&inv.Snapshot,
+		&inv.IssuedAt,
+		&inv.GeneratedAt,
+	)
+	return inv, err
+}
@@ -0,0 +1,267 @@
+package order
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/invoice"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// order yang udah dibayar & dikonfirmasi, cuma ini yang punya invoice
+var invoiceableStatuses = []model.OrderStatus{
+	model.OrderStatusConfirmed,
+	model.OrderStatusPacked,
+	model.OrderStatusShipped,
+	model.OrderStatusDelivered,
+	model.OrderStatusCompleted,
+	model.OrderStatusRefunded,
+}
+
+// payment yang dananya pernah diterima, dipake buat nentuin kwitansi boleh keluar
+var receiptPaymentStatuses = []model.PaymentStatus{
+	model.PaymentStatusApproved,
+	model.PaymentStatusPartiallyRefunded,
+	model.PaymentStatusRefunded,
+}
+
+// PDFFile hasil render invoice / kwitansi
+type PDFFile struct {
+	Name    string
+	Content []byte
+}
+
+// GetInvoicePDF invoice order (customer cuma bisa punya dia sendiri).
+// isi dokumennya diambil dari snapshot, kalau belum ada dibikin sekarang.
+func (o *OrderService) GetInvoicePDF(ctx context.Context, orderId uuid.UUID, userId uuid.UUID, role string) (PDFFile, *common.ErrorResponse) {
+
+	order, getErr := o.GetByOrderId(ctx, orderId, userId, role)
+	if getErr != nil {
+		return PDFFile{}, getErr
+	}
+
+	doc, docErr := o.loadInvoiceDocument(ctx, order, false)
+	if docErr != nil {
+		return PDFFile{}, docErr
+	}
+
+	content, err := invoice.RenderInvoice(doc)
+	if err != nil {
+		return PDFFile{}, common.NewErrorResponse(500, err.Error())
+	}
+	return PDFFile{Name: invoice.FileName(doc.Number), Content: content}, nil
+}
+
+// GetReceiptPDF kwitansi pembayaran, cuma ada kalau payment nya udah di approve
+func (o *OrderService) GetReceiptPDF(ctx context.Context, orderId uuid.UUID, userId uuid.UUID, role string) (PDFFile, *common.ErrorResponse) {
+
+	order, getErr := o.GetByOrderId(ctx, orderId, userId, role)
+	if getErr != nil {
+		return PDFFile{}, getErr
+	}
+
+	if order.Payment == nil || !slices.Contains(receiptPaymentStatuses, order.Payment.Status) {
+		return PDFFile{}, common.NewErrorResponse(409, "kwitansi baru tersedia setelah pembayaran disetujui!")
+	}
+
+	doc, docErr := o.loadInvoiceDocument(ctx, order, false)
+	if docErr != nil {
+		return PDFFile{}, docErr
+	}
+	doc.Payment = paymentInfo(order.Payment)
+
+	content, err := invoice.RenderReceipt(doc)
+	if err != nil {
+		return PDFFile{}, common.NewErrorResponse(500, err.Error())
+	}
+	return PDFFile{Name: invoice.FileName(doc.ReceiptNumber), Content: content}, nil
+}
+
+// RegenerateInvoice admin bikin ulang snapshot invoice dari data order terbaru
+// (misal data toko salah ketik). nomor invoice tetep, revisinya yang naik.
+func (o *OrderService) RegenerateInvoice(ctx context.Context, orderId uuid.UUID, adminId uuid.UUID, role string) (PDFFile, *common.ErrorResponse) {
+
+	order, getErr := o.GetByOrderId(ctx, orderId, adminId, role)
+	if getErr != nil {
+		return PDFFile{}, getErr
+	}
+
+	doc, docErr := o.loadInvoiceDocument(ctx, order, true)
+	if docErr != nil {
+		return PDFFile{}, docErr
+	}
+
+	content, err := invoice.RenderInvoice(doc)
+	if err != nil {
+		return PDFFile{}, common.NewErrorResponse(500, err.Error())
+	}
+	return PDFFile{Name: invoice.FileName(doc.Number), Content: content}, nil
+}
+
+func (o *OrderService) loadInvoiceDocument(ctx context.Context, order model.Order, regenerate bool) (invoice.Document, *common.ErrorResponse) {
+
+	if !slices.Contains(invoiceableStatuses, order.Status) {
+		return invoice.Document{}, common.NewErrorResponse(409, "invoice baru tersedia setelah pembayaran order dikonfirmasi!")
+	}
+
+	inv, err := o.orderRepo.EnsureInvoice(ctx, order.ID)
+	if err != nil {
+		return invoice.Document{}, common.NewErrorResponse(500, "gagal mengambil data invoice! "+err.Error())
+	}
+
+	if inv.Snapshot != nil && !regenerate {
+		var doc invoice.Document
+		if err := json.Unmarshal(inv.Snapshot, &doc); err != nil {
+			return invoice.Document{}, common.NewErrorResponse(500, "data invoice rusak! "+err.Error())
+		}
+		doc.Revision = inv.Revision
+		return doc, nil
+	}
+
+	doc, err := o.buildInvoiceDocument(ctx, order, inv)
+	if err != nil {
+		return invoice.Document{}, common.NewErrorResponse(500, "gagal menyusun invoice! "+err.Error())
+	}
+
+	snapshot, err := json.Marshal(doc)
+	if err != nil {
+		return invoice.Document{}, common.NewErrorResponse(500, err.Error())
+	}
+
+	inv, err = o.orderRepo.SaveInvoiceSnapshot(ctx, order.ID, snapshot, regenerate)
+	if err != nil {
+		return invoice.Document{}, common.NewErrorResponse(500, "gagal menyimpan invoice! "+err.Error())
+	}
+	doc.Revision = inv.Revision
+	return doc, nil
+}
+
+func (o *OrderService) buildInvoiceDocument(ctx context.Context, order model.Order, inv model.Invoice) (invoice.Document, error) {
+
+	doc := invoice.Document{
+		Number:        inv.InvoiceNumber,
+		ReceiptNumber: invoice.ReceiptNumber(inv.InvoiceNumber),
+		OrderID:       order.ID.String(),
+		IssuedAt:      inv.IssuedAt,
+		OrderedAt:     order.CreatedAt,
+		Store:         o.store,
+		Customer: invoice.Party{
+			Name:  order.UserData.FullName,
+			Email: order.UserData.Email,
+		},
+		Lines:        []invoice.Line{},
+		Subtotal:     order.Subtotal,
+		Discount:     order.DiscountAmount,
+		ShippingCost: order.ShippingCost,
+		Total:        order.TotalAmount,
+		Payment:      paymentInfo(order.Payment),
+	}
+
+	if order.UserData.PhoneNumber != nil {
+		doc.Customer.Phone = *order.UserData.PhoneNumber
+	}
+	if order.VoucherCode != nil {
+		doc.VoucherCode = *order.VoucherCode
+	}
+	if order.Notes != nil {
+		doc.Notes = *order.Notes
+	}
+
+	if addr := order.ShippingAddress; addr != nil {
+		doc.ShipTo = &invoice.Party{
+			Name:  addr.RecipientName,
+			Phone: addr.PhoneNumber,
+			Address: fmt.Sprintf("%s, %s, %s, %s %s",
+				addr.AddressLine, addr.District, addr.City, addr.Province, addr.PostalCode),
+		}
+	}
+
+	for _, item := range order.Items {
+		line := invoice.Line{
+			Description: item.ProductName,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.PriceAtPurchase,
+			Total:       item.Subtotal,
+		}
+		if item.ProductSKU != nil {
+			line.SKU = *item.ProductSKU
+		}
+		if item.VariantName != nil && *item.VariantName != "" {
+			line.Description += " (" + *item.VariantName + ")"
+		}
+		if len(item.BundleComponents) != 0 {
+			line.Description += " - isi: " + bundleContents(item.BundleComponents)
+		}
+		doc.Lines = append(doc.Lines, line)
+	}
+
+	// order tanpa barang = pembayaran jasa servis, barisnya diambil dari service request
+	if len(order.Items) == 0 {
+		sr, err := o.orderRepo.GetServiceRequestByOrderID(ctx, order.ID)
+		if err != nil {
+			return invoice.Document{}, err
+		}
+		doc.Lines = append(doc.Lines, invoice.Line{
+			Description: serviceChargeDescription(sr),
+			Quantity:    1,
+			UnitPrice:   order.Subtotal,
+			Total:       order.Subtotal,
+		})
+	}
+
+	return doc, nil
+}
+
+func serviceChargeDescription(sr *model.ServiceRequest) string {
+	if sr == nil {
+		return "Jasa servis"
+	}
+
+	device := []string{sr.DeviceType}
+	if sr.DeviceBrand != nil {
+		device = append(device, *sr.DeviceBrand)
+	}
+	if sr.DeviceModel != nil {
+		device = append(device, *sr.DeviceModel)
+	}
+
+	return "Jasa servis " + strings.Join(device, " ")
+}
+
+func paymentInfo(p *model.Payment) *invoice.PaymentInfo {
+	if p == nil {
+		return nil
+	}
+
+	info := &invoice.PaymentInfo{
+		Method:     p.Method,
+		Amount:     p.Amount,
+		UniqueCode: p.UniqueCode,
+		PaidAt:     p.VerifiedAt,
+	}
+	switch {
+	case p.VANumber != nil:
+		info.Reference = "VA " + *p.VANumber
+	case p.GatewayChargeID != nil:
+		info.Reference = *p.GatewayChargeID
+	}
+	return info
+}
+
+// bundleContents isi bundle buat deskripsi invoice, contoh "Case x1, Charger (20W) x2"
+func bundleContents(components []model.BundleComponent) string {
+	parts := make([]string, len(components))
+	for i, c := range components {
+		name := c.ProductName
+		if c.VariantName != nil && *c.VariantName != "" {
+			name += " (" + *c.VariantName + ")"
+		}
+		parts[i] = fmt.Sprintf("%s x%d", name, c.Quantity)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -4,29 +4,62 @@ package order
 
 import (
 	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/inventory"
+	"backEnd-RingoTechLife/internal/products"
+	"backEnd-RingoTechLife/internal/productunit"
+	"backEnd-RingoTechLife/internal/voucher"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var ErrNoOrderFound = errors.New("No order found!")
+var ErrInsufficientStock = errors.New("insufficient stock")
+var ErrInvalidTransition = errors.New("perubahan status order tidak diizinkan")
+var ErrShipmentRequired = errors.New("data pengiriman (kurir & nomor resi) belum diisi")
+var ErrNoUniqueCode = errors.New("kode unik pembayaran untuk nominal ini sedang habis, coba beberapa saat lagi")
+
+// InsufficientStockError dipakai biar caller tau produk (dan varian) mana yang stoknya kurang
+type InsufficientStockError struct {
+	ProductID uuid.UUID
+	VariantID *uuid.UUID
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for product %s", e.ProductID)
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
 
 type OrderRepositoryInterface interface {
 	Create(ctx context.Context, order *model.Order, items []model.OrderItem) (*model.Order, error)
+	CreateFromCart(ctx context.Context, order *model.Order, items []model.OrderItem, cartItemIDs []uuid.UUID) (*model.Order, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Order, error)
 	GetByIDWithDetails(ctx context.Context, id uuid.UUID) (*model.Order, error)
+	GetStatusHistory(ctx context.Context, orderID uuid.UUID) ([]model.OrderStatusHistory, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.Order, error)
 	GetByUserIDWithDetails(ctx context.Context, userID uuid.UUID) ([]model.Order, error)
 	GetAllWithDetails(ctx context.Context) ([]model.Order, error)
 	GetByStatus(ctx context.Context, status model.OrderStatus) ([]model.Order, error)
-	UpdateStatus(ctx context.Context, id uuid.UUID, status model.OrderStatus) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, status model.OrderStatus, actorID *uuid.UUID, note *string) error
 	Cancel(ctx context.Context, id uuid.UUID) error
-	CreateWithoutItems(ctx context.Context, order *model.Order) (*model.Order, error)
+	CancelExpired(ctx context.Context, limit int, skip []uuid.UUID) ([]uuid.UUID, []SweepFailure, error)
+	GetShipment(ctx context.Context, orderID uuid.UUID) (*model.Shipment, error)
+	Ship(ctx context.Context, id uuid.UUID, courier string, trackingNumber string, actorID *uuid.UUID, note *string) error
+	ConfirmReceipt(ctx context.Context, id uuid.UUID, userID uuid.UUID, note *string) error
+	CompleteDelivered(ctx context.Context, deliveredBefore time.Time, limit int, skip []uuid.UUID) ([]uuid.UUID, []SweepFailure, error)
+	EnsureInvoice(ctx context.Context, orderID uuid.UUID) (model.Invoice, error)
+	SaveInvoiceSnapshot(ctx context.Context, orderID uuid.UUID, snapshot []byte, regenerate bool) (model.Invoice, error)
+	GetServiceRequestByOrderID(ctx context.Context, orderID uuid.UUID) (*model.ServiceRequest, error)
 }
 
 type OrderRepositoryImpl struct {
@@ -45,83 +78,38 @@ func (r *OrderRepositoryImpl) Create(
 	items []model.OrderItem,
 ) (*model.Order, error) {
 	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
-		// 1. Insert order
-		orderQuery := `
-			INSERT INTO orders (user_id, status, subtotal, total_amount, notes, expires_at)
-			VALUES ($1, $2, $3, $4, $5, $6)
-			RETURNING id, created_at, updated_at
-		`
-		err := tx.QueryRow(ctx, orderQuery,
-			order.UserID,
-			order.Status,
-			order.Subtotal,
-			order.TotalAmount,
-			order.Notes,
-			order.ExpiresAt,
-		).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
-		if err != nil {
-			return fmt.Errorf("failed to insert order: %w", err)
-		}
+		return insertOrderWithItems(ctx, tx, order, items)
+	})
 
-		itemQuery := `
-			INSERT INTO order_items
-				(order_id, product_id, product_name, product_sku, price_at_purchase, quantity, subtotal)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-			RETURNING id, created_at
-		`
-		stockQuery := `
-			UPDATE products
-			SET stock = stock - $1
-			WHERE id = $2 AND stock >= $1
-			RETURNING stock
-		`
+	if err != nil {
+		return nil, err
+	}
 
-		for i := range items {
-			items[i].OrderID = order.ID
-
-			// Insert item
-			err := tx.QueryRow(ctx, itemQuery,
-				items[i].OrderID,
-				items[i].ProductID,
-				items[i].ProductName,
-				items[i].ProductSKU,
-				items[i].PriceAtPurchase,
-				items[i].Quantity,
-				items[i].Subtotal,
-			).Scan(&items[i].ID, &items[i].CreatedAt)
-			if err != nil {
-				return fmt.Errorf("failed to insert order item: %w", err)
-			}
+	return order, nil
+}
 
-			// Update stock
-			var newStock int
-			err = tx.QueryRow(ctx, stockQuery, items[i].Quantity, items[i].ProductID).Scan(&newStock)
-			if err != nil {
-				if err == pgx.ErrNoRows {
-					return fmt.Errorf("insufficient stock for product %s", items[i].ProductID)
-				}
-				return fmt.Errorf("failed to update stock: %w", err)
-			}
+// CreateFromCart sama kayak Create tapi sekalian ngapus baris keranjang
+// yang udah di checkout, semuanya di satu transaksi
+func (r *OrderRepositoryImpl) CreateFromCart(
+	ctx context.Context,
+	order *model.Order,
+	items []model.OrderItem,
+	cartItemIDs []uuid.UUID,
+) (*model.Order, error) {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := insertOrderWithItems(ctx, tx, order, items); err != nil {
+			return err
 		}
 
-		// 3. Insert payment record
-		paymentQuery := `
-			INSERT INTO payments (order_id, status, amount)
-			VALUES ($1, $2, $3)
-			RETURNING id, created_at, updated_at
+		cartQuery := `
+			DELETE FROM cart_items
+			WHERE user_id = $1 AND id = ANY($2)
 		`
-		var paymentID uuid.UUID
-		var paymentCreatedAt, paymentUpdatedAt interface{}
-		err = tx.QueryRow(ctx, paymentQuery,
-			order.ID,
-			model.PaymentStatusUnpaid,
-			order.TotalAmount,
-		).Scan(&paymentID, &paymentCreatedAt, &paymentUpdatedAt)
+		_, err := tx.Exec(ctx, cartQuery, order.UserID, cartItemIDs)
 		if err != nil {
-			return fmt.Errorf("failed to insert payment: %w", err)
+			return fmt.Errorf("failed to clear cart items: %w", err)
 		}
 
-		order.Items = items
 		return nil
 	})
 
@@ -132,61 +120,283 @@ func (r *OrderRepositoryImpl) Create(
 	return order, nil
 }
 
-func (r *OrderRepositoryImpl) CreateWithoutItems(
+func insertOrderWithItems(
 	ctx context.Context,
+	tx pgx.Tx,
 	order *model.Order,
-) (*model.Order, error) {
-	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
-		// 1. Insert order
-		orderQuery := `
-			INSERT INTO orders (user_id, status, subtotal, total_amount, notes, expires_at)
-			VALUES ($1, $2, $3, $4, $5, $6)
-			RETURNING id, created_at, updated_at
-		`
-		err := tx.QueryRow(ctx, orderQuery,
-			order.UserID,
-			order.Status,
-			order.Subtotal,
-			order.TotalAmount,
-			order.Notes,
-			order.ExpiresAt,
-		).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+	items []model.OrderItem,
+) error {
+	// 0. voucher dicek & dikunci duluan biar diskonnya masuk ke total order
+	var applied *model.AppliedVoucher
+	if order.VoucherCode != nil {
+		result, err := voucher.ApplyTx(ctx, tx, *order.VoucherCode, order.UserID, items)
 		if err != nil {
-			return fmt.Errorf("failed to insert order: %w", err)
+			return err
 		}
+		applied = &result
 
-		// 2. Insert payment
-		paymentQuery := `
-			INSERT INTO payments (order_id, status, amount)
-			VALUES ($1, $2, $3)
-			RETURNING id, created_at, updated_at
-		`
+		order.VoucherCode = &result.Code
+		order.DiscountAmount = result.Discount
+		order.TotalAmount = order.Subtotal - order.DiscountAmount + order.ShippingCost
+	}
+
+	// 1. Insert order
+	orderQuery := `
+		INSERT INTO orders (user_id, status, subtotal, discount_amount, voucher_code, shipping_cost, total_amount, notes, shipping_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+	err := tx.QueryRow(ctx, orderQuery,
+		order.UserID,
+		order.Status,
+		order.Subtotal,
+		order.DiscountAmount,
+		order.VoucherCode,
+		order.ShippingCost,
+		order.TotalAmount,
+		order.Notes,
+		order.ShippingAddress,
+		order.ExpiresAt,
+	).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert order: %w", err)
+	}
+
+	if applied != nil {
+		if err := voucher.RecordUsageTx(ctx, tx, *applied, order.UserID, order.ID); err != nil {
+			return err
+		}
+	}
+
+	itemQuery := `
+		INSERT INTO order_items
+			(order_id, product_id, variant_id, variant_name, product_name, product_sku,
+			 price_at_purchase, quantity, subtotal, bundle_components)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
 
-		var paymentID uuid.UUID
-		var paymentCreatedAt, paymentUpdatedAt interface{}
+	for i := range items {
+		items[i].OrderID = order.ID
+		items[i].ItemType = model.OrderItemTypeProduct
 
-		err = tx.QueryRow(ctx, paymentQuery,
-			order.ID,
-			model.PaymentStatusUnpaid,
-			order.TotalAmount,
-		).Scan(&paymentID, &paymentCreatedAt, &paymentUpdatedAt)
+		// isi bundle di snapshot di transaksi yang sama, jadi stok komponen
+		// yang di reserve pasti sama dengan isi bundle waktu dibeli
+		components, err := products.BundleComponentsTx(ctx, tx, items[i].ProductID)
 		if err != nil {
-			return fmt.Errorf("failed to insert payment: %w", err)
+			return err
 		}
+		items[i].BundleComponents = components
 
-		return nil
-	})
+		var componentsJSON any
+		if len(components) != 0 {
+			componentsJSON = components
+		}
+
+		// Insert item
+		err = tx.QueryRow(ctx, itemQuery,
+			items[i].OrderID,
+			items[i].ProductID,
+			items[i].VariantID,
+			items[i].VariantName,
+			items[i].ProductName,
+			items[i].ProductSKU,
+			items[i].PriceAtPurchase,
+			items[i].Quantity,
+			items[i].Subtotal,
+			componentsJSON,
+		).Scan(&items[i].ID, &items[i].CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert order item: %w", err)
+		}
+	}
+
+	// 2. stok di reserve aja, baru dipotong beneran pas payment di approve
+	if err := inventory.ReserveTx(ctx, tx, stockLines(items)); err != nil {
+		if shortage, ok := errors.AsType[*inventory.ShortageError](err); ok {
+			return shortageItemError(items, shortage)
+		}
+		return err
+	}
+
+	// produk yang dilacak per serial / IMEI unitnya ikut di reserve
+	if err := productunit.ReserveForOrderTx(ctx, tx, items); err != nil {
+		return err
+	}
+
+	// 3. Insert payment record
+	if err := insertPaymentTx(ctx, tx, order); err != nil {
+		return err
+	}
+
+	if err := insertStatusHistoryTx(ctx, tx, order.ID, nil, order.Status, &order.UserID, nil); err != nil {
+		return err
+	}
+
+	order.Items = items
+	return nil
+}
+
+// uniqueCodeLockKey kunci advisory buat serialisasi pemilihan kode unik,
+// jadi dua checkout barengan ga bisa dapet nominal transfer yang sama
+const uniqueCodeLockKey = "payments_unique_code"
+
+// MaxUniqueCode batas atas kode unik transfer (rupiah)
+const MaxUniqueCode = 999
+
+// insertPaymentTx bikin baris payment unpaid buat order yang baru dibuat.
+// nominalnya total order + kode unik yang belum dipake payment lain yang masih terbuka
+func insertPaymentTx(ctx context.Context, tx pgx.Tx, order *model.Order) error {
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, uniqueCodeLockKey); err != nil {
+		return fmt.Errorf("failed to lock unique code: %w", err)
+	}
+
+	// kode paling kecil yang nominal akhirnya belum dipake payment unpaid / submitted
+	codeQuery := `
+		SELECT c
+		FROM generate_series(1, $2::int) AS c
+		WHERE NOT EXISTS (
+			SELECT 1 FROM payments
+			WHERE status IN ($3, $4) AND amount = $1::bigint + c
+		)
+		ORDER BY c
+		LIMIT 1
+	`
+	var code int
+	err := tx.QueryRow(ctx, codeQuery,
+		order.TotalAmount,
+		MaxUniqueCode,
+		model.PaymentStatusUnpaid,
+		model.PaymentStatusSubmitted,
+	).Scan(&code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoUniqueCode
+		}
+		return fmt.Errorf("failed to reserve unique code: %w", err)
+	}
+
+	payment := model.Payment{
+		OrderID:    order.ID,
+		Status:     model.PaymentStatusUnpaid,
+		Amount:     order.TotalAmount + model.Money(code),
+		UniqueCode: code,
+		Method:     model.PaymentMethodManualTransfer,
+	}
+
+	paymentQuery := `
+		INSERT INTO payments (order_id, status, amount, unique_code)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, paymentQuery,
+		payment.OrderID,
+		payment.Status,
+		payment.Amount,
+		payment.UniqueCode,
+	).Scan(&payment.ID, &payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert payment: %w", err)
+	}
+
+	order.Payment = &payment
+	return nil
+}
+
+// CreateServiceOrderTx order buat bayar jasa servis, lines = baris penawaran yang
+// di acc user dan total order nya jumlah subtotal semua baris. barisnya (jasa,
+// sparepart, diagnosa) masuk ke order_items tapi ga di reserve / ngurangin stok
+// sama sekali. dipanggil di transaksi acc penawaran biar order nya ga nyangkut
+// kalau acc nya gagal
+func CreateServiceOrderTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	userID uuid.UUID,
+	notes string,
+	lines []model.OrderItem,
+	expiresAt time.Time,
+) (*model.Order, error) {
+	var subtotal model.Money
+	for _, line := range lines {
+		subtotal += line.Subtotal
+	}
 
+	order := &model.Order{
+		UserID:      userID,
+		Status:      model.OrderStatusPending,
+		Subtotal:    subtotal,
+		TotalAmount: subtotal,
+		Notes:       &notes,
+		ExpiresAt:   expiresAt,
+	}
+
+	// 1. Insert order
+	orderQuery := `
+		INSERT INTO orders (user_id, status, subtotal, discount_amount, voucher_code, shipping_cost, total_amount, notes, shipping_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+	err := tx.QueryRow(ctx, orderQuery,
+		order.UserID,
+		order.Status,
+		order.Subtotal,
+		order.DiscountAmount,
+		order.VoucherCode,
+		order.ShippingCost,
+		order.TotalAmount,
+		order.Notes,
+		order.ShippingAddress,
+		order.ExpiresAt,
+	).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
+		return nil, fmt.Errorf("failed to insert order: %w", err)
+	}
+
+	lineQuery := `
+		INSERT INTO order_items
+			(order_id, item_type, product_id, product_name, price_at_purchase, quantity, subtotal)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	for i := range lines {
+		lines[i].OrderID = order.ID
+
+		var productID *uuid.UUID
+		if lines[i].ProductID != uuid.Nil {
+			productID = &lines[i].ProductID
+		}
+
+		err := tx.QueryRow(ctx, lineQuery,
+			lines[i].OrderID,
+			lines[i].ItemType,
+			productID,
+			lines[i].ProductName,
+			lines[i].PriceAtPurchase,
+			lines[i].Quantity,
+			lines[i].Subtotal,
+		).Scan(&lines[i].ID, &lines[i].CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert service line: %w", err)
+		}
+	}
+	order.Items = lines
+
+	// 2. Insert payment
+	if err := insertPaymentTx(ctx, tx, order); err != nil {
 		return nil, err
 	}
 
+	if err := insertStatusHistoryTx(ctx, tx, order.ID, nil, order.Status, &order.UserID, nil); err != nil {
+		return nil, err
+	}
 	return order, nil
 }
 
 func (r *OrderRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*model.Order, error) {
 	query := `
-		SELECT id, user_id, status, subtotal, total_amount, notes,
+		SELECT id, user_id, status, subtotal, discount_amount, voucher_code, shipping_cost,
+		       total_amount, notes, shipping_address,
 		       created_at, updated_at, confirmed_at, cancelled_at, expires_at
 		FROM orders
 		WHERE id = $1
@@ -197,8 +407,12 @@ func (r *OrderRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*model
 		&order.UserID,
 		&order.Status,
 		&order.Subtotal,
+		&order.DiscountAmount,
+		&order.VoucherCode,
+		&order.ShippingCost,
 		&order.TotalAmount,
 		&order.Notes,
+		&order.ShippingAddress,
 		&order.CreatedAt,
 		&order.UpdatedAt,
 		&order.ConfirmedAt,
@@ -221,8 +435,12 @@ func (r *OrderRepositoryImpl) GetByIDWithDetails(
         o.user_id,
         o.status,
         o.subtotal,
+        o.discount_amount,
+        o.voucher_code,
+        o.shipping_cost,
         o.total_amount,
         o.notes,
+        o.shipping_address,
         o.created_at,
         o.updated_at,
         o.confirmed_at,
@@ -233,12 +451,21 @@ func (r *OrderRepositoryImpl) GetByIDWithDetails(
                 jsonb_build_object(
                     'id', oi.id,
                     'order_id', oi.order_id,
+                    'item_type', oi.item_type,
                     'product_id', oi.product_id,
+                    'variant_id', oi.variant_id,
+                    'variant_name', oi.variant_name,
                     'product_name', oi.product_name,
                     'product_sku', oi.product_sku,
                     'price_at_purchase', oi.price_at_purchase,
                     'quantity', oi.quantity,
                     'subtotal', oi.subtotal,
+                    'bundle_components', oi.bundle_components,
+                    'serial_numbers', (
+                        SELECT json_agg(pu.serial_number ORDER BY pu.serial_number)
+                        FROM product_units pu
+                        WHERE pu.order_item_id = oi.id
+                    ),
                     'created_at', oi.created_at AT TIME ZONE 'UTC'
                 )
             ) FILTER (WHERE oi.id IS NOT NULL),
@@ -250,7 +477,14 @@ func (r *OrderRepositoryImpl) GetByIDWithDetails(
                     'id', p.id,
                     'order_id', p.order_id,
                     'status', p.status,
-                    'amount', p.amount::float,
+                    'amount', p.amount,
+                    'unique_code', p.unique_code,
+                    'refunded_amount', p.refunded_amount,
+                    'method', p.method,
+                    'gateway_name', p.gateway_name,
+                    'gateway_charge_id', p.gateway_charge_id,
+                    'va_number', p.va_number,
+                    'qr_string', p.qr_string,
                     'proof_image', p.proof_image,
                     'admin_note', p.admin_note,
                     'verified_by', p.verified_by,
@@ -288,8 +522,12 @@ func (r *OrderRepositoryImpl) GetByIDWithDetails(
 		&order.UserID,
 		&order.Status,
 		&order.Subtotal,
+		&order.DiscountAmount,
+		&order.VoucherCode,
+		&order.ShippingCost,
 		&order.TotalAmount,
 		&order.Notes,
+		&order.ShippingAddress,
 		&order.CreatedAt,
 		&order.UpdatedAt,
 		&order.ConfirmedAt,
@@ -324,15 +562,96 @@ func (r *OrderRepositoryImpl) GetByIDWithDetails(
 		return nil, fmt.Errorf("failed to unmarshal user: %w", err)
 	}
 
+	history, err := r.GetStatusHistory(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	order.StatusHistory = history
+
+	shipment, err := r.GetShipment(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	order.Shipment = shipment
+
 	return &order, nil
 }
 
+// GetShipment ngembaliin nil kalau order belum punya data pengiriman
+func (r *OrderRepositoryImpl) GetShipment(ctx context.Context, orderID uuid.UUID) (*model.Shipment, error) {
+	query := `
+		SELECT id, order_id, courier, tracking_number, shipped_at, delivered_at, created_at, updated_at
+		FROM shipments
+		WHERE order_id = $1
+	`
+	var s model.Shipment
+	err := r.db.QueryRow(ctx, query, orderID).Scan(
+		&s.ID,
+		&s.OrderID,
+		&s.Courier,
+		&s.TrackingNumber,
+		&s.ShippedAt,
+		&s.DeliveredAt,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get shipment: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *OrderRepositoryImpl) GetStatusHistory(ctx context.Context, orderID uuid.UUID) ([]model.OrderStatusHistory, error) {
+	query := `
+		SELECT h.id, h.order_id, h.from_status, h.to_status, h.actor_id,
+		       u.full_name, h.note, h.created_at
+		FROM order_status_history h
+		LEFT JOIN users u ON u.id = h.actor_id
+		WHERE h.order_id = $1
+		ORDER BY h.created_at ASC
+	`
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := make([]model.OrderStatusHistory, 0)
+	for rows.Next() {
+		var h model.OrderStatusHistory
+		err := rows.Scan(
+			&h.ID,
+			&h.OrderID,
+			&h.FromStatus,
+			&h.ToStatus,
+			&h.ActorID,
+			&h.ActorName,
+			&h.Note,
+			&h.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status history: %w", err)
+		}
+		history = append(history, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
 func (r *OrderRepositoryImpl) GetByUserID(
 	ctx context.Context,
 	userID uuid.UUID,
 ) ([]model.Order, error) {
 	query := `
-		SELECT id, user_id, status, subtotal, total_amount, notes,
+		SELECT id, user_id, status, subtotal, discount_amount, voucher_code, shipping_cost,
+		       total_amount, notes, shipping_address,
 		       created_at, updated_at, confirmed_at, cancelled_at, expires_at
 		FROM orders
 		WHERE user_id = $1
@@ -352,8 +671,12 @@ func (r *OrderRepositoryImpl) GetByUserID(
 			&order.UserID,
 			&order.Status,
 			&order.Subtotal,
+			&order.DiscountAmount,
+			&order.VoucherCode,
+			&order.ShippingCost,
 			&order.TotalAmount,
 			&order.Notes,
+			&order.ShippingAddress,
 			&order.CreatedAt,
 			&order.UpdatedAt,
 			&order.ConfirmedAt,
@@ -379,8 +702,12 @@ func (r *OrderRepositoryImpl) GetByUserIDWithDetails(
         o.user_id,
         o.status,
         o.subtotal,
+        o.discount_amount,
+        o.voucher_code,
+        o.shipping_cost,
         o.total_amount,
         o.notes,
+        o.shipping_address,
         o.created_at,
         o.updated_at,
         o.confirmed_at,
@@ -391,12 +718,16 @@ func (r *OrderRepositoryImpl) GetByUserIDWithDetails(
                 jsonb_build_object(
                     'id', oi.id,
                     'order_id', oi.order_id,
+                    'item_type', oi.item_type,
                     'product_id', oi.product_id,
+                    'variant_id', oi.variant_id,
+                    'variant_name', oi.variant_name,
                     'product_name', oi.product_name,
                     'product_sku', oi.product_sku,
                     'price_at_purchase', oi.price_at_purchase,
                     'quantity', oi.quantity,
                     'subtotal', oi.subtotal,
+                    'bundle_components', oi.bundle_components,
                     'created_at', oi.created_at AT TIME ZONE 'UTC'
                 )
             ) FILTER (WHERE oi.id IS NOT NULL),
@@ -408,7 +739,14 @@ func (r *OrderRepositoryImpl) GetByUserIDWithDetails(
                     'id', p.id,
                     'order_id', p.order_id,
                     'status', p.status,
-                    'amount', p.amount::float,
+                    'amount', p.amount,
+                    'unique_code', p.unique_code,
+                    'refunded_amount', p.refunded_amount,
+                    'method', p.method,
+                    'gateway_name', p.gateway_name,
+                    'gateway_charge_id', p.gateway_charge_id,
+                    'va_number', p.va_number,
+                    'qr_string', p.qr_string,
                     'proof_image', p.proof_image,
                     'admin_note', p.admin_note,
                     'verified_by', p.verified_by,
@@ -455,8 +793,12 @@ func (r *OrderRepositoryImpl) GetByUserIDWithDetails(
 			&order.UserID,
 			&order.Status,
 			&order.Subtotal,
+			&order.DiscountAmount,
+			&order.VoucherCode,
+			&order.ShippingCost,
 			&order.TotalAmount,
 			&order.Notes,
+			&order.ShippingAddress,
 			&order.CreatedAt,
 			&order.UpdatedAt,
 			&order.ConfirmedAt,
@@ -505,8 +847,12 @@ func (r *OrderRepositoryImpl) GetAllWithDetails(ctx context.Context) ([]model.Or
 		o.user_id,
 		o.status,
 		o.subtotal,
+		o.discount_amount,
+		o.voucher_code,
+		o.shipping_cost,
 		o.total_amount,
 		o.notes,
+		o.shipping_address,
 		o.created_at,
 		o.updated_at,
 		o.confirmed_at,
@@ -517,12 +863,16 @@ func (r *OrderRepositoryImpl) GetAllWithDetails(ctx context.Context) ([]model.Or
 				jsonb_build_object(
 					'id', oi.id,
 					'order_id', oi.order_id,
+					'item_type', oi.item_type,
 					'product_id', oi.product_id,
+					'variant_id', oi.variant_id,
+					'variant_name', oi.variant_name,
 					'product_name', oi.product_name,
 					'product_sku', oi.product_sku,
 					'price_at_purchase', oi.price_at_purchase,
 					'quantity', oi.quantity,
 					'subtotal', oi.subtotal,
+					'bundle_components', oi.bundle_components,
 					'created_at', oi.created_at AT TIME ZONE 'UTC'
 				)
 			) FILTER (WHERE oi.id IS NOT NULL),
@@ -534,7 +884,14 @@ func (r *OrderRepositoryImpl) GetAllWithDetails(ctx context.Context) ([]model.Or
 					'id', p.id,
 					'order_id', p.order_id,
 					'status', p.status,
-					'amount', p.amount::float,
+					'amount', p.amount,
+					'unique_code', p.unique_code,
+					'refunded_amount', p.refunded_amount,
+					'method', p.method,
+					'gateway_name', p.gateway_name,
+					'gateway_charge_id', p.gateway_charge_id,
+					'va_number', p.va_number,
+					'qr_string', p.qr_string,
 					'proof_image', p.proof_image,
 					'admin_note', p.admin_note,
 					'verified_by', p.verified_by,
@@ -579,8 +936,12 @@ func (r *OrderRepositoryImpl) GetAllWithDetails(ctx context.Context) ([]model.Or
 			&order.UserID,
 			&order.Status,
 			&order.Subtotal,
+			&order.DiscountAmount,
+			&order.VoucherCode,
+			&order.ShippingCost,
 			&order.TotalAmount,
 			&order.Notes,
+			&order.ShippingAddress,
 			&order.CreatedAt,
 			&order.UpdatedAt,
 			&order.ConfirmedAt,
@@ -629,8 +990,12 @@ func (r *OrderRepositoryImpl) GetByStatus(
 		o.user_id,
 		o.status,
 		o.subtotal,
+		o.discount_amount,
+		o.voucher_code,
+		o.shipping_cost,
 		o.total_amount,
 		o.notes,
+		o.shipping_address,
 		o.created_at,
 		o.updated_at,
 		o.confirmed_at,
@@ -641,12 +1006,16 @@ func (r *OrderRepositoryImpl) GetByStatus(
 				jsonb_build_object(
 					'id', oi.id,
 					'order_id', oi.order_id,
+					'item_type', oi.item_type,
 					'product_id', oi.product_id,
+					'variant_id', oi.variant_id,
+					'variant_name', oi.variant_name,
 					'product_name', oi.product_name,
 					'product_sku', oi.product_sku,
 					'price_at_purchase', oi.price_at_purchase,
 					'quantity', oi.quantity,
 					'subtotal', oi.subtotal,
+					'bundle_components', oi.bundle_components,
 					'created_at', oi.created_at AT TIME ZONE 'UTC'
 				)
 			) FILTER (WHERE oi.id IS NOT NULL),
@@ -658,7 +1027,14 @@ func (r *OrderRepositoryImpl) GetByStatus(
 					'id', p.id,
 					'order_id', p.order_id,
 					'status', p.status,
-					'amount', p.amount::float,
+					'amount', p.amount,
+					'unique_code', p.unique_code,
+					'refunded_amount', p.refunded_amount,
+					'method', p.method,
+					'gateway_name', p.gateway_name,
+					'gateway_charge_id', p.gateway_charge_id,
+					'va_number', p.va_number,
+					'qr_string', p.qr_string,
 					'proof_image', p.proof_image,
 					'admin_note', p.admin_note,
 					'verified_by', p.verified_by,
@@ -704,8 +1080,12 @@ func (r *OrderRepositoryImpl) GetByStatus(
 			&order.UserID,
 			&order.Status,
 			&order.Subtotal,
+			&order.DiscountAmount,
+			&order.VoucherCode,
+			&order.ShippingCost,
 			&order.TotalAmount,
 			&order.Notes,
+			&order.ShippingAddress,
 			&order.CreatedAt,
 			&order.UpdatedAt,
 			&order.ConfirmedAt,
@@ -748,112 +1128,574 @@ func (r *OrderRepositoryImpl) UpdateStatus(
 	ctx context.Context,
 	id uuid.UUID,
 	status model.OrderStatus,
+	actorID *uuid.UUID,
+	note *string,
 ) error {
 	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
-		var query string
-		var args []interface{}
-
-		switch status {
-		case model.OrderStatusConfirmed:
-			query = `
-				UPDATE orders
-				SET status = $1, confirmed_at = NOW(), updated_at = NOW()
-				WHERE id = $2
-			`
-			args = []interface{}{status, id}
-		case model.OrderStatusCancelled:
-			query = `
-				UPDATE orders
-				SET status = $1, cancelled_at = NOW(), updated_at = NOW()
-				WHERE id = $2
-			`
-			args = []interface{}{status, id}
-		default:
-			query = `
-				UPDATE orders
-				SET status = $1, updated_at = NOW()
-				WHERE id = $2
-			`
-			args = []interface{}{status, id}
-		}
-
-		res, err := tx.Exec(ctx, query, args...)
-		if err != nil {
+		_, err := TransitionTx(ctx, tx, id, status, actorID, note)
+		return err
+	})
+}
+
+func (r *OrderRepositoryImpl) Cancel(ctx context.Context, id uuid.UUID) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := TransitionTx(ctx, tx, id, model.OrderStatusCancelled, nil, nil)
+		return err
+	})
+}
+
+// CancelExpired nyari order pending yang udah lewat expires_at terus di cancel
+// satu per satu (1 order = 1 transaksi). pake FOR UPDATE SKIP LOCKED jadi
+// aman kalo sweeper jalan di beberapa instance sekaligus, order yang lagi
+// dipegang instance lain bakal dilewatin. order di skip (yang gagal di batch
+// sebelumnya) juga dilewatin biar satu order yang error ga nahan order di belakang nya.
+func (r *OrderRepositoryImpl) CancelExpired(ctx context.Context, limit int, skip []uuid.UUID) ([]uuid.UUID, []SweepFailure, error) {
+	selectQuery := `
+		SELECT id
+		FROM orders
+		WHERE status = $1 AND expires_at <= NOW() AND id <> ALL($2::uuid[])
+		ORDER BY expires_at ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`
+	expiredNote := "dibatalkan otomatis karena melewati batas waktu pembayaran"
+
+	return r.sweepOrders(ctx, selectQuery, []any{model.OrderStatusPending}, model.OrderStatusCancelled, expiredNote, limit, skip)
+}
+
+// SweepFailure order yang gagal diproses sweeper, sisa sweep nya order ini dilewatin
+type SweepFailure struct {
+	OrderID uuid.UUID
+	Err     error
+}
+
+// sweepOrders ngambil order satu per satu pakai selectQuery (argumen terakhir nya
+// daftar id yang dilewatin) terus dipindah ke status to. kalau transisi satu order
+// gagal, order nya dicatat di failed dan sweep nya lanjut ke order berikutnya.
+// sweep cuma berhenti kalau context nya abis atau koneksi ke db nya bermasalah
+func (r *OrderRepositoryImpl) sweepOrders(
+	ctx context.Context,
+	selectQuery string,
+	args []any,
+	to model.OrderStatus,
+	note string,
+	limit int,
+	skip []uuid.UUID,
+) ([]uuid.UUID, []SweepFailure, error) {
+	// nil slice kekirim jadi NULL, "id <> ALL(NULL)" ga bakal pernah true
+	skipped := append(make([]uuid.UUID, 0, len(skip)), skip...)
+
+	done := make([]uuid.UUID, 0)
+	failed := make([]SweepFailure, 0)
+	for len(done) < limit {
+		var orderID uuid.UUID
+		var transitionErr error
+		found := true
+
+		err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+			err := tx.QueryRow(ctx, selectQuery, append(args, skipped)...).Scan(&orderID)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					found = false
+					return nil
+				}
+				return fmt.Errorf("failed to get order to sweep: %w", err)
+			}
+
+			_, err = TransitionTx(ctx, tx, orderID, to, nil, &note)
+			if err != nil && !sweepFatal(ctx, tx, err) {
+				transitionErr = err
+			}
 			return err
+		})
+
+		if transitionErr != nil {
+			failed = append(failed, SweepFailure{OrderID: orderID, Err: transitionErr})
+			skipped = append(skipped, orderID)
+			continue
 		}
-		if res.RowsAffected() == 0 {
-			return ErrNoOrderFound
+
+		if err != nil {
+			return done, failed, err
 		}
-		return nil
-	})
+
+		if !found {
+			break
+		}
+
+		done = append(done, orderID)
+	}
+
+	return done, failed, nil
 }
 
-func (r *OrderRepositoryImpl) Cancel(ctx context.Context, id uuid.UUID) error {
+// sweepFatal error yang bikin sweep berhenti: context abis atau koneksi putus.
+// error lain (guard status, stok, voucher) cuma bikin order itu dilewatin
+func sweepFatal(ctx context.Context, tx pgx.Tx, err error) bool {
+	return ctx.Err() != nil || tx.Conn().IsClosed() || pgconn.Timeout(err)
+}
+
+// Ship nyimpen kurir & nomor resi terus mindahin order ke shipped.
+// kalau resi nya salah input dan order masih packed, tinggal panggil lagi.
+func (r *OrderRepositoryImpl) Ship(
+	ctx context.Context,
+	id uuid.UUID,
+	courier string,
+	trackingNumber string,
+	actorID *uuid.UUID,
+	note *string,
+) error {
+	query := `
+		INSERT INTO shipments (order_id, courier, tracking_number)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (order_id)
+		DO UPDATE SET courier = EXCLUDED.courier,
+		              tracking_number = EXCLUDED.tracking_number,
+		              updated_at = NOW()
+	`
 	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
-		itemsQuery := `
-			SELECT product_id, quantity
-			FROM order_items
-			WHERE order_id = $1
-		`
-		rows, err := tx.Query(ctx, itemsQuery, id)
+		// dikunci duluan biar ga nimpa resi order yang udah jalan
+		var status model.OrderStatus
+		err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, id).Scan(&status)
 		if err != nil {
-			return fmt.Errorf("failed to get order items: %w", err)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNoOrderFound
+			}
+			return fmt.Errorf("failed to lock order: %w", err)
+		}
+		if !status.CanTransitionTo(model.OrderStatusShipped) {
+			return fmt.Errorf("%w (%s -> %s)", ErrInvalidTransition, status, model.OrderStatusShipped)
 		}
-		defer rows.Close()
 
-		type stockRestore struct {
-			ProductID uuid.UUID
-			Quantity  int
+		if _, err := tx.Exec(ctx, query, id, courier, trackingNumber); err != nil {
+			return fmt.Errorf("failed to save shipment: %w", err)
 		}
-		var restores []stockRestore
 
-		for rows.Next() {
-			var restore stockRestore
-			err := rows.Scan(&restore.ProductID, &restore.Quantity)
-			if err != nil {
-				return fmt.Errorf("failed to scan item: %w", err)
+		_, err = TransitionTx(ctx, tx, id, model.OrderStatusShipped, actorID, note)
+		return err
+	})
+}
+
+// ConfirmReceipt dipanggil customer pas barang udah sampe.
+// kalau admin belum sempet nandain delivered, order langsung dianggap
+// delivered dulu baru completed, dua-duanya kecatet di history.
+func (r *OrderRepositoryImpl) ConfirmReceipt(
+	ctx context.Context,
+	id uuid.UUID,
+	userID uuid.UUID,
+	note *string,
+) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var ownerID uuid.UUID
+		var status model.OrderStatus
+		err := tx.QueryRow(ctx, `SELECT user_id, status FROM orders WHERE id = $1 FOR UPDATE`, id).
+			Scan(&ownerID, &status)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNoOrderFound
 			}
-			restores = append(restores, restore)
+			return fmt.Errorf("failed to lock order: %w", err)
 		}
 
-		// 2. Restore stock for each product
-		stockQuery := `
-			UPDATE products
-			SET stock = stock + $1
-			WHERE id = $2
-		`
-		for _, restore := range restores {
-			_, err := tx.Exec(ctx, stockQuery, restore.Quantity, restore.ProductID)
-			if err != nil {
-				return fmt.Errorf("failed to restore stock: %w", err)
+		if ownerID != userID {
+			return ErrNoOrderFound
+		}
+
+		if status == model.OrderStatusShipped {
+			if _, err := TransitionTx(ctx, tx, id, model.OrderStatusDelivered, &userID, note); err != nil {
+				return err
 			}
 		}
 
-		// 3. Update order status
-		orderQuery := `
+		_, err = TransitionTx(ctx, tx, id, model.OrderStatusCompleted, &userID, note)
+		return err
+	})
+}
+
+// CompleteDelivered nyelesaiin order delivered yang ga dikonfirmasi customer
+// sampai lewat deliveredBefore. polanya sama kayak CancelExpired.
+func (r *OrderRepositoryImpl) CompleteDelivered(ctx context.Context, deliveredBefore time.Time, limit int, skip []uuid.UUID) ([]uuid.UUID, []SweepFailure, error) {
+	selectQuery := `
+		SELECT o.id
+		FROM orders o
+		INNER JOIN shipments s ON s.order_id = o.id
+		WHERE o.status = $1 AND s.delivered_at <= $2 AND o.id <> ALL($3::uuid[])
+		ORDER BY s.delivered_at ASC
+		LIMIT 1
+		FOR UPDATE OF o SKIP LOCKED
+	`
+	completeNote := "diselesaikan otomatis karena tidak ada konfirmasi dari customer"
+
+	return r.sweepOrders(ctx, selectQuery, []any{model.OrderStatusDelivered, deliveredBefore}, model.OrderStatusCompleted, completeNote, limit, skip)
+}
+
+// TransitionTx satu-satunya jalan buat ngubah status order.
+// ngunci baris order, ngecek ke model.OrderStatusTransitions, jalanin efek
+// samping tiap edge (reservasi / potong stok, sinkron status payment) terus nyatet ke
+// order_status_history. harus dipanggil di dalam transaksi yang sama dengan
+// perubahan lain yang terkait (misal update payment).
+func TransitionTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	id uuid.UUID,
+	to model.OrderStatus,
+	actorID *uuid.UUID,
+	note *string,
+) (model.OrderStatus, error) {
+	var from model.OrderStatus
+	err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, id).Scan(&from)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNoOrderFound
+		}
+		return "", fmt.Errorf("failed to lock order: %w", err)
+	}
+
+	if !from.CanTransitionTo(to) {
+		return from, fmt.Errorf("%w (%s -> %s)", ErrInvalidTransition, from, to)
+	}
+
+	if err := checkTransitionGuardTx(ctx, tx, id, from, to); err != nil {
+		return from, err
+	}
+
+	var orderQuery string
+	switch to {
+	case model.OrderStatusConfirmed:
+		orderQuery = `
+			UPDATE orders
+			SET status = $1, confirmed_at = NOW(), updated_at = NOW()
+			WHERE id = $2
+		`
+	case model.OrderStatusCancelled:
+		orderQuery = `
 			UPDATE orders
 			SET status = $1, cancelled_at = NOW(), updated_at = NOW()
 			WHERE id = $2
 		`
-		res, err := tx.Exec(ctx, orderQuery, model.OrderStatusCancelled, id)
+	default:
+		orderQuery = `
+			UPDATE orders
+			SET status = $1, updated_at = NOW()
+			WHERE id = $2
+		`
+	}
+
+	if _, err := tx.Exec(ctx, orderQuery, to, id); err != nil {
+		return from, fmt.Errorf("failed to update order: %w", err)
+	}
+
+	if err := applyTransitionEffectsTx(ctx, tx, id, to, actorID, note); err != nil {
+		return from, err
+	}
+
+	if err := insertStatusHistoryTx(ctx, tx, id, &from, to, actorID, note); err != nil {
+		return from, err
+	}
+
+	return from, nil
+}
+
+// checkTransitionGuardTx aturan tambahan yang ga bisa ditulis di tabel transisi,
+// soalnya tergantung isi order nya (ada barang atau engga, udah ada resi atau belum)
+func checkTransitionGuardTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	id uuid.UUID,
+	from model.OrderStatus,
+	to model.OrderStatus,
+) error {
+	switch {
+	case to == model.OrderStatusPacked, from == model.OrderStatusConfirmed && to == model.OrderStatusCompleted:
+		var hasItems bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM order_items WHERE order_id = $1 AND item_type = 'product')`, id).Scan(&hasItems)
+		if err != nil {
+			return fmt.Errorf("failed to check order items: %w", err)
+		}
+
+		// order tanpa barang ga ada yang bisa dipacking,
+		// order produk ga boleh langsung completed tanpa dikirim
+		if to == model.OrderStatusPacked && !hasItems {
+			return fmt.Errorf("%w (order tanpa barang tidak perlu dikirim)", ErrInvalidTransition)
+		}
+		if to == model.OrderStatusCompleted && hasItems {
+			return fmt.Errorf("%w (order produk harus dikirim terlebih dahulu)", ErrInvalidTransition)
+		}
+
+	case to == model.OrderStatusShipped:
+		var hasShipment bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM shipments WHERE order_id = $1)`, id).Scan(&hasShipment)
 		if err != nil {
-			return fmt.Errorf("failed to update order: %w", err)
+			return fmt.Errorf("failed to check shipment: %w", err)
+		}
+		if !hasShipment {
+			return ErrShipmentRequired
 		}
-		if res.RowsAffected() == 0 {
-			return fmt.Errorf("order not found")
+	}
+
+	return nil
+}
+
+func stockLines(items []model.OrderItem) []inventory.Line {
+	lines := []inventory.Line{}
+	for _, item := range items {
+		lines = append(lines, inventory.ItemLines(item.ProductID, item.VariantID, item.Quantity, item.BundleComponents)...)
+	}
+	return lines
+}
+
+// shortageItemError balikin item order yang kena stok kurang. kalau yang kurang
+// komponen bundle, yang dilaporin item bundle nya biar caller tau baris mana
+func shortageItemError(items []model.OrderItem, shortage *inventory.ShortageError) error {
+	for _, item := range items {
+		for _, l := range inventory.ItemLines(item.ProductID, item.VariantID, item.Quantity, item.BundleComponents) {
+			sameVariant := (l.VariantID == nil && shortage.VariantID == nil) ||
+				(l.VariantID != nil && shortage.VariantID != nil && *l.VariantID == *shortage.VariantID)
+			if l.ProductID == shortage.ProductID && sameVariant {
+				return &InsufficientStockError{ProductID: item.ProductID, VariantID: item.VariantID}
+			}
+		}
+	}
+	return &InsufficientStockError{ProductID: shortage.ProductID, VariantID: shortage.VariantID}
+}
+
+// orderStockTx ambil item order + status stok nya. stock_committed false berarti
+// stoknya masih di reserve, true berarti udah dipotong dari stok on-hand
+// (order lama sebelum ada reservasi juga true)
+func orderStockTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID) ([]inventory.Line, bool, error) {
+	var committed bool
+	err := tx.QueryRow(ctx, `SELECT stock_committed FROM orders WHERE id = $1`, orderID).Scan(&committed)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get order stock state: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT product_id, variant_id, quantity, bundle_components
+		FROM order_items
+		WHERE order_id = $1 AND item_type = 'product'
+	`, orderID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get order items: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []inventory.Line{}
+	for rows.Next() {
+		var item model.OrderItem
+		if err := rows.Scan(&item.ProductID, &item.VariantID, &item.Quantity, &item.BundleComponents); err != nil {
+			return nil, false, fmt.Errorf("failed to scan order item: %w", err)
+		}
+		lines = append(lines, inventory.ItemLines(item.ProductID, item.VariantID, item.Quantity, item.BundleComponents)...)
+	}
+	return lines, committed, rows.Err()
+}
+
+// releaseServicePartsTx order servis batal (misal kadaluarsa belum dibayar):
+// sparepart dari penawaran yang udah di acc dilepas biar stok nya ga ketahan terus.
+// part revisi yang masih pending ga disentuh, itu dilepas / dipake pas user mutusin
+// revisi nya. request nya tetep bisa direvisi admin terus di acc ulang sama user
+func releaseServicePartsTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID) error {
+	rows, err := tx.Query(ctx, `
+		WITH target AS (
+			SELECT sp.id, sp.status
+			FROM service_request_parts sp
+			JOIN service_requests sr ON sr.id = sp.service_request_id
+			JOIN service_quotes q ON q.id = sp.quote_id
+			WHERE sr.order_id = $1
+			  AND q.status = 'accepted'
+			  AND sp.status IN ('waiting', 'reserved')
+			ORDER BY sp.product_id
+			FOR UPDATE OF sp
+		)
+		UPDATE service_request_parts sp
+		SET status = 'released', updated_at = NOW()
+		FROM target
+		WHERE sp.id = target.id
+		RETURNING sp.product_id, sp.quantity, target.status
+	`, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to release service parts: %w", err)
+	}
+
+	var lines []inventory.Line
+	for rows.Next() {
+		var l inventory.Line
+		var status model.PartStatus
+		if err := rows.Scan(&l.ProductID, &l.Quantity, &status); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan released part: %w", err)
+		}
+		if status == model.PartStatusReserved {
+			lines = append(lines, l)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return inventory.ReleaseTx(ctx, tx, lines)
+}
+
+// releaseStockTx order batal: reservasi nya dilepas, atau kalau stoknya
+// udah terlanjur dipotong dibalikin lewat ledger sebagai cancel
+func releaseStockTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID, actorID *uuid.UUID) error {
+	lines, committed, err := orderStockTx(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	if !committed {
+		return inventory.ReleaseTx(ctx, tx, lines)
+	}
+
+	for _, l := range lines {
+		_, err := inventory.MoveTx(ctx, tx, inventory.Movement{
+			ProductID:     l.ProductID,
+			VariantID:     l.VariantID,
+			Delta:         l.Quantity,
+			Reason:        model.InventoryReasonCancel,
+			ReferenceType: inventory.RefOrder,
+			ReferenceID:   &orderID,
+			ActorID:       actorID,
+		})
+		if err != nil && !errors.Is(err, inventory.ErrProductNotFound) {
+			return fmt.Errorf("failed to restore stock: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE orders SET stock_committed = false WHERE id = $1`, orderID)
+	return err
+}
+
+// commitStockTx payment di approve, reservasi order nya jadi penjualan
+func commitStockTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID, actorID *uuid.UUID) error {
+	lines, committed, err := orderStockTx(ctx, tx, orderID)
+	if err != nil || committed {
+		return err
+	}
+
+	if err := inventory.CommitTx(ctx, tx, lines, inventory.RefOrder, orderID, actorID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE orders SET stock_committed = true WHERE id = $1`, orderID)
+	return err
+}
+
+func applyTransitionEffectsTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	id uuid.UUID,
+	to model.OrderStatus,
+	actorID *uuid.UUID,
+	note *string,
+) error {
+	switch to {
+	case model.OrderStatusCancelled:
+		// reservasi stok nya dilepas biar bisa dibeli orang lain
+		if err := releaseStockTx(ctx, tx, id, actorID); err != nil {
+			return err
+		}
+		if err := productunit.ReleaseForOrderTx(ctx, tx, id); err != nil {
+			return err
+		}
+		if err := releaseServicePartsTx(ctx, tx, id); err != nil {
+			return err
 		}
 
-		// 4. Update payment status if exists
 		paymentQuery := `
 			UPDATE payments
-			SET status = 'rejected', updated_at = NOW()
-			WHERE order_id = $1
+			SET status = $1, updated_at = NOW()
+			WHERE order_id = $2 AND status <> $1
 		`
-		_, err = tx.Exec(ctx, paymentQuery, id)
-		if err != nil {
+		if _, err := tx.Exec(ctx, paymentQuery, model.PaymentStatusRejected, id); err != nil {
 			return fmt.Errorf("failed to update payment: %w", err)
 		}
 
-		return nil
-	})
+		// kuota voucher nya dibalikin biar bisa dipake lagi
+		if err := voucher.ReleaseTx(ctx, tx, id); err != nil {
+			return err
+		}
+
+	case model.OrderStatusConfirmed:
+		// kalo admin confirm manual tanpa lewat approve payment
+		paymentQuery := `
+			UPDATE payments
+			SET status = $1,
+			    verified_by = COALESCE(verified_by, $2),
+			    admin_note = COALESCE(admin_note, $3),
+			    verified_at = COALESCE(verified_at, NOW()),
+			    updated_at = NOW()
+			WHERE order_id = $4 AND status <> $1
+		`
+		if _, err := tx.Exec(ctx, paymentQuery, model.PaymentStatusApproved, actorID, note, id); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		// stok yang di reserve baru dipotong beneran di sini,
+		// unit serial / IMEI nya juga resmi jadi milik item order
+		if err := commitStockTx(ctx, tx, id, actorID); err != nil {
+			return err
+		}
+		if err := productunit.SellForOrderTx(ctx, tx, id); err != nil {
+			return err
+		}
+
+		// nomor invoice keluar pas order dikonfirmasi, di transaksi yang sama
+		if err := issueInvoiceTx(ctx, tx, id); err != nil {
+			return err
+		}
+
+	case model.OrderStatusWaitingConfirmation:
+		paymentQuery := `
+			UPDATE payments
+			SET status = $1, submitted_at = COALESCE(submitted_at, NOW()), updated_at = NOW()
+			WHERE order_id = $2 AND status = $3
+		`
+		if _, err := tx.Exec(ctx, paymentQuery, model.PaymentStatusSubmitted, id, model.PaymentStatusUnpaid); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+	case model.OrderStatusShipped:
+		shipmentQuery := `
+			UPDATE shipments
+			SET shipped_at = COALESCE(shipped_at, NOW()), updated_at = NOW()
+			WHERE order_id = $1
+		`
+		if _, err := tx.Exec(ctx, shipmentQuery, id); err != nil {
+			return fmt.Errorf("failed to update shipment: %w", err)
+		}
+
+	case model.OrderStatusDelivered:
+		shipmentQuery := `
+			UPDATE shipments
+			SET delivered_at = COALESCE(delivered_at, NOW()), updated_at = NOW()
+			WHERE order_id = $1
+		`
+		if _, err := tx.Exec(ctx, shipmentQuery, id); err != nil {
+			return fmt.Errorf("failed to update shipment: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func insertStatusHistoryTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	orderID uuid.UUID,
+	from *model.OrderStatus,
+	to model.OrderStatus,
+	actorID *uuid.UUID,
+	note *string,
+) error {
+	query := `
+		INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, note)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := tx.Exec(ctx, query, orderID, from, to, actorID, note); err != nil {
+		return fmt.Errorf("failed to insert status history: %w", err)
+	}
+	return nil
 }
@@ -3,68 +3,117 @@ package order
 import (
 	"backEnd-RingoTechLife/internal/common"
 	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/invoice"
 	"backEnd-RingoTechLife/internal/middleware"
 	"backEnd-RingoTechLife/internal/products"
+	"backEnd-RingoTechLife/internal/shipping"
+	"backEnd-RingoTechLife/internal/user"
+	"backEnd-RingoTechLife/internal/voucher"
 	"context"
 	"errors"
 	"fmt"
 	"log"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// berapa order expired yang di cancel dalam sekali sapuan
+const expirySweepBatch = 100
+
+// order delivered yang ga dikonfirmasi customer selama ini bakal otomatis completed
+const autoCompleteAfter = 7 * 24 * time.Hour
+
 type OrderService struct {
-	muTransactionsData sync.Mutex
-	transactionsData   map[uuid.UUID]*time.Timer
-	orderRepo          OrderRepositoryInterface
-	productService     *products.ProductsService
-	appContext         context.Context
+	orderRepo       OrderRepositoryInterface
+	productService  *products.ProductsService
+	addressService  *user.AddressService
+	shippingService *shipping.ShippingService
+	store           invoice.Store
+	appContext      context.Context
 }
 
-func NewOrderService(ord *OrderRepositoryImpl, psvc *products.ProductsService, ctx context.Context) *OrderService {
+func NewOrderService(ord *OrderRepositoryImpl, psvc *products.ProductsService, asvc *user.AddressService, shsvc *shipping.ShippingService, store invoice.Store, ctx context.Context) *OrderService {
 	return &OrderService{
-		transactionsData: make(map[uuid.UUID]*time.Timer, 0),
-		orderRepo:        ord,
-		productService:   psvc,
-		appContext:       ctx,
+		orderRepo:       ord,
+		productService:  psvc,
+		addressService:  asvc,
+		shippingService: shsvc,
+		store:           store,
+		appContext:      ctx,
 	}
 }
 
-func (o *OrderService) CreateOneOrder(ctx context.Context, productId uuid.UUID, q int, userId uuid.UUID, notes string) (*model.Order, *common.ErrorResponse) {
+func (o *OrderService) CreateOneOrder(ctx context.Context, productId uuid.UUID, variantId *uuid.UUID, q int, userId uuid.UUID, notes string, addressId *uuid.UUID, voucherCode *string) (*model.Order, *common.ErrorResponse) {
 
 	productData, getErr := o.productService.GetById(ctx, productId)
 	if getErr != nil {
 		return nil, getErr
 	}
 
-	if q > productData.Stock {
+	if productData.Status != model.ProductsStatusActive {
+		return nil, common.NewErrorResponse(404, "produk tidak ditemukan! tidak dapat melakukan pembelian")
+	}
+
+	variant, variantErr := products.SelectVariant(productData, variantId)
+	if variantErr != nil {
+		return nil, variantErr
+	}
+
+	// harga, sku, stok & berat ikut varian yang dipilih
+	price, sku, available, unitWeight := productData.Price, productData.SKU, productData.AvailableStock, productData.Weight
+	var variantName *string
+	if variant != nil {
+		price, sku, available = variant.Price, &variant.SKU, variant.AvailableStock
+		if variant.Weight != nil {
+			unitWeight = variant.Weight
+		}
+		variantName = &variant.Name
+	}
+
+	if q > available {
 		return nil, common.NewErrorResponse(409, "Stok tidak valid!")
 	}
 
-	if productData.Status != model.ProductsStatusActive {
-		return nil, common.NewErrorResponse(404, "produk tidak ditemukan! tidak dapat melakukan pembelian")
+	shippingAddress, addrErr := o.addressService.ResolveShippingAddress(ctx, userId, addressId)
+	if addrErr != nil {
+		return nil, addrErr
+	}
+
+	var weight int
+	if unitWeight != nil {
+		weight = *unitWeight * q
 	}
 
-	totalPrice := productData.Price * float64(q)
+	quote, quoteErr := o.shippingService.Quote(ctx, *shippingAddress, weight)
+	if quoteErr != nil {
+		return nil, quoteErr
+	}
+
+	totalPrice := price.Mul(q)
 	expiresAt := time.Now().UTC().Add(12 * time.Hour)
 	order := model.Order{
-		UserID:      userId,
-		Status:      model.OrderStatusPending,
-		Subtotal:    totalPrice,
-		TotalAmount: totalPrice,
-		Notes:       &notes,
-		ExpiresAt:   expiresAt,
+		UserID:       userId,
+		Status:       model.OrderStatusPending,
+		Subtotal:     totalPrice,
+		ShippingCost: quote.Cost,
+		TotalAmount:  totalPrice + quote.Cost,
+		Notes:        &notes,
+		ExpiresAt:    expiresAt,
+		VoucherCode:  voucherCode,
+
+		ShippingAddress: shippingAddress,
 	}
 
 	orderItems := make([]model.OrderItem, 1)
 
 	orderItems[0] = model.OrderItem{
 		ProductID:       productId,
+		VariantID:       variantId,
+		VariantName:     variantName,
 		ProductName:     productData.Name,
-		ProductSKU:      productData.SKU,
-		PriceAtPurchase: productData.Price,
+		ProductSKU:      sku,
+		PriceAtPurchase: price,
 		Quantity:        q,
 		Subtotal:        totalPrice,
 	}
@@ -72,54 +121,52 @@ func (o *OrderService) CreateOneOrder(ctx context.Context, productId uuid.UUID,
 	result, err := o.orderRepo.Create(ctx, &order, orderItems)
 	if err != nil {
 
-		return nil, common.NewErrorResponse(500, "gagal membuat order! "+err.Error())
-	}
-
-	o.muTransactionsData.Lock()
-	defer o.muTransactionsData.Unlock()
-	orderDeadline := time.AfterFunc(time.Until(expiresAt), func() {
-		o.muTransactionsData.Lock()
-		defer o.muTransactionsData.Unlock()
-
-		if _, ok := o.transactionsData[result.ID]; !ok {
-			return
+		if errors.Is(err, voucher.ErrVoucherInvalid) {
+			return nil, common.NewErrorResponse(422, err.Error())
 		}
 
-		opertionContext, cancel := context.WithTimeout(o.appContext, 10*time.Second)
-		defer cancel()
-
-		err := o.orderRepo.Cancel(opertionContext, result.ID)
-		if err != nil {
-			log.Println("failed auto cancel:", err)
-			return
+		if errors.Is(err, ErrNoUniqueCode) {
+			return nil, common.NewErrorResponse(503, err.Error())
 		}
-		delete(o.transactionsData, result.ID)
-	})
-
-	o.transactionsData[result.ID] = orderDeadline
 
-	fmt.Println(o.transactionsData)
+		return nil, common.NewErrorResponse(500, "gagal membuat order! "+err.Error())
+	}
 
 	return result, nil
 }
 
-func (o *OrderService) CreateOrderWithoutProduct(ctx context.Context, userId uuid.UUID, notes string, subtotal float64, expiresAt time.Time) (model.Order, *common.ErrorResponse) {
+// CreateOrderFromCart bikin satu order dari banyak item keranjang.
+// validasi stok & status per baris plus ongkir dilakuin di cart service,
+// disini cuma ngitung total terus insert semuanya di satu transaksi.
+// voucher (kalau ada) divalidasi ulang & dipotong kuotanya di transaksi itu juga.
+func (o *OrderService) CreateOrderFromCart(ctx context.Context, userId uuid.UUID, notes string, shippingAddress *model.ShippingAddress, shippingCost model.Money, voucherCode *string, items []model.OrderItem, cartItemIds []uuid.UUID) (*model.Order, error) {
+
+	var totalPrice model.Money
+	for i := range items {
+		items[i].Subtotal = items[i].PriceAtPurchase.Mul(items[i].Quantity)
+		totalPrice += items[i].Subtotal
+	}
 
+	expiresAt := time.Now().UTC().Add(12 * time.Hour)
 	order := model.Order{
-		UserID:      userId,
-		Status:      model.OrderStatusPending,
-		Subtotal:    subtotal,
-		TotalAmount: subtotal,
-		Notes:       &notes,
-		ExpiresAt:   expiresAt,
+		UserID:       userId,
+		Status:       model.OrderStatusPending,
+		Subtotal:     totalPrice,
+		ShippingCost: shippingCost,
+		TotalAmount:  totalPrice + shippingCost,
+		Notes:        &notes,
+		ExpiresAt:    expiresAt,
+		VoucherCode:  voucherCode,
+
+		ShippingAddress: shippingAddress,
 	}
 
-	insertData, err := o.orderRepo.CreateWithoutItems(ctx, &order)
+	result, err := o.orderRepo.CreateFromCart(ctx, &order, items, cartItemIds)
 	if err != nil {
-		return model.Order{}, common.NewErrorResponse(500, "gagal melakukan operasi di database")
+		return nil, err
 	}
 
-	return *insertData, nil
+	return result, nil
 }
 
 func (o *OrderService) GetAllOrderByUserId(ctx context.Context, userId uuid.UUID) ([]model.Order, *common.ErrorResponse) {
@@ -176,33 +223,127 @@ func (o *OrderService) GetAllOrdersByStatus(ctx context.Context, status string)
 	return data, nil
 }
 
-func (o *OrderService) UpdateOrderStatus(ctx context.Context, prodId uuid.UUID, status string) *common.ErrorResponse {
+func (o *OrderService) UpdateOrderStatus(ctx context.Context, orderId uuid.UUID, status string, adminId uuid.UUID, note *string) *common.ErrorResponse {
 
-	err := o.orderRepo.UpdateStatus(ctx, prodId, model.OrderStatus(status))
+	err := o.orderRepo.UpdateStatus(ctx, orderId, model.OrderStatus(status), &adminId, note)
 	if err != nil {
+		return orderTransitionError(err)
+	}
+	return nil
+}
 
-		if errors.Is(err, ErrNoOrderFound) {
-			return common.NewErrorResponse(404, "order tidak ditemukan!")
-		}
+func (o *OrderService) PackOrder(ctx context.Context, orderId uuid.UUID, adminId uuid.UUID, note *string) *common.ErrorResponse {
+	return o.UpdateOrderStatus(ctx, orderId, string(model.OrderStatusPacked), adminId, note)
+}
+
+func (o *OrderService) ShipOrder(ctx context.Context, orderId uuid.UUID, courier string, trackingNumber string, adminId uuid.UUID, note *string) *common.ErrorResponse {
 
-		return common.NewErrorResponse(500, "terjadi kesalahan di database "+err.Error())
+	err := o.orderRepo.Ship(ctx, orderId, courier, trackingNumber, &adminId, note)
+	if err != nil {
+		return orderTransitionError(err)
 	}
 	return nil
 }
 
-func (o *OrderService) DeleteTransactionDeadline(orderId uuid.UUID) {
+func (o *OrderService) DeliverOrder(ctx context.Context, orderId uuid.UUID, adminId uuid.UUID, note *string) *common.ErrorResponse {
+	return o.UpdateOrderStatus(ctx, orderId, string(model.OrderStatusDelivered), adminId, note)
+}
+
+func (o *OrderService) ConfirmReceipt(ctx context.Context, orderId uuid.UUID, userId uuid.UUID, note *string) *common.ErrorResponse {
 
-	o.muTransactionsData.Lock()
-	defer o.muTransactionsData.Unlock()
+	err := o.orderRepo.ConfirmReceipt(ctx, orderId, userId, note)
+	if err != nil {
+		return orderTransitionError(err)
+	}
+	return nil
+}
 
-	deadline, ok := o.transactionsData[orderId]
+func orderTransitionError(err error) *common.ErrorResponse {
+	if errors.Is(err, ErrNoOrderFound) {
+		return common.NewErrorResponse(404, "order tidak ditemukan!")
+	}
 
-	if !ok {
-		// udah terlanjur jalan ygy
-		return
+	if errors.Is(err, ErrInvalidTransition) || errors.Is(err, ErrShipmentRequired) {
+		return common.NewErrorResponse(409, err.Error())
+	}
+
+	return common.NewErrorResponse(500, "terjadi kesalahan di database "+err.Error())
+}
+
+// StartExpiryWorker jalanin sweeper di background yang nge-cancel order pending
+// yang udah lewat expires_at. deadline nya kebaca dari database jadi tetep
+// jalan walaupun server restart, dan order dari service request juga ikut kena.
+// sekalian nyelesaiin order delivered yang udah lewat autoCompleteAfter.
+func (o *OrderService) StartExpiryWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		o.sweepExpiredOrders()
+		o.sweepDeliveredOrders()
+		for {
+			select {
+			case <-o.appContext.Done():
+				return
+			case <-ticker.C:
+				o.sweepExpiredOrders()
+				o.sweepDeliveredOrders()
+			}
+		}
+	}()
+}
+
+func (o *OrderService) sweepExpiredOrders() {
+	// order yang gagal dicancel dilewatin sampai sweep berikutnya
+	skip := make([]uuid.UUID, 0)
+	for {
+		opertionContext, cancel := context.WithTimeout(o.appContext, 30*time.Second)
+		cancelled, failed, err := o.orderRepo.CancelExpired(opertionContext, expirySweepBatch, skip)
+		cancel()
+
+		for _, f := range failed {
+			log.Printf("failed auto cancel order %s: %v\n", f.OrderID, f.Err)
+			skip = append(skip, f.OrderID)
+		}
+
+		if len(cancelled) != 0 {
+			log.Printf("auto cancel %d expired order\n", len(cancelled))
+		}
+
+		if err != nil {
+			log.Println("failed auto cancel:", err)
+			return
+		}
+
+		if len(cancelled) < expirySweepBatch {
+			return
+		}
 	}
-	deadline.Stop()
-	delete(o.transactionsData, orderId)
+}
+
+func (o *OrderService) sweepDeliveredOrders() {
+	skip := make([]uuid.UUID, 0)
+	for {
+		opertionContext, cancel := context.WithTimeout(o.appContext, 30*time.Second)
+		completed, failed, err := o.orderRepo.CompleteDelivered(opertionContext, time.Now().Add(-autoCompleteAfter), expirySweepBatch, skip)
+		cancel()
+
+		for _, f := range failed {
+			log.Printf("failed auto complete order %s: %v\n", f.OrderID, f.Err)
+			skip = append(skip, f.OrderID)
+		}
+
+		if len(completed) != 0 {
+			log.Printf("auto complete %d delivered order\n", len(completed))
+		}
+
+		if err != nil {
+			log.Println("failed auto complete:", err)
+			return
+		}
 
-	fmt.Println(o.transactionsData)
+		if len(completed) < expirySweepBatch {
+			return
+		}
+	}
 }
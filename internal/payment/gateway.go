@@ -0,0 +1,95 @@
+package payment
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrGatewaySignature = errors.New("signature callback payment gateway tidak valid")
+var ErrGatewayCallbackInvalid = errors.New("isi callback payment gateway tidak valid")
+var ErrGatewayChargeNotFound = errors.New("charge payment gateway tidak ditemukan")
+var ErrGatewayUnsupportedMethod = errors.New("metode pembayaran tidak didukung payment gateway")
+
+type ChargeStatus string
+
+const (
+	ChargeStatusPending ChargeStatus = "pending"
+	ChargeStatusPaid    ChargeStatus = "paid"
+	ChargeStatusExpired ChargeStatus = "expired"
+	ChargeStatusFailed  ChargeStatus = "failed"
+)
+
+// ChargeRequest data yang dikirim ke gateway buat bikin tagihan
+type ChargeRequest struct {
+	PaymentID     uuid.UUID
+	OrderID       uuid.UUID
+	Amount        model.Money
+	Method        model.PaymentMethod
+	BankCode      string
+	CustomerName  string
+	CustomerEmail string
+	ExpiresAt     time.Time
+}
+
+// Charge tagihan di sisi gateway
+type Charge struct {
+	ID        string
+	Method    model.PaymentMethod
+	Status    ChargeStatus
+	Amount    model.Money
+	VANumber  *string
+	QRString  *string
+	ExpiresAt time.Time
+	PaidAt    *time.Time
+}
+
+// CallbackEvent isi webhook yang udah diverifikasi signature nya.
+// EventID dipake buat idempotensi, gateway bisa ngirim event yang sama berkali-kali.
+type CallbackEvent struct {
+	EventID  string
+	ChargeID string
+	Status   ChargeStatus
+	Amount   model.Money
+	PaidAt   *time.Time
+	Payload  []byte
+}
+
+// PaymentGateway abstraksi payment gateway (VA / QRIS).
+// implementasi: RestGateway buat gateway beneran, FakeGateway buat lokal.
+type PaymentGateway interface {
+	Name() string
+	CreateCharge(ctx context.Context, req ChargeRequest) (Charge, error)
+	GetCharge(ctx context.Context, chargeID string) (Charge, error)
+	// VerifyCallback ngecek signature webhook terus parse isinya
+	VerifyCallback(header http.Header, body []byte) (CallbackEvent, error)
+}
+
+// callbackSignatureHeader header signature webhook, isinya hex HMAC-SHA256 dari body
+const callbackSignatureHeader = "X-Callback-Signature"
+
+func signCallback(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifyCallbackSignature(secret string, header http.Header, body []byte) error {
+	got, err := hex.DecodeString(header.Get(callbackSignatureHeader))
+	if err != nil || secret == "" {
+		return ErrGatewaySignature
+	}
+
+	expected, _ := hex.DecodeString(signCallback(secret, body))
+	if !hmac.Equal(got, expected) {
+		return ErrGatewaySignature
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package payment
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FakeGateway payment gateway bohongan yang nyimpen charge di memori.
+// dipake buat development / testing offline: charge dibikin kayak biasa,
+// terus pembayarannya disimulasiin lewat Simulate yang ngasilin callback
+// dengan signature yang sama persis kayak gateway beneran.
+type FakeGateway struct {
+	callbackSecret string
+
+	mu      sync.Mutex
+	charges map[string]*Charge
+}
+
+func NewFakeGateway(callbackSecret string) *FakeGateway {
+	return &FakeGateway{
+		callbackSecret: callbackSecret,
+		charges:        map[string]*Charge{},
+	}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakeGateway) CreateCharge(ctx context.Context, req ChargeRequest) (Charge, error) {
+
+	if req.Method != model.PaymentMethodVirtualAccount && req.Method != model.PaymentMethodQRIS {
+		return Charge{}, ErrGatewayUnsupportedMethod
+	}
+
+	id := "fake_" + uuid.NewString()
+	charge := Charge{
+		ID:        id,
+		Method:    req.Method,
+		Status:    ChargeStatusPending,
+		Amount:    req.Amount,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if req.Method == model.PaymentMethodVirtualAccount {
+		va := fmt.Sprintf("8808%012d", req.PaymentID.ID())
+		charge.VANumber = &va
+	} else {
+		qr := fmt.Sprintf("FAKEQRIS|%s|%d", id, req.Amount.Int64())
+		charge.QRString = &qr
+	}
+
+	g.mu.Lock()
+	g.charges[id] = &charge
+	g.mu.Unlock()
+
+	return charge, nil
+}
+
+func (g *FakeGateway) GetCharge(ctx context.Context, chargeID string) (Charge, error) {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[chargeID]
+	if !ok {
+		return Charge{}, ErrGatewayChargeNotFound
+	}
+	return *charge, nil
+}
+
+func (g *FakeGateway) VerifyCallback(header http.Header, body []byte) (CallbackEvent, error) {
+
+	if err := verifyCallbackSignature(g.callbackSecret, header, body); err != nil {
+		return CallbackEvent{}, err
+	}
+	return parseCallbackBody(body)
+}
+
+// Simulate ngubah status charge terus ngembaliin header & body webhook yang
+// udah ditandatangani, tinggal diproses kayak callback dari gateway beneran
+func (g *FakeGateway) Simulate(chargeID string, status ChargeStatus) (http.Header, []byte, error) {
+
+	g.mu.Lock()
+	charge, ok := g.charges[chargeID]
+	if !ok {
+		g.mu.Unlock()
+		return nil, nil, ErrGatewayChargeNotFound
+	}
+	charge.Status = status
+	if status == ChargeStatusPaid {
+		now := time.Now().UTC()
+		charge.PaidAt = &now
+	}
+	cb := restCallback{
+		EventID:  "evt_" + uuid.NewString(),
+		ChargeID: charge.ID,
+		Status:   string(status),
+		Amount:   charge.Amount,
+		PaidAt:   charge.PaidAt,
+	}
+	g.mu.Unlock()
+
+	body, err := json.Marshal(cb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set(callbackSignatureHeader, signCallback(g.callbackSecret, body))
+	return header, body, nil
+}
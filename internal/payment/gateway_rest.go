@@ -0,0 +1,197 @@
+package payment
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RestGateway implementasi PaymentGateway buat gateway yang modelnya kayak
+// gateway lokal pada umumnya: bikin charge VA / QRIS lewat REST (basic auth
+// pake server key), status dicek lewat GET charge, dan hasil bayar dikirim
+// lewat webhook yang ditandatangani HMAC-SHA256.
+type RestGateway struct {
+	name           string
+	baseURL        string
+	serverKey      string
+	callbackSecret string
+	client         *http.Client
+}
+
+func NewRestGateway(name string, baseURL string, serverKey string, callbackSecret string) *RestGateway {
+	return &RestGateway{
+		name:           name,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		serverKey:      serverKey,
+		callbackSecret: callbackSecret,
+		client:         &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type restChargeRequest struct {
+	ReferenceID   string      `json:"reference_id"`
+	OrderID       string      `json:"order_id"`
+	Amount        model.Money `json:"amount"`
+	PaymentMethod string      `json:"payment_method"`
+	BankCode      string      `json:"bank_code,omitempty"`
+	Customer      struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"customer"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type restCharge struct {
+	ID            string      `json:"id"`
+	Status        string      `json:"status"`
+	Amount        model.Money `json:"amount"`
+	PaymentMethod string      `json:"payment_method"`
+	VANumber      *string     `json:"va_number"`
+	QRString      *string     `json:"qr_string"`
+	ExpiresAt     time.Time   `json:"expires_at"`
+	PaidAt        *time.Time  `json:"paid_at"`
+}
+
+type restCallback struct {
+	EventID  string      `json:"event_id"`
+	ChargeID string      `json:"charge_id"`
+	Status   string      `json:"status"`
+	Amount   model.Money `json:"amount"`
+	PaidAt   *time.Time  `json:"paid_at"`
+}
+
+func (g *RestGateway) Name() string {
+	return g.name
+}
+
+func (g *RestGateway) CreateCharge(ctx context.Context, req ChargeRequest) (Charge, error) {
+
+	if req.Method != model.PaymentMethodVirtualAccount && req.Method != model.PaymentMethodQRIS {
+		return Charge{}, ErrGatewayUnsupportedMethod
+	}
+
+	body := restChargeRequest{
+		ReferenceID:   req.PaymentID.String(),
+		OrderID:       req.OrderID.String(),
+		Amount:        req.Amount,
+		PaymentMethod: string(req.Method),
+		BankCode:      req.BankCode,
+		ExpiresAt:     req.ExpiresAt,
+	}
+	body.Customer.Name = req.CustomerName
+	body.Customer.Email = req.CustomerEmail
+
+	var res restCharge
+	if err := g.do(ctx, http.MethodPost, "/v1/charges", body, &res); err != nil {
+		return Charge{}, err
+	}
+	return res.toCharge(), nil
+}
+
+func (g *RestGateway) GetCharge(ctx context.Context, chargeID string) (Charge, error) {
+
+	var res restCharge
+	if err := g.do(ctx, http.MethodGet, "/v1/charges/"+url.PathEscape(chargeID), nil, &res); err != nil {
+		return Charge{}, err
+	}
+	return res.toCharge(), nil
+}
+
+func (g *RestGateway) VerifyCallback(header http.Header, body []byte) (CallbackEvent, error) {
+
+	if err := verifyCallbackSignature(g.callbackSecret, header, body); err != nil {
+		return CallbackEvent{}, err
+	}
+	return parseCallbackBody(body)
+}
+
+func (g *RestGateway) do(ctx context.Context, method string, path string, in any, out any) error {
+
+	var reader io.Reader
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(g.serverKey, "")
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("gagal menghubungi payment gateway: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrGatewayChargeNotFound
+	}
+	if res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("payment gateway error %d: %s", res.StatusCode, string(msg))
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func (c restCharge) toCharge() Charge {
+	return Charge{
+		ID:        c.ID,
+		Method:    model.PaymentMethod(c.PaymentMethod),
+		Status:    normalizeChargeStatus(c.Status),
+		Amount:    c.Amount,
+		VANumber:  c.VANumber,
+		QRString:  c.QRString,
+		ExpiresAt: c.ExpiresAt,
+		PaidAt:    c.PaidAt,
+	}
+}
+
+func parseCallbackBody(body []byte) (CallbackEvent, error) {
+	var cb restCallback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		return CallbackEvent{}, fmt.Errorf("%w: %s", ErrGatewayCallbackInvalid, err.Error())
+	}
+	if cb.EventID == "" || cb.ChargeID == "" {
+		return CallbackEvent{}, fmt.Errorf("%w: event_id / charge_id kosong", ErrGatewayCallbackInvalid)
+	}
+
+	return CallbackEvent{
+		EventID:  cb.EventID,
+		ChargeID: cb.ChargeID,
+		Status:   normalizeChargeStatus(cb.Status),
+		Amount:   cb.Amount,
+		PaidAt:   cb.PaidAt,
+		Payload:  body,
+	}, nil
+}
+
+// normalizeChargeStatus gateway beda-beda nyebut status lunas
+// (paid / settlement / succeeded), disini disamain
+func normalizeChargeStatus(raw string) ChargeStatus {
+	switch strings.ToLower(raw) {
+	case "paid", "settlement", "succeeded", "success", "completed":
+		return ChargeStatusPaid
+	case "expired", "expire":
+		return ChargeStatusExpired
+	case "failed", "failure", "deny", "cancel", "cancelled":
+		return ChargeStatusFailed
+	}
+	return ChargeStatusPending
+}
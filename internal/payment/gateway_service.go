@@ -0,0 +1,202 @@
+package payment
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateCharge bikin tagihan VA / QRIS di payment gateway buat order yang masih pending.
+// kalau payment nya udah punya charge, data yang lama yang dibalikin.
+func (ps *PayementService) CreateCharge(ctx context.Context, req dto.CreateChargeRequest, currentUser uuid.UUID) (model.Payment, *common.ErrorResponse) {
+
+	orderId, err := uuid.Parse(req.OrderId)
+	if err != nil {
+		return model.Payment{}, common.NewErrorResponse(400, "id order tidak valid!")
+	}
+
+	val, err := ps.paymentRepo.GetPaymentValidationData(ctx, orderId)
+	if err != nil {
+		if errors.Is(err, ErrNoPaymentfound) {
+			return model.Payment{}, common.NewErrorResponse(404, "data order tidak ditemukan!")
+		}
+		return model.Payment{}, common.NewErrorResponse(500, "gagal memproses order! "+err.Error())
+	}
+
+	if val.IssuerId != currentUser {
+		return model.Payment{}, common.NewErrorResponse(404, "data order tidak ditemukan!")
+	}
+
+	if val.OrderStatus != string(model.OrderStatusPending) || time.Now().After(val.ExpiresAt) {
+		return model.Payment{}, common.NewErrorResponse(400, "waktu pembayaran untuk order ini sudah habis")
+	}
+
+	if req.Method == string(model.PaymentMethodVirtualAccount) && req.BankCode == "" {
+		return model.Payment{}, common.NewErrorResponse(400, "bank virtual account wajib diisi!")
+	}
+
+	if val.GatewayChargeId == nil {
+		charge, err := ps.gateway.CreateCharge(ctx, ChargeRequest{
+			PaymentID:     val.PaymentId,
+			OrderID:       orderId,
+			Amount:        val.Amount,
+			Method:        model.PaymentMethod(req.Method),
+			BankCode:      strings.ToUpper(req.BankCode),
+			CustomerName:  val.IssuerName,
+			CustomerEmail: val.IssuerEmail,
+			ExpiresAt:     val.ExpiresAt,
+		})
+		if err != nil {
+			if errors.Is(err, ErrGatewayUnsupportedMethod) {
+				return model.Payment{}, common.NewErrorResponse(422, err.Error())
+			}
+			return model.Payment{}, common.NewErrorResponse(502, "gagal membuat tagihan di payment gateway! "+err.Error())
+		}
+
+		if err := ps.paymentRepo.AttachCharge(ctx, val.PaymentId, ps.gateway.Name(), charge); err != nil {
+			if errors.Is(err, ErrPaymentNotOpen) {
+				return model.Payment{}, common.NewErrorResponse(409, err.Error())
+			}
+			return model.Payment{}, common.NewErrorResponse(500, "gagal menyimpan tagihan! "+err.Error())
+		}
+	}
+
+	payment, err := ps.paymentRepo.GetByOrderID(ctx, orderId)
+	if err != nil {
+		return model.Payment{}, common.NewErrorResponse(500, "gagal mengambil data pembayaran! "+err.Error())
+	}
+	return *payment, nil
+}
+
+// SyncCharge nanya status charge langsung ke gateway, buat jaga-jaga kalau
+// webhook nya telat / ga nyampe. kalau udah lunas diproses kayak callback biasa.
+func (ps *PayementService) SyncCharge(ctx context.Context, orderId uuid.UUID, currentUser uuid.UUID) (model.Payment, *common.ErrorResponse) {
+
+	val, err := ps.paymentRepo.GetPaymentValidationData(ctx, orderId)
+	if err != nil {
+		if errors.Is(err, ErrNoPaymentfound) {
+			return model.Payment{}, common.NewErrorResponse(404, "data order tidak ditemukan!")
+		}
+		return model.Payment{}, common.NewErrorResponse(500, "gagal memproses order! "+err.Error())
+	}
+
+	if val.IssuerId != currentUser {
+		return model.Payment{}, common.NewErrorResponse(404, "data order tidak ditemukan!")
+	}
+
+	if val.GatewayChargeId == nil {
+		return model.Payment{}, common.NewErrorResponse(409, "order ini tidak dibayar lewat payment gateway")
+	}
+
+	charge, err := ps.gateway.GetCharge(ctx, *val.GatewayChargeId)
+	if err != nil {
+		if errors.Is(err, ErrGatewayChargeNotFound) {
+			return model.Payment{}, common.NewErrorResponse(404, err.Error())
+		}
+		return model.Payment{}, common.NewErrorResponse(502, "gagal mengecek status tagihan! "+err.Error())
+	}
+
+	if charge.Status == ChargeStatusPaid {
+		// event id nya tetap per charge, jadi sync berkali-kali ga dobel proses
+		event := CallbackEvent{
+			EventID:  "sync:" + charge.ID,
+			ChargeID: charge.ID,
+			Status:   charge.Status,
+			Amount:   charge.Amount,
+			PaidAt:   charge.PaidAt,
+		}
+		outcome, err := ps.paymentRepo.SettleGatewayPayment(ctx, ps.gateway.Name(), event)
+		if err != nil && !errors.Is(err, ErrGatewayEventDuplicate) {
+			return model.Payment{}, gatewayCallbackError(err)
+		}
+		if outcome.NeedsReview() {
+			log.Printf("gateway charge %s perlu dicek admin: %s\n", charge.ID, outcome)
+		}
+	}
+
+	payment, err := ps.paymentRepo.GetByOrderID(ctx, orderId)
+	if err != nil {
+		return model.Payment{}, common.NewErrorResponse(500, "gagal mengambil data pembayaran! "+err.Error())
+	}
+	return *payment, nil
+}
+
+// HandleCallback verifikasi signature webhook terus proses pembayarannya.
+// callback yang udah pernah diproses dianggap sukses biar gateway berhenti retry,
+// begitu juga callback lunas yang ga bisa diproses otomatis, itu masuk daftar
+// GetGatewayReview buat dicek admin.
+func (ps *PayementService) HandleCallback(ctx context.Context, header http.Header, body []byte) *common.ErrorResponse {
+
+	event, err := ps.gateway.VerifyCallback(header, body)
+	if err != nil {
+		if errors.Is(err, ErrGatewaySignature) {
+			return common.NewErrorResponse(401, err.Error())
+		}
+		return common.NewErrorResponse(400, err.Error())
+	}
+
+	outcome, err := ps.paymentRepo.SettleGatewayPayment(ctx, ps.gateway.Name(), event)
+	if err != nil && !errors.Is(err, ErrGatewayEventDuplicate) {
+		return gatewayCallbackError(err)
+	}
+	if outcome.NeedsReview() {
+		log.Printf("gateway event %s (charge %s) perlu dicek admin: %s\n", event.EventID, event.ChargeID, outcome)
+	}
+	return nil
+}
+
+// GetGatewayReview callback lunas yang perlu ditindaklanjutin admin
+// (refund / approve manual)
+func (ps *PayementService) GetGatewayReview(ctx context.Context, resolved bool) ([]model.PaymentGatewayEvent, *common.ErrorResponse) {
+	events, err := ps.paymentRepo.GetGatewayEventsForReview(ctx, resolved)
+	if err != nil {
+		return nil, common.NewErrorResponse(500, "gagal mengambil data callback! "+err.Error())
+	}
+	return events, nil
+}
+
+func (ps *PayementService) ResolveGatewayEvent(ctx context.Context, id uuid.UUID, adminId uuid.UUID, note string) *common.ErrorResponse {
+	err := ps.paymentRepo.ResolveGatewayEvent(ctx, id, adminId, note)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrGatewayEventNotFound):
+			return common.NewErrorResponse(404, err.Error())
+		case errors.Is(err, ErrGatewayEventResolved):
+			return common.NewErrorResponse(409, err.Error())
+		}
+		return common.NewErrorResponse(500, "gagal menyimpan tindak lanjut callback! "+err.Error())
+	}
+	return nil
+}
+
+// SimulateCharge cuma jalan kalau pake FakeGateway, buat ngetes alur bayar offline
+func (ps *PayementService) SimulateCharge(ctx context.Context, chargeId string, status ChargeStatus) *common.ErrorResponse {
+
+	fake, ok := ps.gateway.(*FakeGateway)
+	if !ok {
+		return common.NewErrorResponse(404, "simulasi hanya tersedia untuk fake payment gateway")
+	}
+
+	header, body, err := fake.Simulate(chargeId, status)
+	if err != nil {
+		if errors.Is(err, ErrGatewayChargeNotFound) {
+			return common.NewErrorResponse(404, err.Error())
+		}
+		return common.NewErrorResponse(500, err.Error())
+	}
+
+	return ps.HandleCallback(ctx, header, body)
+}
+
+// gatewayCallbackError callback yang gagal di sisi db, dibalikin 500 biar gateway retry
+func gatewayCallbackError(err error) *common.ErrorResponse {
+	return common.NewErrorResponse(500, "gagal memproses pembayaran gateway! "+err.Error())
+}
@@ -7,6 +7,7 @@ import (
 	"backEnd-RingoTechLife/pkg"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 )
 
 const maxFormSize = 5 << 20
+const maxWebhookBodySize = 1 << 20
 
 type PaymentHandler struct {
 	payementService *PayementService
@@ -132,6 +134,229 @@ func (p *PaymentHandler) RejectPaymentHandler(w http.ResponseWriter, r *http.Req
 
 }
 
+func (p *PaymentHandler) ReconcileStatementHandler(w http.ResponseWriter, r *http.Request) {
+
+	if err := r.ParseMultipartForm(maxFormSize); err != nil {
+		pkg.JSONError(w, 400, "gagal parse form data "+err.Error())
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var req dto.ReconcileStatementRequest
+	if err := p.decoder.Decode(&req, r.MultipartForm.Value); err != nil {
+		pkg.JSONError(w, 400, "form data tidak valid")
+		return
+	}
+
+	if err := p.Validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	files := r.MultipartForm.File["statement"]
+	if len(files) == 0 {
+		pkg.JSONError(w, 400, "file mutasi tidak ditemukan! harap isi data dengan benar!")
+		return
+	}
+	req.Statement = files[0]
+
+	report, recErr := p.payementService.Reconcile(r.Context(), req)
+	if recErr != nil {
+		pkg.JSONError(w, recErr.Code, recErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mencocokkan mutasi rekening", report)
+}
+
+func (p *PaymentHandler) BulkApproveHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.BulkApprovePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "Harap isi data dengan benar!")
+		return
+	}
+
+	if err := p.Validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	results := p.payementService.BulkApprove(r.Context(), req, adminId)
+	pkg.JSONSuccess(w, 200, "proses approve pembayaran selesai", results)
+}
+
+func (p *PaymentHandler) CreateChargeHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.CreateChargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "Harap isi data dengan benar!")
+		return
+	}
+
+	if err := p.Validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	currentUser, _ := middleware.GetUserID(r.Context())
+
+	result, chargeErr := p.payementService.CreateCharge(r.Context(), req, currentUser)
+	if chargeErr != nil {
+		pkg.JSONError(w, chargeErr.Code, chargeErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil membuat tagihan pembayaran", result)
+}
+
+func (p *PaymentHandler) SyncChargeHandler(w http.ResponseWriter, r *http.Request) {
+
+	orderId, err := uuid.Parse(chi.URLParam(r, "orderId"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id order tidak valid!")
+		return
+	}
+
+	currentUser, _ := middleware.GetUserID(r.Context())
+
+	result, syncErr := p.payementService.SyncCharge(r.Context(), orderId, currentUser)
+	if syncErr != nil {
+		pkg.JSONError(w, syncErr.Code, syncErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengecek status pembayaran", result)
+}
+
+// GatewayWebhookHandler endpoint callback payment gateway, ga pake auth user
+// karena keasliannya dicek dari signature HMAC
+func (p *PaymentHandler) GatewayWebhookHandler(w http.ResponseWriter, r *http.Request) {
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		pkg.JSONError(w, 400, "gagal membaca callback")
+		return
+	}
+
+	if cbErr := p.payementService.HandleCallback(r.Context(), r.Header, body); cbErr != nil {
+		pkg.JSONError(w, cbErr.Code, cbErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "callback diterima", nil)
+}
+
+func (p *PaymentHandler) SimulateChargeHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.SimulateChargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "Harap isi data dengan benar!")
+		return
+	}
+
+	if err := p.Validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	simErr := p.payementService.SimulateCharge(r.Context(), chi.URLParam(r, "chargeId"), ChargeStatus(req.Status))
+	if simErr != nil {
+		pkg.JSONError(w, simErr.Code, simErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mensimulasikan pembayaran", nil)
+}
+
+// GatewayReviewHandler daftar callback lunas yang ga bisa diproses otomatis,
+// ?resolved=true ikut nampilin yang udah ditindaklanjutin
+func (p *PaymentHandler) GatewayReviewHandler(w http.ResponseWriter, r *http.Request) {
+
+	resolved := r.URL.Query().Get("resolved") == "true"
+
+	result, revErr := p.payementService.GetGatewayReview(r.Context(), resolved)
+	if revErr != nil {
+		pkg.JSONError(w, revErr.Code, revErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data callback", result)
+}
+
+func (p *PaymentHandler) ResolveGatewayEventHandler(w http.ResponseWriter, r *http.Request) {
+
+	eventId, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id callback tidak valid!")
+		return
+	}
+
+	var req dto.ResolveGatewayEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "Harap isi data dengan benar!")
+		return
+	}
+
+	if err := p.Validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	resErr := p.payementService.ResolveGatewayEvent(r.Context(), eventId, adminId, req.Note)
+	if resErr != nil {
+		pkg.JSONError(w, resErr.Code, resErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menyimpan tindak lanjut callback", nil)
+}
+
+func (p *PaymentHandler) GetQRISHandler(w http.ResponseWriter, r *http.Request) {
+
+	orderId, err := uuid.Parse(chi.URLParam(r, "orderId"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id order tidak valid!")
+		return
+	}
+
+	currentUser, _ := middleware.GetUserID(r.Context())
+
+	result, _, qrErr := p.payementService.GenerateQRIS(r.Context(), orderId, currentUser)
+	if qrErr != nil {
+		pkg.JSONError(w, qrErr.Code, qrErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil membuat QRIS", result)
+}
+
+func (p *PaymentHandler) GetQRISImageHandler(w http.ResponseWriter, r *http.Request) {
+
+	orderId, err := uuid.Parse(chi.URLParam(r, "orderId"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id order tidak valid!")
+		return
+	}
+
+	currentUser, _ := middleware.GetUserID(r.Context())
+
+	_, png, qrErr := p.payementService.GenerateQRIS(r.Context(), orderId, currentUser)
+	if qrErr != nil {
+		pkg.JSONError(w, qrErr.Code, qrErr.Message)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
+
 func (p *PaymentHandler) SetupRoute(router chi.Router) {
 
 	router.Route("/payments", func(r chi.Router) {
@@ -146,13 +371,28 @@ func (p *PaymentHandler) SetupRoute(router chi.Router) {
 				w.Write(errorResJson)
 			}),
 		))
-		r.Use(middleware.AuthMiddleware)
 
-		r.Post("/order", p.SubmitPaymentHandler)
+		r.Post("/webhook", p.GatewayWebhookHandler)
+
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.RoleMiddleware(middleware.RoleAdmin))
-			r.Post("/accept", p.AcceptPaymentHandler)
-			r.Post("/reject", p.RejectPaymentHandler)
+			r.Use(middleware.AuthMiddleware)
+
+			r.Post("/order", p.SubmitPaymentHandler)
+			r.Post("/charge", p.CreateChargeHandler)
+			r.Post("/charge/sync/{orderId}", p.SyncChargeHandler)
+			r.Get("/qris/{orderId}", p.GetQRISHandler)
+			r.Get("/qris/{orderId}/image", p.GetQRISImageHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RoleMiddleware(middleware.RoleAdmin))
+				r.Post("/accept", p.AcceptPaymentHandler)
+				r.Post("/reject", p.RejectPaymentHandler)
+				r.Post("/reconcile", p.ReconcileStatementHandler)
+				r.Post("/reconcile/approve", p.BulkApproveHandler)
+				r.Post("/gateway/simulate/{chargeId}", p.SimulateChargeHandler)
+				r.Get("/gateway/review", p.GatewayReviewHandler)
+				r.Post("/gateway/review/{eventId}/resolve", p.ResolveGatewayEventHandler)
+			})
 		})
 	})
 
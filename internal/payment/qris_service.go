@@ -0,0 +1,60 @@
+package payment
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const qrisImageSize = 512
+
+// GenerateQRIS bikin QRIS dinamis dari QRIS statis merchant dengan nominal
+// persis Payment.Amount (udah termasuk kode unik), plus gambar PNG nya
+func (ps *PayementService) GenerateQRIS(ctx context.Context, orderId uuid.UUID, currentUser uuid.UUID) (dto.QRISPaymentResponse, []byte, *common.ErrorResponse) {
+
+	if ps.qrisMerchant == nil {
+		return dto.QRISPaymentResponse{}, nil, common.NewErrorResponse(503, "pembayaran QRIS belum tersedia")
+	}
+
+	val, err := ps.paymentRepo.GetPaymentValidationData(ctx, orderId)
+	if err != nil {
+		if errors.Is(err, ErrNoPaymentfound) {
+			return dto.QRISPaymentResponse{}, nil, common.NewErrorResponse(404, "data order tidak ditemukan!")
+		}
+		return dto.QRISPaymentResponse{}, nil, common.NewErrorResponse(500, "gagal memproses order! "+err.Error())
+	}
+
+	if val.IssuerId != currentUser {
+		return dto.QRISPaymentResponse{}, nil, common.NewErrorResponse(404, "data order tidak ditemukan!")
+	}
+
+	if val.OrderStatus != string(model.OrderStatusPending) || val.PaymentStatus != model.PaymentStatusUnpaid || time.Now().After(val.ExpiresAt) {
+		return dto.QRISPaymentResponse{}, nil, common.NewErrorResponse(400, "order ini sudah tidak menunggu pembayaran")
+	}
+
+	payload, err := ps.qrisMerchant.WithAmount(val.Amount.Int64())
+	if err != nil {
+		return dto.QRISPaymentResponse{}, nil, common.NewErrorResponse(500, "gagal membuat QRIS! "+err.Error())
+	}
+
+	png, err := qrcode.Encode(payload, qrcode.Medium, qrisImageSize)
+	if err != nil {
+		return dto.QRISPaymentResponse{}, nil, common.NewErrorResponse(500, "gagal membuat gambar QRIS! "+err.Error())
+	}
+
+	return dto.QRISPaymentResponse{
+		OrderId:   orderId.String(),
+		PaymentId: val.PaymentId.String(),
+		Amount:    val.Amount,
+		Payload:   payload,
+		Image:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		ExpiresAt: val.ExpiresAt,
+	}, png, nil
+}
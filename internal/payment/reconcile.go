@@ -0,0 +1,171 @@
+package payment
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultReconcileWindowDays transferan biasanya masuk di hari yang sama,
+// tapi mutasi bank kadang baru kecatat H+1 (transfer malem / hari libur)
+const defaultReconcileWindowDays = 2
+
+const reconcileApproveNote = "dicocokkan dari mutasi rekening"
+
+// Reconcile nyocokin baris kredit mutasi rekening ke payment yang masih terbuka
+// berdasarkan nominal (udah termasuk kode unik) dan rentang tanggal.
+// hasilnya cuma laporan, approve nya tetep lewat BulkApprove.
+func (ps *PayementService) Reconcile(ctx context.Context, req dto.ReconcileStatementRequest) (dto.ReconciliationReport, *common.ErrorResponse) {
+
+	file, err := req.Statement.Open()
+	if err != nil {
+		return dto.ReconciliationReport{}, common.NewErrorResponse(400, "gagal membaca file mutasi!")
+	}
+	defer file.Close()
+
+	lines, skipped, err := parseStatement(file, newStatementMapping(req))
+	if err != nil {
+		if errors.Is(err, ErrStatementEmpty) || errors.Is(err, ErrStatementInvalid) {
+			return dto.ReconciliationReport{}, common.NewErrorResponse(422, err.Error())
+		}
+		return dto.ReconciliationReport{}, common.NewErrorResponse(500, "gagal memproses file mutasi! "+err.Error())
+	}
+
+	amounts := make([]int64, 0, len(lines))
+	seen := map[model.Money]bool{}
+	for _, line := range lines {
+		if !seen[line.Amount] {
+			seen[line.Amount] = true
+			amounts = append(amounts, line.Amount.Int64())
+		}
+	}
+
+	payments, err := ps.paymentRepo.GetOpenPaymentsByAmounts(ctx, amounts)
+	if err != nil {
+		return dto.ReconciliationReport{}, common.NewErrorResponse(500, "gagal mengambil data pembayaran! "+err.Error())
+	}
+
+	windowDays := defaultReconcileWindowDays
+	if req.WindowDays != nil {
+		windowDays = *req.WindowDays
+	}
+
+	report := matchStatement(lines, payments, windowDays)
+	report.Summary.Skipped = skipped
+	return report, nil
+}
+
+// matchStatement satu baris dianggap cocok kalau persis satu payment punya nominal
+// yang sama dan tanggal mutasinya masuk rentang [tanggal payment, +windowDays].
+// kalau lebih dari satu payment cocok, atau satu payment kena beberapa baris,
+// semuanya masuk ambiguous biar dicek manual.
+func matchStatement(lines []StatementLine, payments []model.Payment, windowDays int) dto.ReconciliationReport {
+
+	byAmount := map[model.Money][]model.Payment{}
+	for _, p := range payments {
+		byAmount[p.Amount] = append(byAmount[p.Amount], p)
+	}
+
+	candidates := make([][]model.Payment, len(lines))
+	linesPerPayment := map[uuid.UUID]int{}
+	for i, line := range lines {
+		for _, p := range byAmount[line.Amount] {
+			if inReconcileWindow(line.Date, p.CreatedAt, windowDays) {
+				candidates[i] = append(candidates[i], p)
+			}
+		}
+		if len(candidates[i]) == 1 {
+			linesPerPayment[candidates[i][0].ID]++
+		}
+	}
+
+	report := dto.ReconciliationReport{
+		Matched:   []dto.ReconciliationLine{},
+		Ambiguous: []dto.ReconciliationLine{},
+		Unmatched: []dto.ReconciliationLine{},
+	}
+
+	for i, line := range lines {
+		result := dto.ReconciliationLine{
+			LineNumber:  line.LineNumber,
+			Date:        line.Date,
+			Description: line.Description,
+			Amount:      line.Amount,
+			PaymentIds:  []string{},
+			OrderIds:    []string{},
+		}
+		for _, p := range candidates[i] {
+			result.PaymentIds = append(result.PaymentIds, p.ID.String())
+			result.OrderIds = append(result.OrderIds, p.OrderID.String())
+		}
+
+		switch {
+		case len(candidates[i]) == 0:
+			result.Reason = "tidak ada pembayaran terbuka dengan nominal & tanggal ini"
+			report.Unmatched = append(report.Unmatched, result)
+		case len(candidates[i]) > 1:
+			result.Reason = "lebih dari satu pembayaran cocok dengan nominal ini"
+			report.Ambiguous = append(report.Ambiguous, result)
+		case linesPerPayment[candidates[i][0].ID] > 1:
+			result.Reason = "beberapa baris mutasi cocok ke pembayaran yang sama"
+			report.Ambiguous = append(report.Ambiguous, result)
+		default:
+			report.Matched = append(report.Matched, result)
+		}
+	}
+
+	report.Summary = dto.ReconciliationSummary{
+		CreditLines: len(lines),
+		Matched:     len(report.Matched),
+		Ambiguous:   len(report.Ambiguous),
+		Unmatched:   len(report.Unmatched),
+	}
+	return report
+}
+
+// inReconcileWindow dibandingin per tanggal (WIB) karena mutasi bank ga ada jam nya
+func inReconcileWindow(lineDate time.Time, paymentCreated time.Time, windowDays int) bool {
+	y, m, d := paymentCreated.In(statementLocation).Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, statementLocation)
+	end := start.AddDate(0, 0, windowDays)
+
+	return !lineDate.Before(start) && !lineDate.After(end)
+}
+
+// BulkApprove approve banyak payment sekaligus hasil rekonsiliasi.
+// tiap payment diproses di transaksinya sendiri lewat logika Approve yang sama,
+// jadi satu yang gagal ga ngebatalin yang lain.
+func (ps *PayementService) BulkApprove(ctx context.Context, req dto.BulkApprovePaymentRequest, adminId uuid.UUID) []dto.BulkApproveResult {
+
+	note := req.Note
+	if note == nil {
+		defaultNote := reconcileApproveNote
+		note = &defaultNote
+	}
+
+	results := make([]dto.BulkApproveResult, 0, len(req.PaymentIds))
+	for _, rawId := range req.PaymentIds {
+		result := dto.BulkApproveResult{PaymentId: rawId}
+
+		id, err := uuid.Parse(rawId)
+		if err != nil {
+			result.Message = "id payment tidak valid!"
+			results = append(results, result)
+			continue
+		}
+
+		if appErr := ps.AcceptPayment(ctx, id, adminId, note); appErr != nil {
+			result.Message = appErr.Message
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
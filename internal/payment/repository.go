@@ -2,6 +2,7 @@ package payment
 
 import (
 	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/order"
 	"context"
 	"errors"
 	"fmt"
@@ -9,27 +10,44 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type paymentValidationData struct {
-	Amount      float64
-	IssuerId    uuid.UUID
-	OrderStatus string
-	ExpiresAt   time.Time
+	PaymentId       uuid.UUID
+	PaymentStatus   model.PaymentStatus
+	Amount          model.Money
+	UniqueCode      int
+	GatewayChargeId *string
+	IssuerId        uuid.UUID
+	IssuerName      string
+	IssuerEmail     string
+	OrderStatus     string
+	ExpiresAt       time.Time
 }
 
 var ErrNoPaymentfound = errors.New("pembayarab dengan id ini tidak ditemukan!")
+var ErrGatewayEventDuplicate = errors.New("callback payment gateway ini sudah pernah diproses")
+var ErrPaymentNotOpen = errors.New("pembayaran ini sudah tidak bisa diproses")
+var ErrGatewayEventNotFound = errors.New("callback payment gateway tidak ditemukan")
+var ErrGatewayEventResolved = errors.New("callback payment gateway ini tidak perlu ditindaklanjuti")
 
 type PaymentRepositoryInterface interface {
 	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*model.Payment, error)
-	SubmitProof(ctx context.Context, tmp *model.Payment) error
+	SubmitProof(ctx context.Context, tmp *model.Payment, userID uuid.UUID) error
 	Approve(ctx context.Context, paymentID uuid.UUID, adminID uuid.UUID, note *string) error
 	Reject(ctx context.Context, paymentID uuid.UUID, adminID uuid.UUID, note string) error
 	GetPendingPayments(ctx context.Context) ([]model.Payment, error)
+	GetOpenPaymentsByAmounts(ctx context.Context, amounts []int64) ([]model.Payment, error)
 
 	ExistByOrderId(ctx context.Context, orderId uuid.UUID) (bool, error)
 	GetPaymentValidationData(ctx context.Context, orderId uuid.UUID) (paymentValidationData, error)
+
+	AttachCharge(ctx context.Context, paymentID uuid.UUID, gatewayName string, charge Charge) error
+	SettleGatewayPayment(ctx context.Context, gatewayName string, event CallbackEvent) (model.GatewayEventOutcome, error)
+	GetGatewayEventsForReview(ctx context.Context, resolved bool) ([]model.PaymentGatewayEvent, error)
+	ResolveGatewayEvent(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note string) error
 }
 
 type PaymentRepositoryImpl struct {
@@ -47,26 +65,12 @@ func (p *PaymentRepositoryImpl) GetByOrderID(
 	orderID uuid.UUID,
 ) (*model.Payment, error) {
 	query := `
-		SELECT id, order_id, status, amount, proof_image, admin_note, verified_by,
-		       created_at, updated_at, submitted_at, verified_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE order_id = $1
 		LIMIT 1
 	`
-	var payment model.Payment
-	err := p.db.QueryRow(ctx, query, orderID).Scan(
-		&payment.ID,
-		&payment.OrderID,
-		&payment.Status,
-		&payment.Amount,
-		&payment.ProofImage,
-		&payment.AdminNote,
-		&payment.VerifiedBy,
-		&payment.CreatedAt,
-		&payment.UpdatedAt,
-		&payment.SubmittedAt,
-		&payment.VerifiedAt,
-	)
+	payment, err := scanPayment(p.db.QueryRow(ctx, query, orderID))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNoPaymentfound
@@ -79,6 +83,7 @@ func (p *PaymentRepositoryImpl) GetByOrderID(
 func (p *PaymentRepositoryImpl) SubmitProof(
 	ctx context.Context,
 	tempData *model.Payment,
+	userID uuid.UUID,
 ) error {
 	return pgx.BeginFunc(ctx, p.db, func(tx pgx.Tx) error {
 		paymentQuery := `
@@ -102,12 +107,7 @@ func (p *PaymentRepositoryImpl) SubmitProof(
 			return fmt.Errorf("failed to update payment: %w", err)
 		}
 
-		orderQuery := `
-			UPDATE orders
-			SET status = $1, updated_at = NOW()
-			WHERE id = $2
-		`
-		_, err = tx.Exec(ctx, orderQuery, model.OrderStatusWaitingConfirmation, tempData.OrderID)
+		_, err = order.TransitionTx(ctx, tx, tempData.OrderID, model.OrderStatusWaitingConfirmation, &userID, nil)
 		if err != nil {
 			return fmt.Errorf("failed to update order: %w", err)
 		}
@@ -123,23 +123,15 @@ func (p *PaymentRepositoryImpl) Approve(
 	note *string,
 ) error {
 	return pgx.BeginFunc(ctx, p.db, func(tx pgx.Tx) error {
-		// Update payment + order sekaligus dalam 1 CTE query
 		query := `
-			WITH payment_update AS (
-				UPDATE payments
-				SET status = $1,
-					verified_by = $2,
-					admin_note = $3,
-					verified_at = NOW(),
-					updated_at = NOW()
-				WHERE id = $4
-				RETURNING order_id
-			)
-			UPDATE orders
-			SET status = $5, confirmed_at = NOW(), updated_at = NOW()
-			FROM payment_update
-			WHERE orders.id = payment_update.order_id
-			RETURNING orders.id
+			UPDATE payments
+			SET status = $1,
+				verified_by = $2,
+				admin_note = $3,
+				verified_at = NOW(),
+				updated_at = NOW()
+			WHERE id = $4
+			RETURNING order_id
 		`
 
 		var orderID uuid.UUID
@@ -148,17 +140,18 @@ func (p *PaymentRepositoryImpl) Approve(
 			adminID,
 			note,
 			paymentID,
-			model.OrderStatusConfirmed,
 		).Scan(&orderID)
 
 		if err != nil {
 			if err == pgx.ErrNoRows {
-				return fmt.Errorf("payment not found")
+				return ErrNoPaymentfound
 			}
 			return fmt.Errorf("failed to approve payment: %w", err)
 		}
 
-		return nil
+		// status order + history diurus sama state machine order
+		_, err = order.TransitionTx(ctx, tx, orderID, model.OrderStatusConfirmed, &adminID, note)
+		return err
 	})
 }
 
@@ -169,34 +162,15 @@ func (p *PaymentRepositoryImpl) Reject(
 	note string,
 ) error {
 	return pgx.BeginFunc(ctx, p.db, func(tx pgx.Tx) error {
-		// 1. Update payment + order + restore stock sekaligus dalam 1 CTE query
 		query := `
-			WITH payment_update AS (
-				UPDATE payments
-				SET status = $1,
-					verified_by = $2,
-					admin_note = $3,
-					verified_at = NOW(),
-					updated_at = NOW()
-				WHERE id = $4
-				RETURNING order_id
-			),
-			order_update AS (
-				UPDATE orders
-				SET status = $5, cancelled_at = NOW(), updated_at = NOW()
-				FROM payment_update
-				WHERE orders.id = payment_update.order_id
-				RETURNING orders.id
-			),
-			stock_restore AS (
-				UPDATE products
-				SET stock = stock + oi.quantity
-				FROM order_items oi
-				INNER JOIN order_update ON oi.order_id = order_update.id
-				WHERE products.id = oi.product_id
-				RETURNING products.id
-			)
-			SELECT order_update.id FROM order_update
+			UPDATE payments
+			SET status = $1,
+				verified_by = $2,
+				admin_note = $3,
+				verified_at = NOW(),
+				updated_at = NOW()
+			WHERE id = $4
+			RETURNING order_id
 		`
 
 		var orderID uuid.UUID
@@ -205,24 +179,24 @@ func (p *PaymentRepositoryImpl) Reject(
 			adminID,
 			note,
 			paymentID,
-			model.OrderStatusCancelled,
 		).Scan(&orderID)
 
 		if err != nil {
 			if err == pgx.ErrNoRows {
-				return fmt.Errorf("payment not found")
+				return ErrNoPaymentfound
 			}
 			return fmt.Errorf("failed to reject payment: %w", err)
 		}
 
-		return nil
+		// pembatalan order (termasuk balikin stok) lewat state machine order
+		_, err = order.TransitionTx(ctx, tx, orderID, model.OrderStatusCancelled, &adminID, &note)
+		return err
 	})
 }
 
 func (p *PaymentRepositoryImpl) GetPendingPayments(ctx context.Context) ([]model.Payment, error) {
 	query := `
-		SELECT id, order_id, status, amount, proof_image, admin_note, verified_by,
-		       created_at, updated_at, submitted_at, verified_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE status = $1
 		ORDER BY submitted_at ASC
@@ -235,20 +209,7 @@ func (p *PaymentRepositoryImpl) GetPendingPayments(ctx context.Context) ([]model
 
 	var payments []model.Payment
 	for rows.Next() {
-		var payment model.Payment
-		err := rows.Scan(
-			&payment.ID,
-			&payment.OrderID,
-			&payment.Status,
-			&payment.Amount,
-			&payment.ProofImage,
-			&payment.AdminNote,
-			&payment.VerifiedBy,
-			&payment.CreatedAt,
-			&payment.UpdatedAt,
-			&payment.SubmittedAt,
-			&payment.VerifiedAt,
-		)
+		payment, err := scanPayment(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan payment: %w", err)
 		}
@@ -258,6 +219,33 @@ func (p *PaymentRepositoryImpl) GetPendingPayments(ctx context.Context) ([]model
 	return payments, nil
 }
 
+// GetOpenPaymentsByAmounts ambil payment yang belum diverifikasi (unpaid / submitted)
+// dengan nominal yang ada di daftar, dipake buat rekonsiliasi mutasi
+func (p *PaymentRepositoryImpl) GetOpenPaymentsByAmounts(ctx context.Context, amounts []int64) ([]model.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE status IN ($1, $2) AND amount = ANY($3)
+		ORDER BY created_at ASC
+	`
+	rows, err := p.db.Query(ctx, query, model.PaymentStatusUnpaid, model.PaymentStatusSubmitted, amounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []model.Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
 func (p *PaymentRepositoryImpl) ExistByOrderId(ctx context.Context, orderId uuid.UUID) (bool, error) {
 	query := `select exist(
 		select 1 from payments
@@ -275,12 +263,29 @@ func (p *PaymentRepositoryImpl) ExistByOrderId(ctx context.Context, orderId uuid
 
 func (p *PaymentRepositoryImpl) GetPaymentValidationData(ctx context.Context, orderId uuid.UUID) (paymentValidationData, error) {
 
+	// nominal diambil dari payment karena udah termasuk kode unik
 	query := `
-	select o.subtotal, o.user_id, o.status, o.expires_at from orders o where o.id = $1
+	select p.id, p.status, p.amount, p.unique_code, p.gateway_charge_id,
+		o.user_id, u.full_name, u.email, o.status, o.expires_at
+	from orders o
+	join payments p on p.order_id = o.id
+	join users u on u.id = o.user_id
+	where o.id = $1
 	`
 
 	var tempData paymentValidationData
-	err := p.db.QueryRow(ctx, query, orderId).Scan(&tempData.Amount, &tempData.IssuerId, &tempData.OrderStatus, &tempData.ExpiresAt)
+	err := p.db.QueryRow(ctx, query, orderId).Scan(
+		&tempData.PaymentId,
+		&tempData.PaymentStatus,
+		&tempData.Amount,
+		&tempData.UniqueCode,
+		&tempData.GatewayChargeId,
+		&tempData.IssuerId,
+		&tempData.IssuerName,
+		&tempData.IssuerEmail,
+		&tempData.OrderStatus,
+		&tempData.ExpiresAt,
+	)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -292,3 +297,247 @@ func (p *PaymentRepositoryImpl) GetPaymentValidationData(ctx context.Context, or
 	return tempData, nil
 
 }
+
+// AttachCharge nyimpen data tagihan gateway (VA / QRIS) ke payment yang masih unpaid
+func (p *PaymentRepositoryImpl) AttachCharge(ctx context.Context, paymentID uuid.UUID, gatewayName string, charge Charge) error {
+	query := `
+		UPDATE payments
+		SET method = $1,
+		    gateway_name = $2,
+		    gateway_charge_id = $3,
+		    va_number = $4,
+		    qr_string = $5,
+		    updated_at = NOW()
+		WHERE id = $6 AND status = $7
+	`
+	tag, err := p.db.Exec(ctx, query,
+		charge.Method,
+		gatewayName,
+		charge.ID,
+		charge.VANumber,
+		charge.QRString,
+		paymentID,
+		model.PaymentStatusUnpaid,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to attach charge: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPaymentNotOpen
+	}
+	return nil
+}
+
+// SettleGatewayPayment proses callback gateway secara idempoten.
+// event_id dicatet duluan, jadi callback yang dikirim ulang langsung ketahuan
+// (ErrGatewayEventDuplicate). kalau statusnya lunas, payment di approve dan
+// order di confirm lewat state machine yang sama kayak Approve manual.
+// callback lunas yang ga bisa diproses (charge ga dikenal, nominal beda, order
+// udah batal) ga di rollback, event nya tetep kesimpen dengan outcome nya biar
+// gateway berhenti retry dan admin bisa nindaklanjutin. error cuma dibalikin
+// kalau db nya bermasalah, biar gateway retry.
+func (p *PaymentRepositoryImpl) SettleGatewayPayment(ctx context.Context, gatewayName string, event CallbackEvent) (model.GatewayEventOutcome, error) {
+	outcome := model.GatewayEventProcessed
+	err := pgx.BeginFunc(ctx, p.db, func(tx pgx.Tx) error {
+		eventQuery := `
+			INSERT INTO payment_gateway_events (gateway_name, event_id, charge_id, status, amount, payload)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (gateway_name, event_id) DO NOTHING
+			RETURNING id
+		`
+		var eventID uuid.UUID
+		err := tx.QueryRow(ctx, eventQuery, gatewayName, event.EventID, event.ChargeID, event.Status, event.Amount, event.Payload).Scan(&eventID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrGatewayEventDuplicate
+			}
+			return fmt.Errorf("failed to record gateway event: %w", err)
+		}
+
+		if event.Status != ChargeStatusPaid {
+			outcome = model.GatewayEventIgnored
+			return markGatewayEventTx(ctx, tx, eventID, outcome, nil, nil)
+		}
+
+		lockQuery := `
+			SELECT id, order_id, status, amount
+			FROM payments
+			WHERE gateway_name = $1 AND gateway_charge_id = $2
+			FOR UPDATE
+		`
+		var paymentID, orderID uuid.UUID
+		var status model.PaymentStatus
+		var amount model.Money
+		err = tx.QueryRow(ctx, lockQuery, gatewayName, event.ChargeID).Scan(&paymentID, &orderID, &status, &amount)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				outcome = model.GatewayEventUnknownCharge
+				detail := "charge tidak terhubung ke pembayaran manapun"
+				return markGatewayEventTx(ctx, tx, eventID, outcome, nil, &detail)
+			}
+			return err
+		}
+
+		// event lunas yang beda id tapi buat charge yang sama
+		if status == model.PaymentStatusApproved {
+			outcome = model.GatewayEventIgnored
+			return markGatewayEventTx(ctx, tx, eventID, outcome, &paymentID, nil)
+		}
+
+		if amount != event.Amount {
+			outcome = model.GatewayEventAmountMismatch
+			detail := fmt.Sprintf("tagihan %d, dibayar %d", amount, event.Amount)
+			return markGatewayEventTx(ctx, tx, eventID, outcome, &paymentID, &detail)
+		}
+
+		// approve + confirm di savepoint, kalau order nya udah ga bisa di confirm
+		// (misal udah dibatalin sweeper) cuma bagian ini yang di rollback
+		err = pgx.BeginFunc(ctx, tx, func(sp pgx.Tx) error {
+			return approveGatewayPaymentTx(ctx, sp, gatewayName, paymentID, orderID, event.PaidAt)
+		})
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if ctx.Err() != nil || errors.As(err, &pgErr) {
+				return err
+			}
+			outcome = model.GatewayEventOrderNotOpen
+			detail := err.Error()
+			return markGatewayEventTx(ctx, tx, eventID, outcome, &paymentID, &detail)
+		}
+		return markGatewayEventTx(ctx, tx, eventID, outcome, &paymentID, nil)
+	})
+	return outcome, err
+}
+
+func approveGatewayPaymentTx(ctx context.Context, tx pgx.Tx, gatewayName string, paymentID, orderID uuid.UUID, paidAt *time.Time) error {
+	note := "dibayar lewat payment gateway " + gatewayName
+	approveQuery := `
+		UPDATE payments
+		SET status = $1,
+		    admin_note = $2,
+		    submitted_at = COALESCE(submitted_at, $3),
+		    verified_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $4
+	`
+	submittedAt := time.Now().UTC()
+	if paidAt != nil {
+		submittedAt = *paidAt
+	}
+	if _, err := tx.Exec(ctx, approveQuery, model.PaymentStatusApproved, note, submittedAt, paymentID); err != nil {
+		return fmt.Errorf("failed to approve payment: %w", err)
+	}
+
+	_, err := order.TransitionTx(ctx, tx, orderID, model.OrderStatusConfirmed, nil, &note)
+	return err
+}
+
+func markGatewayEventTx(ctx context.Context, tx pgx.Tx, eventID uuid.UUID, outcome model.GatewayEventOutcome, paymentID *uuid.UUID, detail *string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE payment_gateway_events
+		SET outcome = $1, payment_id = $2, detail = $3
+		WHERE id = $4
+	`, outcome, paymentID, detail, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to save gateway event outcome: %w", err)
+	}
+	return nil
+}
+
+// GetGatewayEventsForReview callback lunas yang uang nya udah masuk tapi ga bisa
+// diproses otomatis. resolved = true ikut nampilin yang udah ditindaklanjutin
+func (p *PaymentRepositoryImpl) GetGatewayEventsForReview(ctx context.Context, resolved bool) ([]model.PaymentGatewayEvent, error) {
+	query := `
+		SELECT e.id, e.gateway_name, e.event_id, e.charge_id, e.status, e.amount, e.outcome, e.detail,
+		       e.payment_id, pay.order_id, e.received_at, e.resolved_by, e.resolved_at, e.resolution_note
+		FROM payment_gateway_events e
+		LEFT JOIN payments pay ON pay.id = e.payment_id
+		WHERE e.outcome IN ($1, $2, $3) AND ($4 OR e.resolved_at IS NULL)
+		ORDER BY e.received_at ASC
+	`
+	rows, err := p.db.Query(ctx, query,
+		model.GatewayEventAmountMismatch, model.GatewayEventOrderNotOpen, model.GatewayEventUnknownCharge, resolved,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gateway events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]model.PaymentGatewayEvent, 0)
+	for rows.Next() {
+		var e model.PaymentGatewayEvent
+		err := rows.Scan(
+			&e.ID, &e.GatewayName, &e.EventID, &e.ChargeID, &e.Status, &e.Amount, &e.Outcome, &e.Detail,
+			&e.PaymentID, &e.OrderID, &e.ReceivedAt, &e.ResolvedBy, &e.ResolvedAt, &e.ResolutionNote,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan gateway event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// ResolveGatewayEvent nandain callback yang perlu dicek udah ditindaklanjutin admin
+// (misal udah direfund manual), catatan nya wajib biar ada jejak nya
+func (p *PaymentRepositoryImpl) ResolveGatewayEvent(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note string) error {
+	return pgx.BeginFunc(ctx, p.db, func(tx pgx.Tx) error {
+		var outcome model.GatewayEventOutcome
+		var resolvedAt *time.Time
+		err := tx.QueryRow(ctx, `
+			SELECT outcome, resolved_at FROM payment_gateway_events WHERE id = $1 FOR UPDATE
+		`, id).Scan(&outcome, &resolvedAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrGatewayEventNotFound
+			}
+			return fmt.Errorf("failed to get gateway event: %w", err)
+		}
+		if !outcome.NeedsReview() || resolvedAt != nil {
+			return ErrGatewayEventResolved
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE payment_gateway_events
+			SET resolved_by = $1, resolved_at = NOW(), resolution_note = $2
+			WHERE id = $3
+		`, adminID, note, id)
+		if err != nil {
+			return fmt.Errorf("failed to resolve gateway event: %w", err)
+		}
+		return nil
+	})
+}
+
+const paymentColumns = `id, order_id, status, amount, unique_code, refunded_amount, method, gateway_name, gateway_charge_id,
+		va_number, qr_string, proof_image, admin_note, verified_by,
+		created_at, updated_at, submitted_at, verified_at`
+
+type scannable interface {
+	Scan(dest ...any) error
+}
+
+func scanPayment(row scannable) (model.Payment, error) {
+	var payment model.Payment
+	err := row.Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.Status,
+		&payment.Amount,
+		&payment.UniqueCode,
+		&payment.RefundedAmount,
+		&payment.Method,
+		&payment.GatewayName,
+		&payment.GatewayChargeID,
+		&payment.VANumber,
+		&payment.QRString,
+		&payment.ProofImage,
+		&payment.AdminNote,
+		&payment.VerifiedBy,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+		&payment.SubmittedAt,
+		&payment.VerifiedAt,
+	)
+	return payment, err
+}
@@ -5,6 +5,7 @@ import (
 	"backEnd-RingoTechLife/internal/common/dto"
 	"backEnd-RingoTechLife/internal/common/model"
 	"backEnd-RingoTechLife/internal/order"
+	"backEnd-RingoTechLife/internal/qris"
 	"backEnd-RingoTechLife/internal/storage"
 	"context"
 	"errors"
@@ -20,13 +21,18 @@ type PayementService struct {
 	paymentRepo  PaymentRepositoryInterface
 	fileStorage  *storage.FileStorage
 	orderService *order.OrderService
+	gateway      PaymentGateway
+	qrisMerchant *qris.Payload
 }
 
-func NewPaymentService(repo *PaymentRepositoryImpl, storage *storage.FileStorage, orderSvc *order.OrderService) *PayementService {
+// qrisMerchant boleh nil kalau merchant belum punya QRIS statis
+func NewPaymentService(repo *PaymentRepositoryImpl, storage *storage.FileStorage, orderSvc *order.OrderService, gateway PaymentGateway, qrisMerchant *qris.Payload) *PayementService {
 	return &PayementService{
 		paymentRepo:  repo,
 		fileStorage:  storage,
 		orderService: orderSvc,
+		gateway:      gateway,
+		qrisMerchant: qrisMerchant,
 	}
 }
 
@@ -67,14 +73,18 @@ func (ps *PayementService) SubmitProof(ctx context.Context, submitReq dto.Submit
 		OrderID:    orderId,
 		ProofImage: &savedFileNames,
 		Amount:     val.Amount,
+		UniqueCode: val.UniqueCode,
 	}
 
-	subErr := ps.paymentRepo.SubmitProof(ctx, &tempData)
+	subErr := ps.paymentRepo.SubmitProof(ctx, &tempData, currentUser)
 	if subErr != nil {
+		ps.fileStorage.DeletePublicFile(savedFileNames, paymentImagePlace)
+		if errors.Is(subErr, order.ErrInvalidTransition) {
+			return model.Payment{}, common.NewErrorResponse(409, subErr.Error())
+		}
 		return model.Payment{}, common.NewErrorResponse(500, "gagal memproses transaksi! "+subErr.Error())
 	}
 
-	ps.orderService.DeleteTransactionDeadline(orderId)
 	return tempData, nil
 
 }
@@ -105,7 +115,7 @@ func (ps *PayementService) AcceptPayment(ctx context.Context, id uuid.UUID, admi
 	err := ps.paymentRepo.Approve(ctx, id, adminId, notes)
 
 	if err != nil {
-		return common.NewErrorResponse(500, "gagal mengupdate status pembayaran! operasi dibatalkan!")
+		return paymentUpdateError(err)
 	}
 
 	return nil
@@ -114,7 +124,19 @@ func (ps *PayementService) AcceptPayment(ctx context.Context, id uuid.UUID, admi
 func (ps *PayementService) RejectPayment(ctx context.Context, id uuid.UUID, adminId uuid.UUID, notes string) *common.ErrorResponse {
 	err := ps.paymentRepo.Reject(ctx, id, adminId, notes)
 	if err != nil {
-		return common.NewErrorResponse(500, "gagal mengupdate status pembayaran! operasi dibatalkan!")
+		return paymentUpdateError(err)
 	}
 	return nil
 }
+
+func paymentUpdateError(err error) *common.ErrorResponse {
+	if errors.Is(err, ErrNoPaymentfound) {
+		return common.NewErrorResponse(404, err.Error())
+	}
+
+	if errors.Is(err, order.ErrInvalidTransition) {
+		return common.NewErrorResponse(409, err.Error())
+	}
+
+	return common.NewErrorResponse(500, "gagal mengupdate status pembayaran! operasi dibatalkan!")
+}
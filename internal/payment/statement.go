@@ -0,0 +1,224 @@
+package payment
+
+import (
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrStatementEmpty = errors.New("file mutasi tidak berisi transaksi kredit yang bisa dibaca")
+var ErrStatementInvalid = errors.New("file mutasi tidak valid")
+
+// tanggal di mutasi bank ga ada zona waktu, dianggap WIB
+var statementLocation = time.FixedZone("WIB", 7*60*60)
+
+// StatementMapping nunjukin kolom mana aja yang dipake dari CSV mutasi.
+// TypeColumn -1 berarti mutasi ga punya kolom jenis (semua baris dianggap kredit
+// selama nominalnya ada), kalau TypeColumn == AmountColumn berarti penanda
+// kredit nempel di nominal, contoh "1,250,000.00 CR".
+type StatementMapping struct {
+	DateColumn        int
+	DateLayout        string
+	DescriptionColumn int
+	AmountColumn      int
+	TypeColumn        int
+	CreditMarker      string
+	Delimiter         rune
+	DecimalSeparator  rune
+}
+
+// statementPresets format export mutasi bank yang umum dipake.
+// formatnya beda-beda tiap channel (internet banking / CMS), jadi semua
+// kolom masih bisa ditimpa dari request.
+var statementPresets = map[string]StatementMapping{
+	"bca": {
+		DateColumn:        0,
+		DateLayout:        "02/01/2006",
+		DescriptionColumn: 1,
+		AmountColumn:      3,
+		TypeColumn:        3,
+		CreditMarker:      "CR",
+		Delimiter:         ',',
+		DecimalSeparator:  '.',
+	},
+	"mandiri": {
+		DateColumn:        0,
+		DateLayout:        "02/01/2006",
+		DescriptionColumn: 1,
+		AmountColumn:      3,
+		TypeColumn:        -1,
+		Delimiter:         ',',
+		DecimalSeparator:  ',',
+	},
+	"bri": {
+		DateColumn:        0,
+		DateLayout:        "02/01/06",
+		DescriptionColumn: 1,
+		AmountColumn:      3,
+		TypeColumn:        -1,
+		Delimiter:         ',',
+		DecimalSeparator:  '.',
+	},
+	"bni": {
+		DateColumn:        0,
+		DateLayout:        "02/01/2006",
+		DescriptionColumn: 1,
+		AmountColumn:      3,
+		TypeColumn:        2,
+		CreditMarker:      "K",
+		Delimiter:         ',',
+		DecimalSeparator:  ',',
+	},
+}
+
+// StatementLine satu baris kredit dari mutasi
+type StatementLine struct {
+	LineNumber  int
+	Date        time.Time
+	Description string
+	Amount      model.Money
+}
+
+// newStatementMapping gabungin preset bank sama override kolom dari request
+func newStatementMapping(req dto.ReconcileStatementRequest) StatementMapping {
+	bank := req.Bank
+	if bank == "" {
+		bank = "bca"
+	}
+	m := statementPresets[bank]
+
+	if req.DateColumn != nil {
+		m.DateColumn = *req.DateColumn
+	}
+	if req.DateLayout != nil {
+		m.DateLayout = *req.DateLayout
+	}
+	if req.DescriptionColumn != nil {
+		m.DescriptionColumn = *req.DescriptionColumn
+	}
+	if req.AmountColumn != nil {
+		m.AmountColumn = *req.AmountColumn
+	}
+	if req.TypeColumn != nil {
+		m.TypeColumn = *req.TypeColumn
+	}
+	if req.CreditMarker != nil {
+		m.CreditMarker = *req.CreditMarker
+	}
+	if req.Delimiter != nil {
+		m.Delimiter = rune((*req.Delimiter)[0])
+	}
+	if req.DecimalSeparator != nil {
+		m.DecimalSeparator = rune((*req.DecimalSeparator)[0])
+	}
+	return m
+}
+
+// parseStatement baca semua baris kredit dari CSV mutasi.
+// baris yang tanggalnya ga kebaca (header, saldo awal, footer) dilewatin
+// dan dihitung sebagai skipped.
+func parseStatement(r io.Reader, m StatementMapping) ([]StatementLine, int, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = m.Delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var lines []StatementLine
+	skipped := 0
+	lineNumber := 0
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		lineNumber++
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: baris %d %s", ErrStatementInvalid, lineNumber, err.Error())
+		}
+
+		line, ok := parseStatementRecord(record, m)
+		if !ok {
+			skipped++
+			continue
+		}
+		line.LineNumber = lineNumber
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return nil, skipped, ErrStatementEmpty
+	}
+	return lines, skipped, nil
+}
+
+func parseStatementRecord(record []string, m StatementMapping) (StatementLine, bool) {
+	cell := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	date, err := time.ParseInLocation(m.DateLayout, cell(m.DateColumn), statementLocation)
+	if err != nil {
+		return StatementLine{}, false
+	}
+
+	if m.TypeColumn >= 0 && m.CreditMarker != "" {
+		if !strings.Contains(strings.ToUpper(cell(m.TypeColumn)), strings.ToUpper(m.CreditMarker)) {
+			return StatementLine{}, false
+		}
+	}
+
+	amount, ok := parseStatementAmount(cell(m.AmountColumn), m.DecimalSeparator)
+	if !ok || amount <= 0 {
+		return StatementLine{}, false
+	}
+
+	return StatementLine{
+		Date:        date,
+		Description: cell(m.DescriptionColumn),
+		Amount:      amount,
+	}, true
+}
+
+// parseStatementAmount baca nominal kayak "1.250.000,00" atau "1,250,000.00 CR".
+// pemisah ribuan & huruf dibuang, pecahan harus nol karena rupiah ga ada sen.
+func parseStatementAmount(raw string, decimalSep rune) (model.Money, bool) {
+	var whole, frac strings.Builder
+	inFrac := false
+	for _, c := range raw {
+		switch {
+		case c == '-':
+			return 0, false
+		case c == decimalSep:
+			if inFrac {
+				return 0, false
+			}
+			inFrac = true
+		case c >= '0' && c <= '9':
+			if inFrac {
+				frac.WriteRune(c)
+			} else {
+				whole.WriteRune(c)
+			}
+		}
+	}
+
+	if whole.Len() == 0 || strings.Trim(frac.String(), "0") != "" {
+		return 0, false
+	}
+
+	var amount model.Money
+	if err := amount.Scan(whole.String()); err != nil {
+		return 0, false
+	}
+	return amount, true
+}
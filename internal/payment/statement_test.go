@@ -0,0 +1,61 @@
+package payment
+
+import (
+	"backEnd-RingoTechLife/internal/common/dto"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestReconcileRequestAllowsNoTypeColumn(t *testing.T) {
+	noType := -1
+	req := dto.ReconcileStatementRequest{Bank: "bca", TypeColumn: &noType}
+	if err := validator.New().Struct(req); err != nil {
+		t.Fatalf("type_column -1 harusnya valid, dapet: %v", err)
+	}
+
+	invalid := -2
+	req.TypeColumn = &invalid
+	if err := validator.New().Struct(req); err == nil {
+		t.Fatal("type_column -2 harusnya ditolak")
+	}
+
+	comma := ","
+	req = dto.ReconcileStatementRequest{Bank: "bni", DecimalSeparator: &comma}
+	if err := validator.New().Struct(req); err != nil {
+		t.Fatalf("decimal_separator koma harusnya valid, dapet: %v", err)
+	}
+}
+
+func TestParseStatementTypeColumnOverride(t *testing.T) {
+	// export BCA yang nominal nya ga ada penanda CR
+	csv := strings.Join([]string{
+		"Tanggal,Keterangan,Cabang,Jumlah",
+		"01/02/2025,TRSF E-BANKING ORDER A,0000,150123.00",
+		"02/02/2025,TRSF E-BANKING ORDER B,0000,275456.00",
+	}, "\n")
+
+	// pakai preset bca apa adanya semua baris dilewatin karena ga ada CR
+	preset := newStatementMapping(dto.ReconcileStatementRequest{Bank: "bca"})
+	if _, _, err := parseStatement(strings.NewReader(csv), preset); err != ErrStatementEmpty {
+		t.Fatalf("preset bca tanpa CR harusnya kosong, dapet: %v", err)
+	}
+
+	noType := -1
+	m := newStatementMapping(dto.ReconcileStatementRequest{Bank: "bca", TypeColumn: &noType})
+	if m.TypeColumn != -1 {
+		t.Fatalf("TypeColumn harusnya -1, dapet %d", m.TypeColumn)
+	}
+
+	lines, skipped, err := parseStatement(strings.NewReader(csv), m)
+	if err != nil {
+		t.Fatalf("parseStatement: %v", err)
+	}
+	if len(lines) != 2 || skipped != 1 {
+		t.Fatalf("harusnya 2 baris kredit & 1 dilewatin, dapet %d & %d", len(lines), skipped)
+	}
+	if lines[0].Amount != 150123 || lines[1].Amount != 275456 {
+		t.Fatalf("nominal salah: %d, %d", lines[0].Amount, lines[1].Amount)
+	}
+}
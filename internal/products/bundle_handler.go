@@ -0,0 +1,72 @@
+package products
+
+import (
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/pkg"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (ph *ProductsHandler) GetBundleHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id produk tidak valid!")
+		return
+	}
+
+	data, getErr := ph.service.GetBundle(r.Context(), id)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data bundle", data)
+}
+
+func (ph *ProductsHandler) SetBundleHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id produk tidak valid!")
+		return
+	}
+
+	var req dto.SetBundleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data bundle dengan benar!")
+		return
+	}
+
+	if err := ph.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, setErr := ph.service.SetBundle(r.Context(), id, req)
+	if setErr != nil {
+		pkg.JSONError(w, setErr.Code, setErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menyimpan isi bundle", data)
+}
+
+func (ph *ProductsHandler) RemoveBundleHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id produk tidak valid!")
+		return
+	}
+
+	if delErr := ph.service.RemoveBundle(r.Context(), id); delErr != nil {
+		pkg.JSONError(w, delErr.Code, delErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menghapus bundle", nil)
+}
@@ -0,0 +1,247 @@
+package products
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var ErrBundleComponentInvalid = errors.New("komponen bundle tidak valid")
+var ErrBundleComponentDuplicate = errors.New("komponen bundle dikirim lebih dari sekali")
+var ErrBundleHasStock = errors.New("produk masih punya stok sendiri, kosongkan stok nya dulu sebelum dijadikan bundle")
+var ErrBundleVariantConflict = errors.New("produk bundle tidak bisa punya varian")
+var ErrStockManagedByBundle = errors.New("stok produk bundle ngikut stok komponen nya, tidak bisa diubah langsung")
+
+// nama varian komponen, urut sesuai posisi opsi produk nya
+const bundleVariantNameQuery = `
+	(
+		SELECT string_agg(pov.value, ' / ' ORDER BY po.position, po.name)
+		FROM product_variant_values pvv
+		JOIN product_option_values pov ON pov.id = pvv.option_value_id
+		JOIN product_options po ON po.id = pov.option_id
+		WHERE pvv.variant_id = v.id
+	)
+`
+
+func (r *ProductRepositoryImpl) GetBundleItems(ctx context.Context, bundleID uuid.UUID) ([]model.BundleItem, error) {
+	query := `
+		SELECT bi.id, bi.component_id, bi.component_variant_id, p.name, p.slug,
+		       ` + bundleVariantNameQuery + `,
+		       COALESCE(v.price, p.price),
+		       COALESCE(v.stock - v.reserved_stock, p.stock - p.reserved_stock),
+		       bi.quantity
+		FROM product_bundle_items bi
+		JOIN products p ON p.id = bi.component_id
+		LEFT JOIN product_variants v ON v.id = bi.component_variant_id
+		WHERE bi.bundle_id = $1
+		ORDER BY bi.position ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query, bundleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []model.BundleItem{}
+	for rows.Next() {
+		var it model.BundleItem
+		err := rows.Scan(
+			&it.ID,
+			&it.ProductID,
+			&it.VariantID,
+			&it.ProductName,
+			&it.ProductSlug,
+			&it.VariantName,
+			&it.Price,
+			&it.AvailableStock,
+			&it.Quantity,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bundle item: %w", err)
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// SetBundleItems ganti semua komponen bundle sekaligus dan nandain produk nya sebagai bundle.
+// komponen ga boleh bundle lain, dan komponen bervarian wajib milih varian nya
+func (r *ProductRepositoryImpl) SetBundleItems(ctx context.Context, bundleID uuid.UUID, items []model.BundleItem) error {
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var stock int
+		var isBundle, hasVariants bool
+		err := tx.QueryRow(ctx, `
+			SELECT stock, is_bundle, EXISTS(SELECT 1 FROM product_variants WHERE product_id = products.id)
+			FROM products
+			WHERE id = $1
+			FOR UPDATE
+		`, bundleID).Scan(&stock, &isBundle, &hasVariants)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+
+		if hasVariants {
+			return ErrBundleVariantConflict
+		}
+		if !isBundle && stock > 0 {
+			return ErrBundleHasStock
+		}
+
+		for _, it := range items {
+			if err := checkBundleComponentTx(ctx, tx, bundleID, it); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM product_bundle_items WHERE bundle_id = $1`, bundleID); err != nil {
+			return err
+		}
+
+		insertQuery := `
+			INSERT INTO product_bundle_items (bundle_id, component_id, component_variant_id, quantity, position)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+		for i, it := range items {
+			if _, err := tx.Exec(ctx, insertQuery, bundleID, it.ProductID, it.VariantID, it.Quantity, i); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE products SET is_bundle = TRUE WHERE id = $1`, bundleID)
+		return err
+	})
+
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok {
+			switch pgErr.Code {
+			case "23505":
+				return ErrBundleComponentDuplicate
+			case "23503", "23514":
+				return ErrBundleComponentInvalid
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func checkBundleComponentTx(ctx context.Context, tx pgx.Tx, bundleID uuid.UUID, it model.BundleItem) error {
+	if it.ProductID == bundleID {
+		return fmt.Errorf("%w: bundle tidak bisa berisi dirinya sendiri", ErrBundleComponentInvalid)
+	}
+
+	var name string
+	var isBundle, hasVariants, variantValid bool
+	err := tx.QueryRow(ctx, `
+		SELECT p.name, p.is_bundle,
+		       EXISTS(SELECT 1 FROM product_variants WHERE product_id = p.id),
+		       EXISTS(SELECT 1 FROM product_variants WHERE product_id = p.id AND id = $2)
+		FROM products p
+		WHERE p.id = $1
+	`, it.ProductID, it.VariantID).Scan(&name, &isBundle, &hasVariants, &variantValid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: produk %s tidak ditemukan", ErrBundleComponentInvalid, it.ProductID)
+		}
+		return err
+	}
+
+	switch {
+	case isBundle:
+		return fmt.Errorf("%w: %s adalah bundle, bundle tidak bisa berisi bundle lain", ErrBundleComponentInvalid, name)
+	case hasVariants && it.VariantID == nil:
+		return fmt.Errorf("%w: %s punya varian, pilih varian nya", ErrBundleComponentInvalid, name)
+	case it.VariantID != nil && !variantValid:
+		return fmt.Errorf("%w: varian %s tidak ditemukan", ErrBundleComponentInvalid, name)
+	}
+	return nil
+}
+
+// RemoveBundle balikin produk bundle jadi produk biasa (stok 0), order lama tetep
+// nyimpen snapshot isi bundle nya jadi stok komponen nya tetep bisa dilepas / dibalikin
+func (r *ProductRepositoryImpl) RemoveBundle(ctx context.Context, bundleID uuid.UUID) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, `UPDATE products SET is_bundle = FALSE WHERE id = $1 AND is_bundle`, bundleID)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return ErrProductNotFound
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM product_bundle_items WHERE bundle_id = $1`, bundleID)
+		return err
+	})
+}
+
+// GetBundleStocks stok yang bisa dijual buat produk bundle di antara productIDs,
+// produk yang bukan bundle ga masuk map
+func (r *ProductRepositoryImpl) GetBundleStocks(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+
+	result := map[uuid.UUID]int{}
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, bundle_available_stock(id)
+		FROM products
+		WHERE id = ANY($1) AND is_bundle
+	`, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle stocks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var stock int
+		if err := rows.Scan(&id, &stock); err != nil {
+			return nil, err
+		}
+		result[id] = stock
+	}
+	return result, rows.Err()
+}
+
+// BundleComponentsTx snapshot isi bundle buat disimpen di item order,
+// nil kalau produk nya bukan bundle
+func BundleComponentsTx(ctx context.Context, tx pgx.Tx, productID uuid.UUID) ([]model.BundleComponent, error) {
+	query := `
+		SELECT bi.component_id, bi.component_variant_id, p.name,
+		       ` + bundleVariantNameQuery + `,
+		       COALESCE(v.sku, p.sku),
+		       bi.quantity
+		FROM product_bundle_items bi
+		JOIN products p ON p.id = bi.component_id
+		LEFT JOIN product_variants v ON v.id = bi.component_variant_id
+		WHERE bi.bundle_id = $1
+		ORDER BY bi.position ASC
+	`
+
+	rows, err := tx.Query(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle components: %w", err)
+	}
+	defer rows.Close()
+
+	var components []model.BundleComponent
+	for rows.Next() {
+		var c model.BundleComponent
+		if err := rows.Scan(&c.ProductID, &c.VariantID, &c.ProductName, &c.VariantName, &c.ProductSKU, &c.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan bundle component: %w", err)
+		}
+		components = append(components, c)
+	}
+	return components, rows.Err()
+}
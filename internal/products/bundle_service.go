@@ -0,0 +1,126 @@
+package products
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+func (p *ProductsService) GetBundle(ctx context.Context, id uuid.UUID) (dto.ProductBundleResponse, *common.ErrorResponse) {
+
+	product, err := p.repo.GetByID(ctx, id)
+	if err != nil {
+		return dto.ProductBundleResponse{}, bundleError(err)
+	}
+
+	items, err := p.repo.GetBundleItems(ctx, id)
+	if err != nil {
+		return dto.ProductBundleResponse{}, bundleError(err)
+	}
+
+	if len(items) == 0 {
+		return dto.ProductBundleResponse{}, common.NewErrorResponse(404, "produk ini bukan bundle!")
+	}
+
+	stocks, err := p.repo.GetBundleStocks(ctx, []uuid.UUID{id})
+	if err != nil {
+		return dto.ProductBundleResponse{}, bundleError(err)
+	}
+
+	regular := bundleRegularPrice(items)
+	res := dto.ProductBundleResponse{
+		BundleID:       product.ID,
+		BundleName:     product.Name,
+		BundlePrice:    product.Price,
+		RegularPrice:   regular,
+		AvailableStock: stocks[id],
+		Items:          items,
+	}
+	if regular > product.Price {
+		res.Savings = regular - product.Price
+	}
+	return res, nil
+}
+
+func (p *ProductsService) SetBundle(ctx context.Context, id uuid.UUID, req dto.SetBundleRequest) (dto.ProductBundleResponse, *common.ErrorResponse) {
+
+	items := make([]model.BundleItem, len(req.Items))
+	for i, it := range req.Items {
+		productId, err := uuid.Parse(it.ProductId)
+		if err != nil {
+			return dto.ProductBundleResponse{}, common.NewErrorResponse(400, "id produk komponen tidak valid!")
+		}
+		items[i] = model.BundleItem{ProductID: productId, Quantity: it.Quantity}
+
+		if it.VariantId != nil {
+			variantId, err := uuid.Parse(*it.VariantId)
+			if err != nil {
+				return dto.ProductBundleResponse{}, common.NewErrorResponse(400, "id varian komponen tidak valid!")
+			}
+			items[i].VariantID = &variantId
+		}
+	}
+
+	if err := p.repo.SetBundleItems(ctx, id, items); err != nil {
+		return dto.ProductBundleResponse{}, bundleError(err)
+	}
+
+	return p.GetBundle(ctx, id)
+}
+
+func (p *ProductsService) RemoveBundle(ctx context.Context, id uuid.UUID) *common.ErrorResponse {
+	if err := p.repo.RemoveBundle(ctx, id); err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			return common.NewErrorResponse(404, "produk bundle tidak ditemukan!")
+		}
+		return bundleError(err)
+	}
+	return nil
+}
+
+// attachBundle nempelin isi bundle ke detail produk, stok nya diganti stok turunan dari komponen
+func (p *ProductsService) attachBundle(ctx context.Context, data *dto.ProductDetailResponse) error {
+	items, err := p.repo.GetBundleItems(ctx, data.ID)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+
+	stocks, err := p.repo.GetBundleStocks(ctx, []uuid.UUID{data.ID})
+	if err != nil {
+		return err
+	}
+
+	regular := bundleRegularPrice(items)
+	data.IsBundle = true
+	data.BundleItems = items
+	data.BundleRegularPrice = &regular
+	data.Stock = stocks[data.ID]
+	data.ReservedStock = 0
+	data.AvailableStock = stocks[data.ID]
+	return nil
+}
+
+func bundleRegularPrice(items []model.BundleItem) model.Money {
+	var total model.Money
+	for _, it := range items {
+		total += it.Price.Mul(it.Quantity)
+	}
+	return total
+}
+
+func bundleError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		return common.NewErrorResponse(404, "produk tidak ditemukan!")
+	case errors.Is(err, ErrBundleHasStock), errors.Is(err, ErrBundleVariantConflict),
+		errors.Is(err, ErrBundleComponentDuplicate):
+		return common.NewErrorResponse(409, err.Error())
+	case errors.Is(err, ErrBundleComponentInvalid):
+		return common.NewErrorResponse(422, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di database "+err.Error())
+}
@@ -74,7 +74,9 @@ func (ph *ProductsHandler) AddNewProductsHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	data, savedImages, insertErr := ph.service.Create(r.Context(), productReq)
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	data, savedImages, insertErr := ph.service.Create(r.Context(), productReq, adminId)
 
 	if insertErr != nil {
 		pkg.JSONError(w, insertErr.Code, insertErr.Message)
@@ -215,7 +217,9 @@ func (ph *ProductsHandler) UpdateProductsHandler(w http.ResponseWriter, r *http.
 		}
 	}
 
-	data, updateErr := ph.service.UpdateProducts(r.Context(), updateReq, id)
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	data, updateErr := ph.service.UpdateProducts(r.Context(), updateReq, id, adminId)
 
 	if updateErr != nil {
 		pkg.JSONError(w, updateErr.Code, updateErr.Message)
@@ -286,6 +290,8 @@ func (ph *ProductsHandler) SetUpRoute(r chi.Router) {
 		r.Get("/get", ph.GetProductByStatus)
 		r.Get("/search", ph.GetSearchProducts)
 		r.Get("/home-data", ph.GetHomePageData)
+		r.Get("/variants/{productId}", ph.GetVariantsHandler)
+		r.Get("/bundle/{id}", ph.GetBundleHandler)
 
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.AuthMiddleware)
@@ -294,6 +300,13 @@ func (ph *ProductsHandler) SetUpRoute(r chi.Router) {
 			r.Delete("/delete/{id}", ph.DeleteProductHandler)
 			r.Put("/update/{id}", ph.UpdateProductsHandler)
 
+			r.Post("/variants/add/{productId}", ph.AddVariantHandler)
+			r.Put("/variants/update/{id}", ph.UpdateVariantHandler)
+			r.Delete("/variants/delete/{id}", ph.DeleteVariantHandler)
+
+			r.Put("/bundle/set/{id}", ph.SetBundleHandler)
+			r.Delete("/bundle/delete/{id}", ph.RemoveBundleHandler)
+
 		})
 
 	})
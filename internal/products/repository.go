@@ -3,6 +3,7 @@ package products
 import (
 	"backEnd-RingoTechLife/internal/common/dto"
 	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/inventory"
 	"context"
 	"encoding/json"
 	"errors"
@@ -21,14 +22,17 @@ var ErrConflictSlugName = errors.New("Slug sudah tersedia di database!")
 var ErrNameConflict = errors.New("nama produk sudah terdaftar di database! masukan nama lainnnya")
 var ErrConflicSku = errors.New("Sku produk sudah tersedia di database! harap masukan yg lain!")
 var ErrProductInUse = errors.New("Produk tercatat di transaksi atau order! tidak dapat dihapus. Jika memang dibutuhkan coba buat produk inactive/draft")
+var ErrNegativeStock = errors.New("stok produk tidak boleh kurang dari 0 atau dari jumlah yang sedang direservasi order")
+
+var initialStockNote = "stok awal"
 
 type ProductRepositoryInterface interface {
 	// Basic CRUD
-	Create(ctx context.Context, product *model.Product) (*model.Product, error)
+	Create(ctx context.Context, product *model.Product, actorID uuid.UUID) (*model.Product, error)
 	GetByID(ctx context.Context, id uuid.UUID) (model.Product, error)
 	GetDetailByID(ctx context.Context, id uuid.UUID) (dto.ProductDetailResponse, error)
 	GetBySlug(ctx context.Context, slug string) (dto.ProductDetailResponse, error)
-	Update(ctx context.Context, product *model.Product) (*model.Product, error)
+	Update(ctx context.Context, product *model.Product, stock *int, actorID uuid.UUID) (*model.Product, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 
 	// Listing & Filtering
@@ -43,7 +47,7 @@ type ProductRepositoryInterface interface {
 	IsProductExistsBySKU(ctx context.Context, sku string, excludeID *uuid.UUID) (bool, error)
 
 	// Stock Management
-	UpdateStock(ctx context.Context, id uuid.UUID, quantity int) error
+	UpdateStock(ctx context.Context, id uuid.UUID, quantity int, actorID uuid.UUID) error
 
 	// Search
 	SearchProducts(ctx context.Context, keyword string, cat *string) ([]model.Product, error)
@@ -51,6 +55,20 @@ type ProductRepositoryInterface interface {
 	GetBestSellerProducts(ctx context.Context) ([]model.Product, error)
 
 	GetProductsGroupedByCategory(ctx context.Context) ([]model.Product, error)
+
+	// Variants
+	GetVariants(ctx context.Context, productID uuid.UUID) ([]model.ProductOption, []model.ProductVariant, error)
+	GetVariantByID(ctx context.Context, id uuid.UUID) (model.ProductVariant, error)
+	CreateVariant(ctx context.Context, variant *model.ProductVariant, actorID uuid.UUID) (model.ProductVariant, error)
+	UpdateVariant(ctx context.Context, variant *model.ProductVariant, stock *int, actorID uuid.UUID) (model.ProductVariant, error)
+	DeleteVariant(ctx context.Context, id uuid.UUID) error
+	GetVariantSummaries(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]VariantSummary, error)
+
+	// Bundles
+	GetBundleItems(ctx context.Context, bundleID uuid.UUID) ([]model.BundleItem, error)
+	SetBundleItems(ctx context.Context, bundleID uuid.UUID, items []model.BundleItem) error
+	RemoveBundle(ctx context.Context, bundleID uuid.UUID) error
+	GetBundleStocks(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]int, error)
 }
 
 type ProductRepositoryImpl struct {
@@ -67,18 +85,20 @@ func NewProductsRepository(pool *pgxpool.Pool) *ProductRepositoryImpl {
 func (r *ProductRepositoryImpl) Create(
 	ctx context.Context,
 	product *model.Product,
+	actorID uuid.UUID,
 ) (*model.Product, error) {
 
+	// stok awal ga langsung ditulis, tapi lewat ledger biar kecatat sebagai restock
 	query := `
 		INSERT INTO products
 			(category_id, name, slug, description, brand, condition,
 			 price, stock, sku, specifications, status, is_featured, weight)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 0, $8, $9, $10, $11, $12)
 		RETURNING id, created_at
 	`
 
 	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
-		return tx.QueryRow(ctx, query,
+		err := tx.QueryRow(ctx, query,
 			product.CategoryID,
 			product.Name,
 			product.Slug,
@@ -86,14 +106,28 @@ func (r *ProductRepositoryImpl) Create(
 			product.Brand,
 			product.Condition,
 			product.Price,
-			product.Stock,
 			product.SKU,
 			product.Specifications,
 			product.Status,
 			product.IsFeatured,
 			product.Weight,
 		).Scan(&product.ID, &product.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = inventory.MoveTx(ctx, tx, inventory.Movement{
+			ProductID:     product.ID,
+			Delta:         product.Stock,
+			Reason:        model.InventoryReasonRestock,
+			ReferenceType: inventory.RefProduct,
+			ReferenceID:   &product.ID,
+			ActorID:       &actorID,
+			Note:          &initialStockNote,
+		})
+		return err
 	})
+	product.AvailableStock = product.Stock
 
 	if err != nil {
 
@@ -112,6 +146,10 @@ func (r *ProductRepositoryImpl) Create(
 			}
 		}
 
+		if errors.Is(err, inventory.ErrInsufficientStock) {
+			return nil, ErrNegativeStock
+		}
+
 		return nil, fmt.Errorf("create product failed: %w", err)
 	}
 
@@ -125,7 +163,7 @@ func (r *ProductRepositoryImpl) GetDetailByID(
 	query := `
 		SELECT
 			p.id, p.category_id, p.name, p.slug, p.description, p.brand,
-			p.condition, p.price, p.stock, p.sku, p.specifications,
+			p.condition, p.price, p.stock, p.reserved_stock, p.stock - p.reserved_stock, p.sku, p.specifications,
 			p.status, p.is_featured, p.weight, p.created_at,
 			c.id, c.name, c.slug, c.description, c.created_at,
 			COALESCE(
@@ -189,6 +227,8 @@ func (r *ProductRepositoryImpl) GetDetailByID(
 		&product.Condition,
 		&product.Price,
 		&product.Stock,
+		&product.ReservedStock,
+		&product.AvailableStock,
 		&product.SKU,
 		&product.Specifications,
 		&product.Status,
@@ -240,7 +280,7 @@ func (r *ProductRepositoryImpl) GetByID(
 	query := `
 		SELECT
 			p.id, p.category_id, p.name, p.slug, p.description, p.brand,
-			p.condition, p.price, p.stock, p.sku, p.specifications,
+			p.condition, p.price, p.stock, p.reserved_stock, p.stock - p.reserved_stock, p.sku, p.specifications,
 			p.status, p.is_featured, p.weight, p.created_at,
 			c.id, c.name, c.slug, c.description, c.created_at,
 			COALESCE(
@@ -284,6 +324,8 @@ func (r *ProductRepositoryImpl) GetByID(
 		&p.Condition,
 		&p.Price,
 		&p.Stock,
+		&p.ReservedStock,
+		&p.AvailableStock,
 		&p.SKU,
 		&p.Specifications,
 		&p.Status,
@@ -332,7 +374,7 @@ func (r *ProductRepositoryImpl) GetBySlug(
 	query := `
 		SELECT
 			p.id, p.category_id, p.name, p.slug, p.description, p.brand,
-			p.condition, p.price, p.stock, p.sku, p.specifications,
+			p.condition, p.price, p.stock, p.reserved_stock, p.stock - p.reserved_stock, p.sku, p.specifications,
 			p.status, p.is_featured, p.weight, p.created_at,
 			c.id, c.name, c.slug, c.description, c.created_at,
 			COALESCE(
@@ -396,6 +438,8 @@ func (r *ProductRepositoryImpl) GetBySlug(
 		&product.Condition,
 		&product.Price,
 		&product.Stock,
+		&product.ReservedStock,
+		&product.AvailableStock,
 		&product.SKU,
 		&product.Specifications,
 		&product.Status,
@@ -440,9 +484,12 @@ func (r *ProductRepositoryImpl) GetBySlug(
 }
 
 // Update updates an existing product
+// stock nil berarti stok ga diubah, kalau diisi selisihnya dicatat sebagai adjustment
 func (r *ProductRepositoryImpl) Update(
 	ctx context.Context,
 	product *model.Product,
+	stock *int,
+	actorID uuid.UUID,
 ) (*model.Product, error) {
 
 	query := `
@@ -454,18 +501,17 @@ func (r *ProductRepositoryImpl) Update(
 		    brand = $5,
 		    condition = $6,
 		    price = $7,
-		    stock = $8,
-		    sku = $9,
-		    specifications = $10,
-		    status = $11,
-		    is_featured = $12,
-		    weight = $13
-		WHERE id = $14
-		RETURNING created_at
+		    sku = $8,
+		    specifications = $9,
+		    status = $10,
+		    is_featured = $11,
+		    weight = $12
+		WHERE id = $13
+		RETURNING created_at, stock, reserved_stock
 	`
 
 	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
-		return tx.QueryRow(ctx, query,
+		err := tx.QueryRow(ctx, query,
 			product.CategoryID,
 			product.Name,
 			product.Slug,
@@ -473,15 +519,21 @@ func (r *ProductRepositoryImpl) Update(
 			product.Brand,
 			product.Condition,
 			product.Price,
-			product.Stock,
 			product.SKU,
 			product.Specifications,
 			product.Status,
 			product.IsFeatured,
 			product.Weight,
 			product.ID,
-		).Scan(&product.CreatedAt)
+		).Scan(&product.CreatedAt, &product.Stock, &product.ReservedStock)
+		if err != nil || stock == nil {
+			return err
+		}
+
+		product.Stock, err = inventory.SetTx(ctx, tx, product.ID, *stock, model.InventoryReasonAdjustment, &actorID, nil)
+		return err
 	})
+	product.AvailableStock = product.Stock - product.ReservedStock
 
 	if err != nil {
 
@@ -499,6 +551,18 @@ func (r *ProductRepositoryImpl) Update(
 			}
 		}
 
+		if errors.Is(err, inventory.ErrInsufficientStock) {
+			return nil, ErrNegativeStock
+		}
+
+		if errors.Is(err, inventory.ErrStockManagedByVariant) {
+			return nil, ErrStockManagedByVariant
+		}
+
+		if errors.Is(err, inventory.ErrStockManagedByBundle) {
+			return nil, ErrStockManagedByBundle
+		}
+
 		return nil, fmt.Errorf("update product failed: %w", err)
 	}
 
@@ -537,7 +601,7 @@ func (r *ProductRepositoryImpl) GetAllProducts(
 	query := `
 		SELECT
 			p.id, p.category_id, p.name, p.slug, p.description, p.brand,
-			p.condition, p.price, p.stock, p.sku, p.specifications,
+			p.condition, p.price, p.stock, p.reserved_stock, p.stock - p.reserved_stock, p.sku, p.specifications,
 			p.status, p.is_featured, p.weight, p.created_at,
 			c.id, c.name, c.slug, c.description, c.created_at,
 			COALESCE(
@@ -589,6 +653,8 @@ func (r *ProductRepositoryImpl) GetAllProducts(
 			&p.Condition,
 			&p.Price,
 			&p.Stock,
+			&p.ReservedStock,
+			&p.AvailableStock,
 			&p.SKU,
 			&p.Specifications,
 			&p.Status,
@@ -635,7 +701,7 @@ func (r *ProductRepositoryImpl) GetProductsByCategorySlug(
 	query := `
 		SELECT
 			p.id, p.category_id, p.name, p.slug, p.description, p.brand,
-			p.condition, p.price, p.stock, p.sku, p.specifications,
+			p.condition, p.price, p.stock, p.reserved_stock, p.stock - p.reserved_stock, p.sku, p.specifications,
 			p.status, p.is_featured, p.weight, p.created_at,
 			c.id, c.name, c.slug, c.description, c.created_at,
 			COALESCE(
@@ -688,6 +754,8 @@ func (r *ProductRepositoryImpl) GetProductsByCategorySlug(
 			&p.Condition,
 			&p.Price,
 			&p.Stock,
+			&p.ReservedStock,
+			&p.AvailableStock,
 			&p.SKU,
 			&p.Specifications,
 			&p.Status,
@@ -733,7 +801,7 @@ func (r *ProductRepositoryImpl) GetProductsByStatus(
 	query := `
 		SELECT
 			p.id, p.category_id, p.name, p.slug, p.description, p.brand,
-			p.condition, p.price, p.stock, p.sku, p.specifications,
+			p.condition, p.price, p.stock, p.reserved_stock, p.stock - p.reserved_stock, p.sku, p.specifications,
 			p.status, p.is_featured, p.weight, p.created_at,
 			c.id, c.name, c.slug, c.description, c.created_at,
 			COALESCE(
@@ -786,6 +854,8 @@ func (r *ProductRepositoryImpl) GetProductsByStatus(
 			&p.Condition,
 			&p.Price,
 			&p.Stock,
+			&p.ReservedStock,
+			&p.AvailableStock,
 			&p.SKU,
 			&p.Specifications,
 			&p.Status,
@@ -830,7 +900,7 @@ func (r *ProductRepositoryImpl) GetFeaturedProducts(
 	query := `
 		SELECT
 			p.id, p.category_id, p.name, p.slug, p.description, p.brand,
-			p.condition, p.price, p.stock, p.sku, p.specifications,
+			p.condition, p.price, p.stock, p.reserved_stock, p.stock - p.reserved_stock, p.sku, p.specifications,
 			p.status, p.is_featured, p.weight, p.created_at,
 			c.id, c.name, c.slug, c.description, c.created_at,
 			COALESCE(
@@ -883,6 +953,8 @@ func (r *ProductRepositoryImpl) GetFeaturedProducts(
 			&p.Condition,
 			&p.Price,
 			&p.Stock,
+			&p.ReservedStock,
+			&p.AvailableStock,
 			&p.SKU,
 			&p.Specifications,
 			&p.Status,
@@ -929,7 +1001,7 @@ func (r *ProductRepositoryImpl) IsProductExistsById(
 
 	query := `
 		SELECT id, category_id, name, slug, description, brand, condition,
-		       price, stock, sku, specifications, status, is_featured, weight, created_at
+		       price, stock, reserved_stock, stock - reserved_stock, sku, specifications, status, is_featured, weight, created_at
 		FROM products
 		WHERE id = $1
 		LIMIT 1
@@ -946,6 +1018,8 @@ func (r *ProductRepositoryImpl) IsProductExistsById(
 		&p.Condition,
 		&p.Price,
 		&p.Stock,
+		&p.ReservedStock,
+		&p.AvailableStock,
 		&p.SKU,
 		&p.Specifications,
 		&p.Status,
@@ -1053,25 +1127,21 @@ func (r *ProductRepositoryImpl) UpdateStock(
 	ctx context.Context,
 	id uuid.UUID,
 	quantity int,
+	actorID uuid.UUID,
 ) error {
 
-	query := `
-		UPDATE products
-		SET stock = $1
-		WHERE id = $2
-	`
-
 	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
-		res, err := tx.Exec(ctx, query, quantity, id)
-		if err != nil {
-			return err
-		}
-
-		if res.RowsAffected() == 0 {
+		_, err := inventory.SetTx(ctx, tx, id, quantity, model.InventoryReasonAdjustment, &actorID, nil)
+		if errors.Is(err, inventory.ErrProductNotFound) {
 			return fmt.Errorf("product with id %s not found", id)
 		}
-
-		return nil
+		if errors.Is(err, inventory.ErrStockManagedByVariant) {
+			return ErrStockManagedByVariant
+		}
+		if errors.Is(err, inventory.ErrStockManagedByBundle) {
+			return ErrStockManagedByBundle
+		}
+		return err
 	})
 
 	return err
@@ -1086,7 +1156,7 @@ func (r *ProductRepositoryImpl) SearchProducts(
 	query := `
         SELECT
             p.id, p.category_id, p.name, p.slug, p.description, p.brand,
-            p.condition, p.price, p.stock, p.sku, p.specifications,
+            p.condition, p.price, p.stock, p.reserved_stock, p.stock - p.reserved_stock, p.sku, p.specifications,
             p.status, p.is_featured, p.weight, p.created_at,
             c.id, c.name, c.slug, c.description, c.created_at,
             COALESCE(
@@ -1109,6 +1179,18 @@ func (r *ProductRepositoryImpl) SearchProducts(
 			$1 = '' OR
 			to_tsvector('indonesian', p.name || ' ' || p.slug)
 			@@ plainto_tsquery('indonesian', $1)
+			OR EXISTS (
+				-- cari juga per varian, jadi "iphone 13 128gb hitam" nemu produk nya
+				SELECT 1
+				FROM product_variants pv
+				LEFT JOIN product_variant_values pvv ON pvv.variant_id = pv.id
+				LEFT JOIN product_option_values pov ON pov.id = pvv.option_value_id
+				WHERE pv.product_id = p.id AND pv.is_active
+				GROUP BY pv.id
+				HAVING to_tsvector('indonesian',
+					p.name || ' ' || pv.sku || ' ' || COALESCE(string_agg(pov.value, ' '), ''))
+					@@ plainto_tsquery('indonesian', $1)
+			)
 		)
 		AND ($2::text IS NULL OR c.slug = $2::text)
 		AND p.status = 'active'
@@ -1145,6 +1227,8 @@ func (r *ProductRepositoryImpl) SearchProducts(
 			&p.Condition,
 			&p.Price,
 			&p.Stock,
+			&p.ReservedStock,
+			&p.AvailableStock,
 			&p.SKU,
 			&p.Specifications,
 			&p.Status,
@@ -1188,7 +1272,7 @@ func (r *ProductRepositoryImpl) GetBestSellerProducts(ctx context.Context) ([]mo
 	query := `
 	SELECT
 		p.id, p.category_id, p.name, p.slug, p.description, p.brand,
-		p.condition, p.price, p.stock, p.sku, p.specifications,
+		p.condition, p.price, p.stock, p.reserved_stock, p.stock - p.reserved_stock, p.sku, p.specifications,
 		p.status, p.is_featured, p.weight, p.created_at,
 
 		c.id, c.name, c.slug, c.description, c.created_at,
@@ -1214,6 +1298,7 @@ func (r *ProductRepositoryImpl) GetBestSellerProducts(ctx context.Context) ([]mo
 	LEFT JOIN (
 		SELECT product_id, SUM(quantity) as total_sold
 		FROM order_items
+		WHERE item_type = 'product'
 		GROUP BY product_id
 	) oi ON p.id = oi.product_id
 
@@ -1255,6 +1340,8 @@ func (r *ProductRepositoryImpl) GetBestSellerProducts(ctx context.Context) ([]mo
 			&p.Condition,
 			&p.Price,
 			&p.Stock,
+			&p.ReservedStock,
+			&p.AvailableStock,
 			&p.SKU,
 			&p.Specifications,
 			&p.Status,
@@ -1309,7 +1396,7 @@ func (r *ProductRepositoryImpl) GetProductsGroupedByCategory(ctx context.Context
 
 	SELECT
 		p.id, p.category_id, p.name, p.slug, p.description, p.brand,
-		p.condition, p.price, p.stock, p.sku, p.specifications,
+		p.condition, p.price, p.stock, p.reserved_stock, p.stock - p.reserved_stock, p.sku, p.specifications,
 		p.status, p.is_featured, p.weight, p.created_at,
 
 		c.id, c.name, c.slug, c.description, c.created_at,
@@ -1351,7 +1438,7 @@ func (r *ProductRepositoryImpl) GetProductsGroupedByCategory(ctx context.Context
 
 		err := rows.Scan(
 			&p.ID, &p.CategoryID, &p.Name, &p.Slug, &p.Description, &p.Brand,
-			&p.Condition, &p.Price, &p.Stock, &p.SKU, &p.Specifications,
+			&p.Condition, &p.Price, &p.Stock, &p.ReservedStock, &p.AvailableStock, &p.SKU, &p.Specifications,
 			&p.Status, &p.IsFeatured, &p.Weight, &p.CreatedAt,
 
 			&c.ID, &c.Name, &c.Slug, &c.Description, &c.CreatedAt,
@@ -37,7 +37,7 @@ func NewProductsService(rp *ProductRepositoryImpl, fs *storage.FileStorage, img
 	}
 }
 
-func (p *ProductsService) Create(ctx context.Context, product dto.CreateProductRequest) (model.Product, []*model.ProductImage, *common.ErrorResponse) {
+func (p *ProductsService) Create(ctx context.Context, product dto.CreateProductRequest, adminId uuid.UUID) (model.Product, []*model.ProductImage, *common.ErrorResponse) {
 
 	productModel, err := dto.NewProductFromCreateRequest(product)
 	if err != nil {
@@ -45,7 +45,7 @@ func (p *ProductsService) Create(ctx context.Context, product dto.CreateProductR
 	}
 
 	// fmt.Println(productModel)
-	data, err := p.repo.Create(ctx, &productModel)
+	data, err := p.repo.Create(ctx, &productModel, adminId)
 	if err != nil {
 		if errors.Is(err, ErrConflictSlugName) {
 			return model.Product{}, []*model.ProductImage{}, common.NewErrorResponse(409, err.Error())
@@ -59,6 +59,10 @@ func (p *ProductsService) Create(ctx context.Context, product dto.CreateProductR
 			return model.Product{}, []*model.ProductImage{}, common.NewErrorResponse(404, err.Error()+" operasi dibatalkan")
 		}
 
+		if errors.Is(err, ErrNegativeStock) {
+			return model.Product{}, []*model.ProductImage{}, common.NewErrorResponse(400, err.Error())
+		}
+
 		return model.Product{}, []*model.ProductImage{}, common.NewErrorResponse(500, "gagal insert ke database! : "+err.Error())
 	}
 
@@ -76,6 +80,9 @@ func (p *ProductsService) Create(ctx context.Context, product dto.CreateProductR
 func (p *ProductsService) GetAllProducts(ctx context.Context) ([]model.Product, *common.ErrorResponse) {
 
 	data, err := p.repo.GetAllProducts(ctx)
+	if err == nil {
+		data, err = p.withListingSummary(ctx, data)
+	}
 
 	if err != nil {
 		return []model.Product{}, common.NewErrorResponse(500, "gagal mengambil data dari database :"+err.Error())
@@ -112,6 +119,12 @@ func (p *ProductsService) DeleteProducts(ctx context.Context, id uuid.UUID) *com
 func (p *ProductsService) GetById(ctx context.Context, id uuid.UUID) (dto.ProductDetailResponse, *common.ErrorResponse) {
 
 	data, err := p.repo.GetDetailByID(ctx, id)
+	if err == nil {
+		err = p.attachVariants(ctx, &data)
+	}
+	if err == nil {
+		err = p.attachBundle(ctx, &data)
+	}
 
 	if err != nil {
 
@@ -129,6 +142,12 @@ func (p *ProductsService) GetById(ctx context.Context, id uuid.UUID) (dto.Produc
 func (p *ProductsService) GetBySlug(ctx context.Context, slug string) (dto.ProductDetailResponse, *common.ErrorResponse) {
 
 	data, err := p.repo.GetBySlug(ctx, slug)
+	if err == nil {
+		err = p.attachVariants(ctx, &data)
+	}
+	if err == nil {
+		err = p.attachBundle(ctx, &data)
+	}
 
 	if err != nil {
 		if errors.Is(err, ErrProductNotFound) {
@@ -143,6 +162,9 @@ func (p *ProductsService) GetBySlug(ctx context.Context, slug string) (dto.Produ
 func (p *ProductsService) GetByCategorySlug(ctx context.Context, catSlug string) ([]model.Product, *common.ErrorResponse) {
 
 	data, err := p.repo.GetProductsByCategorySlug(ctx, catSlug)
+	if err == nil {
+		data, err = p.withListingSummary(ctx, data)
+	}
 
 	if err != nil {
 		return []model.Product{}, common.NewErrorResponse(500, "gagal mengambil data dari database! "+err.Error())
@@ -152,7 +174,7 @@ func (p *ProductsService) GetByCategorySlug(ctx context.Context, catSlug string)
 
 }
 
-func (p *ProductsService) UpdateProducts(ctx context.Context, reqData dto.UpdateProductsRequest, id uuid.UUID) (model.Product, *common.ErrorResponse) {
+func (p *ProductsService) UpdateProducts(ctx context.Context, reqData dto.UpdateProductsRequest, id uuid.UUID, adminId uuid.UUID) (model.Product, *common.ErrorResponse) {
 
 	oldData, err := p.repo.GetByID(ctx, id)
 
@@ -176,7 +198,7 @@ func (p *ProductsService) UpdateProducts(ctx context.Context, reqData dto.Update
 	// udah di apply changes di old data
 	//
 	fmt.Println("old data abis apply update : ", oldData.CategoryID)
-	updatedData, err := p.repo.Update(ctx, &oldData)
+	updatedData, err := p.repo.Update(ctx, &oldData, reqData.Stock, adminId)
 
 	if err != nil {
 		if errors.Is(err, ErrConflictSlugName) {
@@ -191,6 +213,14 @@ func (p *ProductsService) UpdateProducts(ctx context.Context, reqData dto.Update
 			return model.Product{}, common.NewErrorResponse(404, err.Error()+" operasi dibatalkan")
 		}
 
+		if errors.Is(err, ErrNegativeStock) {
+			return model.Product{}, common.NewErrorResponse(400, err.Error())
+		}
+
+		if errors.Is(err, ErrStockManagedByVariant) || errors.Is(err, ErrStockManagedByBundle) {
+			return model.Product{}, common.NewErrorResponse(409, err.Error())
+		}
+
 		return model.Product{}, common.NewErrorResponse(500, "gagal mengupdate data di database! "+err.Error())
 	}
 
@@ -261,6 +291,9 @@ func (p *ProductsService) UpdateProducts(ctx context.Context, reqData dto.Update
 func (p *ProductsService) GetProductByStatus(ctx context.Context, status string) ([]model.Product, *common.ErrorResponse) {
 
 	data, err := p.repo.GetProductsByStatus(ctx, status)
+	if err == nil {
+		data, err = p.withListingSummary(ctx, data)
+	}
 
 	if err != nil {
 		fmt.Println(err)
@@ -274,6 +307,9 @@ func (p *ProductsService) GetProductByStatus(ctx context.Context, status string)
 func (p *ProductsService) SearchProductQuery(ctx context.Context, query string, cat *string) ([]model.Product, *common.ErrorResponse) {
 
 	data, err := p.repo.SearchProducts(ctx, query, cat)
+	if err == nil {
+		data, err = p.withListingSummary(ctx, data)
+	}
 	if err != nil {
 		return []model.Product{}, common.NewErrorResponse(500, "Terjadi kesalahan di server! "+err.Error())
 	}
@@ -327,15 +363,12 @@ func applyUpdateProductRequest(p *model.Product, req *dto.UpdateProductsRequest)
 		p.SKU = req.Sku
 	}
 
-	// Price (float32 → float64)
+	// Price
 	if req.Price != nil {
-		p.Price = float64(*req.Price)
+		p.Price = *req.Price
 	}
 
-	// Stock
-	if req.Stock != nil {
-		p.Stock = *req.Stock
-	}
+	// Stock ga di apply di sini, diubah lewat ledger di repository
 
 	// Specifications (string JSON → JSONB)
 	if req.Specifications != nil {
@@ -389,6 +422,9 @@ func (p *ProductsService) GetHomeData(ctx context.Context) (HomePageResponseData
 	var responseData HomePageResponseData = HomePageResponseData{}
 
 	bestSeller, err := p.repo.GetBestSellerProducts(ctx)
+	if err == nil {
+		bestSeller, err = p.withListingSummary(ctx, bestSeller)
+	}
 	if err != nil {
 		fmt.Println("best seller : ", err)
 		return HomePageResponseData{}, common.NewErrorResponse(500, "terjadi kesalahan di server")
@@ -396,6 +432,9 @@ func (p *ProductsService) GetHomeData(ctx context.Context) (HomePageResponseData
 	responseData.BestSellers = bestSeller
 
 	groupProduct, err := p.repo.GetProductsGroupedByCategory(ctx)
+	if err == nil {
+		groupProduct, err = p.withListingSummary(ctx, groupProduct)
+	}
 	if err != nil {
 		fmt.Println("group : ", err)
 		return HomePageResponseData{}, common.NewErrorResponse(500, "terjadi kesalahan di server")
@@ -431,3 +470,45 @@ func (p *ProductsService) GetHomeData(ctx context.Context) (HomePageResponseData
 	responseData.ProductData = result
 	return responseData, nil
 }
+
+// withListingSummary ngisi jumlah varian & rentang harga, plus stok produk bundle
+// di listing produk. satu query buat semua produk, bukan per produk
+func (p *ProductsService) withListingSummary(ctx context.Context, products []model.Product) ([]model.Product, error) {
+
+	ids := make([]uuid.UUID, len(products))
+	for i, pr := range products {
+		ids[i] = pr.ID
+	}
+
+	summaries, err := p.repo.GetVariantSummaries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	bundleStocks, err := p.repo.GetBundleStocks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range products {
+		stock, ok := bundleStocks[products[i].ID]
+		if !ok {
+			continue
+		}
+		products[i].IsBundle = true
+		products[i].Stock = stock
+		products[i].ReservedStock = 0
+		products[i].AvailableStock = stock
+	}
+
+	for i := range products {
+		s, ok := summaries[products[i].ID]
+		if !ok {
+			continue
+		}
+		products[i].VariantCount = s.Count
+		products[i].PriceMin = &s.PriceMin
+		products[i].PriceMax = &s.PriceMax
+	}
+	return products, nil
+}
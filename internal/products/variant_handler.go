@@ -0,0 +1,105 @@
+package products
+
+import (
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/middleware"
+	"backEnd-RingoTechLife/pkg"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (ph *ProductsHandler) GetVariantsHandler(w http.ResponseWriter, r *http.Request) {
+
+	productId, err := uuid.Parse(chi.URLParam(r, "productId"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id produk tidak valid!")
+		return
+	}
+
+	data, getErr := ph.service.GetVariants(r.Context(), productId)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data varian", data)
+}
+
+func (ph *ProductsHandler) AddVariantHandler(w http.ResponseWriter, r *http.Request) {
+
+	productId, err := uuid.Parse(chi.URLParam(r, "productId"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id produk tidak valid!")
+		return
+	}
+
+	var req dto.CreateProductVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data varian dengan benar!")
+		return
+	}
+
+	if err := ph.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	data, addErr := ph.service.CreateVariant(r.Context(), productId, req, adminId)
+	if addErr != nil {
+		pkg.JSONError(w, addErr.Code, addErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menambahkan varian produk", data)
+}
+
+func (ph *ProductsHandler) UpdateVariantHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.UpdateProductVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data varian dengan benar!")
+		return
+	}
+
+	if err := ph.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	data, updateErr := ph.service.UpdateVariant(r.Context(), id, req, adminId)
+	if updateErr != nil {
+		pkg.JSONError(w, updateErr.Code, updateErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengupdate varian produk", data)
+}
+
+func (ph *ProductsHandler) DeleteVariantHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	if delErr := ph.service.DeleteVariant(r.Context(), id); delErr != nil {
+		pkg.JSONError(w, delErr.Code, delErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menghapus varian produk", nil)
+}
@@ -0,0 +1,527 @@
+package products
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/inventory"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var ErrVariantNotFound = errors.New("varian produk tidak ditemukan")
+var ErrVariantSkuConflict = errors.New("sku varian sudah dipakai! harap masukan yg lain!")
+var ErrVariantDuplicate = errors.New("kombinasi opsi varian ini sudah ada di produk ini")
+var ErrVariantOptionMismatch = errors.New("nama opsi varian harus sama dengan varian lain di produk ini")
+var ErrVariantImageInvalid = errors.New("gambar varian harus salah satu gambar produk ini")
+var ErrVariantHasStock = errors.New("varian masih punya stok atau sedang direservasi order, kosongkan stok nya dulu")
+var ErrProductStockNotInVariants = errors.New("produk masih punya stok tanpa varian, kosongkan stok produk dulu sebelum menambah varian")
+var ErrStockManagedByVariant = errors.New("stok produk ini diatur per varian, ubah stok lewat varian nya")
+var ErrVariantInBundle = errors.New("varian masih dipakai sebagai komponen bundle, keluarkan dari bundle dulu")
+
+const variantColumns = `
+	v.id, v.product_id, v.sku, v.price, v.stock, v.reserved_stock, v.stock - v.reserved_stock,
+	v.weight, v.image_id, pi.image_url, v.is_active, v.created_at, v.updated_at,
+	COALESCE((
+		SELECT jsonb_object_agg(po.name, pov.value)
+		FROM product_variant_values pvv
+		JOIN product_option_values pov ON pov.id = pvv.option_value_id
+		JOIN product_options po ON po.id = pov.option_id
+		WHERE pvv.variant_id = v.id
+	), '{}'::jsonb)
+`
+
+type scannable interface {
+	Scan(dest ...any) error
+}
+
+func scanVariant(row scannable) (model.ProductVariant, error) {
+	var v model.ProductVariant
+	err := row.Scan(
+		&v.ID,
+		&v.ProductID,
+		&v.SKU,
+		&v.Price,
+		&v.Stock,
+		&v.ReservedStock,
+		&v.AvailableStock,
+		&v.Weight,
+		&v.ImageID,
+		&v.ImageURL,
+		&v.IsActive,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+		&v.Options,
+	)
+	return v, err
+}
+
+// VariantSummary ringkasan varian aktif buat listing produk
+type VariantSummary struct {
+	Count    int
+	PriceMin model.Money
+	PriceMax model.Money
+}
+
+// normalizeOptions trim nama & nilai opsi terus bikin signature (lowercase, urut nama opsi)
+// biar "Hitam"/"hitam" ga kebikin dua varian
+func normalizeOptions(options map[string]string) (map[string]string, []string, string) {
+	clean := make(map[string]string, len(options))
+	for k, v := range options {
+		clean[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	names := make([]string, 0, len(clean))
+	for k := range clean {
+		names = append(names, k)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = strings.ToLower(n) + "=" + strings.ToLower(clean[n])
+	}
+	return clean, names, strings.Join(parts, ";")
+}
+
+func (r *ProductRepositoryImpl) GetVariants(ctx context.Context, productID uuid.UUID) ([]model.ProductOption, []model.ProductVariant, error) {
+
+	optionRows, err := r.pool.Query(ctx, `
+		SELECT po.id, po.product_id, po.name, po.position,
+		       COALESCE(
+		           json_agg(
+		               json_build_object(
+		                   'id', pov.id,
+		                   'option_id', pov.option_id,
+		                   'value', pov.value,
+		                   'position', pov.position
+		               ) ORDER BY pov.position, pov.value
+		           ) FILTER (WHERE pov.id IS NOT NULL),
+		           '[]'::json
+		       )
+		FROM product_options po
+		LEFT JOIN product_option_values pov ON pov.option_id = po.id
+		WHERE po.product_id = $1
+		GROUP BY po.id
+		ORDER BY po.position, po.name
+	`, productID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get product options: %w", err)
+	}
+	defer optionRows.Close()
+
+	options := []model.ProductOption{}
+	for optionRows.Next() {
+		var o model.ProductOption
+		if err := optionRows.Scan(&o.ID, &o.ProductID, &o.Name, &o.Position, &o.Values); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan product option: %w", err)
+		}
+		options = append(options, o)
+	}
+	if err := optionRows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	variantRows, err := r.pool.Query(ctx, `
+		SELECT `+variantColumns+`
+		FROM product_variants v
+		LEFT JOIN product_images pi ON pi.id = v.image_id
+		WHERE v.product_id = $1
+		ORDER BY v.price ASC, v.created_at ASC
+	`, productID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get product variants: %w", err)
+	}
+	defer variantRows.Close()
+
+	variants := []model.ProductVariant{}
+	for variantRows.Next() {
+		v, err := scanVariant(variantRows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan product variant: %w", err)
+		}
+		v.Name = variantName(options, v.Options)
+		variants = append(variants, v)
+	}
+
+	return options, variants, variantRows.Err()
+}
+
+func (r *ProductRepositoryImpl) GetVariantByID(ctx context.Context, id uuid.UUID) (model.ProductVariant, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT `+variantColumns+`
+		FROM product_variants v
+		LEFT JOIN product_images pi ON pi.id = v.image_id
+		WHERE v.id = $1
+	`, id)
+
+	v, err := scanVariant(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ProductVariant{}, ErrVariantNotFound
+		}
+		return model.ProductVariant{}, err
+	}
+
+	options, _, err := r.GetVariants(ctx, v.ProductID)
+	if err != nil {
+		return model.ProductVariant{}, err
+	}
+	v.Name = variantName(options, v.Options)
+	return v, nil
+}
+
+// variantName gabungin nilai opsi varian sesuai urutan opsi produk, misal "128GB / Hitam"
+func variantName(options []model.ProductOption, values map[string]string) string {
+	parts := []string{}
+	for _, o := range options {
+		if val, ok := values[o.Name]; ok {
+			parts = append(parts, val)
+		}
+	}
+	return strings.Join(parts, " / ")
+}
+
+// CreateVariant nambah satu varian. tipe opsi & nilai nya dibikin otomatis kalau belum ada,
+// stok awal masuk lewat ledger sebagai restock varian.
+// produk yang belum punya varian harus stok nya 0 dulu, soalnya stok produk bervarian = jumlah stok varian
+func (r *ProductRepositoryImpl) CreateVariant(ctx context.Context, variant *model.ProductVariant, actorID uuid.UUID) (model.ProductVariant, error) {
+
+	options, names, signature := normalizeOptions(variant.Options)
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var stock int
+		var hasVariants, isBundle bool
+		err := tx.QueryRow(ctx, `
+			SELECT stock, EXISTS(SELECT 1 FROM product_variants WHERE product_id = products.id), is_bundle
+			FROM products
+			WHERE id = $1
+			FOR UPDATE
+		`, variant.ProductID).Scan(&stock, &hasVariants, &isBundle)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+
+		if isBundle {
+			return ErrBundleVariantConflict
+		}
+
+		if !hasVariants {
+			if stock > 0 {
+				return ErrProductStockNotInVariants
+			}
+			// sisa opsi dari varian yang udah dihapus semua ga dipake lagi
+			if _, err := tx.Exec(ctx, `DELETE FROM product_options WHERE product_id = $1`, variant.ProductID); err != nil {
+				return err
+			}
+		}
+
+		optionIDs, err := upsertOptionsTx(ctx, tx, variant.ProductID, names, hasVariants)
+		if err != nil {
+			return err
+		}
+
+		if variant.ImageID != nil {
+			if err := checkVariantImageTx(ctx, tx, variant.ProductID, *variant.ImageID); err != nil {
+				return err
+			}
+		}
+
+		err = tx.QueryRow(ctx, `
+			INSERT INTO product_variants
+				(product_id, sku, price, stock, weight, image_id, is_active, option_signature)
+			VALUES ($1, $2, $3, 0, $4, $5, $6, $7)
+			RETURNING id
+		`,
+			variant.ProductID,
+			variant.SKU,
+			variant.Price,
+			variant.Weight,
+			variant.ImageID,
+			variant.IsActive,
+			signature,
+		).Scan(&variant.ID)
+		if err != nil {
+			return err
+		}
+
+		for i, name := range names {
+			valueID, err := upsertOptionValueTx(ctx, tx, optionIDs[i], options[name])
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `
+				INSERT INTO product_variant_values (variant_id, option_value_id)
+				VALUES ($1, $2)
+			`, variant.ID, valueID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if variant.Stock == 0 {
+			return nil
+		}
+
+		_, err = inventory.MoveTx(ctx, tx, inventory.Movement{
+			ProductID:     variant.ProductID,
+			VariantID:     &variant.ID,
+			Delta:         variant.Stock,
+			Reason:        model.InventoryReasonRestock,
+			ReferenceType: inventory.RefVariant,
+			ReferenceID:   &variant.ID,
+			ActorID:       &actorID,
+			Note:          &initialStockNote,
+		})
+		return err
+	})
+	if err != nil {
+		return model.ProductVariant{}, variantWriteError(err)
+	}
+
+	return r.GetVariantByID(ctx, variant.ID)
+}
+
+// upsertOptionsTx balikin id opsi sesuai urutan names. kalau produk udah punya varian,
+// nama opsi nya harus persis sama (ga peduli huruf besar kecil) sama yang udah ada
+func upsertOptionsTx(ctx context.Context, tx pgx.Tx, productID uuid.UUID, names []string, hasVariants bool) ([]uuid.UUID, error) {
+
+	rows, err := tx.Query(ctx, `SELECT id, name FROM product_options WHERE product_id = $1`, productID)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		existing[strings.ToLower(name)] = id
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if hasVariants && len(existing) != len(names) {
+		return nil, ErrVariantOptionMismatch
+	}
+
+	ids := make([]uuid.UUID, len(names))
+	for i, name := range names {
+		if id, ok := existing[strings.ToLower(name)]; ok {
+			ids[i] = id
+			continue
+		}
+		if hasVariants {
+			return nil, ErrVariantOptionMismatch
+		}
+
+		err := tx.QueryRow(ctx, `
+			INSERT INTO product_options (product_id, name, position)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, productID, name, i).Scan(&ids[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func upsertOptionValueTx(ctx context.Context, tx pgx.Tx, optionID uuid.UUID, value string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `
+		SELECT id FROM product_option_values
+		WHERE option_id = $1 AND LOWER(value) = LOWER($2)
+	`, optionID, value).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, err
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO product_option_values (option_id, value, position)
+		VALUES ($1, $2, (SELECT COUNT(*) FROM product_option_values WHERE option_id = $1))
+		RETURNING id
+	`, optionID, value).Scan(&id)
+	return id, err
+}
+
+func checkVariantImageTx(ctx context.Context, tx pgx.Tx, productID uuid.UUID, imageID uuid.UUID) error {
+	var ok bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM product_images WHERE id = $1 AND product_id = $2)
+	`, imageID, productID).Scan(&ok)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVariantImageInvalid
+	}
+	return nil
+}
+
+// UpdateVariant stock nil berarti stok ga diubah, kalau diisi selisihnya dicatat sebagai adjustment.
+// ImageID nil berarti varian ga punya gambar sendiri (pake gambar produk)
+func (r *ProductRepositoryImpl) UpdateVariant(ctx context.Context, variant *model.ProductVariant, stock *int, actorID uuid.UUID) (model.ProductVariant, error) {
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if variant.ImageID != nil {
+			if err := checkVariantImageTx(ctx, tx, variant.ProductID, *variant.ImageID); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.Exec(ctx, `
+			UPDATE product_variants
+			SET sku = $1,
+			    price = $2,
+			    weight = $3,
+			    image_id = $4,
+			    is_active = $5,
+			    updated_at = NOW()
+			WHERE id = $6
+		`,
+			variant.SKU,
+			variant.Price,
+			variant.Weight,
+			variant.ImageID,
+			variant.IsActive,
+			variant.ID,
+		)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return ErrVariantNotFound
+		}
+
+		if stock == nil {
+			return nil
+		}
+		_, err = inventory.SetVariantTx(ctx, tx, variant.ProductID, variant.ID, *stock, model.InventoryReasonAdjustment, &actorID, nil)
+		return err
+	})
+	if err != nil {
+		return model.ProductVariant{}, variantWriteError(err)
+	}
+
+	return r.GetVariantByID(ctx, variant.ID)
+}
+
+// DeleteVariant cuma boleh kalau stok & reservasi nya udah 0, item order lama tetep nyimpen
+// snapshot nama varian nya. nilai opsi yang udah ga dipake varian manapun ikut dibersihin
+func (r *ProductRepositoryImpl) DeleteVariant(ctx context.Context, id uuid.UUID) error {
+
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var productID uuid.UUID
+		var stock, reserved int
+		err := tx.QueryRow(ctx, `
+			SELECT product_id, stock, reserved_stock
+			FROM product_variants
+			WHERE id = $1
+			FOR UPDATE
+		`, id).Scan(&productID, &stock, &reserved)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrVariantNotFound
+			}
+			return err
+		}
+
+		if stock > 0 || reserved > 0 {
+			return ErrVariantHasStock
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM product_variants WHERE id = $1`, id); err != nil {
+			if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23503" {
+				return ErrVariantInBundle
+			}
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			DELETE FROM product_option_values pov
+			USING product_options po
+			WHERE pov.option_id = po.id
+			  AND po.product_id = $1
+			  AND NOT EXISTS (
+			      SELECT 1 FROM product_variant_values pvv WHERE pvv.option_value_id = pov.id
+			  )
+		`, productID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			DELETE FROM product_options po
+			WHERE po.product_id = $1
+			  AND NOT EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1)
+		`, productID)
+		return err
+	})
+}
+
+// GetVariantSummaries ngitung jumlah varian aktif + rentang harga nya buat banyak produk sekaligus
+func (r *ProductRepositoryImpl) GetVariantSummaries(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]VariantSummary, error) {
+
+	result := map[uuid.UUID]VariantSummary{}
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT product_id, COUNT(*)::int, MIN(price), MAX(price)
+		FROM product_variants
+		WHERE product_id = ANY($1) AND is_active
+		GROUP BY product_id
+	`, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant summaries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var s VariantSummary
+		if err := rows.Scan(&id, &s.Count, &s.PriceMin, &s.PriceMax); err != nil {
+			return nil, err
+		}
+		result[id] = s
+	}
+	return result, rows.Err()
+}
+
+func variantWriteError(err error) error {
+	if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23505" {
+		switch pgErr.ConstraintName {
+		case "product_variants_sku_key":
+			return ErrVariantSkuConflict
+		case "product_variants_signature_key":
+			return ErrVariantDuplicate
+		}
+	}
+
+	switch {
+	case errors.Is(err, inventory.ErrVariantNotFound):
+		return ErrVariantNotFound
+	case errors.Is(err, inventory.ErrInsufficientStock):
+		return ErrNegativeStock
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package products
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+func (p *ProductsService) GetVariants(ctx context.Context, productId uuid.UUID) (dto.ProductVariantsResponse, *common.ErrorResponse) {
+
+	if _, err := p.repo.GetByID(ctx, productId); err != nil {
+		return dto.ProductVariantsResponse{}, variantError(err)
+	}
+
+	options, variants, err := p.repo.GetVariants(ctx, productId)
+	if err != nil {
+		return dto.ProductVariantsResponse{}, variantError(err)
+	}
+
+	return dto.ProductVariantsResponse{Options: options, Variants: variants}, nil
+}
+
+func (p *ProductsService) CreateVariant(ctx context.Context, productId uuid.UUID, req dto.CreateProductVariantRequest, adminId uuid.UUID) (model.ProductVariant, *common.ErrorResponse) {
+
+	// nama opsi ga boleh dobel walaupun beda huruf besar kecil ("Warna" & "warna")
+	seen := map[string]bool{}
+	for name, value := range req.Options {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" || strings.TrimSpace(value) == "" {
+			return model.ProductVariant{}, common.NewErrorResponse(400, "nama dan nilai opsi varian tidak boleh kosong!")
+		}
+		if seen[key] {
+			return model.ProductVariant{}, common.NewErrorResponse(400, "nama opsi "+name+" dikirim lebih dari sekali!")
+		}
+		seen[key] = true
+	}
+
+	variant := model.ProductVariant{
+		ProductID: productId,
+		SKU:       strings.TrimSpace(req.Sku),
+		Price:     req.Price,
+		Stock:     req.Stock,
+		Weight:    req.Weight,
+		IsActive:  true,
+		Options:   req.Options,
+	}
+
+	if req.IsActive != nil {
+		variant.IsActive = *req.IsActive
+	}
+
+	if req.ImageId != nil {
+		imageId, err := uuid.Parse(*req.ImageId)
+		if err != nil {
+			return model.ProductVariant{}, common.NewErrorResponse(400, "id gambar tidak valid!")
+		}
+		variant.ImageID = &imageId
+	}
+
+	data, err := p.repo.CreateVariant(ctx, &variant, adminId)
+	if err != nil {
+		return model.ProductVariant{}, variantError(err)
+	}
+	return data, nil
+}
+
+func (p *ProductsService) UpdateVariant(ctx context.Context, id uuid.UUID, req dto.UpdateProductVariantRequest, adminId uuid.UUID) (model.ProductVariant, *common.ErrorResponse) {
+
+	variant, err := p.repo.GetVariantByID(ctx, id)
+	if err != nil {
+		return model.ProductVariant{}, variantError(err)
+	}
+
+	if req.Sku != nil {
+		variant.SKU = strings.TrimSpace(*req.Sku)
+	}
+
+	if req.Price != nil {
+		variant.Price = *req.Price
+	}
+
+	if req.Weight != nil {
+		variant.Weight = req.Weight
+	}
+
+	if req.IsActive != nil {
+		variant.IsActive = *req.IsActive
+	}
+
+	if req.ImageId != nil {
+		if *req.ImageId == "" {
+			variant.ImageID = nil
+		} else {
+			imageId, err := uuid.Parse(*req.ImageId)
+			if err != nil {
+				return model.ProductVariant{}, common.NewErrorResponse(400, "id gambar tidak valid!")
+			}
+			variant.ImageID = &imageId
+		}
+	}
+
+	data, err := p.repo.UpdateVariant(ctx, &variant, req.Stock, adminId)
+	if err != nil {
+		return model.ProductVariant{}, variantError(err)
+	}
+	return data, nil
+}
+
+func (p *ProductsService) DeleteVariant(ctx context.Context, id uuid.UUID) *common.ErrorResponse {
+	if err := p.repo.DeleteVariant(ctx, id); err != nil {
+		return variantError(err)
+	}
+	return nil
+}
+
+// SelectVariant nentuin varian yang dibeli dari detail produk.
+// produk bervarian wajib milih varian aktif, produk biasa ga boleh dikirim varian
+func SelectVariant(product dto.ProductDetailResponse, variantId *uuid.UUID) (*model.ProductVariant, *common.ErrorResponse) {
+
+	if len(product.Variants) == 0 {
+		if variantId != nil {
+			return nil, common.NewErrorResponse(404, "varian produk tidak ditemukan!")
+		}
+		return nil, nil
+	}
+
+	if variantId == nil {
+		return nil, common.NewErrorResponse(422, "produk ini punya varian, harap pilih varian nya!")
+	}
+
+	for i := range product.Variants {
+		v := &product.Variants[i]
+		if v.ID != *variantId {
+			continue
+		}
+		if !v.IsActive {
+			return nil, common.NewErrorResponse(404, "varian produk tidak tersedia!")
+		}
+		return v, nil
+	}
+
+	return nil, common.NewErrorResponse(404, "varian produk tidak ditemukan!")
+}
+
+// attachVariants nempelin opsi & varian ke detail produk
+func (p *ProductsService) attachVariants(ctx context.Context, data *dto.ProductDetailResponse) error {
+	options, variants, err := p.repo.GetVariants(ctx, data.ID)
+	if err != nil {
+		return err
+	}
+	data.Options = options
+	data.Variants = variants
+	return nil
+}
+
+func variantError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		return common.NewErrorResponse(404, "produk tidak ditemukan!")
+	case errors.Is(err, ErrVariantNotFound):
+		return common.NewErrorResponse(404, err.Error())
+	case errors.Is(err, ErrVariantSkuConflict), errors.Is(err, ErrVariantDuplicate),
+		errors.Is(err, ErrVariantHasStock), errors.Is(err, ErrProductStockNotInVariants),
+		errors.Is(err, ErrVariantInBundle), errors.Is(err, ErrBundleVariantConflict):
+		return common.NewErrorResponse(409, err.Error())
+	case errors.Is(err, ErrVariantOptionMismatch), errors.Is(err, ErrVariantImageInvalid):
+		return common.NewErrorResponse(422, err.Error())
+	case errors.Is(err, ErrNegativeStock):
+		return common.NewErrorResponse(400, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di database "+err.Error())
+}
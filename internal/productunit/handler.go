@@ -0,0 +1,219 @@
+package productunit
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/middleware"
+	"backEnd-RingoTechLife/pkg"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type ProductUnitHandler struct {
+	unitService *ProductUnitService
+	validator   *validator.Validate
+}
+
+func NewProductUnitHandler(svc *ProductUnitService, vld *validator.Validate) *ProductUnitHandler {
+	return &ProductUnitHandler{
+		unitService: svc,
+		validator:   vld,
+	}
+}
+
+func (uh *ProductUnitHandler) AddHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.AddProductUnitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data unit dengan benar!")
+		return
+	}
+
+	if err := uh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, addErr := uh.unitService.Create(r.Context(), req)
+	if addErr != nil {
+		pkg.JSONError(w, addErr.Code, addErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menambahkan unit produk", data)
+}
+
+func (uh *ProductUnitHandler) GetByProductHandler(w http.ResponseWriter, r *http.Request) {
+
+	productId, err := uuid.Parse(chi.URLParam(r, "productId"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id produk tidak valid!")
+		return
+	}
+
+	var status *model.ProductUnitStatus
+	if raw := r.URL.Query().Get("status"); raw != "" {
+		st := model.ProductUnitStatus(raw)
+		switch st {
+		case model.ProductUnitInStock, model.ProductUnitReserved, model.ProductUnitSold, model.ProductUnitReturned:
+			status = &st
+		default:
+			pkg.JSONError(w, 400, "status unit tidak valid!")
+			return
+		}
+	}
+
+	data, getErr := uh.unitService.GetByProduct(r.Context(), productId, status)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data unit", data)
+}
+
+func (uh *ProductUnitHandler) GetByIdHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	data, getErr := uh.unitService.GetById(r.Context(), id)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data unit", data)
+}
+
+func (uh *ProductUnitHandler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.UpdateProductUnitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data unit dengan benar!")
+		return
+	}
+
+	if err := uh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, updateErr := uh.unitService.Update(r.Context(), id, req)
+	if updateErr != nil {
+		pkg.JSONError(w, updateErr.Code, updateErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengupdate unit produk", data)
+}
+
+func (uh *ProductUnitHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	if delErr := uh.unitService.Delete(r.Context(), id); delErr != nil {
+		pkg.JSONError(w, delErr.Code, delErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menghapus unit produk", nil)
+}
+
+func (uh *ProductUnitHandler) AssignHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.AssignProductUnitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data unit dengan benar!")
+		return
+	}
+
+	if err := uh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, assignErr := uh.unitService.Assign(r.Context(), req)
+	if assignErr != nil {
+		pkg.JSONError(w, assignErr.Code, assignErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil memasang unit ke item order", data)
+}
+
+func (uh *ProductUnitHandler) UnassignHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	data, unassignErr := uh.unitService.Unassign(r.Context(), id)
+	if unassignErr != nil {
+		pkg.JSONError(w, unassignErr.Code, unassignErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil melepas unit dari item order", data)
+}
+
+func (uh *ProductUnitHandler) LookupHandler(w http.ResponseWriter, r *http.Request) {
+
+	data, err := uh.unitService.Lookup(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menemukan unit", data)
+}
+
+func (uh *ProductUnitHandler) SetUpRoute(router chi.Router) {
+
+	router.Route("/product-units", func(r chi.Router) {
+		r.Use(httprate.Limit(
+			50,
+			time.Minute,
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				errorRes := common.NewErrorResponse(http.StatusTooManyRequests, "Terlalu banyak request, coba lagi nanti")
+				errorResJson, _ := json.Marshal(errorRes)
+				w.Write(errorResJson)
+			}),
+		))
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware(middleware.RoleAdmin))
+
+		r.Post("/add", uh.AddHandler)
+		r.Get("/product/{productId}", uh.GetByProductHandler)
+		r.Get("/id/{id}", uh.GetByIdHandler)
+		r.Get("/lookup/{code}", uh.LookupHandler)
+		r.Put("/update/{id}", uh.UpdateHandler)
+		r.Delete("/delete/{id}", uh.DeleteHandler)
+		r.Put("/assign", uh.AssignHandler)
+		r.Put("/unassign/{id}", uh.UnassignHandler)
+	})
+}
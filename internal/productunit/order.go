@@ -0,0 +1,130 @@
+package productunit
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/inventory"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// fillUnitsTx pasang unit in_stock paling lama (FIFO) ke item order sampai
+// quantity nya kepenuhi. item bundle dipecah ke komponen nya (sama kaya stok),
+// jadi device serial / IMEI di dalam bundle juga kebagian unit. produk yang ga
+// punya unit terdaftar (stok agregat doang) ga kena apa-apa, kalau unitnya kurang
+// dipasang seadanya, sisanya bisa dipasang manual sama admin lewat Assign.
+// unit nya harus varian yang sama dengan item / komponen nya
+func fillUnitsTx(ctx context.Context, tx pgx.Tx, item model.OrderItem, status model.ProductUnitStatus) error {
+	for _, line := range inventory.ItemLines(item.ProductID, item.VariantID, item.Quantity, item.BundleComponents) {
+		if err := fillLineUnitsTx(ctx, tx, item.ID, line, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fillLineUnitsTx(ctx context.Context, tx pgx.Tx, orderItemID uuid.UUID, line inventory.Line, status model.ProductUnitStatus) error {
+	assigned, err := assignedLineCountTx(ctx, tx, orderItemID, line)
+	if err != nil {
+		return err
+	}
+
+	need := line.Quantity - assigned
+	if need <= 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE product_units
+		SET status = $1,
+		    order_item_id = $2,
+		    reserved_at = NOW(),
+		    sold_at = CASE WHEN $1 = 'sold' THEN NOW() END,
+		    returned_at = NULL,
+		    updated_at = NOW()
+		WHERE id IN (
+			SELECT id
+			FROM product_units
+			WHERE product_id = $3 AND status = 'in_stock'
+			  AND variant_id IS NOT DISTINCT FROM $5
+			ORDER BY created_at ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+	`
+	if _, err := tx.Exec(ctx, query, status, orderItemID, line.ProductID, need, line.VariantID); err != nil {
+		return fmt.Errorf("failed to assign product units: %w", err)
+	}
+	return nil
+}
+
+// ReserveForOrderTx dipanggil pas order dibuat, unit ikut di reserve bareng stok nya
+func ReserveForOrderTx(ctx context.Context, tx pgx.Tx, items []model.OrderItem) error {
+	for _, item := range items {
+		if err := fillUnitsTx(ctx, tx, item, model.ProductUnitReserved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SellForOrderTx dipanggil pas order dikonfirmasi: unit yang di reserve jadi sold,
+// item yang unitnya belum lengkap (misal unitnya baru didaftarin) ditambahin dari stok
+func SellForOrderTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID) error {
+	rows, err := tx.Query(ctx, `
+		SELECT id, product_id, variant_id, quantity, bundle_components
+		FROM order_items
+		WHERE order_id = $1 AND item_type = 'product'
+	`, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	var items []model.OrderItem
+	for rows.Next() {
+		var item model.OrderItem
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.VariantID, &item.Quantity, &item.BundleComponents); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan order item: %w", err)
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	soldQuery := `
+		UPDATE product_units
+		SET status = 'sold', sold_at = NOW(), updated_at = NOW()
+		WHERE order_item_id = $1 AND status = 'reserved'
+	`
+	for _, item := range items {
+		if _, err := tx.Exec(ctx, soldQuery, item.ID); err != nil {
+			return fmt.Errorf("failed to sell product units: %w", err)
+		}
+		if err := fillUnitsTx(ctx, tx, item, model.ProductUnitSold); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseForOrderTx order batal, unit yang di reserve balik ke in_stock
+func ReleaseForOrderTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID) error {
+	query := `
+		UPDATE product_units
+		SET status = 'in_stock',
+		    order_item_id = NULL,
+		    reserved_at = NULL,
+		    updated_at = NOW()
+		WHERE status = 'reserved'
+		  AND order_item_id IN (SELECT id FROM order_items WHERE order_id = $1)
+	`
+	if _, err := tx.Exec(ctx, query, orderID); err != nil {
+		return fmt.Errorf("failed to release product units: %w", err)
+	}
+	return nil
+}
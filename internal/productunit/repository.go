@@ -0,0 +1,432 @@
+package productunit
+
+import (
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/inventory"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrUnitNotFound = errors.New("unit produk tidak ditemukan")
+var ErrUnitProductNotFound = errors.New("produk tidak ditemukan")
+var ErrUnitSerialTaken = errors.New("serial number sudah terdaftar")
+var ErrUnitIMEITaken = errors.New("IMEI sudah terdaftar")
+var ErrUnitInvalidState = errors.New("status unit tidak bisa diubah ke status ini")
+var ErrUnitInUse = errors.New("unit sudah pernah dipakai di order, tidak bisa dihapus")
+var ErrUnitAssignInvalid = errors.New("unit tidak bisa dipasang ke item order ini")
+var ErrUnitVariantInvalid = errors.New("varian unit tidak valid, produk bervarian wajib milih salah satu varian nya")
+
+const unitColumns = `
+	id, product_id, variant_id, serial_number, imei, condition_note, status, order_item_id,
+	reserved_at, sold_at, returned_at, created_at, updated_at
+`
+
+type scannable interface {
+	Scan(dest ...any) error
+}
+
+func scanUnit(row scannable) (model.ProductUnit, error) {
+	var u model.ProductUnit
+	err := row.Scan(
+		&u.ID,
+		&u.ProductID,
+		&u.VariantID,
+		&u.SerialNumber,
+		&u.IMEI,
+		&u.ConditionNote,
+		&u.Status,
+		&u.OrderItemID,
+		&u.ReservedAt,
+		&u.SoldAt,
+		&u.ReturnedAt,
+		&u.CreatedAt,
+		&u.UpdatedAt,
+	)
+	return u, err
+}
+
+type ProductUnitRepositoryInterface interface {
+	CreateBulk(ctx context.Context, productID uuid.UUID, variantID *uuid.UUID, units []model.ProductUnit) ([]model.ProductUnit, error)
+	GetByID(ctx context.Context, id uuid.UUID) (model.ProductUnit, error)
+	GetByProductID(ctx context.Context, productID uuid.UUID, status *model.ProductUnitStatus) ([]model.ProductUnit, error)
+	Update(ctx context.Context, id uuid.UUID, conditionNote *string, status *model.ProductUnitStatus) (model.ProductUnit, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Assign(ctx context.Context, unitID uuid.UUID, orderItemID uuid.UUID) (model.ProductUnit, error)
+	Unassign(ctx context.Context, unitID uuid.UUID) (model.ProductUnit, error)
+	Lookup(ctx context.Context, code string) (dto.UnitSaleLookup, error)
+}
+
+type ProductUnitRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewProductUnitRepository(pool *pgxpool.Pool) *ProductUnitRepositoryImpl {
+	return &ProductUnitRepositoryImpl{
+		db: pool,
+	}
+}
+
+// CreateBulk unit produk bervarian wajib nyantumin varian nya, biar pas order
+// unit yang dipasang sesuai varian yang dibeli
+func (r *ProductUnitRepositoryImpl) CreateBulk(ctx context.Context, productID uuid.UUID, variantID *uuid.UUID, units []model.ProductUnit) ([]model.ProductUnit, error) {
+
+	query := `
+		INSERT INTO product_units (product_id, variant_id, serial_number, imei, condition_note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + unitColumns
+
+	created := make([]model.ProductUnit, 0, len(units))
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var hasVariants, variantValid bool
+		checkQuery := `
+			SELECT EXISTS(SELECT 1 FROM product_variants WHERE product_id = $1),
+			       EXISTS(SELECT 1 FROM product_variants WHERE product_id = $1 AND id = $2)
+		`
+		if err := tx.QueryRow(ctx, checkQuery, productID, variantID).Scan(&hasVariants, &variantValid); err != nil {
+			return err
+		}
+		if hasVariants != (variantID != nil) || (variantID != nil && !variantValid) {
+			return ErrUnitVariantInvalid
+		}
+
+		for _, u := range units {
+			unit, err := scanUnit(tx.QueryRow(ctx, query, productID, variantID, u.SerialNumber, u.IMEI, u.ConditionNote))
+			if err != nil {
+				return err
+			}
+			created = append(created, unit)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok {
+			switch {
+			case pgErr.Code == "23503":
+				return nil, ErrUnitProductNotFound
+			case pgErr.Code == "23505" && pgErr.ConstraintName == "product_units_imei_key":
+				return nil, ErrUnitIMEITaken
+			case pgErr.Code == "23505":
+				return nil, ErrUnitSerialTaken
+			}
+		}
+		return nil, fmt.Errorf("create product units failed: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *ProductUnitRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (model.ProductUnit, error) {
+	query := `SELECT ` + unitColumns + ` FROM product_units WHERE id = $1`
+
+	unit, err := scanUnit(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ProductUnit{}, ErrUnitNotFound
+		}
+		return model.ProductUnit{}, err
+	}
+	return unit, nil
+}
+
+func (r *ProductUnitRepositoryImpl) GetByProductID(ctx context.Context, productID uuid.UUID, status *model.ProductUnitStatus) ([]model.ProductUnit, error) {
+	query := `
+		SELECT ` + unitColumns + `
+		FROM product_units
+		WHERE product_id = $1 AND ($2::text IS NULL OR status = $2)
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, productID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []model.ProductUnit{}
+	for rows.Next() {
+		unit, err := scanUnit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product unit: %w", err)
+		}
+		units = append(units, unit)
+	}
+	return units, rows.Err()
+}
+
+// Update ganti catatan kondisi dan/atau status unit secara manual,
+// perpindahan status nya dicek ke model.ProductUnitManualTransitions
+func (r *ProductUnitRepositoryImpl) Update(ctx context.Context, id uuid.UUID, conditionNote *string, status *model.ProductUnitStatus) (model.ProductUnit, error) {
+
+	var unit model.ProductUnit
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		current, err := scanUnit(tx.QueryRow(ctx, `SELECT `+unitColumns+` FROM product_units WHERE id = $1 FOR UPDATE`, id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUnitNotFound
+			}
+			return err
+		}
+
+		if status != nil && *status != current.Status && !current.Status.CanManuallyMoveTo(*status) {
+			return ErrUnitInvalidState
+		}
+
+		query := `
+			UPDATE product_units
+			SET condition_note = COALESCE($1, condition_note),
+			    status = COALESCE($2, status),
+			    returned_at = CASE WHEN $2 = 'returned' AND status <> 'returned' THEN NOW() ELSE returned_at END,
+			    updated_at = NOW()
+			WHERE id = $3
+			RETURNING ` + unitColumns
+
+		unit, err = scanUnit(tx.QueryRow(ctx, query, conditionNote, status, id))
+		return err
+	})
+	if err != nil {
+		return model.ProductUnit{}, err
+	}
+	return unit, nil
+}
+
+// Delete cuma buat unit yang salah input, unit yang pernah nempel ke order ga boleh dihapus
+func (r *ProductUnitRepositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `
+		DELETE FROM product_units
+		WHERE id = $1 AND status = 'in_stock' AND order_item_id IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return ErrUnitInUse
+	}
+	return nil
+}
+
+// Assign admin milih unit spesifik buat item order (misal customer minta warna / kondisi tertentu).
+// unitnya harus in_stock, produknya sama, dan slot item nya (quantity) masih ada yang kosong.
+// order yang udah dikonfirmasi langsung jadi sold, yang masih nunggu bayar jadi reserved
+func (r *ProductUnitRepositoryImpl) Assign(ctx context.Context, unitID uuid.UUID, orderItemID uuid.UUID) (model.ProductUnit, error) {
+
+	var unit model.ProductUnit
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var item model.OrderItem
+		var orderStatus model.OrderStatus
+		itemQuery := `
+			SELECT oi.product_id, oi.variant_id, oi.quantity, oi.bundle_components, o.status
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE oi.id = $1 AND oi.item_type = 'product'
+			FOR UPDATE OF o, oi
+		`
+		err := tx.QueryRow(ctx, itemQuery, orderItemID).Scan(&item.ProductID, &item.VariantID, &item.Quantity, &item.BundleComponents, &orderStatus)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUnitAssignInvalid
+			}
+			return err
+		}
+
+		status, ok := unitStatusForOrder(orderStatus)
+		if !ok {
+			return ErrUnitAssignInvalid
+		}
+
+		current, err := scanUnit(tx.QueryRow(ctx, `SELECT `+unitColumns+` FROM product_units WHERE id = $1 FOR UPDATE`, unitID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUnitNotFound
+			}
+			return err
+		}
+		if current.Status != model.ProductUnitInStock {
+			return ErrUnitAssignInvalid
+		}
+
+		// item bundle: unit nya harus salah satu komponen bundle nya
+		lines := inventory.ItemLines(item.ProductID, item.VariantID, item.Quantity, item.BundleComponents)
+		idx := slices.IndexFunc(lines, func(l inventory.Line) bool {
+			return l.ProductID == current.ProductID && sameVariant(l.VariantID, current.VariantID)
+		})
+		if idx < 0 {
+			return ErrUnitAssignInvalid
+		}
+
+		assigned, err := assignedLineCountTx(ctx, tx, orderItemID, lines[idx])
+		if err != nil {
+			return err
+		}
+		if assigned >= lines[idx].Quantity {
+			return ErrUnitAssignInvalid
+		}
+
+		query := `
+			UPDATE product_units
+			SET status = $1,
+			    order_item_id = $2,
+			    reserved_at = NOW(),
+			    sold_at = CASE WHEN $1 = 'sold' THEN NOW() END,
+			    returned_at = NULL,
+			    updated_at = NOW()
+			WHERE id = $3
+			RETURNING ` + unitColumns
+
+		unit, err = scanUnit(tx.QueryRow(ctx, query, status, orderItemID, unitID))
+		return err
+	})
+	if err != nil {
+		return model.ProductUnit{}, err
+	}
+	return unit, nil
+}
+
+// Unassign lepas unit dari item order yang barangnya belum dikirim, balik ke in_stock
+func (r *ProductUnitRepositoryImpl) Unassign(ctx context.Context, unitID uuid.UUID) (model.ProductUnit, error) {
+
+	var unit model.ProductUnit
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var status model.ProductUnitStatus
+		var orderStatus *model.OrderStatus
+		lockQuery := `
+			SELECT pu.status, o.status
+			FROM product_units pu
+			LEFT JOIN order_items oi ON oi.id = pu.order_item_id
+			LEFT JOIN orders o ON o.id = oi.order_id
+			WHERE pu.id = $1
+			FOR UPDATE OF pu
+		`
+		err := tx.QueryRow(ctx, lockQuery, unitID).Scan(&status, &orderStatus)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUnitNotFound
+			}
+			return err
+		}
+
+		if status != model.ProductUnitReserved && status != model.ProductUnitSold {
+			return ErrUnitInvalidState
+		}
+		if orderStatus != nil {
+			if _, ok := unitStatusForOrder(*orderStatus); !ok {
+				return ErrUnitInvalidState
+			}
+		}
+
+		query := `
+			UPDATE product_units
+			SET status = 'in_stock',
+			    order_item_id = NULL,
+			    reserved_at = NULL,
+			    sold_at = NULL,
+			    updated_at = NOW()
+			WHERE id = $1
+			RETURNING ` + unitColumns
+
+		unit, err = scanUnit(tx.QueryRow(ctx, query, unitID))
+		return err
+	})
+	if err != nil {
+		return model.ProductUnit{}, err
+	}
+	return unit, nil
+}
+
+// Lookup cari unit dari serial number atau IMEI, sekalian order & customer terakhir nya
+func (r *ProductUnitRepositoryImpl) Lookup(ctx context.Context, code string) (dto.UnitSaleLookup, error) {
+	query := `
+		SELECT pu.id, pu.product_id, pu.variant_id, pu.serial_number, pu.imei, pu.condition_note, pu.status,
+		       pu.order_item_id, pu.reserved_at, pu.sold_at, pu.returned_at, pu.created_at, pu.updated_at,
+		       p.name, o.id, o.status, o.created_at,
+		       u.id, u.full_name, u.email, u.phone_number
+		FROM product_units pu
+		JOIN products p ON p.id = pu.product_id
+		LEFT JOIN order_items oi ON oi.id = pu.order_item_id
+		LEFT JOIN orders o ON o.id = oi.order_id
+		LEFT JOIN users u ON u.id = o.user_id
+		WHERE pu.serial_number = UPPER($1) OR pu.imei = $1
+		LIMIT 1
+	`
+
+	var res dto.UnitSaleLookup
+	u := &res.Unit
+	err := r.db.QueryRow(ctx, query, code).Scan(
+		&u.ID,
+		&u.ProductID,
+		&u.VariantID,
+		&u.SerialNumber,
+		&u.IMEI,
+		&u.ConditionNote,
+		&u.Status,
+		&u.OrderItemID,
+		&u.ReservedAt,
+		&u.SoldAt,
+		&u.ReturnedAt,
+		&u.CreatedAt,
+		&u.UpdatedAt,
+		&res.ProductName,
+		&res.OrderID,
+		&res.OrderStatus,
+		&res.OrderedAt,
+		&res.CustomerID,
+		&res.CustomerName,
+		&res.CustomerEmail,
+		&res.CustomerPhone,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dto.UnitSaleLookup{}, ErrUnitNotFound
+		}
+		return dto.UnitSaleLookup{}, err
+	}
+	res.OrderItemID = u.OrderItemID
+	return res, nil
+}
+
+// unitStatusForOrder status unit yang pas buat order di status ini.
+// order yang udah dikirim / batal ga bisa diutak-atik unitnya lagi
+func unitStatusForOrder(status model.OrderStatus) (model.ProductUnitStatus, bool) {
+	switch status {
+	case model.OrderStatusPending, model.OrderStatusWaitingConfirmation:
+		return model.ProductUnitReserved, true
+	case model.OrderStatusConfirmed, model.OrderStatusPacked:
+		return model.ProductUnitSold, true
+	}
+	return "", false
+}
+
+// assignedLineCountTx jumlah unit yang udah kepasang ke item order buat satu
+// produk / varian (item biasa cuma satu line, item bundle satu line per komponen)
+func assignedLineCountTx(ctx context.Context, tx pgx.Tx, orderItemID uuid.UUID, line inventory.Line) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*)
+		FROM product_units
+		WHERE order_item_id = $1 AND product_id = $2
+		  AND variant_id IS NOT DISTINCT FROM $3
+		  AND status IN ('reserved', 'sold')
+	`
+	err := tx.QueryRow(ctx, query, orderItemID, line.ProductID, line.VariantID).Scan(&count)
+	return count, err
+}
+
+func sameVariant(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
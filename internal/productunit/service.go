@@ -0,0 +1,155 @@
+package productunit
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type ProductUnitService struct {
+	unitRepo ProductUnitRepositoryInterface
+}
+
+func NewProductUnitService(repo *ProductUnitRepositoryImpl) *ProductUnitService {
+	return &ProductUnitService{
+		unitRepo: repo,
+	}
+}
+
+func (s *ProductUnitService) Create(ctx context.Context, req dto.AddProductUnitsRequest) ([]model.ProductUnit, *common.ErrorResponse) {
+
+	productId, err := uuid.Parse(req.ProductId)
+	if err != nil {
+		return []model.ProductUnit{}, common.NewErrorResponse(400, "id produk tidak valid!")
+	}
+
+	var variantId *uuid.UUID
+	if req.VariantId != nil {
+		parsed, err := uuid.Parse(*req.VariantId)
+		if err != nil {
+			return []model.ProductUnit{}, common.NewErrorResponse(400, "id varian tidak valid!")
+		}
+		variantId = &parsed
+	}
+
+	// serial disimpen uppercase biar pencarian ga kejebak beda huruf besar kecil
+	units := make([]model.ProductUnit, len(req.Units))
+	seen := map[string]bool{}
+	for i, u := range req.Units {
+		serial := strings.ToUpper(strings.TrimSpace(u.SerialNumber))
+		if serial == "" {
+			return []model.ProductUnit{}, common.NewErrorResponse(400, "serial number tidak boleh kosong!")
+		}
+		if seen[serial] {
+			return []model.ProductUnit{}, common.NewErrorResponse(400, "serial number "+serial+" dikirim lebih dari sekali!")
+		}
+		seen[serial] = true
+
+		units[i] = model.ProductUnit{
+			SerialNumber:  serial,
+			IMEI:          u.IMEI,
+			ConditionNote: u.ConditionNote,
+		}
+	}
+
+	data, err := s.unitRepo.CreateBulk(ctx, productId, variantId, units)
+	if err != nil {
+		return []model.ProductUnit{}, productUnitError(err)
+	}
+	return data, nil
+}
+
+func (s *ProductUnitService) GetById(ctx context.Context, id uuid.UUID) (model.ProductUnit, *common.ErrorResponse) {
+	data, err := s.unitRepo.GetByID(ctx, id)
+	if err != nil {
+		return model.ProductUnit{}, productUnitError(err)
+	}
+	return data, nil
+}
+
+func (s *ProductUnitService) GetByProduct(ctx context.Context, productId uuid.UUID, status *model.ProductUnitStatus) ([]model.ProductUnit, *common.ErrorResponse) {
+	data, err := s.unitRepo.GetByProductID(ctx, productId, status)
+	if err != nil {
+		return []model.ProductUnit{}, productUnitError(err)
+	}
+	return data, nil
+}
+
+func (s *ProductUnitService) Update(ctx context.Context, id uuid.UUID, req dto.UpdateProductUnitRequest) (model.ProductUnit, *common.ErrorResponse) {
+
+	var status *model.ProductUnitStatus
+	if req.Status != nil {
+		st := model.ProductUnitStatus(*req.Status)
+		status = &st
+	}
+
+	data, err := s.unitRepo.Update(ctx, id, req.ConditionNote, status)
+	if err != nil {
+		return model.ProductUnit{}, productUnitError(err)
+	}
+	return data, nil
+}
+
+func (s *ProductUnitService) Delete(ctx context.Context, id uuid.UUID) *common.ErrorResponse {
+	if err := s.unitRepo.Delete(ctx, id); err != nil {
+		return productUnitError(err)
+	}
+	return nil
+}
+
+func (s *ProductUnitService) Assign(ctx context.Context, req dto.AssignProductUnitRequest) (model.ProductUnit, *common.ErrorResponse) {
+
+	unitId, err := uuid.Parse(req.UnitId)
+	if err != nil {
+		return model.ProductUnit{}, common.NewErrorResponse(400, "id unit tidak valid!")
+	}
+	orderItemId, err := uuid.Parse(req.OrderItemId)
+	if err != nil {
+		return model.ProductUnit{}, common.NewErrorResponse(400, "id item order tidak valid!")
+	}
+
+	data, err := s.unitRepo.Assign(ctx, unitId, orderItemId)
+	if err != nil {
+		return model.ProductUnit{}, productUnitError(err)
+	}
+	return data, nil
+}
+
+func (s *ProductUnitService) Unassign(ctx context.Context, id uuid.UUID) (model.ProductUnit, *common.ErrorResponse) {
+	data, err := s.unitRepo.Unassign(ctx, id)
+	if err != nil {
+		return model.ProductUnit{}, productUnitError(err)
+	}
+	return data, nil
+}
+
+func (s *ProductUnitService) Lookup(ctx context.Context, code string) (dto.UnitSaleLookup, *common.ErrorResponse) {
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return dto.UnitSaleLookup{}, common.NewErrorResponse(400, "serial number / IMEI wajib diisi!")
+	}
+
+	data, err := s.unitRepo.Lookup(ctx, code)
+	if err != nil {
+		return dto.UnitSaleLookup{}, productUnitError(err)
+	}
+	return data, nil
+}
+
+func productUnitError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, ErrUnitNotFound), errors.Is(err, ErrUnitProductNotFound):
+		return common.NewErrorResponse(404, err.Error())
+	case errors.Is(err, ErrUnitSerialTaken), errors.Is(err, ErrUnitIMEITaken), errors.Is(err, ErrUnitInUse):
+		return common.NewErrorResponse(409, err.Error())
+	case errors.Is(err, ErrUnitInvalidState), errors.Is(err, ErrUnitAssignInvalid), errors.Is(err, ErrUnitVariantInvalid):
+		return common.NewErrorResponse(422, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di database "+err.Error())
+}
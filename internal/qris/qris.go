@@ -0,0 +1,235 @@
+// Package qris encode / decode payload QRIS (EMVCo Merchant Presented Mode).
+// payload nya TLV: 2 digit ID, 2 digit panjang, terus isinya, ditutup
+// tag 63 isinya CRC16-CCITT dari seluruh string termasuk "6304".
+package qris
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	TagPayloadFormat      = "00"
+	TagPointOfInitiation  = "01"
+	TagMerchantCategory   = "52"
+	TagCurrency           = "53"
+	TagAmount             = "54"
+	TagTipIndicator       = "55"
+	TagTipFixed           = "56"
+	TagTipPercentage      = "57"
+	TagCountry            = "58"
+	TagMerchantName       = "59"
+	TagMerchantCity       = "60"
+	TagPostalCode         = "61"
+	TagAdditionalData     = "62"
+	TagCRC                = "63"
+	initiationStatic      = "11"
+	initiationDynamic     = "12"
+	currencyRupiah        = "360"
+	countryIndonesia      = "ID"
+	merchantAccountMinTag = 26
+	merchantAccountMaxTag = 51
+)
+
+var ErrInvalidPayload = errors.New("payload QRIS tidak valid")
+var ErrInvalidCRC = errors.New("checksum CRC payload QRIS tidak cocok")
+var ErrInvalidAmount = errors.New("nominal QRIS harus lebih dari 0")
+
+// Field satu data object TLV
+type Field struct {
+	ID    string
+	Value string
+}
+
+// Payload hasil parse QRIS, urutan field dijaga sesuai aslinya
+type Payload struct {
+	Fields []Field
+}
+
+// Parse baca payload QRIS terus validasi struktur & CRC nya
+func Parse(raw string) (Payload, error) {
+	raw = strings.TrimSpace(raw)
+
+	fields, err := decodeTLV(raw)
+	if err != nil {
+		return Payload{}, err
+	}
+
+	p := Payload{Fields: fields}
+	if err := p.validate(raw); err != nil {
+		return Payload{}, err
+	}
+	return p, nil
+}
+
+// Get ngambil isi tag, string kosong kalau ga ada
+func (p Payload) Get(id string) string {
+	for _, f := range p.Fields {
+		if f.ID == id {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// IsDynamic true kalau payload nya QRIS dinamis (sekali pakai, nominal ditentuin)
+func (p Payload) IsDynamic() bool {
+	return p.Get(TagPointOfInitiation) == initiationDynamic
+}
+
+// WithAmount bikin QRIS dinamis dari QRIS statis merchant dengan nominal tertentu.
+// tag tip dibuang karena nominal nya udah pasti.
+func (p Payload) WithAmount(amount int64) (string, error) {
+	if amount <= 0 {
+		return "", ErrInvalidAmount
+	}
+
+	fields := make([]Field, 0, len(p.Fields)+1)
+	for _, f := range p.Fields {
+		switch f.ID {
+		case TagCRC, TagAmount, TagTipIndicator, TagTipFixed, TagTipPercentage:
+			continue
+		case TagPointOfInitiation:
+			fields = append(fields, Field{ID: f.ID, Value: initiationDynamic})
+		default:
+			fields = append(fields, f)
+		}
+	}
+	fields = append(fields, Field{ID: TagAmount, Value: strconv.FormatInt(amount, 10)})
+
+	// payload format indicator wajib paling depan, sisanya urut ID
+	slices.SortStableFunc(fields, func(a, b Field) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return Encode(fields)
+}
+
+// Encode nyusun field jadi payload lengkap dengan CRC di akhir
+func Encode(fields []Field) (string, error) {
+	var sb strings.Builder
+	for _, f := range fields {
+		if f.ID == TagCRC {
+			continue
+		}
+		if err := writeTLV(&sb, f.ID, f.Value); err != nil {
+			return "", err
+		}
+	}
+
+	sb.WriteString(TagCRC + "04")
+	sb.WriteString(CRC16(sb.String()))
+	return sb.String(), nil
+}
+
+func writeTLV(sb *strings.Builder, id string, value string) error {
+	if len(id) != 2 || len(value) > 99 {
+		return fmt.Errorf("%w: tag %s terlalu panjang", ErrInvalidPayload, id)
+	}
+	sb.WriteString(id)
+	sb.WriteString(fmt.Sprintf("%02d", len(value)))
+	sb.WriteString(value)
+	return nil
+}
+
+func decodeTLV(raw string) ([]Field, error) {
+	var fields []Field
+	for i := 0; i < len(raw); {
+		if i+4 > len(raw) {
+			return nil, fmt.Errorf("%w: data terpotong di posisi %d", ErrInvalidPayload, i)
+		}
+		id := raw[i : i+2]
+		length, err := strconv.Atoi(raw[i+2 : i+4])
+		if err != nil || !isDigits(id) {
+			return nil, fmt.Errorf("%w: header tag di posisi %d", ErrInvalidPayload, i)
+		}
+		start := i + 4
+		if start+length > len(raw) {
+			return nil, fmt.Errorf("%w: panjang tag %s melebihi payload", ErrInvalidPayload, id)
+		}
+		fields = append(fields, Field{ID: id, Value: raw[start : start+length]})
+		i = start + length
+	}
+	return fields, nil
+}
+
+func (p Payload) validate(raw string) error {
+	if len(p.Fields) < 2 || p.Fields[0].ID != TagPayloadFormat || p.Fields[0].Value != "01" {
+		return fmt.Errorf("%w: tag 00 harus di awal dengan nilai 01", ErrInvalidPayload)
+	}
+
+	last := p.Fields[len(p.Fields)-1]
+	if last.ID != TagCRC || len(last.Value) != 4 {
+		return fmt.Errorf("%w: tag 63 (CRC) harus di akhir", ErrInvalidPayload)
+	}
+	if !strings.EqualFold(CRC16(raw[:len(raw)-4]), last.Value) {
+		return ErrInvalidCRC
+	}
+
+	if init := p.Get(TagPointOfInitiation); init != "" && init != initiationStatic && init != initiationDynamic {
+		return fmt.Errorf("%w: tag 01 harus 11 atau 12", ErrInvalidPayload)
+	}
+
+	for _, id := range []string{TagMerchantCategory, TagCurrency, TagCountry, TagMerchantName, TagMerchantCity} {
+		if p.Get(id) == "" {
+			return fmt.Errorf("%w: tag %s wajib ada", ErrInvalidPayload, id)
+		}
+	}
+	if p.Get(TagCurrency) != currencyRupiah {
+		return fmt.Errorf("%w: mata uang harus 360 (IDR)", ErrInvalidPayload)
+	}
+	if p.Get(TagCountry) != countryIndonesia {
+		return fmt.Errorf("%w: kode negara harus ID", ErrInvalidPayload)
+	}
+
+	hasAccount := false
+	for _, f := range p.Fields {
+		n, _ := strconv.Atoi(f.ID)
+		if n < merchantAccountMinTag || n > merchantAccountMaxTag {
+			continue
+		}
+		if _, err := decodeTLV(f.Value); err != nil {
+			return fmt.Errorf("%w: merchant account tag %s", ErrInvalidPayload, f.ID)
+		}
+		hasAccount = true
+	}
+	if !hasAccount {
+		return fmt.Errorf("%w: merchant account (tag 26-51) tidak ditemukan", ErrInvalidPayload)
+	}
+
+	if additional := p.Get(TagAdditionalData); additional != "" {
+		if _, err := decodeTLV(additional); err != nil {
+			return fmt.Errorf("%w: additional data tag 62", ErrInvalidPayload)
+		}
+	}
+
+	return nil
+}
+
+// CRC16 CRC16-CCITT (poly 0x1021, init 0xFFFF), hasilnya 4 digit hex kapital
+func CRC16(data string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,231 @@
+package refund
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/middleware"
+	"backEnd-RingoTechLife/pkg"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	"github.com/go-playground/form/v4"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const maxRefundFormSize = 5 << 20
+
+type RefundHandler struct {
+	refundService *RefundService
+	decoder       *form.Decoder
+	validator     *validator.Validate
+}
+
+func NewRefundHandler(svc *RefundService, dec *form.Decoder, vld *validator.Validate) *RefundHandler {
+	return &RefundHandler{
+		refundService: svc,
+		decoder:       dec,
+		validator:     vld,
+	}
+}
+
+func (rh *RefundHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.CreateRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data refund dengan benar!")
+		return
+	}
+
+	if err := rh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, addErr := rh.refundService.Create(r.Context(), req, userId)
+	if addErr != nil {
+		pkg.JSONError(w, addErr.Code, addErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengajukan refund", data)
+}
+
+func (rh *RefundHandler) GetMyRefundsHandler(w http.ResponseWriter, r *http.Request) {
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, err := rh.refundService.GetByUserId(r.Context(), userId)
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (rh *RefundHandler) GetByIdHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+	role, _ := middleware.GetRole(r.Context())
+
+	data, getErr := rh.refundService.GetById(r.Context(), id, userId, role)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (rh *RefundHandler) GetAllHandler(w http.ResponseWriter, r *http.Request) {
+
+	data, err := rh.refundService.GetAll(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (rh *RefundHandler) ApproveHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.ApproveRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data dengan benar!")
+		return
+	}
+
+	if err := rh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	if appErr := rh.refundService.Approve(r.Context(), id, adminId, req); appErr != nil {
+		pkg.JSONError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menyetujui refund", nil)
+}
+
+func (rh *RefundHandler) RejectHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.RejectRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data dengan benar!")
+		return
+	}
+
+	if err := rh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	if rejErr := rh.refundService.Reject(r.Context(), id, adminId, req.Note); rejErr != nil {
+		pkg.JSONError(w, rejErr.Code, rejErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menolak refund", nil)
+}
+
+func (rh *RefundHandler) CompleteHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxRefundFormSize); err != nil {
+		pkg.JSONError(w, 400, "gagal parse form data "+err.Error())
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var req dto.CompleteRefundRequest
+	if err := rh.decoder.Decode(&req, r.MultipartForm.Value); err != nil {
+		pkg.JSONError(w, 400, "form data tidak valid")
+		return
+	}
+
+	if err := rh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	files := r.MultipartForm.File["proof_image"]
+	if len(files) == 0 {
+		pkg.JSONError(w, 400, "bukti transfer refund tidak ditemukan! harap isi data dengan benar!")
+		return
+	}
+	req.ProofImage = files[0]
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	if compErr := rh.refundService.Complete(r.Context(), id, adminId, req); compErr != nil {
+		pkg.JSONError(w, compErr.Code, compErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "refund selesai diproses", nil)
+}
+
+func (rh *RefundHandler) SetUpRoute(router chi.Router) {
+
+	router.Route("/refunds", func(r chi.Router) {
+		r.Use(httprate.Limit(
+			30,
+			time.Minute,
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				errorRes := common.NewErrorResponse(http.StatusTooManyRequests, "Terlalu banyak request, coba lagi nanti")
+				errorResJson, _ := json.Marshal(errorRes)
+				w.Write(errorResJson)
+			}),
+		))
+		r.Use(middleware.AuthMiddleware)
+
+		r.Post("/add", rh.CreateHandler)
+		r.Get("/my", rh.GetMyRefundsHandler)
+		r.Get("/id/{id}", rh.GetByIdHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware(middleware.RoleAdmin))
+			r.Get("/get-all", rh.GetAllHandler)
+			r.Put("/approve/{id}", rh.ApproveHandler)
+			r.Put("/reject/{id}", rh.RejectHandler)
+			r.Put("/complete/{id}", rh.CompleteHandler)
+		})
+	})
+}
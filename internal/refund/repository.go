@@ -0,0 +1,624 @@
+package refund
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/inventory"
+	"backEnd-RingoTechLife/internal/order"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrRefundNotFound = errors.New("data refund tidak ditemukan")
+var ErrRefundNotAllowed = errors.New("order ini belum dibayar lunas, refund tidak bisa diajukan")
+var ErrRefundExceedsPayment = errors.New("total refund melebihi nominal pembayaran yang sudah di approve")
+var ErrRefundItemInvalid = errors.New("item refund tidak valid atau jumlahnya melebihi sisa barang yang bisa direfund")
+var ErrRefundInvalidState = errors.New("status refund tidak bisa diubah ke status ini")
+
+// RefundLine permintaan refund per order item
+type RefundLine struct {
+	OrderItemID uuid.UUID
+	Quantity    int
+}
+
+type RefundRepositoryInterface interface {
+	Create(ctx context.Context, refund *model.Refund, lines []RefundLine) (*model.Refund, error)
+	GetByID(ctx context.Context, id uuid.UUID) (model.Refund, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.Refund, error)
+	GetAll(ctx context.Context, status *model.RefundStatus) ([]model.Refund, error)
+	Approve(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note *string, restockItemIDs []uuid.UUID) error
+	Reject(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note string) error
+	Complete(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note *string, proofImage string) error
+}
+
+type RefundRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRefundRepository(pool *pgxpool.Pool) *RefundRepositoryImpl {
+	return &RefundRepositoryImpl{
+		db: pool,
+	}
+}
+
+// refund yang masih "makan" jatah dana pembayaran
+var activeRefundStatuses = []string{
+	string(model.RefundStatusRequested),
+	string(model.RefundStatusApproved),
+	string(model.RefundStatusCompleted),
+}
+
+type refundableItem struct {
+	ID          uuid.UUID
+	ProductID   uuid.UUID
+	ProductName string
+	Price       model.Money
+	Ordered     int
+	Remaining   int
+}
+
+// Create ngajuin refund. payment dikunci biar dua pengajuan barengan ga bisa
+// ngelewatin nominal pembayaran. refund penuh (lines kosong) ngambil semua sisa
+// dana & sisa barang, refund sebagian nominalnya harga x jumlah per item
+// dikurangi bagian diskon voucher item itu.
+func (r *RefundRepositoryImpl) Create(ctx context.Context, refund *model.Refund, lines []RefundLine) (*model.Refund, error) {
+
+	refund.Status = model.RefundStatusRequested
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return CreateTx(ctx, tx, refund, lines)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// CreateTx versi Create di dalam transaksi yang udah jalan, dipake modul lain
+// (misal retur) yang bikin refund sekalian sama perubahan datanya sendiri.
+// refund.Status boleh diisi dari luar (misal langsung approved), default requested.
+func CreateTx(ctx context.Context, tx pgx.Tx, refund *model.Refund, lines []RefundLine) error {
+
+	paymentQuery := `
+		SELECT p.id, p.status, p.amount, o.user_id, o.discount_amount
+		FROM payments p
+		JOIN orders o ON o.id = p.order_id
+		WHERE p.order_id = $1
+		FOR UPDATE OF p
+	`
+	var paymentStatus model.PaymentStatus
+	var paymentAmount model.Money
+	var ownerID uuid.UUID
+	var discount model.Money
+	err := tx.QueryRow(ctx, paymentQuery, refund.OrderID).Scan(&refund.PaymentID, &paymentStatus, &paymentAmount, &ownerID, &discount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return order.ErrNoOrderFound
+		}
+		return err
+	}
+
+	if ownerID != refund.UserID {
+		return order.ErrNoOrderFound
+	}
+
+	if paymentStatus != model.PaymentStatusApproved && paymentStatus != model.PaymentStatusPartiallyRefunded {
+		return ErrRefundNotAllowed
+	}
+
+	var reserved model.Money
+	reservedQuery := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM refunds
+		WHERE payment_id = $1 AND status = ANY($2)
+	`
+	if err := tx.QueryRow(ctx, reservedQuery, refund.PaymentID, activeRefundStatuses).Scan(&reserved); err != nil {
+		return fmt.Errorf("failed to sum refunds: %w", err)
+	}
+
+	remaining := paymentAmount - reserved
+	if remaining <= 0 {
+		return ErrRefundExceedsPayment
+	}
+
+	items, err := refundableItemsTx(ctx, tx, refund.OrderID)
+	if err != nil {
+		return err
+	}
+
+	refund.Items, err = buildRefundItems(items, lines, discount)
+	if err != nil {
+		return err
+	}
+
+	refund.IsFull = len(lines) == 0
+	if refund.IsFull {
+		refund.Amount = remaining
+	} else {
+		refund.Amount = 0
+		for _, item := range refund.Items {
+			refund.Amount += item.Amount
+		}
+		if refund.Amount > remaining {
+			return ErrRefundExceedsPayment
+		}
+	}
+
+	if refund.Status == "" {
+		refund.Status = model.RefundStatusRequested
+	}
+	refundQuery := `
+		INSERT INTO refunds (order_id, payment_id, user_id, status, is_full, amount, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, refundQuery,
+		refund.OrderID,
+		refund.PaymentID,
+		refund.UserID,
+		refund.Status,
+		refund.IsFull,
+		refund.Amount,
+		refund.Reason,
+	).Scan(&refund.ID, &refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert refund: %w", err)
+	}
+
+	itemQuery := `
+		INSERT INTO refund_items (refund_id, order_item_id, product_id, product_name, quantity, amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	for i := range refund.Items {
+		refund.Items[i].RefundID = refund.ID
+		err := tx.QueryRow(ctx, itemQuery,
+			refund.ID,
+			refund.Items[i].OrderItemID,
+			refund.Items[i].ProductID,
+			refund.Items[i].ProductName,
+			refund.Items[i].Quantity,
+			refund.Items[i].Amount,
+		).Scan(&refund.Items[i].ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert refund item: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// refundableItemsTx sisa jumlah tiap order item yang belum kena refund aktif
+func refundableItemsTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID) ([]refundableItem, error) {
+	query := `
+		SELECT oi.id, oi.product_id, oi.product_name, oi.price_at_purchase, oi.quantity,
+		       oi.quantity - COALESCE(SUM(ri.quantity), 0) AS remaining
+		FROM order_items oi
+		LEFT JOIN (
+			refund_items ri
+			JOIN refunds r ON r.id = ri.refund_id AND r.status = ANY($2)
+		) ON ri.order_item_id = oi.id
+		WHERE oi.order_id = $1
+		GROUP BY oi.id
+		ORDER BY oi.created_at
+	`
+	rows, err := tx.Query(ctx, query, orderID, activeRefundStatuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []refundableItem
+	for rows.Next() {
+		var item refundableItem
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.Price, &item.Ordered, &item.Remaining); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// paidAmount nominal yang beneran dibayar buat qty barang: harga x qty dikurangi
+// bagian diskon voucher order nya. diskon dibagi proporsional ke nilai semua item,
+// dibulatkan ke atas biar total refund nya ga pernah lebih dari yang dibayar
+func paidAmount(price model.Money, qty int, discount model.Money, itemsTotal model.Money) model.Money {
+	gross := price.Mul(qty)
+	if discount <= 0 || itemsTotal <= 0 {
+		return gross
+	}
+	share := (discount*gross + itemsTotal - 1) / itemsTotal
+	return max(gross-share, 0)
+}
+
+func buildRefundItems(items []refundableItem, lines []RefundLine, discount model.Money) ([]model.RefundItem, error) {
+
+	result := []model.RefundItem{}
+
+	var itemsTotal model.Money
+	for _, item := range items {
+		itemsTotal += item.Price.Mul(item.Ordered)
+	}
+
+	// refund penuh: semua barang yang masih tersisa
+	if len(lines) == 0 {
+		for _, item := range items {
+			if item.Remaining <= 0 {
+				continue
+			}
+			result = append(result, model.RefundItem{
+				OrderItemID: item.ID,
+				ProductID:   item.ProductID,
+				ProductName: item.ProductName,
+				Quantity:    item.Remaining,
+				Amount:      paidAmount(item.Price, item.Remaining, discount, itemsTotal),
+			})
+		}
+		return result, nil
+	}
+
+	byID := make(map[uuid.UUID]refundableItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, line := range lines {
+		item, ok := byID[line.OrderItemID]
+		if !ok || seen[line.OrderItemID] || line.Quantity <= 0 || line.Quantity > item.Remaining {
+			return nil, ErrRefundItemInvalid
+		}
+		seen[line.OrderItemID] = true
+
+		result = append(result, model.RefundItem{
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    line.Quantity,
+			Amount:      paidAmount(item.Price, line.Quantity, discount, itemsTotal),
+		})
+	}
+	return result, nil
+}
+
+func (r *RefundRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (model.Refund, error) {
+	query := `SELECT ` + refundColumns + ` FROM refunds WHERE id = $1`
+
+	refund, err := scanRefund(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Refund{}, ErrRefundNotFound
+		}
+		return model.Refund{}, err
+	}
+
+	refunds := []model.Refund{refund}
+	if err := r.attachItems(ctx, refunds); err != nil {
+		return model.Refund{}, err
+	}
+	return refunds[0], nil
+}
+
+func (r *RefundRepositoryImpl) GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.Refund, error) {
+	query := `SELECT ` + refundColumns + ` FROM refunds WHERE user_id = $1 ORDER BY created_at DESC`
+	return r.queryRefunds(ctx, query, userID)
+}
+
+func (r *RefundRepositoryImpl) GetAll(ctx context.Context, status *model.RefundStatus) ([]model.Refund, error) {
+	query := `
+		SELECT ` + refundColumns + `
+		FROM refunds
+		WHERE $1::text IS NULL OR status = $1
+		ORDER BY created_at ASC
+	`
+	return r.queryRefunds(ctx, query, status)
+}
+
+func (r *RefundRepositoryImpl) queryRefunds(ctx context.Context, query string, args ...any) ([]model.Refund, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []model.Refund{}
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan refund: %w", err)
+		}
+		refunds = append(refunds, refund)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachItems(ctx, refunds); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *RefundRepositoryImpl) attachItems(ctx context.Context, refunds []model.Refund) error {
+	if len(refunds) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(refunds))
+	index := make(map[uuid.UUID]int, len(refunds))
+	for i := range refunds {
+		ids[i] = refunds[i].ID
+		index[refunds[i].ID] = i
+		refunds[i].Items = []model.RefundItem{}
+	}
+
+	query := `
+		SELECT id, refund_id, order_item_id, product_id, product_name, quantity, amount, restock
+		FROM refund_items
+		WHERE refund_id = ANY($1)
+		ORDER BY product_name
+	`
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item model.RefundItem
+		err := rows.Scan(
+			&item.ID,
+			&item.RefundID,
+			&item.OrderItemID,
+			&item.ProductID,
+			&item.ProductName,
+			&item.Quantity,
+			&item.Amount,
+			&item.Restock,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan refund item: %w", err)
+		}
+		i := index[item.RefundID]
+		refunds[i].Items = append(refunds[i].Items, item)
+	}
+	return rows.Err()
+}
+
+// Approve admin setuju refund, sekalian nentuin item mana yang stoknya dibalikin
+func (r *RefundRepositoryImpl) Approve(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note *string, restockItemIDs []uuid.UUID) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		query := `
+			UPDATE refunds
+			SET status = $1, admin_note = $2, reviewed_by = $3, reviewed_at = NOW(), updated_at = NOW()
+			WHERE id = $4 AND status = $5
+		`
+		tag, err := tx.Exec(ctx, query, model.RefundStatusApproved, note, adminID, id, model.RefundStatusRequested)
+		if err != nil {
+			return fmt.Errorf("failed to approve refund: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return refundStateError(ctx, tx, id)
+		}
+
+		restockQuery := `
+			UPDATE refund_items
+			SET restock = COALESCE(id = ANY($2), false)
+			WHERE refund_id = $1
+		`
+		if _, err := tx.Exec(ctx, restockQuery, id, restockItemIDs); err != nil {
+			return fmt.Errorf("failed to update restock: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *RefundRepositoryImpl) Reject(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note string) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		query := `
+			UPDATE refunds
+			SET status = $1, admin_note = $2, reviewed_by = $3, reviewed_at = NOW(), updated_at = NOW()
+			WHERE id = $4 AND status IN ($5, $6)
+		`
+		tag, err := tx.Exec(ctx, query,
+			model.RefundStatusRejected,
+			note,
+			adminID,
+			id,
+			model.RefundStatusRequested,
+			model.RefundStatusApproved,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to reject refund: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return refundStateError(ctx, tx, id)
+		}
+		return nil
+	})
+}
+
+// restockTx balikin stok item refund yang ditandain restock, dicatat di ledger
+// sebagai return, balik ke varian yang dibeli kalau ada. item bundle dibalikin
+// ke stok komponen nya sesuai snapshot order. produk yang udah dihapus dilewatin
+func restockTx(ctx context.Context, tx pgx.Tx, refundID uuid.UUID, adminID uuid.UUID) error {
+	rows, err := tx.Query(ctx, `
+		SELECT ri.product_id, oi.variant_id, ri.quantity, oi.bundle_components
+		FROM refund_items ri
+		JOIN order_items oi ON oi.id = ri.order_item_id
+		WHERE ri.refund_id = $1 AND ri.restock AND oi.item_type = 'product'
+	`, refundID)
+	if err != nil {
+		return fmt.Errorf("failed to get restock items: %w", err)
+	}
+
+	var lines []inventory.Line
+	for rows.Next() {
+		var item model.OrderItem
+		if err := rows.Scan(&item.ProductID, &item.VariantID, &item.Quantity, &item.BundleComponents); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan restock item: %w", err)
+		}
+		lines = append(lines, inventory.ItemLines(item.ProductID, item.VariantID, item.Quantity, item.BundleComponents)...)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// urut per produk biar urutan lock nya sama kaya alur stok lain
+	slices.SortFunc(lines, func(a, b inventory.Line) int {
+		return slices.Compare(a.ProductID[:], b.ProductID[:])
+	})
+
+	moves := make([]inventory.Movement, len(lines))
+	for i, l := range lines {
+		moves[i] = inventory.Movement{
+			ProductID:     l.ProductID,
+			VariantID:     l.VariantID,
+			Delta:         l.Quantity,
+			Reason:        model.InventoryReasonReturn,
+			ReferenceType: inventory.RefRefund,
+			ReferenceID:   &refundID,
+			ActorID:       &adminID,
+		}
+	}
+
+	for _, m := range moves {
+		_, err := inventory.MoveTx(ctx, tx, m)
+		if err != nil && !errors.Is(err, inventory.ErrProductNotFound) {
+			return fmt.Errorf("failed to restock: %w", err)
+		}
+	}
+	return nil
+}
+
+// Complete dana udah ditransfer balik (ada bukti). di transaksi yang sama:
+// stok item yang ditandain restock dibalikin, refunded_amount payment ditambah,
+// status payment jadi partially_refunded / refunded, dan kalau seluruh dana
+// udah balik order nya pindah ke refunded lewat state machine order.
+func (r *RefundRepositoryImpl) Complete(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note *string, proofImage string) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+
+		lockQuery := `
+			SELECT order_id, payment_id, amount, status
+			FROM refunds
+			WHERE id = $1
+			FOR UPDATE
+		`
+		var orderID, paymentID uuid.UUID
+		var amount model.Money
+		var status model.RefundStatus
+		err := tx.QueryRow(ctx, lockQuery, id).Scan(&orderID, &paymentID, &amount, &status)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrRefundNotFound
+			}
+			return err
+		}
+		if status != model.RefundStatusApproved {
+			return ErrRefundInvalidState
+		}
+
+		paymentQuery := `
+			SELECT amount, refunded_amount
+			FROM payments
+			WHERE id = $1
+			FOR UPDATE
+		`
+		var paymentAmount, refunded model.Money
+		if err := tx.QueryRow(ctx, paymentQuery, paymentID).Scan(&paymentAmount, &refunded); err != nil {
+			return fmt.Errorf("failed to lock payment: %w", err)
+		}
+
+		refunded += amount
+		if refunded > paymentAmount {
+			return ErrRefundExceedsPayment
+		}
+
+		paymentStatus := model.PaymentStatusPartiallyRefunded
+		if refunded == paymentAmount {
+			paymentStatus = model.PaymentStatusRefunded
+		}
+
+		updatePayment := `
+			UPDATE payments
+			SET refunded_amount = $1, status = $2, updated_at = NOW()
+			WHERE id = $3
+		`
+		if _, err := tx.Exec(ctx, updatePayment, refunded, paymentStatus, paymentID); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		if err := restockTx(ctx, tx, id, adminID); err != nil {
+			return err
+		}
+
+		refundQuery := `
+			UPDATE refunds
+			SET status = $1,
+			    proof_image = $2,
+			    admin_note = COALESCE($3, admin_note),
+			    completed_at = NOW(),
+			    updated_at = NOW()
+			WHERE id = $4
+		`
+		if _, err := tx.Exec(ctx, refundQuery, model.RefundStatusCompleted, proofImage, note, id); err != nil {
+			return fmt.Errorf("failed to complete refund: %w", err)
+		}
+
+		if paymentStatus == model.PaymentStatusRefunded {
+			if _, err := order.TransitionTx(ctx, tx, orderID, model.OrderStatusRefunded, &adminID, note); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// refundStateError bedain refund yang ga ada sama yang statusnya ga cocok
+func refundStateError(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM refunds WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrRefundNotFound
+	}
+	return ErrRefundInvalidState
+}
+
+const refundColumns = `id, order_id, payment_id, user_id, status, is_full, amount, reason,
+	admin_note, proof_image, reviewed_by, created_at, updated_at, reviewed_at, completed_at`
+
+type scannable interface {
+	Scan(dest ...any) error
+}
+
+func scanRefund(row scannable) (model.Refund, error) {
+	var refund model.Refund
+	err := row.Scan(
+		&refund.ID,
+		&refund.OrderID,
+		&refund.PaymentID,
+		&refund.UserID,
+		&refund.Status,
+		&refund.IsFull,
+		&refund.Amount,
+		&refund.Reason,
+		&refund.AdminNote,
+		&refund.ProofImage,
+		&refund.ReviewedBy,
+		&refund.CreatedAt,
+		&refund.UpdatedAt,
+		&refund.ReviewedAt,
+		&refund.CompletedAt,
+	)
+	return refund, err
+}
@@ -0,0 +1,157 @@
+package refund
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/middleware"
+	"backEnd-RingoTechLife/internal/order"
+	"backEnd-RingoTechLife/internal/storage"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+const refundImagePlace = "refunds"
+
+type RefundService struct {
+	refundRepo  RefundRepositoryInterface
+	fileStorage *storage.FileStorage
+}
+
+func NewRefundService(repo *RefundRepositoryImpl, storage *storage.FileStorage) *RefundService {
+	return &RefundService{
+		refundRepo:  repo,
+		fileStorage: storage,
+	}
+}
+
+func (s *RefundService) Create(ctx context.Context, req dto.CreateRefundRequest, userId uuid.UUID) (model.Refund, *common.ErrorResponse) {
+
+	orderId, err := uuid.Parse(req.OrderId)
+	if err != nil {
+		return model.Refund{}, common.NewErrorResponse(400, "id order tidak valid!")
+	}
+
+	lines := make([]RefundLine, 0, len(req.Items))
+	for _, item := range req.Items {
+		itemId, err := uuid.Parse(item.OrderItemId)
+		if err != nil {
+			return model.Refund{}, common.NewErrorResponse(400, "id item order tidak valid!")
+		}
+		lines = append(lines, RefundLine{OrderItemID: itemId, Quantity: item.Quantity})
+	}
+
+	refund := model.Refund{
+		OrderID: orderId,
+		UserID:  userId,
+		Reason:  req.Reason,
+	}
+
+	data, err := s.refundRepo.Create(ctx, &refund, lines)
+	if err != nil {
+		return model.Refund{}, refundError(err)
+	}
+	return *data, nil
+}
+
+func (s *RefundService) GetById(ctx context.Context, id uuid.UUID, userId uuid.UUID, role string) (model.Refund, *common.ErrorResponse) {
+
+	data, err := s.refundRepo.GetByID(ctx, id)
+	if err != nil {
+		return model.Refund{}, refundError(err)
+	}
+
+	if role != middleware.RoleAdmin && data.UserID != userId {
+		return model.Refund{}, common.NewErrorResponse(404, ErrRefundNotFound.Error())
+	}
+	return data, nil
+}
+
+func (s *RefundService) GetByUserId(ctx context.Context, userId uuid.UUID) ([]model.Refund, *common.ErrorResponse) {
+
+	data, err := s.refundRepo.GetByUserID(ctx, userId)
+	if err != nil {
+		return []model.Refund{}, common.NewErrorResponse(500, "gagal mengambil data refund! "+err.Error())
+	}
+	return data, nil
+}
+
+func (s *RefundService) GetAll(ctx context.Context, status string) ([]model.Refund, *common.ErrorResponse) {
+
+	var filter *model.RefundStatus
+	if status != "" {
+		st := model.RefundStatus(status)
+		filter = &st
+	}
+
+	data, err := s.refundRepo.GetAll(ctx, filter)
+	if err != nil {
+		return []model.Refund{}, common.NewErrorResponse(500, "gagal mengambil data refund! "+err.Error())
+	}
+	return data, nil
+}
+
+func (s *RefundService) Approve(ctx context.Context, id uuid.UUID, adminId uuid.UUID, req dto.ApproveRefundRequest) *common.ErrorResponse {
+
+	restockIds := make([]uuid.UUID, 0, len(req.RestockItemIds))
+	for _, raw := range req.RestockItemIds {
+		itemId, err := uuid.Parse(raw)
+		if err != nil {
+			return common.NewErrorResponse(400, "id item refund tidak valid!")
+		}
+		restockIds = append(restockIds, itemId)
+	}
+
+	if err := s.refundRepo.Approve(ctx, id, adminId, req.Note, restockIds); err != nil {
+		return refundError(err)
+	}
+	return nil
+}
+
+func (s *RefundService) Reject(ctx context.Context, id uuid.UUID, adminId uuid.UUID, note string) *common.ErrorResponse {
+
+	if err := s.refundRepo.Reject(ctx, id, adminId, note); err != nil {
+		return refundError(err)
+	}
+	return nil
+}
+
+// Complete nyimpen bukti transfer refund terus nyelesaiin refund nya.
+// kalau gagal di database, file bukti nya dihapus lagi.
+func (s *RefundService) Complete(ctx context.Context, id uuid.UUID, adminId uuid.UUID, req dto.CompleteRefundRequest) *common.ErrorResponse {
+
+	mimeType, err := s.fileStorage.DetectFileType(req.ProofImage)
+	if err != nil {
+		return common.NewErrorResponse(500, "gagal memproses file! mungkin file tidak didukung")
+	}
+
+	ext, ok := s.fileStorage.IsTypeSupportted(mimeType)
+	if !ok {
+		return common.NewErrorResponse(400, "format file tidak didukung!")
+	}
+
+	savedFileName, err := s.fileStorage.SavePublicFile(req.ProofImage, ext, refundImagePlace)
+	if err != nil {
+		return common.NewErrorResponse(500, "gagal menyimpan bukti refund! "+err.Error())
+	}
+
+	if err := s.refundRepo.Complete(ctx, id, adminId, req.Note, savedFileName); err != nil {
+		s.fileStorage.DeletePublicFile(savedFileName, refundImagePlace)
+		return refundError(err)
+	}
+	return nil
+}
+
+func refundError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, ErrRefundNotFound), errors.Is(err, order.ErrNoOrderFound):
+		return common.NewErrorResponse(404, err.Error())
+	case errors.Is(err, ErrRefundNotAllowed), errors.Is(err, ErrRefundInvalidState), errors.Is(err, order.ErrInvalidTransition):
+		return common.NewErrorResponse(409, err.Error())
+	case errors.Is(err, ErrRefundExceedsPayment), errors.Is(err, ErrRefundItemInvalid):
+		return common.NewErrorResponse(422, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di database "+err.Error())
+}
@@ -20,6 +20,7 @@ var (
 	ErrReviewAlreadyExists   = errors.New("user already reviewed this product")
 	ErrReviewUserNotFound    = errors.New("user not found")
 	ErrReviewProductNotFound = errors.New("product not found")
+	ErrReviewNotPurchased    = errors.New("user has no completed order for this product")
 )
 
 const reviewDetailQuery = `
@@ -380,7 +381,28 @@ func (r *ReviewRepositoryImpl) Create(
 		RETURNING id, created_at
 	`
 
+	// review cuma boleh dari user yang order nya udah completed
+	purchaseQuery := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM orders o
+			INNER JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.user_id = $1 AND oi.product_id = $2 AND o.status = $3
+			  AND oi.item_type = 'product'
+		)
+	`
+
 	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var purchased bool
+		err := tx.QueryRow(ctx, purchaseQuery, review.UserID, review.ProductID, model.OrderStatusCompleted).
+			Scan(&purchased)
+		if err != nil {
+			return err
+		}
+		if !purchased {
+			return ErrReviewNotPurchased
+		}
+
 		return tx.QueryRow(ctx, query,
 			review.ProductID,
 			review.UserID,
@@ -390,6 +412,9 @@ func (r *ReviewRepositoryImpl) Create(
 	})
 
 	if err != nil {
+		if errors.Is(err, ErrReviewNotPurchased) {
+			return nil, err
+		}
 		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok {
 			if pgErr.Code == "23505" {
 				// unique_user_product_review constraint
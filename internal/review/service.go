@@ -37,6 +37,12 @@ func (r *ReviewService) Create(ctx context.Context, req model.Review) (dto.Revie
 		if errors.Is(err, ErrReviewProductNotFound) {
 			return dto.ReviewDetail{}, common.NewErrorResponse(404, "produk yang di review tidak ditemukan! mungkin sudah dihapus!")
 		}
+
+		if errors.Is(err, ErrReviewNotPurchased) {
+			return dto.ReviewDetail{}, common.NewErrorResponse(403, "kamu hanya bisa review produk dari pesanan yang sudah selesai!")
+		}
+
+		return dto.ReviewDetail{}, common.NewErrorResponse(500, "internal server error! gagal menyimpan review")
 	}
 
 	reviewResp, err := r.reviewRepo.GetDetailByID(ctx, data.ID)
@@ -0,0 +1,335 @@
+package rma
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/middleware"
+	"backEnd-RingoTechLife/pkg"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	"github.com/go-playground/form/v4"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const maxReturnFormSize = 25 << 20
+const maxReturnPhotoSize = 4 << 20
+const maxReturnPhotos = 5
+
+type ReturnHandler struct {
+	returnService *ReturnService
+	decoder       *form.Decoder
+	validator     *validator.Validate
+}
+
+func NewReturnHandler(svc *ReturnService, dec *form.Decoder, vld *validator.Validate) *ReturnHandler {
+	return &ReturnHandler{
+		returnService: svc,
+		decoder:       dec,
+		validator:     vld,
+	}
+}
+
+func (rh *ReturnHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
+
+	if err := r.ParseMultipartForm(maxReturnFormSize); err != nil {
+		pkg.JSONError(w, 400, "gagal parse form data")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var req dto.CreateReturnRequest
+	if err := rh.decoder.Decode(&req, r.MultipartForm.Value); err != nil {
+		pkg.JSONError(w, 400, "form data tidak valid")
+		return
+	}
+
+	req.Photos = r.MultipartForm.File["photos"]
+	if len(req.Photos) == 0 || len(req.Photos) > maxReturnPhotos {
+		pkg.JSONError(w, 400, "minimal 1 dan maksimal 5 foto bukti kerusakan!")
+		return
+	}
+	for _, fileHeader := range req.Photos {
+		if fileHeader.Size > maxReturnPhotoSize {
+			pkg.JSONError(w, 400, "gambar terlalu besar! maksimal 4mb")
+			return
+		}
+	}
+
+	if err := rh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, addErr := rh.returnService.Create(r.Context(), req, userId)
+	if addErr != nil {
+		pkg.JSONError(w, addErr.Code, addErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengajukan retur", data)
+}
+
+func (rh *ReturnHandler) GetMyReturnsHandler(w http.ResponseWriter, r *http.Request) {
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, err := rh.returnService.GetByUserId(r.Context(), userId)
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (rh *ReturnHandler) GetByIdHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+	role, _ := middleware.GetRole(r.Context())
+
+	data, getErr := rh.returnService.GetById(r.Context(), id, userId, role)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (rh *ReturnHandler) GetAllHandler(w http.ResponseWriter, r *http.Request) {
+
+	data, err := rh.returnService.GetAll(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (rh *ReturnHandler) CancelHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	if cancelErr := rh.returnService.Cancel(r.Context(), id, userId); cancelErr != nil {
+		pkg.JSONError(w, cancelErr.Code, cancelErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil membatalkan retur", nil)
+}
+
+func (rh *ReturnHandler) ShipHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.ShipReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data pengiriman dengan benar!")
+		return
+	}
+
+	if err := rh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	userId, _ := middleware.GetUserID(r.Context())
+
+	if shipErr := rh.returnService.MarkShipped(r.Context(), id, userId, req); shipErr != nil {
+		pkg.JSONError(w, shipErr.Code, shipErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menyimpan data pengiriman retur", nil)
+}
+
+func (rh *ReturnHandler) ApproveHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.ApproveReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data dengan benar!")
+		return
+	}
+
+	if err := rh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	if appErr := rh.returnService.Approve(r.Context(), id, adminId, req.Note); appErr != nil {
+		pkg.JSONError(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menyetujui retur", nil)
+}
+
+func (rh *ReturnHandler) RejectHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.RejectReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data dengan benar!")
+		return
+	}
+
+	if err := rh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	if rejErr := rh.returnService.Reject(r.Context(), id, adminId, req.Note); rejErr != nil {
+		pkg.JSONError(w, rejErr.Code, rejErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menolak retur", nil)
+}
+
+func (rh *ReturnHandler) ReceiveHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	if recErr := rh.returnService.MarkReceived(r.Context(), id); recErr != nil {
+		pkg.JSONError(w, recErr.Code, recErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "barang retur sudah diterima", nil)
+}
+
+func (rh *ReturnHandler) InspectHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.InspectReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi hasil inspeksi dengan benar!")
+		return
+	}
+
+	if err := rh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	if insErr := rh.returnService.Inspect(r.Context(), id, adminId, req); insErr != nil {
+		pkg.JSONError(w, insErr.Code, insErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menyimpan hasil inspeksi", nil)
+}
+
+func (rh *ReturnHandler) ResolveHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.ResolveReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data dengan benar!")
+		return
+	}
+
+	if err := rh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	adminId, _ := middleware.GetUserID(r.Context())
+
+	data, resErr := rh.returnService.Resolve(r.Context(), id, adminId, req)
+	if resErr != nil {
+		pkg.JSONError(w, resErr.Code, resErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "retur selesai diproses", data)
+}
+
+func (rh *ReturnHandler) SetUpRoute(router chi.Router) {
+
+	router.Route("/returns", func(r chi.Router) {
+		r.Use(httprate.Limit(
+			30,
+			time.Minute,
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				errorRes := common.NewErrorResponse(http.StatusTooManyRequests, "Terlalu banyak request, coba lagi nanti")
+				errorResJson, _ := json.Marshal(errorRes)
+				w.Write(errorResJson)
+			}),
+		))
+		r.Use(middleware.AuthMiddleware)
+
+		r.Post("/add", rh.CreateHandler)
+		r.Get("/my", rh.GetMyReturnsHandler)
+		r.Get("/id/{id}", rh.GetByIdHandler)
+		r.Put("/cancel/{id}", rh.CancelHandler)
+		r.Put("/ship/{id}", rh.ShipHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware(middleware.RoleAdmin))
+			r.Get("/get-all", rh.GetAllHandler)
+			r.Put("/approve/{id}", rh.ApproveHandler)
+			r.Put("/reject/{id}", rh.RejectHandler)
+			r.Put("/receive/{id}", rh.ReceiveHandler)
+			r.Put("/inspect/{id}", rh.InspectHandler)
+			r.Put("/resolve/{id}", rh.ResolveHandler)
+		})
+	})
+}
@@ -0,0 +1,706 @@
+package rma
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/inventory"
+	"backEnd-RingoTechLife/internal/order"
+	"backEnd-RingoTechLife/internal/refund"
+	"backEnd-RingoTechLife/internal/servicerequest"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrReturnNotFound = errors.New("data retur tidak ditemukan")
+var ErrReturnNotAllowed = errors.New("retur cuma bisa diajukan untuk order yang sudah diterima")
+var ErrReturnItemInvalid = errors.New("item retur tidak valid atau jumlahnya melebihi sisa barang yang bisa diretur")
+var ErrReturnInvalidState = errors.New("status retur tidak bisa diubah ke status ini")
+var ErrReturnNoDefect = errors.New("hasil inspeksi tidak menemukan cacat, retur tidak bisa diselesaikan dengan refund / barang pengganti")
+var ErrReplacementOutOfStock = errors.New("stok barang pengganti tidak cukup")
+
+// ReturnLine permintaan retur per order item
+type ReturnLine struct {
+	OrderItemID uuid.UUID
+	Quantity    int
+}
+
+// ResolveData keputusan akhir retur dari admin
+type ResolveData struct {
+	Resolution                model.ReturnResolution
+	Note                      *string
+	ReplacementCourier        *string
+	ReplacementTrackingNumber *string
+}
+
+type ReturnRepositoryInterface interface {
+	Create(ctx context.Context, ret *model.ReturnRequest, lines []ReturnLine) (*model.ReturnRequest, error)
+	GetByID(ctx context.Context, id uuid.UUID) (model.ReturnRequest, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.ReturnRequest, error)
+	GetAll(ctx context.Context, status *model.ReturnStatus) ([]model.ReturnRequest, error)
+	Approve(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note *string) error
+	Reject(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note string) error
+	Cancel(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	MarkShipped(ctx context.Context, id uuid.UUID, userID uuid.UUID, courier string, trackingNumber string) error
+	MarkReceived(ctx context.Context, id uuid.UUID) error
+	Inspect(ctx context.Context, id uuid.UUID, adminID uuid.UUID, result model.ReturnInspectionResult, note string) error
+	Resolve(ctx context.Context, id uuid.UUID, adminID uuid.UUID, data ResolveData) error
+}
+
+type ReturnRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewReturnRepository(pool *pgxpool.Pool) *ReturnRepositoryImpl {
+	return &ReturnRepositoryImpl{
+		db: pool,
+	}
+}
+
+// retur yang masih "makan" jatah barang di order item
+var activeReturnStatuses = []string{
+	string(model.ReturnStatusRequested),
+	string(model.ReturnStatusApproved),
+	string(model.ReturnStatusShipped),
+	string(model.ReturnStatusReceived),
+	string(model.ReturnStatusInspected),
+	string(model.ReturnStatusResolved),
+}
+
+type returnableItem struct {
+	ID          uuid.UUID
+	ProductID   uuid.UUID
+	ProductName string
+	Remaining   int
+}
+
+// Create ngajuin retur. order dikunci biar dua pengajuan barengan
+// ga bisa ngeretur barang yang sama lebih dari jumlah yang dibeli.
+func (r *ReturnRepositoryImpl) Create(ctx context.Context, ret *model.ReturnRequest, lines []ReturnLine) (*model.ReturnRequest, error) {
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+
+		var ownerID uuid.UUID
+		var status model.OrderStatus
+		orderQuery := `SELECT user_id, status FROM orders WHERE id = $1 FOR UPDATE`
+		if err := tx.QueryRow(ctx, orderQuery, ret.OrderID).Scan(&ownerID, &status); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return order.ErrNoOrderFound
+			}
+			return err
+		}
+
+		if ownerID != ret.UserID {
+			return order.ErrNoOrderFound
+		}
+
+		if status != model.OrderStatusDelivered && status != model.OrderStatusCompleted {
+			return ErrReturnNotAllowed
+		}
+
+		items, err := returnableItemsTx(ctx, tx, ret.OrderID)
+		if err != nil {
+			return err
+		}
+
+		ret.Items, err = buildReturnItems(items, lines)
+		if err != nil {
+			return err
+		}
+
+		ret.Status = model.ReturnStatusRequested
+		returnQuery := `
+			INSERT INTO returns (order_id, user_id, status, reason, photos)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at, updated_at
+		`
+		err = tx.QueryRow(ctx, returnQuery,
+			ret.OrderID,
+			ret.UserID,
+			ret.Status,
+			ret.Reason,
+			ret.Photos,
+		).Scan(&ret.ID, &ret.CreatedAt, &ret.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert return: %w", err)
+		}
+
+		itemQuery := `
+			INSERT INTO return_items (return_id, order_item_id, product_id, product_name, quantity)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`
+		for i := range ret.Items {
+			ret.Items[i].ReturnID = ret.ID
+			err := tx.QueryRow(ctx, itemQuery,
+				ret.ID,
+				ret.Items[i].OrderItemID,
+				ret.Items[i].ProductID,
+				ret.Items[i].ProductName,
+				ret.Items[i].Quantity,
+			).Scan(&ret.Items[i].ID)
+			if err != nil {
+				return fmt.Errorf("failed to insert return item: %w", err)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// returnableItemsTx sisa jumlah tiap order item yang belum masuk retur aktif
+func returnableItemsTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID) ([]returnableItem, error) {
+	query := `
+		SELECT oi.id, oi.product_id, oi.product_name,
+		       oi.quantity - COALESCE(SUM(ri.quantity), 0) AS remaining
+		FROM order_items oi
+		LEFT JOIN (
+			return_items ri
+			JOIN returns rt ON rt.id = ri.return_id AND rt.status = ANY($2)
+		) ON ri.order_item_id = oi.id
+		WHERE oi.order_id = $1 AND oi.item_type = 'product'
+		GROUP BY oi.id
+		ORDER BY oi.created_at
+	`
+	rows, err := tx.Query(ctx, query, orderID, activeReturnStatuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []returnableItem
+	for rows.Next() {
+		var item returnableItem
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.Remaining); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func buildReturnItems(items []returnableItem, lines []ReturnLine) ([]model.ReturnItem, error) {
+
+	byID := make(map[uuid.UUID]returnableItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	result := make([]model.ReturnItem, 0, len(lines))
+	seen := map[uuid.UUID]bool{}
+	for _, line := range lines {
+		item, ok := byID[line.OrderItemID]
+		if !ok || seen[line.OrderItemID] || line.Quantity <= 0 || line.Quantity > item.Remaining {
+			return nil, ErrReturnItemInvalid
+		}
+		seen[line.OrderItemID] = true
+
+		result = append(result, model.ReturnItem{
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    line.Quantity,
+		})
+	}
+
+	if len(result) == 0 {
+		return nil, ErrReturnItemInvalid
+	}
+	return result, nil
+}
+
+func (r *ReturnRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (model.ReturnRequest, error) {
+	query := `SELECT ` + returnColumns + ` FROM returns WHERE id = $1`
+
+	ret, err := scanReturn(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ReturnRequest{}, ErrReturnNotFound
+		}
+		return model.ReturnRequest{}, err
+	}
+
+	returns := []model.ReturnRequest{ret}
+	if err := r.attachItems(ctx, returns); err != nil {
+		return model.ReturnRequest{}, err
+	}
+	return returns[0], nil
+}
+
+func (r *ReturnRepositoryImpl) GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.ReturnRequest, error) {
+	query := `SELECT ` + returnColumns + ` FROM returns WHERE user_id = $1 ORDER BY created_at DESC`
+	return r.queryReturns(ctx, query, userID)
+}
+
+func (r *ReturnRepositoryImpl) GetAll(ctx context.Context, status *model.ReturnStatus) ([]model.ReturnRequest, error) {
+	query := `
+		SELECT ` + returnColumns + `
+		FROM returns
+		WHERE $1::text IS NULL OR status = $1
+		ORDER BY created_at ASC
+	`
+	return r.queryReturns(ctx, query, status)
+}
+
+func (r *ReturnRepositoryImpl) queryReturns(ctx context.Context, query string, args ...any) ([]model.ReturnRequest, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returns := []model.ReturnRequest{}
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan return: %w", err)
+		}
+		returns = append(returns, ret)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachItems(ctx, returns); err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+func (r *ReturnRepositoryImpl) attachItems(ctx context.Context, returns []model.ReturnRequest) error {
+	if len(returns) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(returns))
+	index := make(map[uuid.UUID]int, len(returns))
+	for i := range returns {
+		ids[i] = returns[i].ID
+		index[returns[i].ID] = i
+		returns[i].Items = []model.ReturnItem{}
+	}
+
+	query := `
+		SELECT id, return_id, order_item_id, product_id, product_name, quantity
+		FROM return_items
+		WHERE return_id = ANY($1)
+		ORDER BY product_name
+	`
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item model.ReturnItem
+		err := rows.Scan(
+			&item.ID,
+			&item.ReturnID,
+			&item.OrderItemID,
+			&item.ProductID,
+			&item.ProductName,
+			&item.Quantity,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan return item: %w", err)
+		}
+		i := index[item.ReturnID]
+		returns[i].Items = append(returns[i].Items, item)
+	}
+	return rows.Err()
+}
+
+func (r *ReturnRepositoryImpl) Approve(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note *string) error {
+	query := `
+		UPDATE returns
+		SET status = $1, admin_note = $2, reviewed_by = $3, reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = $4 AND status = $5
+	`
+	return r.updateState(ctx, id, query, model.ReturnStatusApproved, note, adminID, id, model.ReturnStatusRequested)
+}
+
+// Reject bisa pas review awal, atau setelah inspeksi kalau ternyata ga ada cacat
+func (r *ReturnRepositoryImpl) Reject(ctx context.Context, id uuid.UUID, adminID uuid.UUID, note string) error {
+	query := `
+		UPDATE returns
+		SET status = $1, admin_note = $2, reviewed_by = COALESCE(reviewed_by, $3),
+		    reviewed_at = COALESCE(reviewed_at, NOW()), updated_at = NOW()
+		WHERE id = $4 AND status IN ($5, $6)
+	`
+	return r.updateState(ctx, id, query,
+		model.ReturnStatusRejected,
+		note,
+		adminID,
+		id,
+		model.ReturnStatusRequested,
+		model.ReturnStatusInspected,
+	)
+}
+
+func (r *ReturnRepositoryImpl) Cancel(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	query := `
+		UPDATE returns
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND user_id = $3 AND status IN ($4, $5)
+	`
+	return r.updateState(ctx, id, query,
+		model.ReturnStatusCancelled,
+		id,
+		userID,
+		model.ReturnStatusRequested,
+		model.ReturnStatusApproved,
+	)
+}
+
+// MarkShipped customer ngisi resi pengiriman barang balik ke toko
+func (r *ReturnRepositoryImpl) MarkShipped(ctx context.Context, id uuid.UUID, userID uuid.UUID, courier string, trackingNumber string) error {
+	query := `
+		UPDATE returns
+		SET status = $1, inbound_courier = $2, inbound_tracking_number = $3, shipped_at = NOW(), updated_at = NOW()
+		WHERE id = $4 AND user_id = $5 AND status = $6
+	`
+	return r.updateState(ctx, id, query,
+		model.ReturnStatusShipped,
+		courier,
+		trackingNumber,
+		id,
+		userID,
+		model.ReturnStatusApproved,
+	)
+}
+
+// MarkReceived barang udah sampai di toko. dari approved juga boleh
+// buat customer yang nganter langsung ke toko tanpa kurir.
+func (r *ReturnRepositoryImpl) MarkReceived(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE returns
+		SET status = $1, received_at = NOW(), updated_at = NOW()
+		WHERE id = $2 AND status IN ($3, $4)
+	`
+	return r.updateState(ctx, id, query,
+		model.ReturnStatusReceived,
+		id,
+		model.ReturnStatusApproved,
+		model.ReturnStatusShipped,
+	)
+}
+
+func (r *ReturnRepositoryImpl) Inspect(ctx context.Context, id uuid.UUID, adminID uuid.UUID, result model.ReturnInspectionResult, note string) error {
+	query := `
+		UPDATE returns
+		SET status = $1, inspection_result = $2, inspection_note = $3, inspected_by = $4,
+		    inspected_at = NOW(), updated_at = NOW()
+		WHERE id = $5 AND status = $6
+	`
+	return r.updateState(ctx, id, query,
+		model.ReturnStatusInspected,
+		result,
+		note,
+		adminID,
+		id,
+		model.ReturnStatusReceived,
+	)
+}
+
+func (r *ReturnRepositoryImpl) updateState(ctx context.Context, id uuid.UUID, query string, args ...any) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to update return: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return returnStateError(ctx, tx, id)
+		}
+		return nil
+	})
+}
+
+// Resolve nutup retur sesuai keputusan admin, semuanya di satu transaksi:
+//   - refund: bikin refund yang langsung approved buat item yang diretur,
+//     transfer dananya tetep lewat alur refund (complete + bukti)
+//   - replacement: stok barang pengganti dikurangin, barang cacatnya ga balik ke stok
+//   - repair: dibikinin service request dari produk item pertama
+func (r *ReturnRepositoryImpl) Resolve(ctx context.Context, id uuid.UUID, adminID uuid.UUID, data ResolveData) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+
+		lockQuery := `SELECT ` + returnColumns + ` FROM returns WHERE id = $1 FOR UPDATE`
+		ret, err := scanReturn(tx.QueryRow(ctx, lockQuery, id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrReturnNotFound
+			}
+			return err
+		}
+		if ret.Status != model.ReturnStatusInspected {
+			return ErrReturnInvalidState
+		}
+
+		defectConfirmed := ret.InspectionResult != nil && *ret.InspectionResult == model.InspectionDefectConfirmed
+		if data.Resolution != model.ReturnResolutionRepair && !defectConfirmed {
+			return ErrReturnNoDefect
+		}
+
+		items, err := returnItemsTx(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		var refundID, serviceRequestID *uuid.UUID
+		switch data.Resolution {
+		case model.ReturnResolutionRefund:
+			created, err := createReturnRefundTx(ctx, tx, ret, items, adminID, data.Note)
+			if err != nil {
+				return err
+			}
+			refundID = &created
+
+		case model.ReturnResolutionReplacement:
+			if err := reserveReplacementTx(ctx, tx, id, adminID, items); err != nil {
+				return err
+			}
+
+		case model.ReturnResolutionRepair:
+			created, err := createRepairRequestTx(ctx, tx, ret, items[0])
+			if err != nil {
+				return err
+			}
+			serviceRequestID = &created
+		}
+
+		query := `
+			UPDATE returns
+			SET status = $1,
+			    resolution = $2,
+			    refund_id = $3,
+			    service_request_id = $4,
+			    replacement_courier = $5,
+			    replacement_tracking_number = $6,
+			    admin_note = COALESCE($7, admin_note),
+			    resolved_by = $8,
+			    resolved_at = NOW(),
+			    updated_at = NOW()
+			WHERE id = $9
+		`
+		_, err = tx.Exec(ctx, query,
+			model.ReturnStatusResolved,
+			data.Resolution,
+			refundID,
+			serviceRequestID,
+			data.ReplacementCourier,
+			data.ReplacementTrackingNumber,
+			data.Note,
+			adminID,
+			id,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to resolve return: %w", err)
+		}
+		return nil
+	})
+}
+
+func returnItemsTx(ctx context.Context, tx pgx.Tx, returnID uuid.UUID) ([]model.ReturnItem, error) {
+	query := `
+		SELECT id, return_id, order_item_id, product_id, product_name, quantity
+		FROM return_items
+		WHERE return_id = $1
+		ORDER BY product_name
+	`
+	rows, err := tx.Query(ctx, query, returnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.ReturnItem
+	for rows.Next() {
+		var item model.ReturnItem
+		if err := rows.Scan(&item.ID, &item.ReturnID, &item.OrderItemID, &item.ProductID, &item.ProductName, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrReturnItemInvalid
+	}
+	return items, nil
+}
+
+// createReturnRefundTx refund dari retur ga perlu review lagi (udah diinspeksi),
+// jadi langsung approved. barangnya cacat, jadi ga ada yang di restock.
+func createReturnRefundTx(ctx context.Context, tx pgx.Tx, ret model.ReturnRequest, items []model.ReturnItem, adminID uuid.UUID, note *string) (uuid.UUID, error) {
+
+	lines := make([]refund.RefundLine, len(items))
+	for i, item := range items {
+		lines[i] = refund.RefundLine{OrderItemID: item.OrderItemID, Quantity: item.Quantity}
+	}
+
+	rf := model.Refund{
+		OrderID: ret.OrderID,
+		UserID:  ret.UserID,
+		Status:  model.RefundStatusApproved,
+		Reason:  "retur barang cacat: " + ret.Reason,
+	}
+	if err := refund.CreateTx(ctx, tx, &rf, lines); err != nil {
+		return uuid.Nil, err
+	}
+
+	query := `
+		UPDATE refunds
+		SET admin_note = $1, reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $3
+	`
+	if _, err := tx.Exec(ctx, query, note, adminID, rf.ID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to review refund: %w", err)
+	}
+	return rf.ID, nil
+}
+
+// reserveReplacementTx stok barang pengganti dipotong lewat ledger (reason return),
+// pengganti nya varian yang sama dengan yang dibeli. pengganti bundle motong
+// stok komponen sesuai snapshot isi bundle di order
+func reserveReplacementTx(ctx context.Context, tx pgx.Tx, returnID uuid.UUID, adminID uuid.UUID, items []model.ReturnItem) error {
+	for _, item := range items {
+		var variantID *uuid.UUID
+		var components []model.BundleComponent
+		err := tx.QueryRow(ctx, `
+			SELECT variant_id, bundle_components FROM order_items WHERE id = $1
+		`, item.OrderItemID).Scan(&variantID, &components)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to get order item variant: %w", err)
+		}
+
+		for _, l := range inventory.ItemLines(item.ProductID, variantID, item.Quantity, components) {
+			_, err = inventory.MoveTx(ctx, tx, inventory.Movement{
+				ProductID:     l.ProductID,
+				VariantID:     l.VariantID,
+				Delta:         -l.Quantity,
+				Reason:        model.InventoryReasonReturn,
+				ReferenceType: inventory.RefReturn,
+				ReferenceID:   &returnID,
+				ActorID:       &adminID,
+			})
+			if err != nil {
+				if errors.Is(err, inventory.ErrInsufficientStock) || errors.Is(err, inventory.ErrProductNotFound) ||
+					errors.Is(err, inventory.ErrVariantNotFound) {
+					return fmt.Errorf("%w: %s", ErrReplacementOutOfStock, item.ProductName)
+				}
+				return fmt.Errorf("failed to reserve replacement: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// createRepairRequestTx data perangkat diambil dari produk yang dibeli,
+// foto retur (maks 3) ikut dipake sebagai foto service request
+func createRepairRequestTx(ctx context.Context, tx pgx.Tx, ret model.ReturnRequest, item model.ReturnItem) (uuid.UUID, error) {
+
+	var deviceType string
+	var brand *string
+	query := `
+		SELECT COALESCE(c.name, 'lainnya'), p.brand
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.id = $1
+	`
+	err := tx.QueryRow(ctx, query, item.ProductID).Scan(&deviceType, &brand)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, err
+	}
+	if deviceType == "" {
+		deviceType = "lainnya"
+	}
+
+	problem := ret.Reason
+	if ret.InspectionNote != nil {
+		problem += "\n\nhasil inspeksi: " + *ret.InspectionNote
+	}
+
+	deviceModel := item.ProductName
+	sr := model.ServiceRequest{
+		UserID:             ret.UserID,
+		DeviceType:         deviceType,
+		DeviceBrand:        brand,
+		DeviceModel:        &deviceModel,
+		ProblemDescription: problem,
+	}
+	photos := []**string{&sr.Photo1, &sr.Photo2, &sr.Photo3}
+	for i := 0; i < len(photos) && i < len(ret.Photos); i++ {
+		photo := ret.Photos[i]
+		*photos[i] = &photo
+	}
+
+	if err := servicerequest.CreateTx(ctx, tx, &sr); err != nil {
+		return uuid.Nil, err
+	}
+	return sr.ID, nil
+}
+
+// returnStateError bedain retur yang ga ada sama yang statusnya ga cocok
+func returnStateError(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM returns WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrReturnNotFound
+	}
+	return ErrReturnInvalidState
+}
+
+const returnColumns = `id, order_id, user_id, status, reason, photos,
+	admin_note, reviewed_by, reviewed_at,
+	inbound_courier, inbound_tracking_number, shipped_at, received_at,
+	inspection_result, inspection_note, inspected_by, inspected_at,
+	resolution, refund_id, service_request_id, replacement_courier, replacement_tracking_number,
+	resolved_by, resolved_at, created_at, updated_at`
+
+type scannable interface {
+	Scan(dest ...any) error
+}
+
+func scanReturn(row scannable) (model.ReturnRequest, error) {
+	var ret model.ReturnRequest
+	err := row.Scan(
+		&ret.ID,
+		&ret.OrderID,
+		&ret.UserID,
+		&ret.Status,
+		&ret.Reason,
+		&ret.Photos,
+		&ret.AdminNote,
+		&ret.ReviewedBy,
+		&ret.ReviewedAt,
+		&ret.InboundCourier,
+		&ret.InboundTrackingNumber,
+		&ret.ShippedAt,
+		&ret.ReceivedAt,
+		&ret.InspectionResult,
+		&ret.InspectionNote,
+		&ret.InspectedBy,
+		&ret.InspectedAt,
+		&ret.Resolution,
+		&ret.RefundID,
+		&ret.ServiceRequestID,
+		&ret.ReplacementCourier,
+		&ret.ReplacementTrackingNumber,
+		&ret.ResolvedBy,
+		&ret.ResolvedAt,
+		&ret.CreatedAt,
+		&ret.UpdatedAt,
+	)
+	return ret, err
+}
@@ -0,0 +1,208 @@
+package rma
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/middleware"
+	"backEnd-RingoTechLife/internal/order"
+	"backEnd-RingoTechLife/internal/refund"
+	"backEnd-RingoTechLife/internal/storage"
+	"context"
+	"errors"
+	"mime/multipart"
+
+	"github.com/google/uuid"
+)
+
+const returnImagePlace = "returns"
+
+type ReturnService struct {
+	returnRepo  ReturnRepositoryInterface
+	fileStorage *storage.FileStorage
+}
+
+func NewReturnService(repo *ReturnRepositoryImpl, storage *storage.FileStorage) *ReturnService {
+	return &ReturnService{
+		returnRepo:  repo,
+		fileStorage: storage,
+	}
+}
+
+// Create nyimpen foto bukti cacat dulu baru ngajuin retur,
+// kalau gagal di database fotonya dihapus lagi
+func (s *ReturnService) Create(ctx context.Context, req dto.CreateReturnRequest, userId uuid.UUID) (model.ReturnRequest, *common.ErrorResponse) {
+
+	orderId, err := uuid.Parse(req.OrderId)
+	if err != nil {
+		return model.ReturnRequest{}, common.NewErrorResponse(400, "id order tidak valid!")
+	}
+
+	lines := make([]ReturnLine, 0, len(req.Items))
+	for _, item := range req.Items {
+		itemId, err := uuid.Parse(item.OrderItemId)
+		if err != nil {
+			return model.ReturnRequest{}, common.NewErrorResponse(400, "id item order tidak valid!")
+		}
+		lines = append(lines, ReturnLine{OrderItemID: itemId, Quantity: item.Quantity})
+	}
+
+	photos, appErr := s.processReturnImages(ctx, req.Photos)
+	if appErr != nil {
+		return model.ReturnRequest{}, appErr
+	}
+
+	ret := model.ReturnRequest{
+		OrderID: orderId,
+		UserID:  userId,
+		Reason:  req.Reason,
+		Photos:  photos,
+	}
+
+	data, err := s.returnRepo.Create(ctx, &ret, lines)
+	if err != nil {
+		s.fileStorage.DeleteAllPublicFile(photos, returnImagePlace)
+		return model.ReturnRequest{}, returnError(err)
+	}
+	return *data, nil
+}
+
+func (s *ReturnService) processReturnImages(ctx context.Context, files []*multipart.FileHeader) ([]string, *common.ErrorResponse) {
+
+	exts := make([]string, len(files))
+	for i, f := range files {
+		mimeType, err := s.fileStorage.DetectFileType(f)
+		if err != nil {
+			return nil, common.NewErrorResponse(500, "gagal memproses file! mungkin file tidak didukung")
+		}
+
+		ext, ok := s.fileStorage.IsTypeSupportted(mimeType)
+		if !ok {
+			return nil, common.NewErrorResponse(400, "format file tidak didukung!")
+		}
+		exts[i] = ext
+	}
+
+	saved, err := s.fileStorage.SaveAllPublicFiles(ctx, files, exts, returnImagePlace)
+	if err != nil {
+		s.fileStorage.DeleteAllPublicFile(saved, returnImagePlace)
+		return nil, common.NewErrorResponse(500, "gagal menyimpan foto retur ke server")
+	}
+	return saved, nil
+}
+
+func (s *ReturnService) GetById(ctx context.Context, id uuid.UUID, userId uuid.UUID, role string) (model.ReturnRequest, *common.ErrorResponse) {
+
+	data, err := s.returnRepo.GetByID(ctx, id)
+	if err != nil {
+		return model.ReturnRequest{}, returnError(err)
+	}
+
+	if role != middleware.RoleAdmin && data.UserID != userId {
+		return model.ReturnRequest{}, common.NewErrorResponse(404, ErrReturnNotFound.Error())
+	}
+	return data, nil
+}
+
+func (s *ReturnService) GetByUserId(ctx context.Context, userId uuid.UUID) ([]model.ReturnRequest, *common.ErrorResponse) {
+
+	data, err := s.returnRepo.GetByUserID(ctx, userId)
+	if err != nil {
+		return []model.ReturnRequest{}, common.NewErrorResponse(500, "gagal mengambil data retur! "+err.Error())
+	}
+	return data, nil
+}
+
+func (s *ReturnService) GetAll(ctx context.Context, status string) ([]model.ReturnRequest, *common.ErrorResponse) {
+
+	var filter *model.ReturnStatus
+	if status != "" {
+		st := model.ReturnStatus(status)
+		filter = &st
+	}
+
+	data, err := s.returnRepo.GetAll(ctx, filter)
+	if err != nil {
+		return []model.ReturnRequest{}, common.NewErrorResponse(500, "gagal mengambil data retur! "+err.Error())
+	}
+	return data, nil
+}
+
+func (s *ReturnService) Approve(ctx context.Context, id uuid.UUID, adminId uuid.UUID, note *string) *common.ErrorResponse {
+	if err := s.returnRepo.Approve(ctx, id, adminId, note); err != nil {
+		return returnError(err)
+	}
+	return nil
+}
+
+func (s *ReturnService) Reject(ctx context.Context, id uuid.UUID, adminId uuid.UUID, note string) *common.ErrorResponse {
+	if err := s.returnRepo.Reject(ctx, id, adminId, note); err != nil {
+		return returnError(err)
+	}
+	return nil
+}
+
+func (s *ReturnService) Cancel(ctx context.Context, id uuid.UUID, userId uuid.UUID) *common.ErrorResponse {
+	if err := s.returnRepo.Cancel(ctx, id, userId); err != nil {
+		return returnError(err)
+	}
+	return nil
+}
+
+func (s *ReturnService) MarkShipped(ctx context.Context, id uuid.UUID, userId uuid.UUID, req dto.ShipReturnRequest) *common.ErrorResponse {
+	if err := s.returnRepo.MarkShipped(ctx, id, userId, req.Courier, req.TrackingNumber); err != nil {
+		return returnError(err)
+	}
+	return nil
+}
+
+func (s *ReturnService) MarkReceived(ctx context.Context, id uuid.UUID) *common.ErrorResponse {
+	if err := s.returnRepo.MarkReceived(ctx, id); err != nil {
+		return returnError(err)
+	}
+	return nil
+}
+
+func (s *ReturnService) Inspect(ctx context.Context, id uuid.UUID, adminId uuid.UUID, req dto.InspectReturnRequest) *common.ErrorResponse {
+	result := model.ReturnInspectionResult(req.Result)
+	if err := s.returnRepo.Inspect(ctx, id, adminId, result, req.Note); err != nil {
+		return returnError(err)
+	}
+	return nil
+}
+
+func (s *ReturnService) Resolve(ctx context.Context, id uuid.UUID, adminId uuid.UUID, req dto.ResolveReturnRequest) (model.ReturnRequest, *common.ErrorResponse) {
+
+	data := ResolveData{
+		Resolution: model.ReturnResolution(req.Resolution),
+		Note:       req.Note,
+	}
+	if data.Resolution == model.ReturnResolutionReplacement {
+		data.ReplacementCourier = req.ReplacementCourier
+		data.ReplacementTrackingNumber = req.ReplacementTrackingNumber
+	}
+
+	if err := s.returnRepo.Resolve(ctx, id, adminId, data); err != nil {
+		return model.ReturnRequest{}, returnError(err)
+	}
+
+	ret, err := s.returnRepo.GetByID(ctx, id)
+	if err != nil {
+		return model.ReturnRequest{}, returnError(err)
+	}
+	return ret, nil
+}
+
+func returnError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, ErrReturnNotFound), errors.Is(err, order.ErrNoOrderFound):
+		return common.NewErrorResponse(404, err.Error())
+	case errors.Is(err, ErrReturnNotAllowed), errors.Is(err, ErrReturnInvalidState),
+		errors.Is(err, refund.ErrRefundNotAllowed), errors.Is(err, ErrReplacementOutOfStock):
+		return common.NewErrorResponse(409, err.Error())
+	case errors.Is(err, ErrReturnItemInvalid), errors.Is(err, ErrReturnNoDefect),
+		errors.Is(err, refund.ErrRefundExceedsPayment), errors.Is(err, refund.ErrRefundItemInvalid):
+		return common.NewErrorResponse(422, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di database "+err.Error())
+}
@@ -0,0 +1,435 @@
+package servicerequest
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrSlotUnavailable = errors.New("slot jadwal tidak tersedia, silakan pilih slot lain")
+var ErrSlotFull = errors.New("slot jadwal sudah penuh, silakan pilih slot lain")
+var ErrAppointmentExists = errors.New("request servis ini sudah punya jadwal aktif")
+var ErrAppointmentNotAllowed = errors.New("jadwal antar perangkat tidak bisa dibooking untuk request servis ini")
+var ErrAppointmentNotBooked = errors.New("request servis ini tidak punya jadwal aktif")
+var ErrHolidayNotFound = errors.New("tanggal libur tidak ditemukan")
+
+const dateLayout = "2006-01-02"
+const clockLayout = "15:04"
+
+// ─── SLOT ────────────────────────────────────────────────────────────────────
+
+// slotStarts semua slot di satu hari (jam lokal toko), kosong kalau tutup / libur
+func slotStarts(s model.AppointmentSettings, day time.Time) []time.Time {
+	loc := s.Location()
+	day = day.In(loc)
+
+	if slices.ContainsFunc(s.Holidays, func(h model.ServiceHoliday) bool {
+		return h.Date == day.Format(dateLayout)
+	}) {
+		return nil
+	}
+
+	i := slices.IndexFunc(s.OpeningHours, func(h model.ServiceOpeningHour) bool {
+		return h.Weekday == int(day.Weekday())
+	})
+	if i < 0 {
+		return nil
+	}
+
+	openAt, err1 := time.Parse(clockLayout, s.OpeningHours[i].OpenTime)
+	closeAt, err2 := time.Parse(clockLayout, s.OpeningHours[i].CloseTime)
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+
+	y, m, d := day.Date()
+	openTime := time.Date(y, m, d, openAt.Hour(), openAt.Minute(), 0, 0, loc)
+	closeTime := time.Date(y, m, d, closeAt.Hour(), closeAt.Minute(), 0, 0, loc)
+	length := time.Duration(s.SlotMinutes) * time.Minute
+
+	var starts []time.Time
+	for t := openTime; !t.Add(length).After(closeTime); t = t.Add(length) {
+		starts = append(starts, t)
+	}
+	return starts
+}
+
+// bookableDays range tanggal yang boleh dibooking: hari ini s/d BookingDaysAhead ke depan
+func bookableDays(s model.AppointmentSettings, now time.Time) (time.Time, time.Time) {
+	now = now.In(s.Location())
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, s.Location())
+	return today, today.AddDate(0, 0, s.BookingDaysAhead)
+}
+
+// bookableSlot mastiin start itu awal slot yang valid & belum lewat, balikin akhir slot nya
+func bookableSlot(s model.AppointmentSettings, start time.Time, now time.Time) (time.Time, bool) {
+	if !start.After(now) {
+		return time.Time{}, false
+	}
+
+	first, last := bookableDays(s, now)
+	local := start.In(s.Location())
+	y, m, d := local.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, s.Location())
+	if day.Before(first) || day.After(last) {
+		return time.Time{}, false
+	}
+
+	if !slices.ContainsFunc(slotStarts(s, day), start.Equal) {
+		return time.Time{}, false
+	}
+	return start.Add(time.Duration(s.SlotMinutes) * time.Minute), true
+}
+
+// ─── SETTINGS ────────────────────────────────────────────────────────────────
+
+func (r *ServiceRequestRepository) GetAppointmentSettings(ctx context.Context) (model.AppointmentSettings, error) {
+	var settings model.AppointmentSettings
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		settings, err = appointmentSettingsTx(ctx, tx, false)
+		return err
+	})
+	return settings, err
+}
+
+// appointmentSettingsTx lock = true dipake pas booking biar pengaturan nya
+// ga diganti di tengah jalan. libur yang udah lewat ga ikut diambil
+func appointmentSettingsTx(ctx context.Context, tx pgx.Tx, lock bool) (model.AppointmentSettings, error) {
+	query := `
+		SELECT slot_minutes, capacity_per_slot, booking_days_ahead, utc_offset_minutes
+		FROM service_appointment_settings`
+	if lock {
+		query += ` FOR SHARE`
+	}
+
+	var s model.AppointmentSettings
+	err := tx.QueryRow(ctx, query).Scan(&s.SlotMinutes, &s.CapacityPerSlot, &s.BookingDaysAhead, &s.UTCOffsetMinutes)
+	if err != nil {
+		return s, fmt.Errorf("failed to get appointment settings: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT weekday, to_char(open_time, 'HH24:MI'), to_char(close_time, 'HH24:MI')
+		FROM service_opening_hours
+		ORDER BY weekday`)
+	if err != nil {
+		return s, fmt.Errorf("failed to get opening hours: %w", err)
+	}
+	s.OpeningHours = make([]model.ServiceOpeningHour, 0)
+	for rows.Next() {
+		var h model.ServiceOpeningHour
+		if err := rows.Scan(&h.Weekday, &h.OpenTime, &h.CloseTime); err != nil {
+			rows.Close()
+			return s, fmt.Errorf("failed to scan opening hour: %w", err)
+		}
+		s.OpeningHours = append(s.OpeningHours, h)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return s, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT to_char(date, 'YYYY-MM-DD'), note
+		FROM service_holidays
+		WHERE date >= CURRENT_DATE - 1
+		ORDER BY date`)
+	if err != nil {
+		return s, fmt.Errorf("failed to get holidays: %w", err)
+	}
+	s.Holidays = make([]model.ServiceHoliday, 0)
+	for rows.Next() {
+		var h model.ServiceHoliday
+		if err := rows.Scan(&h.Date, &h.Note); err != nil {
+			rows.Close()
+			return s, fmt.Errorf("failed to scan holiday: %w", err)
+		}
+		s.Holidays = append(s.Holidays, h)
+	}
+	rows.Close()
+	return s, rows.Err()
+}
+
+// UpdateAppointmentSettings jam buka lama diganti semua sama yang baru.
+// booking yang udah ada ga diubah walaupun kapasitas / jam buka nya berubah
+func (r *ServiceRequestRepository) UpdateAppointmentSettings(ctx context.Context, s model.AppointmentSettings, adminID uuid.UUID) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			UPDATE service_appointment_settings SET
+				slot_minutes       = $1,
+				capacity_per_slot  = $2,
+				booking_days_ahead = $3,
+				utc_offset_minutes = $4,
+				updated_by         = $5,
+				updated_at         = NOW()
+		`, s.SlotMinutes, s.CapacityPerSlot, s.BookingDaysAhead, s.UTCOffsetMinutes, adminID)
+		if err != nil {
+			return fmt.Errorf("UpdateAppointmentSettings: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM service_opening_hours`); err != nil {
+			return fmt.Errorf("UpdateAppointmentSettings: %w", err)
+		}
+
+		for _, h := range s.OpeningHours {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO service_opening_hours (weekday, open_time, close_time)
+				VALUES ($1, $2::time, $3::time)
+			`, h.Weekday, h.OpenTime, h.CloseTime)
+			if err != nil {
+				return fmt.Errorf("failed to insert opening hour: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ServiceRequestRepository) AddHoliday(ctx context.Context, h model.ServiceHoliday) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO service_holidays (date, note) VALUES ($1::date, $2)
+		ON CONFLICT (date) DO UPDATE SET note = EXCLUDED.note
+	`, h.Date, h.Note)
+	if err != nil {
+		return fmt.Errorf("AddHoliday: %w", err)
+	}
+	return nil
+}
+
+func (r *ServiceRequestRepository) DeleteHoliday(ctx context.Context, date string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM service_holidays WHERE date = $1::date`, date)
+	if err != nil {
+		return fmt.Errorf("DeleteHoliday: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrHolidayNotFound
+	}
+	return nil
+}
+
+// GetSlotBookings jumlah booking aktif per slot di range [from, to)
+func (r *ServiceRequestRepository) GetSlotBookings(ctx context.Context, from time.Time, to time.Time) (map[time.Time]int, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT slot_start, booked
+		FROM service_appointment_slots
+		WHERE slot_start >= $1 AND slot_start < $2`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("GetSlotBookings: %w", err)
+	}
+	defer rows.Close()
+
+	booked := map[time.Time]int{}
+	for rows.Next() {
+		var start time.Time
+		var count int
+		if err := rows.Scan(&start, &count); err != nil {
+			return nil, fmt.Errorf("GetSlotBookings: %w", err)
+		}
+		booked[start.UTC()] = count
+	}
+	return booked, rows.Err()
+}
+
+// ─── BOOKING ─────────────────────────────────────────────────────────────────
+
+// BookAppointment booking jadwal antar perangkat buat request yang udah ada.
+// cuma bisa selama perangkat nya belum diterima toko
+func (r *ServiceRequestRepository) BookAppointment(ctx context.Context, id uuid.UUID, userID uuid.UUID, start time.Time) (model.ServiceAppointment, error) {
+	var appointment model.ServiceAppointment
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var status model.ServiceRequestStatus
+		var repairStatus *model.RepairStatus
+		err := tx.QueryRow(ctx, `
+			SELECT status, repair_status FROM service_requests WHERE id = $1 FOR UPDATE
+		`, id).Scan(&status, &repairStatus)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return notFoundError
+			}
+			return fmt.Errorf("BookAppointment: %w", err)
+		}
+
+		switch status {
+		case model.StatusPendingReview, model.StatusQuoted, model.StatusAccepted:
+		default:
+			return ErrAppointmentNotAllowed
+		}
+		if repairStatus != nil {
+			return ErrAppointmentNotAllowed
+		}
+
+		appointment, err = bookAppointmentTx(ctx, tx, id, userID, start)
+		return err
+	})
+	return appointment, err
+}
+
+// bookAppointmentTx request nya harus udah dikunci sama caller. booking lama
+// yang jadwal nya udah lewat tanpa perangkat diterima otomatis jadi no_show
+func bookAppointmentTx(ctx context.Context, tx pgx.Tx, requestID uuid.UUID, userID uuid.UUID, start time.Time) (model.ServiceAppointment, error) {
+	settings, err := appointmentSettingsTx(ctx, tx, true)
+	if err != nil {
+		return model.ServiceAppointment{}, err
+	}
+
+	end, ok := bookableSlot(settings, start, time.Now())
+	if !ok {
+		return model.ServiceAppointment{}, ErrSlotUnavailable
+	}
+
+	lapsed := "jadwal terlewat"
+	if _, err := closeAppointmentTx(ctx, tx, requestID, model.AppointmentNoShow, &lapsed, true); err != nil {
+		return model.ServiceAppointment{}, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM service_appointments
+			WHERE service_request_id = $1 AND status = 'booked'
+		)
+	`, requestID).Scan(&exists)
+	if err != nil {
+		return model.ServiceAppointment{}, fmt.Errorf("failed to check appointment: %w", err)
+	}
+	if exists {
+		return model.ServiceAppointment{}, ErrAppointmentExists
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO service_appointment_slots (slot_start) VALUES ($1) ON CONFLICT DO NOTHING
+	`, start)
+	if err != nil {
+		return model.ServiceAppointment{}, fmt.Errorf("failed to create slot: %w", err)
+	}
+
+	// row slot nya ke lock di sini, booking lain di slot yang sama nunggu giliran
+	tag, err := tx.Exec(ctx, `
+		UPDATE service_appointment_slots SET booked = booked + 1
+		WHERE slot_start = $1 AND booked < $2
+	`, start, settings.CapacityPerSlot)
+	if err != nil {
+		return model.ServiceAppointment{}, fmt.Errorf("failed to book slot: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ServiceAppointment{}, ErrSlotFull
+	}
+
+	a := model.ServiceAppointment{
+		ServiceRequestID: requestID,
+		UserID:           userID,
+		SlotStart:        start,
+		SlotEnd:          end,
+		Status:           model.AppointmentBooked,
+	}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO service_appointments (service_request_id, user_id, slot_start, slot_end)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, requestID, userID, start, end).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return model.ServiceAppointment{}, fmt.Errorf("failed to insert appointment: %w", err)
+	}
+	return a, nil
+}
+
+// CloseAppointment nutup booking aktif request nya (cancelled / completed / no_show)
+func (r *ServiceRequestRepository) CloseAppointment(ctx context.Context, id uuid.UUID, status model.AppointmentStatus, note *string) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		closed, err := closeAppointmentTx(ctx, tx, id, status, note, false)
+		if err != nil {
+			return err
+		}
+		if !closed {
+			return ErrAppointmentNotBooked
+		}
+		return nil
+	})
+}
+
+// closeAppointmentTx balikin false kalau ga ada booking aktif. selain completed,
+// kapasitas slot nya dilepas lagi. onlyLapsed = cuma booking yang jadwal nya udah lewat
+func closeAppointmentTx(ctx context.Context, tx pgx.Tx, requestID uuid.UUID, status model.AppointmentStatus, note *string, onlyLapsed bool) (bool, error) {
+	var start time.Time
+	err := tx.QueryRow(ctx, `
+		UPDATE service_appointments SET
+			status     = $1,
+			note       = COALESCE($2, note),
+			updated_at = NOW()
+		WHERE service_request_id = $3
+		  AND status = 'booked'
+		  AND (NOT $4 OR slot_end <= NOW())
+		RETURNING slot_start
+	`, status, note, requestID, onlyLapsed).Scan(&start)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to close appointment: %w", err)
+	}
+
+	if status == model.AppointmentCompleted {
+		return true, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE service_appointment_slots SET booked = booked - 1
+		WHERE slot_start = $1 AND booked > 0
+	`, start)
+	if err != nil {
+		return false, fmt.Errorf("failed to release slot: %w", err)
+	}
+	return true, nil
+}
+
+// ─── READ ────────────────────────────────────────────────────────────────────
+
+func (r *ServiceRequestRepository) GetAppointments(ctx context.Context, id uuid.UUID) ([]model.ServiceAppointment, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, service_request_id, user_id, slot_start, slot_end, status, note, created_at, updated_at
+		FROM service_appointments
+		WHERE service_request_id = $1
+		ORDER BY created_at ASC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetAppointments: %w", err)
+	}
+	defer rows.Close()
+	return collectAppointments(rows)
+}
+
+// GetAppointmentsBetween jadwal di range [from, to), buat admin nyiapin perangkat yang mau dateng
+func (r *ServiceRequestRepository) GetAppointmentsBetween(ctx context.Context, from time.Time, to time.Time) ([]model.ServiceAppointment, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, service_request_id, user_id, slot_start, slot_end, status, note, created_at, updated_at
+		FROM service_appointments
+		WHERE slot_start >= $1 AND slot_start < $2
+		ORDER BY slot_start ASC, created_at ASC`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("GetAppointmentsBetween: %w", err)
+	}
+	defer rows.Close()
+	return collectAppointments(rows)
+}
+
+func collectAppointments(rows pgx.Rows) ([]model.ServiceAppointment, error) {
+	appointments := make([]model.ServiceAppointment, 0)
+	for rows.Next() {
+		var a model.ServiceAppointment
+		err := rows.Scan(
+			&a.ID, &a.ServiceRequestID, &a.UserID, &a.SlotStart, &a.SlotEnd,
+			&a.Status, &a.Note, &a.CreatedAt, &a.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan appointment: %w", err)
+		}
+		appointments = append(appointments, a)
+	}
+	return appointments, rows.Err()
+}
@@ -0,0 +1,204 @@
+package servicerequest
+
+import (
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/middleware"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrTechnicianInvalid = errors.New("user yang dipilih bukan teknisi")
+var ErrAssignClosed = errors.New("request servis ini sudah selesai, tidak bisa di assign")
+var ErrAlreadyAssigned = errors.New("request servis sudah dipegang teknisi ini")
+var ErrNotAssigned = errors.New("request servis ini tidak di assign ke kamu!")
+
+// request servis yang masih jalan: belum ditolak / dibatalin dan perangkat nya belum diambil / dibalikin
+const openServiceCondition = `
+	sr.status NOT IN ('rejected_by_user', 'rejected_by_admin', 'cancelled')
+	AND (sr.repair_status IS NULL OR sr.repair_status NOT IN ('picked_up', 'returned'))
+`
+
+// checkAssignee dicek di dalam lock baris request nya, jadi teknisi yang barusan
+// dipindah ga bisa nyelip ngerjain. assignee nil = admin, bebas
+func checkAssignee(assignee *uuid.UUID, assignedTo *uuid.UUID) error {
+	if assignee == nil {
+		return nil
+	}
+	if assignedTo == nil || *assignedTo != *assignee {
+		return ErrNotAssigned
+	}
+	return nil
+}
+
+// Assign masangin / mindahin request servis ke teknisi, riwayat nya dicatat di transaksi yang sama
+func (r *ServiceRequestRepository) Assign(ctx context.Context, id uuid.UUID, technicianID uuid.UUID, adminID uuid.UUID, note *string) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var role string
+		err := tx.QueryRow(ctx, `SELECT role::text FROM users WHERE id = $1`, technicianID).Scan(&role)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrTechnicianInvalid
+			}
+			return fmt.Errorf("Assign: %w", err)
+		}
+		if role != middleware.RoleTechnician {
+			return ErrTechnicianInvalid
+		}
+
+		var from *uuid.UUID
+		var open bool
+		err = tx.QueryRow(ctx, `
+			SELECT sr.assigned_to, `+openServiceCondition+`
+			FROM service_requests sr
+			WHERE sr.id = $1
+			FOR UPDATE
+		`, id).Scan(&from, &open)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return notFoundError
+			}
+			return fmt.Errorf("Assign: %w", err)
+		}
+
+		if !open {
+			return ErrAssignClosed
+		}
+		if from != nil && *from == technicianID {
+			return ErrAlreadyAssigned
+		}
+
+		query := `
+			UPDATE service_requests SET
+				assigned_to = $1,
+				assigned_at = NOW(),
+				updated_at  = NOW()
+			WHERE id = $2`
+		if _, err := tx.Exec(ctx, query, technicianID, id); err != nil {
+			return fmt.Errorf("Assign: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO service_request_assignments
+				(service_request_id, from_technician_id, to_technician_id, assigned_by, note)
+			VALUES ($1, $2, $3, $4, $5)
+		`, id, from, technicianID, adminID, note)
+		if err != nil {
+			return fmt.Errorf("failed to insert assignment history: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *ServiceRequestRepository) GetAssignments(ctx context.Context, id uuid.UUID) ([]model.ServiceAssignment, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT a.id, a.service_request_id, a.from_technician_id, f.full_name,
+		       a.to_technician_id, t.full_name, a.assigned_by, b.full_name,
+		       a.note, a.created_at
+		FROM service_request_assignments a
+		LEFT JOIN users f ON f.id = a.from_technician_id
+		LEFT JOIN users t ON t.id = a.to_technician_id
+		LEFT JOIN users b ON b.id = a.assigned_by
+		WHERE a.service_request_id = $1
+		ORDER BY a.created_at ASC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetAssignments: %w", err)
+	}
+	defer rows.Close()
+
+	assignments := make([]model.ServiceAssignment, 0)
+	for rows.Next() {
+		var a model.ServiceAssignment
+		err := rows.Scan(
+			&a.ID, &a.ServiceRequestID, &a.FromTechnicianID, &a.FromTechnicianName,
+			&a.ToTechnicianID, &a.ToTechnicianName, &a.AssignedBy, &a.AssignedByName,
+			&a.Note, &a.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("GetAssignments: %w", err)
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+// GetByTechnician antrian teknisi: request yang masih jalan, yang paling lama masuk duluan
+func (r *ServiceRequestRepository) GetByTechnician(ctx context.Context, technicianID uuid.UUID) ([]*model.ServiceRequest, error) {
+	query := `
+        SELECT sr.id, sr.user_id, sr.device_type, sr.device_brand, sr.device_model,
+               sr.problem_description, sr.photo_1, sr.photo_2, sr.photo_3, sr.status,
+               sr.quoted_price, sr.estimated_duration, sr.admin_note, sr.quoted_by,
+               sr.order_id, sr.created_at, sr.updated_at, sr.quoted_at, sr.decided_at,
+               sr.repair_status, sr.received_at, sr.ready_at, sr.picked_up_at,
+               sr.assigned_to, t.full_name, sr.assigned_at,
+               jsonb_build_object(
+                   'id', u.id,
+                   'full_name', u.full_name,
+                   'email', u.email,
+                   'phone_number', u.phone_number,
+                   'role', u.role,
+                   'profile_picture', u.profile_picture,
+                   'created_at', u.created_at AT TIME ZONE 'UTC'
+               ) AS user_data
+        FROM service_requests sr
+        INNER JOIN users u ON u.id = sr.user_id
+        LEFT JOIN users t ON t.id = sr.assigned_to
+        WHERE sr.assigned_to = $1 AND ` + openServiceCondition + `
+        ORDER BY sr.created_at ASC`
+	rows, err := r.pool.Query(ctx, query, technicianID)
+	if err != nil {
+		return nil, fmt.Errorf("GetByTechnician: %w", err)
+	}
+	defer rows.Close()
+	return collectServiceRequests(rows)
+}
+
+// GetWorkload jumlah request yang masih jalan per teknisi dikelompokin per status,
+// teknisi yang lagi kosong tetep muncul dengan total 0
+func (r *ServiceRequestRepository) GetWorkload(ctx context.Context) ([]dto.TechnicianWorkload, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT u.id, u.full_name, COALESCE(sr.repair_status, sr.status::text), COUNT(sr.id)::int
+		FROM users u
+		LEFT JOIN service_requests sr ON sr.assigned_to = u.id AND `+openServiceCondition+`
+		WHERE u.role::text = $1
+		GROUP BY u.id, u.full_name, COALESCE(sr.repair_status, sr.status::text)
+		ORDER BY u.full_name`, middleware.RoleTechnician)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkload: %w", err)
+	}
+	defer rows.Close()
+
+	workloads := make([]dto.TechnicianWorkload, 0)
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		var id uuid.UUID
+		var name string
+		var status *string
+		var count int
+		if err := rows.Scan(&id, &name, &status, &count); err != nil {
+			return nil, fmt.Errorf("GetWorkload: %w", err)
+		}
+
+		i, ok := index[id]
+		if !ok {
+			i = len(workloads)
+			index[id] = i
+			workloads = append(workloads, dto.TechnicianWorkload{
+				TechnicianID:   id,
+				TechnicianName: name,
+				ByStatus:       map[string]int{},
+			})
+		}
+
+		// status nil = teknisi yang ga lagi megang request apa-apa
+		if status != nil {
+			workloads[i].ByStatus[*status] = count
+			workloads[i].Total += count
+		}
+	}
+	return workloads, rows.Err()
+}
@@ -140,13 +140,14 @@ func (sr *ServiceRequestHandler) QuoteServiceHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	qerr := sr.service.QuoteService(r.Context(), serviceId, req, userId)
+	role, _ := middleware.GetRole(r.Context())
+	quote, qerr := sr.service.QuoteService(r.Context(), serviceId, req, userId, role)
 	if qerr != nil {
-		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		pkg.JSONError(w, qerr.Code, qerr.Message)
 		return
 	}
 
-	pkg.JSONSuccess(w, 200, "Berhasil mengambil data", nil)
+	pkg.JSONSuccess(w, 200, "Penawaran berhasil dikirim", quote)
 }
 
 func (sr *ServiceRequestHandler) RejectServiceHandler(w http.ResponseWriter, r *http.Request) {
@@ -194,7 +195,7 @@ func (sr *ServiceRequestHandler) UserDecisionHandler(w http.ResponseWriter, r *h
 	}
 
 	if req.Accept {
-		if aerr := sr.service.AcceptServiceByUser(r.Context(), serviceId, userId, ""); aerr != nil {
+		if aerr := sr.service.AcceptServiceByUser(r.Context(), serviceId, userId, req.QuoteVersion, ""); aerr != nil {
 			pkg.JSONError(w, aerr.Code, aerr.Message)
 			return
 		}
@@ -202,13 +203,251 @@ func (sr *ServiceRequestHandler) UserDecisionHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	if rerr := sr.service.RejectServiceByUser(r.Context(), serviceId, userId); rerr != nil {
+	if rerr := sr.service.RejectServiceByUser(r.Context(), serviceId, userId, req.QuoteVersion); rerr != nil {
 		pkg.JSONError(w, rerr.Code, rerr.Message)
 		return
 	}
 	pkg.JSONSuccess(w, 200, "Penawaran berhasil ditolak", nil)
 }
 
+func (sr *ServiceRequestHandler) UpdateRepairStatusHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	userId, _ := middleware.GetUserID(r.Context())
+	serviceId, err := uuid.Parse(idStr)
+	if err != nil {
+		pkg.JSONError(w, 400, "ID tidak valid")
+		return
+	}
+	var req dto.UpdateRepairStatusDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "Body tidak valid! harap masukan data dengan benar")
+		return
+	}
+	if err := sr.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+	role, _ := middleware.GetRole(r.Context())
+	status, uerr := sr.service.UpdateRepairStatus(r.Context(), serviceId, req, userId, role)
+	if uerr != nil {
+		pkg.JSONError(w, uerr.Code, uerr.Message)
+		return
+	}
+
+	data := map[string]any{"repair_status": status}
+	if string(status) != req.Status {
+		pkg.JSONSuccess(w, 200, "Stok sparepart kurang, perbaikan dipindah ke menunggu sparepart", data)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Status perbaikan berhasil diubah", data)
+}
+
+func (sr *ServiceRequestHandler) AssignTechnicianHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	adminId, _ := middleware.GetUserID(r.Context())
+	serviceId, err := uuid.Parse(idStr)
+	if err != nil {
+		pkg.JSONError(w, 400, "ID tidak valid")
+		return
+	}
+	var req dto.AssignTechnicianDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "Body tidak valid! harap masukan data dengan benar")
+		return
+	}
+	if err := sr.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+	if aerr := sr.service.AssignTechnician(r.Context(), serviceId, req, adminId); aerr != nil {
+		pkg.JSONError(w, aerr.Code, aerr.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Teknisi berhasil di assign", nil)
+}
+
+func (sr *ServiceRequestHandler) GetTechnicianQueueHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "technicianId")
+	technicianId, err := uuid.Parse(idStr)
+	if err != nil {
+		pkg.JSONError(w, 400, "ID tidak valid")
+		return
+	}
+
+	data, getErr := sr.service.GetTechnicianQueue(r.Context(), technicianId)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Berhasil mengambil data", data)
+}
+
+func (sr *ServiceRequestHandler) GetMyQueueHandler(w http.ResponseWriter, r *http.Request) {
+	userId, _ := middleware.GetUserID(r.Context())
+
+	data, err := sr.service.GetTechnicianQueue(r.Context(), userId)
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Berhasil mengambil data", data)
+}
+
+func (sr *ServiceRequestHandler) GetWorkloadHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := sr.service.GetWorkload(r.Context())
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Berhasil mengambil data", data)
+}
+
+func (sr *ServiceRequestHandler) GetAvailableSlotsHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := sr.service.GetAvailableSlots(r.Context(), r.URL.Query().Get("date"))
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Berhasil mengambil data", data)
+}
+
+func (sr *ServiceRequestHandler) BookAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	userId, _ := middleware.GetUserID(r.Context())
+	idStr := chi.URLParam(r, "id")
+	serviceId, err := uuid.Parse(idStr)
+	if err != nil {
+		pkg.JSONError(w, 400, "ID tidak valid")
+		return
+	}
+	var req dto.BookAppointmentDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "Body tidak valid! harap masukan data dengan benar")
+		return
+	}
+	if err := sr.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	data, berr := sr.service.BookAppointment(r.Context(), serviceId, userId, req)
+	if berr != nil {
+		pkg.JSONError(w, berr.Code, berr.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Jadwal berhasil dibooking", data)
+}
+
+func (sr *ServiceRequestHandler) CancelAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	userId, _ := middleware.GetUserID(r.Context())
+	role, _ := middleware.GetRole(r.Context())
+	idStr := chi.URLParam(r, "id")
+	serviceId, err := uuid.Parse(idStr)
+	if err != nil {
+		pkg.JSONError(w, 400, "ID tidak valid")
+		return
+	}
+
+	if cerr := sr.service.CancelAppointment(r.Context(), serviceId, userId, role); cerr != nil {
+		pkg.JSONError(w, cerr.Code, cerr.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Jadwal berhasil dibatalkan", nil)
+}
+
+func (sr *ServiceRequestHandler) UpdateAppointmentStatusHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	serviceId, err := uuid.Parse(idStr)
+	if err != nil {
+		pkg.JSONError(w, 400, "ID tidak valid")
+		return
+	}
+	var req dto.UpdateAppointmentStatusDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "Body tidak valid! harap masukan data dengan benar")
+		return
+	}
+	if err := sr.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	if uerr := sr.service.UpdateAppointmentStatus(r.Context(), serviceId, req); uerr != nil {
+		pkg.JSONError(w, uerr.Code, uerr.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Status jadwal berhasil diubah", nil)
+}
+
+func (sr *ServiceRequestHandler) GetAppointmentsHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := sr.service.GetAppointmentsByDate(r.Context(), r.URL.Query().Get("date"))
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Berhasil mengambil data", data)
+}
+
+func (sr *ServiceRequestHandler) GetAppointmentSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := sr.service.GetAppointmentSettings(r.Context())
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Berhasil mengambil data", data)
+}
+
+func (sr *ServiceRequestHandler) UpdateAppointmentSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	adminId, _ := middleware.GetUserID(r.Context())
+	var req dto.AppointmentSettingsDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "Body tidak valid! harap masukan data dengan benar")
+		return
+	}
+	if err := sr.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	data, uerr := sr.service.UpdateAppointmentSettings(r.Context(), req, adminId)
+	if uerr != nil {
+		pkg.JSONError(w, uerr.Code, uerr.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Pengaturan jadwal berhasil disimpan", data)
+}
+
+func (sr *ServiceRequestHandler) AddHolidayHandler(w http.ResponseWriter, r *http.Request) {
+	var req dto.HolidayDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "Body tidak valid! harap masukan data dengan benar")
+		return
+	}
+	if err := sr.validator.Struct(req); err != nil {
+		validationErr := pkg.ValidationErrorsToMap(err)
+		pkg.JSONError(w, 400, validationErr)
+		return
+	}
+
+	if aerr := sr.service.AddHoliday(r.Context(), req); aerr != nil {
+		pkg.JSONError(w, aerr.Code, aerr.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Tanggal libur berhasil disimpan", nil)
+}
+
+func (sr *ServiceRequestHandler) DeleteHolidayHandler(w http.ResponseWriter, r *http.Request) {
+	if derr := sr.service.DeleteHoliday(r.Context(), chi.URLParam(r, "date")); derr != nil {
+		pkg.JSONError(w, derr.Code, derr.Message)
+		return
+	}
+	pkg.JSONSuccess(w, 200, "Tanggal libur berhasil dihapus", nil)
+}
+
 func (sr *ServiceRequestHandler) SetUpRoute(router chi.Router) {
 
 	router.Route("/device-service", func(r chi.Router) {
@@ -228,17 +467,38 @@ func (sr *ServiceRequestHandler) SetUpRoute(router chi.Router) {
 			}),
 		))
 		r.Use(middleware.AuthMiddleware)
-		r.Use(middleware.RoleMiddleware(middleware.RoleAdmin, middleware.RoleUser))
+		r.Use(middleware.RoleMiddleware(middleware.RoleAdmin, middleware.RoleUser, middleware.RoleTechnician))
 
-		r.Get("/get-my-service", sr.GetMyServiceHistoryHandler)
+		// akses per request nya dicek lagi di service (punya sendiri / di assign ke teknisi nya)
 		r.Get("/details/{id}", sr.GetDetails)
-		r.Put("/status-service/{id}", sr.UserDecisionHandler)
-		r.Post("/new", sr.CreateHandler)
+		r.Get("/appointment/slots", sr.GetAvailableSlotsHandler)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware(middleware.RoleAdmin, middleware.RoleUser))
+			r.Get("/get-my-service", sr.GetMyServiceHistoryHandler)
+			r.Put("/status-service/{id}", sr.UserDecisionHandler)
+			r.Post("/new", sr.CreateHandler)
+			r.Post("/appointment/{id}", sr.BookAppointmentHandler)
+			r.Delete("/appointment/{id}", sr.CancelAppointmentHandler)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware(middleware.RoleAdmin, middleware.RoleTechnician))
+			r.Get("/my-queue", sr.GetMyQueueHandler)
+			r.Post("/quote-service/{id}", sr.QuoteServiceHandler)
+			r.Put("/repair-status/{id}", sr.UpdateRepairStatusHandler)
+		})
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RoleMiddleware(middleware.RoleAdmin))
 			r.Get("/get-all", sr.GetAllHandler)
-			r.Post("/quote-service/{id}", sr.QuoteServiceHandler)
 			r.Put("/admin-reject/{id}", sr.RejectServiceHandler)
+			r.Put("/assign/{id}", sr.AssignTechnicianHandler)
+			r.Get("/queue/{technicianId}", sr.GetTechnicianQueueHandler)
+			r.Get("/workload", sr.GetWorkloadHandler)
+			r.Get("/appointments", sr.GetAppointmentsHandler)
+			r.Put("/appointment-status/{id}", sr.UpdateAppointmentStatusHandler)
+			r.Get("/appointment/settings", sr.GetAppointmentSettingsHandler)
+			r.Put("/appointment/settings", sr.UpdateAppointmentSettingsHandler)
+			r.Post("/appointment/holidays", sr.AddHolidayHandler)
+			r.Delete("/appointment/holidays/{date}", sr.DeleteHolidayHandler)
 		})
 	})
 }
@@ -0,0 +1,244 @@
+package servicerequest
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/inventory"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrPartsUnavailable = errors.New("stok sparepart belum cukup, perbaikan masih menunggu sparepart")
+
+// stok sparepart di reserve di level produk, jadi produk bervarian (stok nya jumlah
+// stok varian) atau bundle (stok nya dari komponen) ga bisa dipake sebagai sparepart
+const unsupportedPartCondition = `
+	(products.is_bundle OR EXISTS(SELECT 1 FROM product_variants WHERE product_id = products.id))`
+
+func (r *ServiceRequestRepository) GetParts(ctx context.Context, id uuid.UUID) ([]model.ServiceRequestPart, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT sp.id, sp.service_request_id, sp.quote_id, sp.quote_item_id, sp.product_id,
+		       p.name, sp.quantity, sp.status, sp.created_at, sp.updated_at, sp.committed_at
+		FROM service_request_parts sp
+		JOIN products p ON p.id = sp.product_id
+		WHERE sp.service_request_id = $1
+		ORDER BY sp.created_at ASC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetParts: %w", err)
+	}
+	defer rows.Close()
+
+	parts := make([]model.ServiceRequestPart, 0)
+	for rows.Next() {
+		var p model.ServiceRequestPart
+		err := rows.Scan(
+			&p.ID, &p.ServiceRequestID, &p.QuoteID, &p.QuoteItemID, &p.ProductID,
+			&p.ProductName, &p.Quantity, &p.Status, &p.CreatedAt, &p.UpdatedAt, &p.CommittedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("GetParts: %w", err)
+		}
+		parts = append(parts, p)
+	}
+	return parts, rows.Err()
+}
+
+// reserveQuotePartsTx nge-reserve stok buat baris part revisi penawaran yang nyambung
+// ke produk. stok kurang ga bikin penawaran nya gagal, part nya dicatat waiting dulu.
+// balikin true kalau ada part yang masih nunggu stok
+func reserveQuotePartsTx(ctx context.Context, tx pgx.Tx, requestID uuid.UUID, quote model.ServiceQuote) (bool, error) {
+	var items []model.ServiceQuoteItem
+	for _, it := range quote.Items {
+		if it.ItemType == model.QuoteItemPart && it.ProductID != nil {
+			items = append(items, it)
+		}
+	}
+
+	// urut per produk biar urutan lock nya sama kaya alur stok lain
+	slices.SortFunc(items, func(a, b model.ServiceQuoteItem) int {
+		return slices.Compare(a.ProductID[:], b.ProductID[:])
+	})
+
+	waiting := false
+	for _, it := range items {
+		status, err := reservePartTx(ctx, tx, *it.ProductID, it.Quantity)
+		if err != nil {
+			return false, err
+		}
+		if status == model.PartStatusWaiting {
+			waiting = true
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO service_request_parts (service_request_id, quote_id, quote_item_id, product_id, quantity, status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, requestID, quote.ID, it.ID, *it.ProductID, it.Quantity, status)
+		if err != nil {
+			return false, fmt.Errorf("failed to insert service part: %w", err)
+		}
+	}
+	return waiting, nil
+}
+
+// reserveWaitingPartsTx nyoba reserve lagi part yang masih waiting (misal stok baru masuk).
+// balikin true kalau masih ada part yang stok nya kurang
+func reserveWaitingPartsTx(ctx context.Context, tx pgx.Tx, requestID uuid.UUID) (bool, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, product_id, quantity
+		FROM service_request_parts
+		WHERE service_request_id = $1 AND status = 'waiting'
+		ORDER BY product_id
+		FOR UPDATE
+	`, requestID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get waiting parts: %w", err)
+	}
+
+	var parts []model.ServiceRequestPart
+	for rows.Next() {
+		var p model.ServiceRequestPart
+		if err := rows.Scan(&p.ID, &p.ProductID, &p.Quantity); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("failed to scan waiting part: %w", err)
+		}
+		parts = append(parts, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	waiting := false
+	for _, p := range parts {
+		status, err := reservePartTx(ctx, tx, p.ProductID, p.Quantity)
+		if err != nil {
+			return false, err
+		}
+		if status == model.PartStatusWaiting {
+			waiting = true
+			continue
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE service_request_parts SET status = 'reserved', updated_at = NOW() WHERE id = $1
+		`, p.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to update service part: %w", err)
+		}
+	}
+	return waiting, nil
+}
+
+// reservePartTx waiting kalau stok produk nya ga cukup, selain itu reserved.
+// produk nya dicek ulang karena bisa aja dapet varian / jadi bundle setelah ditawarin
+func reservePartTx(ctx context.Context, tx pgx.Tx, productID uuid.UUID, quantity int) (model.PartStatus, error) {
+	var unsupported bool
+	err := tx.QueryRow(ctx, `SELECT `+unsupportedPartCondition+` FROM products WHERE id = $1`, productID).Scan(&unsupported)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("failed to check part product: %w", err)
+	}
+	if unsupported {
+		return "", ErrQuotePartUnsupported
+	}
+
+	err = inventory.ReserveTx(ctx, tx, []inventory.Line{{ProductID: productID, Quantity: quantity}})
+	if err != nil {
+		if _, ok := errors.AsType[*inventory.ShortageError](err); ok {
+			return model.PartStatusWaiting, nil
+		}
+		return "", err
+	}
+	return model.PartStatusReserved, nil
+}
+
+// releasePartsTx ngelepas part yang masih kebuka (waiting / reserved), reserved_stock
+// produk nya dibalikin. quoteID != nil cuma part dari revisi itu, exceptQuoteID != nil
+// semua part kecuali dari revisi itu, dua-duanya nil berarti semua part request nya
+func releasePartsTx(ctx context.Context, tx pgx.Tx, requestID uuid.UUID, quoteID *uuid.UUID, exceptQuoteID *uuid.UUID) error {
+	rows, err := tx.Query(ctx, `
+		WITH target AS (
+			SELECT id, status
+			FROM service_request_parts
+			WHERE service_request_id = $1
+			  AND status IN ('waiting', 'reserved')
+			  AND ($2::uuid IS NULL OR quote_id = $2)
+			  AND ($3::uuid IS NULL OR quote_id <> $3)
+			FOR UPDATE
+		)
+		UPDATE service_request_parts sp
+		SET status = 'released', updated_at = NOW()
+		FROM target
+		WHERE sp.id = target.id
+		RETURNING sp.product_id, sp.quantity, target.status
+	`, requestID, quoteID, exceptQuoteID)
+	if err != nil {
+		return fmt.Errorf("failed to release service parts: %w", err)
+	}
+
+	var lines []inventory.Line
+	for rows.Next() {
+		var l inventory.Line
+		var status model.PartStatus
+		if err := rows.Scan(&l.ProductID, &l.Quantity, &status); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan released part: %w", err)
+		}
+		if status == model.PartStatusReserved {
+			lines = append(lines, l)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return inventory.ReleaseTx(ctx, tx, lines)
+}
+
+// commitPartsTx perbaikan selesai: part yang di reserve dipotong dari stok
+// dan dicatat di ledger. gagal kalau masih ada part yang nunggu stok
+func commitPartsTx(ctx context.Context, tx pgx.Tx, requestID uuid.UUID, actorID uuid.UUID) error {
+	var waiting bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM service_request_parts
+			WHERE service_request_id = $1 AND status = 'waiting'
+		)
+	`, requestID).Scan(&waiting)
+	if err != nil {
+		return fmt.Errorf("failed to check waiting parts: %w", err)
+	}
+	if waiting {
+		return ErrPartsUnavailable
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE service_request_parts
+		SET status = 'committed', committed_at = NOW(), updated_at = NOW()
+		WHERE service_request_id = $1 AND status = 'reserved'
+		RETURNING product_id, quantity
+	`, requestID)
+	if err != nil {
+		return fmt.Errorf("failed to commit service parts: %w", err)
+	}
+
+	var lines []inventory.Line
+	for rows.Next() {
+		var l inventory.Line
+		if err := rows.Scan(&l.ProductID, &l.Quantity); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan committed part: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return inventory.CommitTx(ctx, tx, lines, inventory.RefServiceRequest, requestID, &actorID)
+}
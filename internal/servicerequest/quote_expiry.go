@@ -0,0 +1,102 @@
+package servicerequest
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// quoteValidity lama penawaran nunggu keputusan user. sparepart nya udah di
+// reserve dari penawaran dibikin, jadi ga boleh ditahan selamanya
+const quoteValidity = 3 * 24 * time.Hour
+
+// GetExpiredQuoteIDs penawaran pending yang udah lewat expires_at, yang ada di
+// skip (gagal diproses sebelumnya) dilewatin
+func (r *ServiceRequestRepository) GetExpiredQuoteIDs(ctx context.Context, limit int, skip []uuid.UUID) ([]uuid.UUID, error) {
+	// nil slice kekirim jadi NULL, "id <> ALL(NULL)" ga bakal pernah true
+	skipped := append(make([]uuid.UUID, 0, len(skip)), skip...)
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id FROM service_quotes
+		WHERE status = 'pending' AND expires_at <= NOW() AND id <> ALL($1::uuid[])
+		ORDER BY expires_at ASC
+		LIMIT $2
+	`, skipped, limit)
+	if err != nil {
+		return nil, fmt.Errorf("GetExpiredQuoteIDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("GetExpiredQuoteIDs: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ExpireQuote nutup penawaran pending yang udah kadaluarsa, sparepart nya dilepas.
+// request yang belum pernah acc penawaran balik ke pending_review biar admin bisa
+// kirim penawaran baru, revisi dari penawaran yang udah di acc balik ke revisi
+// terakhir yang di acc (sama kayak ditolak user). penawaran yang keburu
+// diputusin user dilewatin aja
+func (r *ServiceRequestRepository) ExpireQuote(ctx context.Context, quoteID uuid.UUID) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var id uuid.UUID
+		err := tx.QueryRow(ctx, `SELECT service_request_id FROM service_quotes WHERE id = $1`, quoteID).Scan(&id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return fmt.Errorf("ExpireQuote: %w", err)
+		}
+
+		// urutan kunci nya sama kayak lockPendingQuoteTx: request dulu baru penawaran
+		var status model.ServiceRequestStatus
+		var orderID *uuid.UUID
+		err = tx.QueryRow(ctx, `
+			SELECT status, order_id FROM service_requests WHERE id = $1 FOR UPDATE
+		`, id).Scan(&status, &orderID)
+		if err != nil {
+			return fmt.Errorf("ExpireQuote: %w", err)
+		}
+
+		now := time.Now()
+		tag, err := tx.Exec(ctx, `
+			UPDATE service_quotes SET status = 'expired', decided_at = $1
+			WHERE id = $2 AND status = 'pending' AND expires_at <= $1
+		`, now, quoteID)
+		if err != nil {
+			return fmt.Errorf("ExpireQuote: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+
+		if err := releasePartsTx(ctx, tx, id, &quoteID, nil); err != nil {
+			return err
+		}
+
+		if status != model.StatusQuoted {
+			return nil
+		}
+		if orderID == nil {
+			_, err := tx.Exec(ctx, `
+				UPDATE service_requests SET status = 'pending_review', updated_at = $1 WHERE id = $2
+			`, now, id)
+			if err != nil {
+				return fmt.Errorf("ExpireQuote: %w", err)
+			}
+			return nil
+		}
+		return restoreAcceptedQuoteTx(ctx, tx, id, now)
+	})
+}
@@ -3,6 +3,7 @@ package servicerequest
 import (
 	"backEnd-RingoTechLife/internal/common/dto"
 	"backEnd-RingoTechLife/internal/common/model"
+	"backEnd-RingoTechLife/internal/order"
 	"context"
 	"encoding/json"
 	"errors"
@@ -15,18 +16,53 @@ import (
 )
 
 var notFoundError = errors.New("Data tidak ditemukan!")
+var ErrServiceNotAccepted = errors.New("penawaran servis belum diterima user, perbaikan belum bisa dimulai")
+var ErrInvalidRepairTransition = errors.New("status perbaikan tidak bisa diubah ke status ini")
+var ErrRepairUnpaid = errors.New("biaya servis belum dibayar, perangkat belum bisa diambil")
+var ErrServiceOrderCancelled = errors.New("order servis sudah dibatalkan, kirim revisi penawaran dulu")
+var ErrQuoteNotAllowed = errors.New("request servis ini tidak bisa diberi penawaran")
+var ErrQuoteNotPending = errors.New("tidak ada penawaran yang menunggu keputusan")
+var ErrQuoteVersionMismatch = errors.New("revisi penawaran sudah diganti, cek penawaran terbaru")
+var ErrQuoteLocked = errors.New("biaya servis sudah dibayar atau perbaikan sudah selesai, penawaran tidak bisa direvisi")
+var ErrQuoteProductInvalid = errors.New("produk sparepart tidak ditemukan")
+var ErrQuotePartUnsupported = errors.New("produk bervarian atau bundle tidak bisa dipakai sebagai sparepart")
+var ErrQuoteExpired = errors.New("penawaran sudah kadaluarsa, tunggu penawaran baru dari toko")
 
 type ServiceRequestRepositoryInterface interface {
-	Create(ctx context.Context, req *model.ServiceRequest) error
+	Create(ctx context.Context, req *model.ServiceRequest, appointmentAt *time.Time) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.ServiceRequest, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.ServiceRequest, error)
 	GetAll(ctx context.Context) ([]*model.ServiceRequest, error) // untuk admin
 
-	AdminQuote(ctx context.Context, id uuid.UUID, dto *dto.AdminQuoteServiceRequestDTO, adminID uuid.UUID) error
+	AdminQuote(ctx context.Context, id uuid.UUID, quote *model.ServiceQuote, adminID uuid.UUID, assignee *uuid.UUID) error
 	AdminReject(ctx context.Context, id uuid.UUID, dto *dto.AdminRejectServiceRequestDTO, adminID uuid.UUID) error
-	UserAccept(ctx context.Context, id uuid.UUID, orderID uuid.UUID) error
-	UserReject(ctx context.Context, id uuid.UUID) error
+	UserAccept(ctx context.Context, id uuid.UUID, version int, userID uuid.UUID, notes string, expiresAt time.Time) error
+	UserReject(ctx context.Context, id uuid.UUID, version int) error
 	Cancel(ctx context.Context, id uuid.UUID) error
+
+	UpdateRepairStatus(ctx context.Context, id uuid.UUID, to model.RepairStatus, actorID uuid.UUID, note *string, assignee *uuid.UUID) (model.RepairStatus, error)
+	GetStatusHistory(ctx context.Context, id uuid.UUID) ([]model.ServiceStatusHistory, error)
+
+	GetQuotes(ctx context.Context, id uuid.UUID) ([]model.ServiceQuote, error)
+	GetPartProduct(ctx context.Context, productID uuid.UUID) (string, model.Money, error)
+	GetParts(ctx context.Context, id uuid.UUID) ([]model.ServiceRequestPart, error)
+	GetExpiredQuoteIDs(ctx context.Context, limit int, skip []uuid.UUID) ([]uuid.UUID, error)
+	ExpireQuote(ctx context.Context, quoteID uuid.UUID) error
+
+	Assign(ctx context.Context, id uuid.UUID, technicianID uuid.UUID, adminID uuid.UUID, note *string) error
+	GetAssignments(ctx context.Context, id uuid.UUID) ([]model.ServiceAssignment, error)
+	GetByTechnician(ctx context.Context, technicianID uuid.UUID) ([]*model.ServiceRequest, error)
+	GetWorkload(ctx context.Context) ([]dto.TechnicianWorkload, error)
+
+	GetAppointmentSettings(ctx context.Context) (model.AppointmentSettings, error)
+	UpdateAppointmentSettings(ctx context.Context, s model.AppointmentSettings, adminID uuid.UUID) error
+	AddHoliday(ctx context.Context, h model.ServiceHoliday) error
+	DeleteHoliday(ctx context.Context, date string) error
+	GetSlotBookings(ctx context.Context, from time.Time, to time.Time) (map[time.Time]int, error)
+	BookAppointment(ctx context.Context, id uuid.UUID, userID uuid.UUID, start time.Time) (model.ServiceAppointment, error)
+	CloseAppointment(ctx context.Context, id uuid.UUID, status model.AppointmentStatus, note *string) error
+	GetAppointments(ctx context.Context, id uuid.UUID) ([]model.ServiceAppointment, error)
+	GetAppointmentsBetween(ctx context.Context, from time.Time, to time.Time) ([]model.ServiceAppointment, error)
 }
 
 type ServiceRequestRepository struct {
@@ -45,6 +81,8 @@ func (r *ServiceRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*
                sr.problem_description, sr.photo_1, sr.photo_2, sr.photo_3, sr.status,
                sr.quoted_price, sr.estimated_duration, sr.admin_note, sr.quoted_by,
                sr.order_id, sr.created_at, sr.updated_at, sr.quoted_at, sr.decided_at,
+               sr.repair_status, sr.received_at, sr.ready_at, sr.picked_up_at,
+               sr.assigned_to, t.full_name, sr.assigned_at,
                jsonb_build_object(
                    'id', u.id,
                    'full_name', u.full_name,
@@ -56,6 +94,7 @@ func (r *ServiceRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*
                ) AS user_data
         FROM service_requests sr
         INNER JOIN users u ON u.id = sr.user_id
+        LEFT JOIN users t ON t.id = sr.assigned_to
         WHERE sr.id = $1`
 	row := r.pool.QueryRow(ctx, query, id)
 	sr, err := scanServiceRequest(row)
@@ -74,6 +113,8 @@ func (r *ServiceRequestRepository) GetByUserID(ctx context.Context, userID uuid.
                sr.problem_description, sr.photo_1, sr.photo_2, sr.photo_3, sr.status,
                sr.quoted_price, sr.estimated_duration, sr.admin_note, sr.quoted_by,
                sr.order_id, sr.created_at, sr.updated_at, sr.quoted_at, sr.decided_at,
+               sr.repair_status, sr.received_at, sr.ready_at, sr.picked_up_at,
+               sr.assigned_to, t.full_name, sr.assigned_at,
                jsonb_build_object(
                    'id', u.id,
                    'full_name', u.full_name,
@@ -85,6 +126,7 @@ func (r *ServiceRequestRepository) GetByUserID(ctx context.Context, userID uuid.
                ) AS user_data
         FROM service_requests sr
         INNER JOIN users u ON u.id = sr.user_id
+        LEFT JOIN users t ON t.id = sr.assigned_to
         WHERE sr.user_id = $1
         ORDER BY sr.created_at DESC`
 	rows, err := r.pool.Query(ctx, query, userID)
@@ -101,6 +143,8 @@ func (r *ServiceRequestRepository) GetAll(ctx context.Context) ([]*model.Service
                sr.problem_description, sr.photo_1, sr.photo_2, sr.photo_3, sr.status,
                sr.quoted_price, sr.estimated_duration, sr.admin_note, sr.quoted_by,
                sr.order_id, sr.created_at, sr.updated_at, sr.quoted_at, sr.decided_at,
+               sr.repair_status, sr.received_at, sr.ready_at, sr.picked_up_at,
+               sr.assigned_to, t.full_name, sr.assigned_at,
                jsonb_build_object(
                    'id', u.id,
                    'full_name', u.full_name,
@@ -112,6 +156,7 @@ func (r *ServiceRequestRepository) GetAll(ctx context.Context) ([]*model.Service
                ) AS user_data
         FROM service_requests sr
         INNER JOIN users u ON u.id = sr.user_id
+        LEFT JOIN users t ON t.id = sr.assigned_to
         ORDER BY sr.created_at DESC`
 	rows, err := r.pool.Query(ctx, query)
 	if err != nil {
@@ -123,7 +168,29 @@ func (r *ServiceRequestRepository) GetAll(ctx context.Context) ([]*model.Service
 
 // ─── CREATE ──────────────────────────────────────────────────────────────────
 
-func (r *ServiceRequestRepository) Create(ctx context.Context, req *model.ServiceRequest) error {
+// Create appointmentAt != nil sekalian booking jadwal antar perangkat, slot penuh
+// bikin request nya ikut batal biar user milih slot lain
+func (r *ServiceRequestRepository) Create(ctx context.Context, req *model.ServiceRequest, appointmentAt *time.Time) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := CreateTx(ctx, tx, req); err != nil {
+			return err
+		}
+		if appointmentAt == nil {
+			return nil
+		}
+
+		appointment, err := bookAppointmentTx(ctx, tx, req.ID, req.UserID, *appointmentAt)
+		if err != nil {
+			return err
+		}
+		req.Appointments = []model.ServiceAppointment{appointment}
+		return nil
+	})
+}
+
+// CreateTx insert service request di transaksi yang udah jalan,
+// dipake modul retur buat ngubah retur jadi request servis
+func CreateTx(ctx context.Context, tx pgx.Tx, req *model.ServiceRequest) error {
 	query := `
 		INSERT INTO service_requests (
 			id, user_id, device_type, device_brand, device_model,
@@ -133,9 +200,10 @@ func (r *ServiceRequestRepository) Create(ctx context.Context, req *model.Servic
 		)`
 
 	req.ID = uuid.New()
-	_, err := r.pool.Exec(ctx, query,
+	req.Status = model.StatusPendingReview
+	_, err := tx.Exec(ctx, query,
 		req.ID, req.UserID, req.DeviceType, req.DeviceBrand, req.DeviceModel,
-		req.ProblemDescription, req.Photo1, req.Photo2, req.Photo3, model.StatusPendingReview,
+		req.ProblemDescription, req.Photo1, req.Photo2, req.Photo3, req.Status,
 	)
 	if err != nil {
 		return fmt.Errorf("Create: %w", err)
@@ -145,8 +213,105 @@ func (r *ServiceRequestRepository) Create(ctx context.Context, req *model.Servic
 
 // ─── STATUS TRANSITIONS (semua pakai TX) ─────────────────────────────────────
 
-func (r *ServiceRequestRepository) AdminQuote(ctx context.Context, id uuid.UUID, d *dto.AdminQuoteServiceRequestDTO, adminID uuid.UUID) error {
+// AdminQuote nyimpen revisi penawaran baru. revisi pending sebelumnya jadi superseded,
+// request nya balik ke quoted nunggu keputusan user. revisi setelah penawaran
+// di acc (misal ketemu kerusakan lain) cuma boleh selama order servis nya belum dibayar.
+// assignee != nil (teknisi) request nya harus lagi di assign ke dia
+func (r *ServiceRequestRepository) AdminQuote(ctx context.Context, id uuid.UUID, quote *model.ServiceQuote, adminID uuid.UUID, assignee *uuid.UUID) error {
 	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var status model.ServiceRequestStatus
+		var repairStatus *model.RepairStatus
+		var orderID *uuid.UUID
+		var assignedTo *uuid.UUID
+		err := tx.QueryRow(ctx, `
+			SELECT status, repair_status, order_id, assigned_to
+			FROM service_requests
+			WHERE id = $1
+			FOR UPDATE
+		`, id).Scan(&status, &repairStatus, &orderID, &assignedTo)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return notFoundError
+			}
+			return fmt.Errorf("AdminQuote: %w", err)
+		}
+		if err := checkAssignee(assignee, assignedTo); err != nil {
+			return err
+		}
+
+		switch status {
+		case model.StatusPendingReview, model.StatusQuoted, model.StatusAccepted:
+		default:
+			return ErrQuoteNotAllowed
+		}
+
+		if orderID != nil {
+			if err := checkRevisableTx(ctx, tx, repairStatus, *orderID); err != nil {
+				return err
+			}
+		}
+
+		// revisi pending yang diganti sparepart nya dilepas
+		var supersededID *uuid.UUID
+		err = tx.QueryRow(ctx, `
+			UPDATE service_quotes SET status = 'superseded'
+			WHERE service_request_id = $1 AND status = 'pending'
+			RETURNING id
+		`, id).Scan(&supersededID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("AdminQuote: %w", err)
+		}
+		if supersededID != nil {
+			if err := releasePartsTx(ctx, tx, id, supersededID, nil); err != nil {
+				return err
+			}
+		}
+
+		err = tx.QueryRow(ctx, `
+			SELECT COALESCE(MAX(version), 0) + 1 FROM service_quotes WHERE service_request_id = $1
+		`, id).Scan(&quote.Version)
+		if err != nil {
+			return fmt.Errorf("AdminQuote: %w", err)
+		}
+
+		expiresAt := time.Now().Add(quoteValidity)
+		quote.ServiceRequestID = id
+		quote.Status = model.QuoteStatusPending
+		quote.CreatedBy = &adminID
+		quote.ExpiresAt = &expiresAt
+		err = tx.QueryRow(ctx, `
+			INSERT INTO service_quotes (service_request_id, version, status, subtotal, estimated_duration, note, created_by, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at
+		`, id, quote.Version, quote.Status, quote.Subtotal, quote.EstimatedDuration, quote.Note, adminID, expiresAt).
+			Scan(&quote.ID, &quote.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("AdminQuote: %w", err)
+		}
+
+		itemQuery := `
+			INSERT INTO service_quote_items (quote_id, item_type, product_id, description, quantity, unit_price, subtotal, position)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id`
+		for i := range quote.Items {
+			it := &quote.Items[i]
+			it.QuoteID = quote.ID
+			err := tx.QueryRow(ctx, itemQuery,
+				it.QuoteID, it.ItemType, it.ProductID, it.Description, it.Quantity, it.UnitPrice, it.Subtotal, i,
+			).Scan(&it.ID)
+			if err != nil {
+				return fmt.Errorf("AdminQuote: %w", err)
+			}
+		}
+
+		// sparepart dari stok toko langsung di reserve, yang stok nya kurang dicatat waiting.
+		// perangkat yang udah siap diambil sparepart nya udah kepotong dari stok
+		if repairStatus == nil || *repairStatus != model.RepairStatusReadyForPickup {
+			if _, err := reserveQuotePartsTx(ctx, tx, id, *quote); err != nil {
+				return err
+			}
+		}
+
 		query := `
 			UPDATE service_requests SET
 				status             = 'quoted',
@@ -156,22 +321,51 @@ func (r *ServiceRequestRepository) AdminQuote(ctx context.Context, id uuid.UUID,
 				quoted_by          = $4,
 				quoted_at          = $5,
 				updated_at         = $5
-			WHERE id = $6 AND status = 'pending_review'`
+			WHERE id = $6`
 
 		now := time.Now()
-		tag, err := tx.Exec(ctx, query,
-			d.QuotedPrice, d.EstimatedDuration, d.AdminNote, adminID, now, id,
+		_, err = tx.Exec(ctx, query,
+			quote.Subtotal, quote.EstimatedDuration, quote.Note, adminID, now, id,
 		)
 		if err != nil {
 			return fmt.Errorf("AdminQuote: %w", err)
 		}
-		if tag.RowsAffected() == 0 {
-			return fmt.Errorf("AdminQuote: request not found or not in pending_review status")
-		}
 		return nil
 	})
 }
 
+// checkRevisableTx penawaran yang udah di acc masih boleh direvisi selama
+// order servis nya masih pending (belum upload bukti bayar) atau udah batal,
+// dan perangkat nya belum selesai dikerjain. order yang bukti bayar nya lagi
+// dicek admin ga boleh diganti, nanti pembayaran nya ikut ditolak.
+// perangkat yang udah siap diambil tapi order nya batal (misal kadaluarsa)
+// tetep boleh direvisi, biar user bisa bayar lagi terus ngambil perangkat nya
+func checkRevisableTx(ctx context.Context, tx pgx.Tx, repairStatus *model.RepairStatus, orderID uuid.UUID) error {
+	if repairStatus != nil {
+		switch *repairStatus {
+		case model.RepairStatusPickedUp, model.RepairStatusReturned:
+			return ErrQuoteLocked
+		}
+	}
+
+	var status model.OrderStatus
+	err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&status)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get service order: %w", err)
+	}
+
+	if repairStatus != nil && *repairStatus == model.RepairStatusReadyForPickup &&
+		status != model.OrderStatusCancelled && status != "" {
+		return ErrQuoteLocked
+	}
+
+	switch status {
+	case model.OrderStatusPending, model.OrderStatusCancelled, "":
+		return nil
+	}
+	return ErrQuoteLocked
+}
+
 func (r *ServiceRequestRepository) AdminReject(ctx context.Context, id uuid.UUID, d *dto.AdminRejectServiceRequestDTO, adminID uuid.UUID) error {
 	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
 		query := `
@@ -190,53 +384,246 @@ func (r *ServiceRequestRepository) AdminReject(ctx context.Context, id uuid.UUID
 		if tag.RowsAffected() == 0 {
 			return fmt.Errorf("AdminReject: request not found or not in pending_review status")
 		}
-		return nil
+
+		_, err = closeAppointmentTx(ctx, tx, id, model.AppointmentCancelled, &d.AdminNote, false)
+		return err
 	})
 }
 
-func (r *ServiceRequestRepository) UserAccept(ctx context.Context, id uuid.UUID, orderID uuid.UUID) error {
+// UserAccept acc revisi penawaran yang lagi pending, order servis nya dibikin dari
+// baris revisi ini di transaksi yang sama. order servis dari revisi yang di acc
+// sebelumnya (kalau ada & belum dibayar) dibatalin, sparepart revisi lama nya juga
+// dilepas. kalau sparepart revisi ini ada yang nunggu stok dan perbaikan nya lagi
+// jalan, perbaikan nya dipindah ke waiting_parts
+func (r *ServiceRequestRepository) UserAccept(ctx context.Context, id uuid.UUID, version int, userID uuid.UUID, notes string, expiresAt time.Time) error {
 	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		prevOrderID, quoteID, err := lockPendingQuoteTx(ctx, tx, id, version)
+		if err != nil {
+			return err
+		}
+
+		items, err := quoteItemsTx(ctx, tx, quoteID)
+		if err != nil {
+			return err
+		}
+		newOrder, err := order.CreateServiceOrderTx(ctx, tx, userID, notes, quoteOrderLines(items), expiresAt)
+		if err != nil {
+			return err
+		}
+		orderID := newOrder.ID
+
+		if prevOrderID != nil {
+			if err := replaceServiceOrderTx(ctx, tx, *prevOrderID, version); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		_, err = tx.Exec(ctx, `
+			UPDATE service_quotes SET status = 'accepted', decided_at = $1 WHERE id = $2
+		`, now, quoteID)
+		if err != nil {
+			return fmt.Errorf("UserAccept: %w", err)
+		}
+
 		query := `
 			UPDATE service_requests SET
 				status     = 'accepted',
 				order_id   = $1,
 				decided_at = $2,
 				updated_at = $2
-			WHERE id = $3 AND status = 'quoted'`
-
-		now := time.Now()
-		tag, err := tx.Exec(ctx, query, orderID, now, id)
-		if err != nil {
+			WHERE id = $3`
+		if _, err := tx.Exec(ctx, query, orderID, now, id); err != nil {
 			return fmt.Errorf("UserAccept: %w", err)
 		}
-		if tag.RowsAffected() == 0 {
-			return fmt.Errorf("UserAccept: request not found or not in quoted status")
+
+		if err := releasePartsTx(ctx, tx, id, nil, &quoteID); err != nil {
+			return err
 		}
-		return nil
+		return holdForPartsTx(ctx, tx, id)
 	})
 }
 
-func (r *ServiceRequestRepository) UserReject(ctx context.Context, id uuid.UUID) error {
+// quoteItemsTx baris revisi penawaran, dibaca di transaksi yang ngunci revisi nya
+func quoteItemsTx(ctx context.Context, tx pgx.Tx, quoteID uuid.UUID) ([]model.ServiceQuoteItem, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, quote_id, item_type, product_id, description, quantity, unit_price, subtotal
+		FROM service_quote_items
+		WHERE quote_id = $1
+		ORDER BY position ASC`, quoteID)
+	if err != nil {
+		return nil, fmt.Errorf("quoteItemsTx: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]model.ServiceQuoteItem, 0)
+	for rows.Next() {
+		var it model.ServiceQuoteItem
+		err := rows.Scan(
+			&it.ID, &it.QuoteID, &it.ItemType, &it.ProductID, &it.Description,
+			&it.Quantity, &it.UnitPrice, &it.Subtotal,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("quoteItemsTx: %w", err)
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// holdForPartsTx perbaikan yang lagi jalan dipindah ke waiting_parts
+// kalau masih ada sparepart yang nunggu stok
+func holdForPartsTx(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	var repairStatus *model.RepairStatus
+	var waiting bool
+	err := tx.QueryRow(ctx, `
+		SELECT repair_status, EXISTS(
+			SELECT 1 FROM service_request_parts
+			WHERE service_request_id = $1 AND status = 'waiting'
+		)
+		FROM service_requests
+		WHERE id = $1
+	`, id).Scan(&repairStatus, &waiting)
+	if err != nil {
+		return fmt.Errorf("failed to check waiting parts: %w", err)
+	}
+
+	if !waiting || repairStatus == nil || !repairStatus.CanTransitionTo(model.RepairStatusWaitingParts) {
+		return nil
+	}
+	return setRepairStatusTx(ctx, tx, id, repairStatus, model.RepairStatusWaitingParts, nil, partsShortNote(nil))
+}
+
+// UserReject nolak revisi penawaran yang lagi pending. kalau belum pernah ada
+// penawaran yang di acc request nya selesai (rejected_by_user), kalau ini revisi
+// dari penawaran yang udah di acc, request nya balik ke penawaran yang di acc terakhir
+func (r *ServiceRequestRepository) UserReject(ctx context.Context, id uuid.UUID, version int) error {
 	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
-		query := `
-			UPDATE service_requests SET
-				status     = 'rejected_by_user',
-				decided_at = $1,
-				updated_at = $1
-			WHERE id = $2 AND status = 'quoted'`
+		prevOrderID, quoteID, err := lockPendingQuoteTx(ctx, tx, id, version)
+		if err != nil {
+			return err
+		}
 
 		now := time.Now()
-		tag, err := tx.Exec(ctx, query, now, id)
+		_, err = tx.Exec(ctx, `
+			UPDATE service_quotes SET status = 'rejected', decided_at = $1 WHERE id = $2
+		`, now, quoteID)
 		if err != nil {
 			return fmt.Errorf("UserReject: %w", err)
 		}
-		if tag.RowsAffected() == 0 {
-			return fmt.Errorf("UserReject: request not found or not in quoted status")
+
+		if err := releasePartsTx(ctx, tx, id, &quoteID, nil); err != nil {
+			return err
 		}
-		return nil
+
+		if prevOrderID == nil {
+			query := `
+				UPDATE service_requests SET
+					status     = 'rejected_by_user',
+					decided_at = $1,
+					updated_at = $1
+				WHERE id = $2`
+			if _, err := tx.Exec(ctx, query, now, id); err != nil {
+				return fmt.Errorf("UserReject: %w", err)
+			}
+
+			// perangkat nya ga jadi dianter, slot nya dilepas
+			_, err := closeAppointmentTx(ctx, tx, id, model.AppointmentCancelled, nil, false)
+			return err
+		}
+
+		return restoreAcceptedQuoteTx(ctx, tx, id, now)
 	})
 }
 
+// restoreAcceptedQuoteTx revisi yang ga jadi (ditolak / kadaluarsa) balikin
+// request nya ke revisi terakhir yang udah di acc
+func restoreAcceptedQuoteTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, now time.Time) error {
+	query := `
+		UPDATE service_requests sr SET
+			status             = 'accepted',
+			quoted_price       = q.subtotal,
+			estimated_duration = q.estimated_duration,
+			updated_at         = $1
+		FROM (
+			SELECT subtotal, estimated_duration
+			FROM service_quotes
+			WHERE service_request_id = $2 AND status = 'accepted'
+			ORDER BY version DESC
+			LIMIT 1
+		) q
+		WHERE sr.id = $2`
+	if _, err := tx.Exec(ctx, query, now, id); err != nil {
+		return fmt.Errorf("failed to restore accepted quote: %w", err)
+	}
+	return nil
+}
+
+// lockPendingQuoteTx ngunci request nya terus mastiin revisi yang diputusin user
+// itu revisi yang lagi pending, balikin order servis yang udah ada + id revisi nya
+func lockPendingQuoteTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, version int) (*uuid.UUID, uuid.UUID, error) {
+	var status model.ServiceRequestStatus
+	var orderID *uuid.UUID
+	err := tx.QueryRow(ctx, `
+		SELECT status, order_id FROM service_requests WHERE id = $1 FOR UPDATE
+	`, id).Scan(&status, &orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, uuid.Nil, notFoundError
+		}
+		return nil, uuid.Nil, err
+	}
+	if status != model.StatusQuoted {
+		return nil, uuid.Nil, ErrQuoteNotPending
+	}
+
+	var quoteID uuid.UUID
+	var pendingVersion int
+	var expiresAt *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT id, version, expires_at FROM service_quotes
+		WHERE service_request_id = $1 AND status = 'pending'
+		FOR UPDATE
+	`, id).Scan(&quoteID, &pendingVersion, &expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, uuid.Nil, ErrQuoteNotPending
+		}
+		return nil, uuid.Nil, err
+	}
+	if pendingVersion != version {
+		return nil, uuid.Nil, ErrQuoteVersionMismatch
+	}
+	// sweeper nya bisa telat, jadi dicek lagi disini
+	if expiresAt != nil && !time.Now().Before(*expiresAt) {
+		return nil, uuid.Nil, ErrQuoteExpired
+	}
+	return orderID, quoteID, nil
+}
+
+// replaceServiceOrderTx batalin order servis revisi lama yang masih pending.
+// kalau bukti bayar nya udah diupload / udah dibayar revisi nya ga bisa di acc lagi
+func replaceServiceOrderTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID, version int) error {
+	var status model.OrderStatus
+	err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to get service order: %w", err)
+	}
+
+	switch status {
+	case model.OrderStatusCancelled:
+		return nil
+	case model.OrderStatusPending:
+		note := fmt.Sprintf("diganti order revisi penawaran v%d", version)
+		_, err := order.TransitionTx(ctx, tx, orderID, model.OrderStatusCancelled, nil, &note)
+		return err
+	}
+	return ErrQuoteLocked
+}
+
 func (r *ServiceRequestRepository) Cancel(ctx context.Context, id uuid.UUID) error {
 	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
 		query := `
@@ -257,6 +644,289 @@ func (r *ServiceRequestRepository) Cancel(ctx context.Context, id uuid.UUID) err
 	})
 }
 
+// ─── REPAIR ──────────────────────────────────────────────────────────────────
+
+// UpdateRepairStatus mindahin status perbaikan lewat RepairStatusTransitions,
+// timestamp nya diisi + riwayat nya dicatat di transaksi yang sama.
+// mulai dikerjain (in_repair) sparepart yang masih nunggu stok dicoba di reserve,
+// kalau stok nya masih kurang perbaikan nya otomatis pindah ke waiting_parts.
+// siap diambil = perbaikan selesai, sparepart nya dipotong dari stok.
+// perangkat cuma bisa diambil kalau order servis nya udah dibayar,
+// dan pas diambil order nya sekalian ditutup (completed).
+// balikin status perbaikan yang akhirnya kepasang
+func (r *ServiceRequestRepository) UpdateRepairStatus(ctx context.Context, id uuid.UUID, to model.RepairStatus, actorID uuid.UUID, note *string, assignee *uuid.UUID) (model.RepairStatus, error) {
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var status model.ServiceRequestStatus
+		var from *model.RepairStatus
+		var orderID *uuid.UUID
+		var assignedTo *uuid.UUID
+		err := tx.QueryRow(ctx, `
+			SELECT status, repair_status, order_id, assigned_to
+			FROM service_requests
+			WHERE id = $1
+			FOR UPDATE
+		`, id).Scan(&status, &from, &orderID, &assignedTo)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return notFoundError
+			}
+			return fmt.Errorf("UpdateRepairStatus: %w", err)
+		}
+		if err := checkAssignee(assignee, assignedTo); err != nil {
+			return err
+		}
+
+		// selama revisi penawaran nunggu keputusan user (quoted tapi udah punya order)
+		// progres perbaikan nya tetep boleh dicatat
+		if status != model.StatusAccepted && (status != model.StatusQuoted || orderID == nil) {
+			return ErrServiceNotAccepted
+		}
+
+		var current model.RepairStatus
+		if from != nil {
+			current = *from
+		}
+		if !current.CanTransitionTo(to) {
+			return fmt.Errorf("%w (%s -> %s)", ErrInvalidRepairTransition, current, to)
+		}
+
+		switch to {
+		case model.RepairStatusInRepair:
+			waiting, err := reserveWaitingPartsTx(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if waiting {
+				if !current.CanTransitionTo(model.RepairStatusWaitingParts) {
+					return ErrPartsUnavailable
+				}
+				to = model.RepairStatusWaitingParts
+				note = partsShortNote(note)
+			}
+		case model.RepairStatusReadyForPickup:
+			// order nya batal berarti sparepart nya udah dilepas, harus direvisi dulu
+			if err := checkServiceOrderActiveTx(ctx, tx, orderID); err != nil {
+				return err
+			}
+			if err := commitPartsTx(ctx, tx, id, actorID); err != nil {
+				return err
+			}
+		case model.RepairStatusReturned:
+			if err := releasePartsTx(ctx, tx, id, nil, nil); err != nil {
+				return err
+			}
+		case model.RepairStatusPickedUp:
+			if err := completeServiceOrderTx(ctx, tx, orderID, actorID); err != nil {
+				return err
+			}
+		}
+
+		return setRepairStatusTx(ctx, tx, id, from, to, &actorID, note)
+	})
+	if err != nil {
+		return "", err
+	}
+	return to, nil
+}
+
+func checkServiceOrderActiveTx(ctx context.Context, tx pgx.Tx, orderID *uuid.UUID) error {
+	if orderID == nil {
+		return nil
+	}
+
+	var status model.OrderStatus
+	err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE id = $1`, *orderID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to get service order: %w", err)
+	}
+	if status == model.OrderStatusCancelled {
+		return ErrServiceOrderCancelled
+	}
+	return nil
+}
+
+// setRepairStatusTx nulis status perbaikan + timestamp nya dan nyatet riwayat nya.
+// actorID nil berarti dipindah otomatis sama sistem
+func setRepairStatusTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, from *model.RepairStatus, to model.RepairStatus, actorID *uuid.UUID, note *string) error {
+	query := `
+		UPDATE service_requests SET
+			repair_status = $1,
+			received_at   = CASE WHEN $1 = 'device_received' THEN NOW() ELSE received_at END,
+			ready_at      = CASE WHEN $1 = 'ready_for_pickup' THEN NOW() ELSE ready_at END,
+			picked_up_at  = CASE WHEN $1 IN ('picked_up', 'returned') THEN NOW() ELSE picked_up_at END,
+			updated_at    = NOW()
+		WHERE id = $2`
+	if _, err := tx.Exec(ctx, query, to, id); err != nil {
+		return fmt.Errorf("UpdateRepairStatus: %w", err)
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO service_request_status_history (service_request_id, from_status, to_status, actor_id, note)
+		VALUES ($1, $2, $3, $4, $5)
+	`, id, from, to, actorID, note)
+	if err != nil {
+		return fmt.Errorf("failed to insert repair history: %w", err)
+	}
+
+	if to == model.RepairStatusDeviceReceived {
+		if _, err := closeAppointmentTx(ctx, tx, id, model.AppointmentCompleted, nil, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func partsShortNote(note *string) *string {
+	text := "stok sparepart kurang, menunggu sparepart"
+	if note != nil && *note != "" {
+		text = *note + " (" + text + ")"
+	}
+	return &text
+}
+
+// completeServiceOrderTx order servis wajib udah dibayar (confirmed) sebelum
+// perangkat diambil, terus ditutup jadi completed lewat state machine order
+func completeServiceOrderTx(ctx context.Context, tx pgx.Tx, orderID *uuid.UUID, actorID uuid.UUID) error {
+	if orderID == nil {
+		return ErrRepairUnpaid
+	}
+
+	var status model.OrderStatus
+	err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE id = $1`, *orderID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRepairUnpaid
+		}
+		return fmt.Errorf("failed to get service order: %w", err)
+	}
+
+	switch status {
+	case model.OrderStatusCompleted:
+		return nil
+	case model.OrderStatusConfirmed:
+		note := "perangkat servis sudah diambil"
+		_, err := order.TransitionTx(ctx, tx, *orderID, model.OrderStatusCompleted, &actorID, &note)
+		return err
+	}
+	return ErrRepairUnpaid
+}
+
+func (r *ServiceRequestRepository) GetStatusHistory(ctx context.Context, id uuid.UUID) ([]model.ServiceStatusHistory, error) {
+	query := `
+		SELECT h.id, h.service_request_id, h.from_status, h.to_status, h.actor_id,
+		       u.full_name, h.note, h.created_at
+		FROM service_request_status_history h
+		LEFT JOIN users u ON u.id = h.actor_id
+		WHERE h.service_request_id = $1
+		ORDER BY h.created_at ASC`
+	rows, err := r.pool.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetStatusHistory: %w", err)
+	}
+	defer rows.Close()
+
+	history := make([]model.ServiceStatusHistory, 0)
+	for rows.Next() {
+		var h model.ServiceStatusHistory
+		err := rows.Scan(
+			&h.ID, &h.ServiceRequestID, &h.FromStatus, &h.ToStatus, &h.ActorID,
+			&h.ActorName, &h.Note, &h.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("GetStatusHistory: %w", err)
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// ─── QUOTES ──────────────────────────────────────────────────────────────────
+
+func (r *ServiceRequestRepository) GetQuotes(ctx context.Context, id uuid.UUID) ([]model.ServiceQuote, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, service_request_id, version, status, subtotal, estimated_duration,
+		       note, created_by, created_at, expires_at, decided_at
+		FROM service_quotes
+		WHERE service_request_id = $1
+		ORDER BY version ASC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetQuotes: %w", err)
+	}
+	defer rows.Close()
+
+	quotes := make([]model.ServiceQuote, 0)
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		var q model.ServiceQuote
+		err := rows.Scan(
+			&q.ID, &q.ServiceRequestID, &q.Version, &q.Status, &q.Subtotal, &q.EstimatedDuration,
+			&q.Note, &q.CreatedBy, &q.CreatedAt, &q.ExpiresAt, &q.DecidedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("GetQuotes: %w", err)
+		}
+		q.Items = []model.ServiceQuoteItem{}
+		index[q.ID] = len(quotes)
+		quotes = append(quotes, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	itemRows, err := r.pool.Query(ctx, `
+		SELECT i.id, i.quote_id, i.item_type, i.product_id, i.description,
+		       i.quantity, i.unit_price, i.subtotal
+		FROM service_quote_items i
+		JOIN service_quotes q ON q.id = i.quote_id
+		WHERE q.service_request_id = $1
+		ORDER BY i.position ASC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetQuotes: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var it model.ServiceQuoteItem
+		err := itemRows.Scan(
+			&it.ID, &it.QuoteID, &it.ItemType, &it.ProductID, &it.Description,
+			&it.Quantity, &it.UnitPrice, &it.Subtotal,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("GetQuotes: %w", err)
+		}
+		i := index[it.QuoteID]
+		quotes[i].Items = append(quotes[i].Items, it)
+	}
+	return quotes, itemRows.Err()
+}
+
+// GetPartProduct nama & harga produk toko yang dipake sebagai sparepart
+func (r *ServiceRequestRepository) GetPartProduct(ctx context.Context, productID uuid.UUID) (string, model.Money, error) {
+	var name string
+	var price model.Money
+	var unsupported bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT name, price, `+unsupportedPartCondition+`
+		FROM products
+		WHERE id = $1
+	`, productID).Scan(&name, &price, &unsupported)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", 0, ErrQuoteProductInvalid
+		}
+		return "", 0, err
+	}
+
+	// stok sparepart di reserve per produk, jadi produk bervarian / bundle ga bisa dipake
+	if unsupported {
+		return "", 0, ErrQuotePartUnsupported
+	}
+	return name, price, nil
+}
+
 // ─── HELPERS ─────────────────────────────────────────────────────────────────
 
 type scannable interface {
@@ -271,6 +941,8 @@ func scanServiceRequest(row scannable) (*model.ServiceRequest, error) {
 		&sr.ProblemDescription, &sr.Photo1, &sr.Photo2, &sr.Photo3, &sr.Status,
 		&sr.QuotedPrice, &sr.EstimatedDuration, &sr.AdminNote, &sr.QuotedBy,
 		&sr.OrderID, &sr.CreatedAt, &sr.UpdatedAt, &sr.QuotedAt, &sr.DecidedAt,
+		&sr.RepairStatus, &sr.ReceivedAt, &sr.ReadyAt, &sr.PickedUpAt,
+		&sr.AssignedTo, &sr.AssignedToName, &sr.AssignedAt,
 		&userJSON,
 	)
 	if err != nil {
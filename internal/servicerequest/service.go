@@ -9,7 +9,9 @@ import (
 	"backEnd-RingoTechLife/internal/storage"
 	"context"
 	"errors"
+	"log"
 	"mime/multipart"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -30,6 +32,15 @@ func NewDeviceService(drp *ServiceRequestRepository, serverStorage storage.FileS
 }
 
 func (ds *DeviceService) CreateNew(ctx context.Context, newData dto.CreateServiceRequestDTO, userId uuid.UUID) (model.ServiceRequest, *common.ErrorResponse) {
+	var appointmentAt *time.Time
+	if newData.AppointmentAt != nil {
+		t, err := time.Parse(time.RFC3339, *newData.AppointmentAt)
+		if err != nil {
+			return model.ServiceRequest{}, common.NewErrorResponse(400, "format jadwal tidak valid!")
+		}
+		appointmentAt = &t
+	}
+
 	savedImages, err := ds.processDeviceImage(ctx, newData.ProductPictures)
 	if err != nil {
 		ds.FileStorage.DeleteAllPublicFile(savedImages, "device_service")
@@ -59,8 +70,14 @@ func (ds *DeviceService) CreateNew(ctx context.Context, newData dto.CreateServic
 		UserID:             userId,
 	}
 
-	err = ds.DeviceServiceRepo.Create(ctx, &newModel)
+	err = ds.DeviceServiceRepo.Create(ctx, &newModel, appointmentAt)
 	if err != nil {
+		ds.FileStorage.DeleteAllPublicFile(savedImages, "device_service")
+		if appointmentAt != nil {
+			if aerr := appointmentError(err); aerr.Code != 500 {
+				return model.ServiceRequest{}, aerr
+			}
+		}
 		return model.ServiceRequest{}, common.NewErrorResponse(500, "gagal menyimpan data ke database")
 	}
 
@@ -103,12 +120,46 @@ func (ds *DeviceService) GetByServiceID(ctx context.Context, id uuid.UUID, userI
 		if errors.Is(err, notFoundError) {
 			return model.ServiceRequest{}, common.NewErrorResponse(404, "data tidak ditemukan!")
 		}
+		return model.ServiceRequest{}, common.NewErrorResponse(500, "gagal mengambil data di database")
 	}
 
-	if data.UserID != userId && role != middleware.RoleAdmin {
+	if !canAccess(data, userId, role) {
 		return model.ServiceRequest{}, common.NewErrorResponse(403, "Kamu tidak dapat mengakses fitur ini!")
 	}
 
+	quotes, err := ds.DeviceServiceRepo.GetQuotes(ctx, id)
+	if err != nil {
+		return model.ServiceRequest{}, common.NewErrorResponse(500, "gagal mengambil data penawaran")
+	}
+	data.Quotes = quotes
+
+	parts, err := ds.DeviceServiceRepo.GetParts(ctx, id)
+	if err != nil {
+		return model.ServiceRequest{}, common.NewErrorResponse(500, "gagal mengambil data sparepart")
+	}
+	data.Parts = parts
+
+	history, err := ds.DeviceServiceRepo.GetStatusHistory(ctx, id)
+	if err != nil {
+		return model.ServiceRequest{}, common.NewErrorResponse(500, "gagal mengambil riwayat perbaikan")
+	}
+	data.StatusHistory = history
+
+	appointments, err := ds.DeviceServiceRepo.GetAppointments(ctx, id)
+	if err != nil {
+		return model.ServiceRequest{}, common.NewErrorResponse(500, "gagal mengambil jadwal antar perangkat")
+	}
+	data.Appointments = appointments
+
+	// riwayat teknisi cuma buat internal toko
+	if role != middleware.RoleUser {
+		assignments, err := ds.DeviceServiceRepo.GetAssignments(ctx, id)
+		if err != nil {
+			return model.ServiceRequest{}, common.NewErrorResponse(500, "gagal mengambil riwayat teknisi")
+		}
+		data.Assignments = assignments
+	}
+
 	return *data, nil
 }
 
@@ -149,13 +200,73 @@ func (ds *DeviceService) GetCurrentUserHistory(ctx context.Context, userId uuid.
 	return respData, nil
 }
 
-func (ds *DeviceService) QuoteService(ctx context.Context, serviceId uuid.UUID, d dto.AdminQuoteServiceRequestDTO, adminId uuid.UUID) *common.ErrorResponse {
-	err := ds.DeviceServiceRepo.AdminQuote(ctx, serviceId, &d, adminId)
-	if err != nil {
-		return common.NewErrorResponse(500, "terjadi kesalahan di server")
+func (ds *DeviceService) QuoteService(ctx context.Context, serviceId uuid.UUID, d dto.AdminQuoteServiceRequestDTO, actorId uuid.UUID, role string) (model.ServiceQuote, *common.ErrorResponse) {
+
+	reqItems := d.Items
+	if len(reqItems) == 0 {
+		if d.QuotedPrice <= 0 {
+			return model.ServiceQuote{}, common.NewErrorResponse(400, "harap isi rincian penawaran!")
+		}
+		reqItems = []dto.QuoteItemDTO{{
+			Type:        string(model.QuoteItemLabour),
+			Description: "Biaya servis",
+			Quantity:    1,
+			UnitPrice:   &d.QuotedPrice,
+		}}
 	}
 
-	return nil
+	quote := model.ServiceQuote{
+		EstimatedDuration: d.EstimatedDuration,
+		Note:              d.AdminNote,
+		Items:             make([]model.ServiceQuoteItem, len(reqItems)),
+	}
+
+	for i, it := range reqItems {
+		item := model.ServiceQuoteItem{
+			ItemType:    model.QuoteItemType(it.Type),
+			Description: strings.TrimSpace(it.Description),
+			Quantity:    it.Quantity,
+		}
+
+		// sparepart dari produk toko: nama & harga default nya ngikut produk
+		if it.ProductId != nil {
+			if item.ItemType != model.QuoteItemPart {
+				return model.ServiceQuote{}, common.NewErrorResponse(400, "cuma baris sparepart yang bisa dihubungkan ke produk!")
+			}
+			productId, err := uuid.Parse(*it.ProductId)
+			if err != nil {
+				return model.ServiceQuote{}, common.NewErrorResponse(400, "id produk sparepart tidak valid!")
+			}
+			name, price, err := ds.DeviceServiceRepo.GetPartProduct(ctx, productId)
+			if err != nil {
+				return model.ServiceQuote{}, quoteError(err)
+			}
+			item.ProductID = &productId
+			if item.Description == "" {
+				item.Description = name
+			}
+			item.UnitPrice = price
+		}
+
+		if it.UnitPrice != nil {
+			item.UnitPrice = *it.UnitPrice
+		} else if item.ProductID == nil {
+			return model.ServiceQuote{}, common.NewErrorResponse(400, "harga tiap baris penawaran wajib diisi!")
+		}
+
+		if item.Description == "" {
+			return model.ServiceQuote{}, common.NewErrorResponse(400, "deskripsi tiap baris penawaran wajib diisi!")
+		}
+
+		item.Subtotal = item.UnitPrice.Mul(item.Quantity)
+		quote.Subtotal += item.Subtotal
+		quote.Items[i] = item
+	}
+
+	if err := ds.DeviceServiceRepo.AdminQuote(ctx, serviceId, &quote, actorId, assigneeFor(actorId, role)); err != nil {
+		return model.ServiceQuote{}, quoteError(err)
+	}
+	return quote, nil
 }
 
 func (ds *DeviceService) RejectService(ctx context.Context, serviceId uuid.UUID, d dto.AdminRejectServiceRequestDTO, adminId uuid.UUID) *common.ErrorResponse {
@@ -166,43 +277,421 @@ func (ds *DeviceService) RejectService(ctx context.Context, serviceId uuid.UUID,
 	return nil
 }
 
-func (ds *DeviceService) AcceptServiceByUser(ctx context.Context, serviceId uuid.UUID, userId uuid.UUID, notes string) *common.ErrorResponse {
+func (ds *DeviceService) AcceptServiceByUser(ctx context.Context, serviceId uuid.UUID, userId uuid.UUID, version int, notes string) *common.ErrorResponse {
 	oldData, err := ds.DeviceServiceRepo.GetByID(ctx, serviceId)
 	if err != nil {
-		return common.NewErrorResponse(500, "gagal mengambil data di database")
-
+		return quoteError(err)
 	}
 	if oldData.UserID != userId {
 		return common.NewErrorResponse(403, "Kamu tidak dapat mengakses ini")
 	}
 
+	// order servis nya dibikin di transaksi UserAccept juga, biar ga ada order
+	// nyangkut kalau revisi nya udah basi
 	expiresAt := time.Now().UTC().Add(12 * time.Hour)
-	newInserted, insErr := ds.OrderService.CreateOrderWithoutProduct(ctx, userId, notes, *oldData.QuotedPrice, expiresAt)
-	if insErr != nil {
-		return insErr
+	err = ds.DeviceServiceRepo.UserAccept(ctx, serviceId, version, userId, notes, expiresAt)
+	if err != nil {
+		return quoteError(err)
 	}
+	return nil
+}
 
-	err = ds.DeviceServiceRepo.UserAccept(ctx, serviceId, newInserted.ID)
+func (ds *DeviceService) RejectServiceByUser(ctx context.Context, serviceId uuid.UUID, userId uuid.UUID, version int) *common.ErrorResponse {
+	oldData, err := ds.DeviceServiceRepo.GetByID(ctx, serviceId)
 	if err != nil {
-		return common.NewErrorResponse(500, "terjadi kesalahan di server")
+		return quoteError(err)
+	}
+
+	if oldData.UserID != userId {
+		return common.NewErrorResponse(403, "Kamu tidak dapat mengakses ini")
+	}
+
+	err = ds.DeviceServiceRepo.UserReject(ctx, serviceId, version)
+	if err != nil {
+		return quoteError(err)
 	}
 	return nil
 }
 
-func (ds *DeviceService) RejectServiceByUser(ctx context.Context, serviceId uuid.UUID, userId uuid.UUID) *common.ErrorResponse {
-	oldData, err := ds.DeviceServiceRepo.GetByID(ctx, serviceId)
+const quoteExpirySweepBatch = 100
+
+// StartQuoteExpiryWorker jalanin sweeper di background yang nutup penawaran
+// pending yang udah lewat expires_at, biar sparepart yang di reserve nya dilepas
+func (ds *DeviceService) StartQuoteExpiryWorker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		ds.sweepExpiredQuotes(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ds.sweepExpiredQuotes(ctx)
+			}
+		}
+	}()
+}
+
+func (ds *DeviceService) sweepExpiredQuotes(ctx context.Context) {
+	// penawaran yang gagal ditutup dilewatin sampai sweep berikutnya
+	skip := make([]uuid.UUID, 0)
+	for {
+		operationContext, cancel := context.WithTimeout(ctx, 30*time.Second)
+		ids, err := ds.DeviceServiceRepo.GetExpiredQuoteIDs(operationContext, quoteExpirySweepBatch, skip)
+		if err != nil {
+			cancel()
+			log.Println("failed to get expired quotes:", err)
+			return
+		}
+
+		expired := 0
+		for _, id := range ids {
+			if err := ds.DeviceServiceRepo.ExpireQuote(operationContext, id); err != nil {
+				if operationContext.Err() != nil {
+					break
+				}
+				log.Printf("failed to expire quote %s: %v\n", id, err)
+				skip = append(skip, id)
+				continue
+			}
+			expired++
+		}
+		timedOut := operationContext.Err() != nil
+		cancel()
+
+		if expired != 0 {
+			log.Printf("expire %d service quote\n", expired)
+		}
+
+		if timedOut || len(ids) < quoteExpirySweepBatch {
+			return
+		}
+	}
+}
+
+// quoteOrderLines baris penawaran dijadiin item order servis
+func quoteOrderLines(items []model.ServiceQuoteItem) []model.OrderItem {
+	lines := make([]model.OrderItem, len(items))
+	for i, it := range items {
+		lines[i] = model.OrderItem{
+			ItemType:        string(it.ItemType),
+			ProductName:     it.Description,
+			PriceAtPurchase: it.UnitPrice,
+			Quantity:        it.Quantity,
+			Subtotal:        it.Subtotal,
+		}
+		if it.ProductID != nil {
+			lines[i].ProductID = *it.ProductID
+		}
+	}
+	return lines
+}
+
+func quoteError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, notFoundError):
+		return common.NewErrorResponse(404, "data tidak ditemukan!")
+	case errors.Is(err, ErrNotAssigned):
+		return common.NewErrorResponse(403, err.Error())
+	case errors.Is(err, ErrQuoteProductInvalid), errors.Is(err, ErrQuotePartUnsupported):
+		return common.NewErrorResponse(422, err.Error())
+	case errors.Is(err, ErrQuoteNotAllowed), errors.Is(err, ErrQuoteNotPending),
+		errors.Is(err, ErrQuoteVersionMismatch), errors.Is(err, ErrQuoteLocked), errors.Is(err, ErrQuoteExpired):
+		return common.NewErrorResponse(409, err.Error())
+	case errors.Is(err, order.ErrNoUniqueCode):
+		return common.NewErrorResponse(503, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di server")
+}
+
+// UpdateRepairStatus balikin status perbaikan yang kepasang, bisa beda dari yang
+// diminta kalau perbaikan nya otomatis ditahan nunggu sparepart
+func (ds *DeviceService) UpdateRepairStatus(ctx context.Context, serviceId uuid.UUID, d dto.UpdateRepairStatusDTO, actorId uuid.UUID, role string) (model.RepairStatus, *common.ErrorResponse) {
+	status, err := ds.DeviceServiceRepo.UpdateRepairStatus(ctx, serviceId, model.RepairStatus(d.Status), actorId, d.Note, assigneeFor(actorId, role))
+	if err != nil {
+		return "", repairError(err)
+	}
+	return status, nil
+}
+
+func repairError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, notFoundError):
+		return common.NewErrorResponse(404, "data tidak ditemukan!")
+	case errors.Is(err, ErrServiceNotAccepted), errors.Is(err, ErrInvalidRepairTransition),
+		errors.Is(err, ErrRepairUnpaid), errors.Is(err, ErrPartsUnavailable),
+		errors.Is(err, ErrServiceOrderCancelled):
+		return common.NewErrorResponse(409, err.Error())
+	case errors.Is(err, ErrQuotePartUnsupported):
+		return common.NewErrorResponse(422, err.Error())
+	case errors.Is(err, ErrNotAssigned):
+		return common.NewErrorResponse(403, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di server")
+}
+
+// canAccess admin bebas, teknisi cuma request yang di assign ke dia, user cuma punya nya sendiri
+func canAccess(data *model.ServiceRequest, userId uuid.UUID, role string) bool {
+	switch role {
+	case middleware.RoleAdmin:
+		return true
+	case middleware.RoleTechnician:
+		return data.AssignedTo != nil && *data.AssignedTo == userId
+	}
+	return data.UserID == userId
+}
+
+// assigneeFor teknisi cuma boleh ngerjain request yang di assign ke dia,
+// dicek di repository di dalam lock request nya. admin nil = bebas
+func assigneeFor(actorId uuid.UUID, role string) *uuid.UUID {
+	if role != middleware.RoleTechnician {
+		return nil
+	}
+	return &actorId
+}
+
+func (ds *DeviceService) AssignTechnician(ctx context.Context, serviceId uuid.UUID, d dto.AssignTechnicianDTO, adminId uuid.UUID) *common.ErrorResponse {
+	technicianId, err := uuid.Parse(d.TechnicianId)
+	if err != nil {
+		return common.NewErrorResponse(400, "id teknisi tidak valid!")
+	}
+
+	err = ds.DeviceServiceRepo.Assign(ctx, serviceId, technicianId, adminId, d.Note)
 	if err != nil {
-		return common.NewErrorResponse(500, "gagal mengambil data di database")
+		return assignmentError(err)
+	}
+	return nil
+}
 
+func (ds *DeviceService) GetTechnicianQueue(ctx context.Context, technicianId uuid.UUID) ([]model.ServiceRequest, *common.ErrorResponse) {
+	data, err := ds.DeviceServiceRepo.GetByTechnician(ctx, technicianId)
+	if err != nil {
+		return []model.ServiceRequest{}, common.NewErrorResponse(500, "gagal mengambil antrian teknisi")
 	}
 
+	respData := make([]model.ServiceRequest, len(data))
+	for i, v := range data {
+		respData[i] = *v
+	}
+	return respData, nil
+}
+
+func (ds *DeviceService) GetWorkload(ctx context.Context) ([]dto.TechnicianWorkload, *common.ErrorResponse) {
+	data, err := ds.DeviceServiceRepo.GetWorkload(ctx)
+	if err != nil {
+		return []dto.TechnicianWorkload{}, common.NewErrorResponse(500, "gagal mengambil beban kerja teknisi")
+	}
+	return data, nil
+}
+
+func assignmentError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, notFoundError):
+		return common.NewErrorResponse(404, "data tidak ditemukan!")
+	case errors.Is(err, ErrTechnicianInvalid):
+		return common.NewErrorResponse(422, err.Error())
+	case errors.Is(err, ErrAssignClosed), errors.Is(err, ErrAlreadyAssigned):
+		return common.NewErrorResponse(409, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di server")
+}
+
+func (ds *DeviceService) GetAppointmentSettings(ctx context.Context) (model.AppointmentSettings, *common.ErrorResponse) {
+	settings, err := ds.DeviceServiceRepo.GetAppointmentSettings(ctx)
+	if err != nil {
+		return model.AppointmentSettings{}, common.NewErrorResponse(500, "gagal mengambil pengaturan jadwal")
+	}
+	return settings, nil
+}
+
+func (ds *DeviceService) UpdateAppointmentSettings(ctx context.Context, d dto.AppointmentSettingsDTO, adminId uuid.UUID) (model.AppointmentSettings, *common.ErrorResponse) {
+	old, err := ds.DeviceServiceRepo.GetAppointmentSettings(ctx)
+	if err != nil {
+		return model.AppointmentSettings{}, common.NewErrorResponse(500, "gagal mengambil pengaturan jadwal")
+	}
+
+	settings := model.AppointmentSettings{
+		SlotMinutes:      d.SlotMinutes,
+		CapacityPerSlot:  d.CapacityPerSlot,
+		BookingDaysAhead: d.BookingDaysAhead,
+		UTCOffsetMinutes: old.UTCOffsetMinutes,
+		OpeningHours:     make([]model.ServiceOpeningHour, len(d.OpeningHours)),
+		Holidays:         old.Holidays,
+	}
+	if d.UTCOffsetMinutes != nil {
+		settings.UTCOffsetMinutes = *d.UTCOffsetMinutes
+	}
+
+	seen := map[int]bool{}
+	for i, h := range d.OpeningHours {
+		if seen[*h.Weekday] {
+			return model.AppointmentSettings{}, common.NewErrorResponse(400, "jam buka tiap hari cuma boleh satu!")
+		}
+		seen[*h.Weekday] = true
+
+		// format nya sama-sama 15:04, jadi bisa dibandingin langsung
+		if h.CloseTime <= h.OpenTime {
+			return model.AppointmentSettings{}, common.NewErrorResponse(400, "jam tutup harus setelah jam buka!")
+		}
+		settings.OpeningHours[i] = model.ServiceOpeningHour{
+			Weekday:   *h.Weekday,
+			OpenTime:  h.OpenTime,
+			CloseTime: h.CloseTime,
+		}
+	}
+
+	if err := ds.DeviceServiceRepo.UpdateAppointmentSettings(ctx, settings, adminId); err != nil {
+		return model.AppointmentSettings{}, common.NewErrorResponse(500, "gagal menyimpan pengaturan jadwal")
+	}
+	return settings, nil
+}
+
+func (ds *DeviceService) AddHoliday(ctx context.Context, d dto.HolidayDTO) *common.ErrorResponse {
+	err := ds.DeviceServiceRepo.AddHoliday(ctx, model.ServiceHoliday{Date: d.Date, Note: d.Note})
+	if err != nil {
+		return common.NewErrorResponse(500, "gagal menyimpan tanggal libur")
+	}
+	return nil
+}
+
+func (ds *DeviceService) DeleteHoliday(ctx context.Context, date string) *common.ErrorResponse {
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return common.NewErrorResponse(400, "format tanggal tidak valid!")
+	}
+	if err := ds.DeviceServiceRepo.DeleteHoliday(ctx, date); err != nil {
+		return appointmentError(err)
+	}
+	return nil
+}
+
+// GetAvailableSlots slot di satu tanggal (default hari ini, jam lokal toko) yang belum lewat.
+// slot yang udah penuh tetep dikirim dengan available 0
+func (ds *DeviceService) GetAvailableSlots(ctx context.Context, date string) ([]dto.AppointmentSlot, *common.ErrorResponse) {
+	settings, err := ds.DeviceServiceRepo.GetAppointmentSettings(ctx)
+	if err != nil {
+		return []dto.AppointmentSlot{}, common.NewErrorResponse(500, "gagal mengambil pengaturan jadwal")
+	}
+
+	now := time.Now()
+	first, last := bookableDays(settings, now)
+	day := first
+	if date != "" {
+		day, err = time.ParseInLocation(dateLayout, date, settings.Location())
+		if err != nil {
+			return []dto.AppointmentSlot{}, common.NewErrorResponse(400, "format tanggal tidak valid!")
+		}
+	}
+	if day.Before(first) || day.After(last) {
+		return []dto.AppointmentSlot{}, nil
+	}
+
+	starts := slotStarts(settings, day)
+	if len(starts) == 0 {
+		return []dto.AppointmentSlot{}, nil
+	}
+
+	booked, err := ds.DeviceServiceRepo.GetSlotBookings(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return []dto.AppointmentSlot{}, common.NewErrorResponse(500, "gagal mengambil data jadwal")
+	}
+
+	length := time.Duration(settings.SlotMinutes) * time.Minute
+	slots := make([]dto.AppointmentSlot, 0, len(starts))
+	for _, start := range starts {
+		if !start.After(now) {
+			continue
+		}
+		count := booked[start.UTC()]
+		slots = append(slots, dto.AppointmentSlot{
+			Start:     start,
+			End:       start.Add(length),
+			Capacity:  settings.CapacityPerSlot,
+			Booked:    count,
+			Available: max(settings.CapacityPerSlot-count, 0),
+		})
+	}
+	return slots, nil
+}
+
+func (ds *DeviceService) BookAppointment(ctx context.Context, serviceId uuid.UUID, userId uuid.UUID, d dto.BookAppointmentDTO) (model.ServiceAppointment, *common.ErrorResponse) {
+	start, err := time.Parse(time.RFC3339, d.AppointmentAt)
+	if err != nil {
+		return model.ServiceAppointment{}, common.NewErrorResponse(400, "format jadwal tidak valid!")
+	}
+
+	oldData, err := ds.DeviceServiceRepo.GetByID(ctx, serviceId)
+	if err != nil {
+		return model.ServiceAppointment{}, appointmentError(err)
+	}
 	if oldData.UserID != userId {
+		return model.ServiceAppointment{}, common.NewErrorResponse(403, "Kamu tidak dapat mengakses ini")
+	}
+
+	appointment, err := ds.DeviceServiceRepo.BookAppointment(ctx, serviceId, userId, start)
+	if err != nil {
+		return model.ServiceAppointment{}, appointmentError(err)
+	}
+	return appointment, nil
+}
+
+// CancelAppointment user batalin booking nya sendiri, admin bisa batalin booking siapa aja
+func (ds *DeviceService) CancelAppointment(ctx context.Context, serviceId uuid.UUID, userId uuid.UUID, role string) *common.ErrorResponse {
+	oldData, err := ds.DeviceServiceRepo.GetByID(ctx, serviceId)
+	if err != nil {
+		return appointmentError(err)
+	}
+	if oldData.UserID != userId && role != middleware.RoleAdmin {
 		return common.NewErrorResponse(403, "Kamu tidak dapat mengakses ini")
 	}
 
-	err = ds.DeviceServiceRepo.UserReject(ctx, serviceId)
+	err = ds.DeviceServiceRepo.CloseAppointment(ctx, serviceId, model.AppointmentCancelled, nil)
 	if err != nil {
-		return common.NewErrorResponse(500, "terjadi kesalahan di server")
+		return appointmentError(err)
+	}
+	return nil
+}
+
+func (ds *DeviceService) UpdateAppointmentStatus(ctx context.Context, serviceId uuid.UUID, d dto.UpdateAppointmentStatusDTO) *common.ErrorResponse {
+	err := ds.DeviceServiceRepo.CloseAppointment(ctx, serviceId, model.AppointmentStatus(d.Status), d.Note)
+	if err != nil {
+		return appointmentError(err)
 	}
 	return nil
 }
+
+// GetAppointmentsByDate jadwal antar perangkat di satu tanggal (default hari ini)
+func (ds *DeviceService) GetAppointmentsByDate(ctx context.Context, date string) ([]model.ServiceAppointment, *common.ErrorResponse) {
+	settings, err := ds.DeviceServiceRepo.GetAppointmentSettings(ctx)
+	if err != nil {
+		return []model.ServiceAppointment{}, common.NewErrorResponse(500, "gagal mengambil pengaturan jadwal")
+	}
+
+	day, _ := bookableDays(settings, time.Now())
+	if date != "" {
+		day, err = time.ParseInLocation(dateLayout, date, settings.Location())
+		if err != nil {
+			return []model.ServiceAppointment{}, common.NewErrorResponse(400, "format tanggal tidak valid!")
+		}
+	}
+
+	data, err := ds.DeviceServiceRepo.GetAppointmentsBetween(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return []model.ServiceAppointment{}, common.NewErrorResponse(500, "gagal mengambil data jadwal")
+	}
+	return data, nil
+}
+
+func appointmentError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, notFoundError), errors.Is(err, ErrHolidayNotFound):
+		return common.NewErrorResponse(404, err.Error())
+	case errors.Is(err, ErrSlotUnavailable):
+		return common.NewErrorResponse(422, err.Error())
+	case errors.Is(err, ErrSlotFull), errors.Is(err, ErrAppointmentExists),
+		errors.Is(err, ErrAppointmentNotAllowed), errors.Is(err, ErrAppointmentNotBooked):
+		return common.NewErrorResponse(409, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di server")
+}
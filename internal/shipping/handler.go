@@ -0,0 +1,270 @@
+package shipping
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/middleware"
+	"backEnd-RingoTechLife/pkg"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type ShippingHandler struct {
+	shippingService *ShippingService
+	validator       *validator.Validate
+}
+
+func NewShippingHandler(svc *ShippingService, vld *validator.Validate) *ShippingHandler {
+	return &ShippingHandler{
+		shippingService: svc,
+		validator:       vld,
+	}
+}
+
+func (sh *ShippingHandler) GetAllZonesHandler(w http.ResponseWriter, r *http.Request) {
+
+	data, err := sh.shippingService.GetAllZones(r.Context())
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (sh *ShippingHandler) GetZoneByIdHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	data, getErr := sh.shippingService.GetZoneById(r.Context(), id)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (sh *ShippingHandler) AddZoneHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.ShippingZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data zona dengan benar!")
+		return
+	}
+
+	if err := sh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, addErr := sh.shippingService.CreateZone(r.Context(), req)
+	if addErr != nil {
+		pkg.JSONError(w, addErr.Code, addErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menambahkan zona", data)
+}
+
+func (sh *ShippingHandler) UpdateZoneHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.ShippingZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data zona dengan benar!")
+		return
+	}
+
+	if err := sh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, updateErr := sh.shippingService.UpdateZone(r.Context(), id, req)
+	if updateErr != nil {
+		pkg.JSONError(w, updateErr.Code, updateErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengupdate zona", data)
+}
+
+func (sh *ShippingHandler) DeleteZoneHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	if delErr := sh.shippingService.DeleteZone(r.Context(), id); delErr != nil {
+		pkg.JSONError(w, delErr.Code, delErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menghapus zona", nil)
+}
+
+func (sh *ShippingHandler) AddRegionHandler(w http.ResponseWriter, r *http.Request) {
+
+	zoneId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.ShippingRegionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data wilayah dengan benar!")
+		return
+	}
+
+	if err := sh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, addErr := sh.shippingService.AddRegion(r.Context(), zoneId, req)
+	if addErr != nil {
+		pkg.JSONError(w, addErr.Code, addErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menambahkan wilayah", data)
+}
+
+func (sh *ShippingHandler) DeleteRegionHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	if delErr := sh.shippingService.DeleteRegion(r.Context(), id); delErr != nil {
+		pkg.JSONError(w, delErr.Code, delErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menghapus wilayah", nil)
+}
+
+func (sh *ShippingHandler) AddRateHandler(w http.ResponseWriter, r *http.Request) {
+
+	zoneId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.ShippingRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data tarif dengan benar!")
+		return
+	}
+
+	if err := sh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, addErr := sh.shippingService.AddRate(r.Context(), zoneId, req)
+	if addErr != nil {
+		pkg.JSONError(w, addErr.Code, addErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menambahkan tarif", data)
+}
+
+func (sh *ShippingHandler) UpdateRateHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.ShippingRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data tarif dengan benar!")
+		return
+	}
+
+	if err := sh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, updateErr := sh.shippingService.UpdateRate(r.Context(), id, req)
+	if updateErr != nil {
+		pkg.JSONError(w, updateErr.Code, updateErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengupdate tarif", data)
+}
+
+func (sh *ShippingHandler) DeleteRateHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	if delErr := sh.shippingService.DeleteRate(r.Context(), id); delErr != nil {
+		pkg.JSONError(w, delErr.Code, delErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menghapus tarif", nil)
+}
+
+func (sh *ShippingHandler) SetUpRoute(router chi.Router) {
+
+	router.Route("/shipping", func(r chi.Router) {
+		r.Use(httprate.Limit(
+			50,
+			time.Minute,
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				errorRes := common.NewErrorResponse(http.StatusTooManyRequests, "Terlalu banyak request, coba lagi nanti")
+				errorResJson, _ := json.Marshal(errorRes)
+				w.Write(errorResJson)
+			}),
+		))
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware(middleware.RoleAdmin))
+
+		r.Get("/zones", sh.GetAllZonesHandler)
+		r.Get("/zones/id/{id}", sh.GetZoneByIdHandler)
+		r.Post("/zones/add", sh.AddZoneHandler)
+		r.Put("/zones/update/{id}", sh.UpdateZoneHandler)
+		r.Delete("/zones/delete/{id}", sh.DeleteZoneHandler)
+
+		r.Post("/zones/{id}/regions/add", sh.AddRegionHandler)
+		r.Delete("/regions/delete/{id}", sh.DeleteRegionHandler)
+
+		r.Post("/zones/{id}/rates/add", sh.AddRateHandler)
+		r.Put("/rates/update/{id}", sh.UpdateRateHandler)
+		r.Delete("/rates/delete/{id}", sh.DeleteRateHandler)
+	})
+}
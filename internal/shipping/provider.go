@@ -0,0 +1,54 @@
+package shipping
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+)
+
+// ShippingRateProvider sumber tarif ongkir. sekarang cuma ada tabel tarif
+// yang diatur admin, nanti kalau mau pake API kurir tinggal bikin
+// implementasi baru terus ganti di configs.
+type ShippingRateProvider interface {
+	Quote(ctx context.Context, destination model.ShippingAddress, weightGram int) (model.ShippingQuote, error)
+}
+
+type TableRateProvider struct {
+	shippingRepo ShippingRepositoryInterface
+}
+
+func NewTableRateProvider(repo *ShippingRepositoryImpl) *TableRateProvider {
+	return &TableRateProvider{
+		shippingRepo: repo,
+	}
+}
+
+func (p *TableRateProvider) Quote(ctx context.Context, destination model.ShippingAddress, weightGram int) (model.ShippingQuote, error) {
+
+	zone, err := p.shippingRepo.FindZoneForDestination(ctx, destination.Province, destination.City)
+	if err != nil {
+		return model.ShippingQuote{}, err
+	}
+
+	rate, err := p.shippingRepo.FindRateForWeight(ctx, zone.ID, weightGram)
+	if err != nil {
+		return model.ShippingQuote{}, err
+	}
+
+	return model.ShippingQuote{
+		ZoneID:     zone.ID,
+		ZoneName:   zone.Name,
+		WeightGram: weightGram,
+		Cost:       rateCost(rate, weightGram),
+	}, nil
+}
+
+// rateCost harga bracket, kalau bracket terakhir (tanpa batas atas)
+// kelebihan berat di atas MinWeight dihitung per kg dibuletin ke atas
+func rateCost(rate model.ShippingRate, weightGram int) model.Money {
+	cost := rate.Price
+	if rate.MaxWeight == nil && weightGram > rate.MinWeight && rate.ExtraPerKg > 0 {
+		extraKg := (weightGram - rate.MinWeight + 999) / 1000
+		cost += rate.ExtraPerKg.Mul(extraKg)
+	}
+	return cost
+}
@@ -0,0 +1,340 @@
+package shipping
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrZoneNotFound     = errors.New("zona pengiriman tidak ditemukan!")
+	ErrRegionNotFound   = errors.New("wilayah pengiriman tidak ditemukan!")
+	ErrRateNotFound     = errors.New("tarif pengiriman tidak ditemukan!")
+	ErrRegionTaken      = errors.New("wilayah ini sudah terdaftar di zona lain!")
+	ErrRateOverlap      = errors.New("rentang berat bertabrakan dengan tarif lain di zona ini!")
+	ErrNoZoneForAddress = errors.New("alamat tujuan belum terjangkau pengiriman")
+	ErrNoRateForWeight  = errors.New("berat pesanan melebihi batas pengiriman")
+)
+
+type ShippingRepositoryInterface interface {
+	GetAllZones(ctx context.Context) ([]model.ShippingZone, error)
+	GetZoneByID(ctx context.Context, id uuid.UUID) (model.ShippingZone, error)
+	CreateZone(ctx context.Context, zone *model.ShippingZone) (*model.ShippingZone, error)
+	UpdateZone(ctx context.Context, zone *model.ShippingZone) (*model.ShippingZone, error)
+	DeleteZone(ctx context.Context, id uuid.UUID) error
+
+	AddRegion(ctx context.Context, region *model.ShippingZoneRegion) (*model.ShippingZoneRegion, error)
+	DeleteRegion(ctx context.Context, id uuid.UUID) error
+
+	AddRate(ctx context.Context, rate *model.ShippingRate) (*model.ShippingRate, error)
+	UpdateRate(ctx context.Context, rate *model.ShippingRate) (*model.ShippingRate, error)
+	DeleteRate(ctx context.Context, id uuid.UUID) error
+
+	FindZoneForDestination(ctx context.Context, province string, city string) (model.ShippingZone, error)
+	FindRateForWeight(ctx context.Context, zoneID uuid.UUID, weightGram int) (model.ShippingRate, error)
+}
+
+type ShippingRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewShippingRepository(pool *pgxpool.Pool) *ShippingRepositoryImpl {
+	return &ShippingRepositoryImpl{
+		db: pool,
+	}
+}
+
+func (r *ShippingRepositoryImpl) GetAllZones(ctx context.Context) ([]model.ShippingZone, error) {
+	rows, err := r.db.Query(ctx, `SELECT id, name, created_at, updated_at FROM shipping_zones ORDER BY name ASC`)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := make([]model.ShippingZone, 0)
+	for rows.Next() {
+		var z model.ShippingZone
+		if err := rows.Scan(&z.ID, &z.Name, &z.CreatedAt, &z.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan zone: %w", err)
+		}
+		zones = append(zones, z)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range zones {
+		if err := r.loadZoneDetails(ctx, &zones[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return zones, nil
+}
+
+func (r *ShippingRepositoryImpl) GetZoneByID(ctx context.Context, id uuid.UUID) (model.ShippingZone, error) {
+	var z model.ShippingZone
+	err := r.db.QueryRow(ctx, `SELECT id, name, created_at, updated_at FROM shipping_zones WHERE id = $1`, id).
+		Scan(&z.ID, &z.Name, &z.CreatedAt, &z.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ShippingZone{}, ErrZoneNotFound
+		}
+		return model.ShippingZone{}, err
+	}
+
+	if err := r.loadZoneDetails(ctx, &z); err != nil {
+		return model.ShippingZone{}, err
+	}
+
+	return z, nil
+}
+
+func (r *ShippingRepositoryImpl) loadZoneDetails(ctx context.Context, zone *model.ShippingZone) error {
+	regionRows, err := r.db.Query(ctx, `
+		SELECT id, zone_id, province, city, created_at
+		FROM shipping_zone_regions
+		WHERE zone_id = $1
+		ORDER BY province ASC, city ASC NULLS FIRST
+	`, zone.ID)
+	if err != nil {
+		return err
+	}
+
+	zone.Regions, err = pgx.CollectRows(regionRows, func(row pgx.CollectableRow) (model.ShippingZoneRegion, error) {
+		var rg model.ShippingZoneRegion
+		err := row.Scan(&rg.ID, &rg.ZoneID, &rg.Province, &rg.City, &rg.CreatedAt)
+		return rg, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan region: %w", err)
+	}
+
+	rateRows, err := r.db.Query(ctx, rateSelectQuery+`
+		WHERE zone_id = $1
+		ORDER BY min_weight ASC
+	`, zone.ID)
+	if err != nil {
+		return err
+	}
+
+	zone.Rates, err = pgx.CollectRows(rateRows, func(row pgx.CollectableRow) (model.ShippingRate, error) {
+		return scanRate(row)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan rate: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ShippingRepositoryImpl) CreateZone(ctx context.Context, zone *model.ShippingZone) (*model.ShippingZone, error) {
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO shipping_zones (name) VALUES ($1) RETURNING id, created_at, updated_at`,
+		zone.Name,
+	).Scan(&zone.ID, &zone.CreatedAt, &zone.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zone: %w", err)
+	}
+
+	zone.Regions = []model.ShippingZoneRegion{}
+	zone.Rates = []model.ShippingRate{}
+	return zone, nil
+}
+
+func (r *ShippingRepositoryImpl) UpdateZone(ctx context.Context, zone *model.ShippingZone) (*model.ShippingZone, error) {
+	err := r.db.QueryRow(ctx,
+		`UPDATE shipping_zones SET name = $1, updated_at = NOW() WHERE id = $2 RETURNING created_at, updated_at`,
+		zone.Name, zone.ID,
+	).Scan(&zone.CreatedAt, &zone.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrZoneNotFound
+		}
+		return nil, fmt.Errorf("failed to update zone: %w", err)
+	}
+	return zone, nil
+}
+
+// DeleteZone ikut ngapus wilayah & tarifnya (ON DELETE CASCADE)
+func (r *ShippingRepositoryImpl) DeleteZone(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.Exec(ctx, `DELETE FROM shipping_zones WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrZoneNotFound
+	}
+	return nil
+}
+
+func (r *ShippingRepositoryImpl) AddRegion(ctx context.Context, region *model.ShippingZoneRegion) (*model.ShippingZoneRegion, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO shipping_zone_regions (zone_id, province, city)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, region.ZoneID, region.Province, region.City).Scan(&region.ID, &region.CreatedAt)
+
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok {
+			switch pgErr.Code {
+			case "23505":
+				return nil, ErrRegionTaken
+			case "23503":
+				return nil, ErrZoneNotFound
+			}
+		}
+		return nil, fmt.Errorf("failed to add region: %w", err)
+	}
+	return region, nil
+}
+
+func (r *ShippingRepositoryImpl) DeleteRegion(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.Exec(ctx, `DELETE FROM shipping_zone_regions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrRegionNotFound
+	}
+	return nil
+}
+
+const rateSelectQuery = `
+	SELECT id, zone_id, min_weight, max_weight, price, extra_per_kg, created_at, updated_at
+	FROM shipping_rates
+`
+
+func (r *ShippingRepositoryImpl) AddRate(ctx context.Context, rate *model.ShippingRate) (*model.ShippingRate, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO shipping_rates (zone_id, min_weight, max_weight, price, extra_per_kg)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`,
+		rate.ZoneID,
+		rate.MinWeight,
+		rate.MaxWeight,
+		rate.Price,
+		rate.ExtraPerKg,
+	).Scan(&rate.ID, &rate.CreatedAt, &rate.UpdatedAt)
+
+	if err != nil {
+		return nil, rateWriteError(err)
+	}
+	return rate, nil
+}
+
+func (r *ShippingRepositoryImpl) UpdateRate(ctx context.Context, rate *model.ShippingRate) (*model.ShippingRate, error) {
+	err := r.db.QueryRow(ctx, `
+		UPDATE shipping_rates
+		SET min_weight = $1, max_weight = $2, price = $3, extra_per_kg = $4, updated_at = NOW()
+		WHERE id = $5
+		RETURNING zone_id, created_at, updated_at
+	`,
+		rate.MinWeight,
+		rate.MaxWeight,
+		rate.Price,
+		rate.ExtraPerKg,
+		rate.ID,
+	).Scan(&rate.ZoneID, &rate.CreatedAt, &rate.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRateNotFound
+		}
+		return nil, rateWriteError(err)
+	}
+	return rate, nil
+}
+
+func (r *ShippingRepositoryImpl) DeleteRate(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.Exec(ctx, `DELETE FROM shipping_rates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrRateNotFound
+	}
+	return nil
+}
+
+// FindZoneForDestination nyari zona buat alamat tujuan.
+// wilayah yang nyebut kota lebih diutamain daripada yang cuma provinsi.
+func (r *ShippingRepositoryImpl) FindZoneForDestination(ctx context.Context, province string, city string) (model.ShippingZone, error) {
+	query := `
+		SELECT z.id, z.name, z.created_at, z.updated_at
+		FROM shipping_zone_regions rg
+		INNER JOIN shipping_zones z ON z.id = rg.zone_id
+		WHERE LOWER(TRIM(rg.province)) = LOWER(TRIM($1))
+		  AND (rg.city IS NULL OR LOWER(TRIM(rg.city)) = LOWER(TRIM($2)))
+		ORDER BY rg.city IS NULL ASC
+		LIMIT 1
+	`
+
+	var z model.ShippingZone
+	err := r.db.QueryRow(ctx, query, province, city).Scan(&z.ID, &z.Name, &z.CreatedAt, &z.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ShippingZone{}, ErrNoZoneForAddress
+		}
+		return model.ShippingZone{}, err
+	}
+	return z, nil
+}
+
+func (r *ShippingRepositoryImpl) FindRateForWeight(ctx context.Context, zoneID uuid.UUID, weightGram int) (model.ShippingRate, error) {
+	query := rateSelectQuery + `
+		WHERE zone_id = $1 AND min_weight <= $2 AND (max_weight IS NULL OR max_weight >= $2)
+		ORDER BY min_weight DESC
+		LIMIT 1
+	`
+
+	rate, err := scanRate(r.db.QueryRow(ctx, query, zoneID, weightGram))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ShippingRate{}, ErrNoRateForWeight
+		}
+		return model.ShippingRate{}, err
+	}
+	return rate, nil
+}
+
+func rateWriteError(err error) error {
+	if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok {
+		switch pgErr.Code {
+		case "23P01":
+			// exclusion constraint, rentang berat nya numpuk
+			return ErrRateOverlap
+		case "23503":
+			return ErrZoneNotFound
+		}
+	}
+	return fmt.Errorf("failed to save rate: %w", err)
+}
+
+type scannable interface {
+	Scan(dest ...any) error
+}
+
+func scanRate(row scannable) (model.ShippingRate, error) {
+	var rate model.ShippingRate
+	err := row.Scan(
+		&rate.ID,
+		&rate.ZoneID,
+		&rate.MinWeight,
+		&rate.MaxWeight,
+		&rate.Price,
+		&rate.ExtraPerKg,
+		&rate.CreatedAt,
+		&rate.UpdatedAt,
+	)
+	return rate, err
+}
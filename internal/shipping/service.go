@@ -0,0 +1,157 @@
+package shipping
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+type ShippingService struct {
+	shippingRepo ShippingRepositoryInterface
+	rateProvider ShippingRateProvider
+}
+
+func NewShippingService(repo *ShippingRepositoryImpl, provider ShippingRateProvider) *ShippingService {
+	return &ShippingService{
+		shippingRepo: repo,
+		rateProvider: provider,
+	}
+}
+
+// Quote ngitung ongkir ke alamat tujuan, dipake pas checkout & preview ongkir
+func (s *ShippingService) Quote(ctx context.Context, destination model.ShippingAddress, weightGram int) (model.ShippingQuote, *common.ErrorResponse) {
+
+	quote, err := s.rateProvider.Quote(ctx, destination, weightGram)
+	if err != nil {
+		if errors.Is(err, ErrNoZoneForAddress) || errors.Is(err, ErrNoRateForWeight) {
+			return model.ShippingQuote{}, common.NewErrorResponse(422, err.Error())
+		}
+		return model.ShippingQuote{}, common.NewErrorResponse(500, "gagal menghitung ongkir! "+err.Error())
+	}
+
+	return quote, nil
+}
+
+func (s *ShippingService) GetAllZones(ctx context.Context) ([]model.ShippingZone, *common.ErrorResponse) {
+
+	data, err := s.shippingRepo.GetAllZones(ctx)
+	if err != nil {
+		return []model.ShippingZone{}, common.NewErrorResponse(500, "gagal mengambil data zona! "+err.Error())
+	}
+	return data, nil
+}
+
+func (s *ShippingService) GetZoneById(ctx context.Context, id uuid.UUID) (model.ShippingZone, *common.ErrorResponse) {
+
+	data, err := s.shippingRepo.GetZoneByID(ctx, id)
+	if err != nil {
+		return model.ShippingZone{}, shippingError(err)
+	}
+	return data, nil
+}
+
+func (s *ShippingService) CreateZone(ctx context.Context, req dto.ShippingZoneRequest) (model.ShippingZone, *common.ErrorResponse) {
+
+	zone := model.ShippingZone{Name: req.Name}
+	data, err := s.shippingRepo.CreateZone(ctx, &zone)
+	if err != nil {
+		return model.ShippingZone{}, shippingError(err)
+	}
+	return *data, nil
+}
+
+func (s *ShippingService) UpdateZone(ctx context.Context, id uuid.UUID, req dto.ShippingZoneRequest) (model.ShippingZone, *common.ErrorResponse) {
+
+	zone := model.ShippingZone{ID: id, Name: req.Name}
+	if _, err := s.shippingRepo.UpdateZone(ctx, &zone); err != nil {
+		return model.ShippingZone{}, shippingError(err)
+	}
+	return s.GetZoneById(ctx, id)
+}
+
+func (s *ShippingService) DeleteZone(ctx context.Context, id uuid.UUID) *common.ErrorResponse {
+
+	if err := s.shippingRepo.DeleteZone(ctx, id); err != nil {
+		return shippingError(err)
+	}
+	return nil
+}
+
+func (s *ShippingService) AddRegion(ctx context.Context, zoneId uuid.UUID, req dto.ShippingRegionRequest) (model.ShippingZoneRegion, *common.ErrorResponse) {
+
+	region := model.ShippingZoneRegion{
+		ZoneID:   zoneId,
+		Province: req.Province,
+		City:     req.City,
+	}
+
+	data, err := s.shippingRepo.AddRegion(ctx, &region)
+	if err != nil {
+		return model.ShippingZoneRegion{}, shippingError(err)
+	}
+	return *data, nil
+}
+
+func (s *ShippingService) DeleteRegion(ctx context.Context, id uuid.UUID) *common.ErrorResponse {
+
+	if err := s.shippingRepo.DeleteRegion(ctx, id); err != nil {
+		return shippingError(err)
+	}
+	return nil
+}
+
+func (s *ShippingService) AddRate(ctx context.Context, zoneId uuid.UUID, req dto.ShippingRateRequest) (model.ShippingRate, *common.ErrorResponse) {
+
+	rate := model.ShippingRate{
+		ZoneID:     zoneId,
+		MinWeight:  req.MinWeight,
+		MaxWeight:  req.MaxWeight,
+		Price:      req.Price,
+		ExtraPerKg: req.ExtraPerKg,
+	}
+
+	data, err := s.shippingRepo.AddRate(ctx, &rate)
+	if err != nil {
+		return model.ShippingRate{}, shippingError(err)
+	}
+	return *data, nil
+}
+
+func (s *ShippingService) UpdateRate(ctx context.Context, id uuid.UUID, req dto.ShippingRateRequest) (model.ShippingRate, *common.ErrorResponse) {
+
+	rate := model.ShippingRate{
+		ID:         id,
+		MinWeight:  req.MinWeight,
+		MaxWeight:  req.MaxWeight,
+		Price:      req.Price,
+		ExtraPerKg: req.ExtraPerKg,
+	}
+
+	data, err := s.shippingRepo.UpdateRate(ctx, &rate)
+	if err != nil {
+		return model.ShippingRate{}, shippingError(err)
+	}
+	return *data, nil
+}
+
+func (s *ShippingService) DeleteRate(ctx context.Context, id uuid.UUID) *common.ErrorResponse {
+
+	if err := s.shippingRepo.DeleteRate(ctx, id); err != nil {
+		return shippingError(err)
+	}
+	return nil
+}
+
+func shippingError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, ErrZoneNotFound), errors.Is(err, ErrRegionNotFound), errors.Is(err, ErrRateNotFound):
+		return common.NewErrorResponse(404, err.Error())
+	case errors.Is(err, ErrRegionTaken), errors.Is(err, ErrRateOverlap):
+		return common.NewErrorResponse(409, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di database "+err.Error())
+}
@@ -0,0 +1,270 @@
+package user
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrAddressNotFound = errors.New("alamat tidak ditemukan!")
+
+type AddressRepositoryInterface interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.UserAddress, error)
+	GetByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (model.UserAddress, error)
+	GetDefault(ctx context.Context, userID uuid.UUID) (model.UserAddress, error)
+	Create(ctx context.Context, address *model.UserAddress) (*model.UserAddress, error)
+	Update(ctx context.Context, address *model.UserAddress) (*model.UserAddress, error)
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	SetDefault(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+}
+
+type AddressRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewAddressRepository(pool *pgxpool.Pool) *AddressRepositoryImpl {
+	return &AddressRepositoryImpl{
+		db: pool,
+	}
+}
+
+const addressSelectQuery = `
+	SELECT id, user_id, label, recipient_name, phone_number,
+	       province, city, district, postal_code, address_line,
+	       is_default, created_at, updated_at
+	FROM user_addresses
+`
+
+func (r *AddressRepositoryImpl) GetByUserID(ctx context.Context, userID uuid.UUID) ([]model.UserAddress, error) {
+	query := addressSelectQuery + `
+	WHERE user_id = $1
+	ORDER BY is_default DESC, created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := make([]model.UserAddress, 0)
+	for rows.Next() {
+		a, err := scanAddress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address: %w", err)
+		}
+		addresses = append(addresses, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+func (r *AddressRepositoryImpl) GetByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (model.UserAddress, error) {
+	query := addressSelectQuery + `
+	WHERE user_id = $1 AND id = $2
+	`
+
+	a, err := scanAddress(r.db.QueryRow(ctx, query, userID, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.UserAddress{}, ErrAddressNotFound
+		}
+		return model.UserAddress{}, err
+	}
+	return a, nil
+}
+
+func (r *AddressRepositoryImpl) GetDefault(ctx context.Context, userID uuid.UUID) (model.UserAddress, error) {
+	query := addressSelectQuery + `
+	WHERE user_id = $1 AND is_default = TRUE
+	`
+
+	a, err := scanAddress(r.db.QueryRow(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.UserAddress{}, ErrAddressNotFound
+		}
+		return model.UserAddress{}, err
+	}
+	return a, nil
+}
+
+// Create nyimpen alamat baru. alamat pertama user otomatis jadi default,
+// kalau IsDefault true default yang lama dicabut dulu di transaksi yang sama.
+func (r *AddressRepositoryImpl) Create(ctx context.Context, address *model.UserAddress) (*model.UserAddress, error) {
+	query := `
+		INSERT INTO user_addresses
+			(user_id, label, recipient_name, phone_number, province, city, district, postal_code, address_line, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// dikunci per user biar dua request barengan ga sama-sama jadi default
+		if _, err := tx.Exec(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, address.UserID); err != nil {
+			return err
+		}
+
+		var hasDefault bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM user_addresses WHERE user_id = $1 AND is_default = TRUE)`, address.UserID).
+			Scan(&hasDefault)
+		if err != nil {
+			return err
+		}
+
+		if !hasDefault {
+			address.IsDefault = true
+		} else if address.IsDefault {
+			if err := clearDefaultTx(ctx, tx, address.UserID); err != nil {
+				return err
+			}
+		}
+
+		return tx.QueryRow(ctx, query,
+			address.UserID,
+			address.Label,
+			address.RecipientName,
+			address.PhoneNumber,
+			address.Province,
+			address.City,
+			address.District,
+			address.PostalCode,
+			address.AddressLine,
+			address.IsDefault,
+		).Scan(&address.ID, &address.CreatedAt, &address.UpdatedAt)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create address: %w", err)
+	}
+
+	return address, nil
+}
+
+func (r *AddressRepositoryImpl) Update(ctx context.Context, address *model.UserAddress) (*model.UserAddress, error) {
+	query := `
+		UPDATE user_addresses
+		SET label = $1, recipient_name = $2, phone_number = $3, province = $4,
+		    city = $5, district = $6, postal_code = $7, address_line = $8,
+		    updated_at = NOW()
+		WHERE id = $9 AND user_id = $10
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		address.Label,
+		address.RecipientName,
+		address.PhoneNumber,
+		address.Province,
+		address.City,
+		address.District,
+		address.PostalCode,
+		address.AddressLine,
+		address.ID,
+		address.UserID,
+	).Scan(&address.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAddressNotFound
+		}
+		return nil, fmt.Errorf("failed to update address: %w", err)
+	}
+
+	return address, nil
+}
+
+// Delete ngapus alamat, kalau yang dihapus default nya pindah ke alamat terbaru
+func (r *AddressRepositoryImpl) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var wasDefault bool
+		err := tx.QueryRow(ctx,
+			`DELETE FROM user_addresses WHERE id = $1 AND user_id = $2 RETURNING is_default`,
+			id, userID,
+		).Scan(&wasDefault)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrAddressNotFound
+			}
+			return err
+		}
+
+		if !wasDefault {
+			return nil
+		}
+
+		promoteQuery := `
+			UPDATE user_addresses
+			SET is_default = TRUE, updated_at = NOW()
+			WHERE id = (
+				SELECT id FROM user_addresses
+				WHERE user_id = $1
+				ORDER BY created_at DESC
+				LIMIT 1
+			)
+		`
+		_, err = tx.Exec(ctx, promoteQuery, userID)
+		return err
+	})
+}
+
+func (r *AddressRepositoryImpl) SetDefault(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := clearDefaultTx(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(ctx,
+			`UPDATE user_addresses SET is_default = TRUE, updated_at = NOW() WHERE id = $1 AND user_id = $2`,
+			id, userID,
+		)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return ErrAddressNotFound
+		}
+		return nil
+	})
+}
+
+func clearDefaultTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE user_addresses SET is_default = FALSE, updated_at = NOW() WHERE user_id = $1 AND is_default = TRUE`,
+		userID,
+	)
+	return err
+}
+
+type scannable interface {
+	Scan(dest ...any) error
+}
+
+func scanAddress(row scannable) (model.UserAddress, error) {
+	var a model.UserAddress
+	err := row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.Label,
+		&a.RecipientName,
+		&a.PhoneNumber,
+		&a.Province,
+		&a.City,
+		&a.District,
+		&a.PostalCode,
+		&a.AddressLine,
+		&a.IsDefault,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	return a, err
+}
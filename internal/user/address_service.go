@@ -0,0 +1,142 @@
+package user
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+type AddressService struct {
+	addressRepo AddressRepositoryInterface
+}
+
+func NewAddressService(repo *AddressRepositoryImpl) *AddressService {
+	return &AddressService{
+		addressRepo: repo,
+	}
+}
+
+func (s *AddressService) GetMyAddresses(ctx context.Context, userId uuid.UUID) ([]model.UserAddress, *common.ErrorResponse) {
+
+	data, err := s.addressRepo.GetByUserID(ctx, userId)
+	if err != nil {
+		return []model.UserAddress{}, common.NewErrorResponse(500, "gagal mengambil data alamat! "+err.Error())
+	}
+	return data, nil
+}
+
+func (s *AddressService) Create(ctx context.Context, userId uuid.UUID, req dto.CreateAddressRequest) (model.UserAddress, *common.ErrorResponse) {
+
+	address := model.UserAddress{
+		UserID:        userId,
+		Label:         req.Label,
+		RecipientName: req.RecipientName,
+		PhoneNumber:   req.PhoneNumber,
+		Province:      req.Province,
+		City:          req.City,
+		District:      req.District,
+		PostalCode:    req.PostalCode,
+		AddressLine:   req.AddressLine,
+		IsDefault:     req.IsDefault,
+	}
+
+	result, err := s.addressRepo.Create(ctx, &address)
+	if err != nil {
+		return model.UserAddress{}, common.NewErrorResponse(500, "gagal menyimpan alamat! "+err.Error())
+	}
+
+	return *result, nil
+}
+
+func (s *AddressService) Update(ctx context.Context, userId uuid.UUID, id uuid.UUID, req dto.UpdateAddressRequest) (model.UserAddress, *common.ErrorResponse) {
+
+	address, err := s.addressRepo.GetByID(ctx, userId, id)
+	if err != nil {
+		return model.UserAddress{}, addressError(err)
+	}
+
+	if req.Label != nil {
+		address.Label = req.Label
+	}
+	if req.RecipientName != nil {
+		address.RecipientName = *req.RecipientName
+	}
+	if req.PhoneNumber != nil {
+		address.PhoneNumber = *req.PhoneNumber
+	}
+	if req.Province != nil {
+		address.Province = *req.Province
+	}
+	if req.City != nil {
+		address.City = *req.City
+	}
+	if req.District != nil {
+		address.District = *req.District
+	}
+	if req.PostalCode != nil {
+		address.PostalCode = *req.PostalCode
+	}
+	if req.AddressLine != nil {
+		address.AddressLine = *req.AddressLine
+	}
+
+	result, err := s.addressRepo.Update(ctx, &address)
+	if err != nil {
+		return model.UserAddress{}, addressError(err)
+	}
+
+	return *result, nil
+}
+
+func (s *AddressService) Delete(ctx context.Context, userId uuid.UUID, id uuid.UUID) *common.ErrorResponse {
+
+	if err := s.addressRepo.Delete(ctx, userId, id); err != nil {
+		return addressError(err)
+	}
+	return nil
+}
+
+func (s *AddressService) SetDefault(ctx context.Context, userId uuid.UUID, id uuid.UUID) *common.ErrorResponse {
+
+	if err := s.addressRepo.SetDefault(ctx, userId, id); err != nil {
+		return addressError(err)
+	}
+	return nil
+}
+
+// ResolveShippingAddress ngambil alamat buat checkout, kalau addressId nil
+// pake alamat default user. hasilnya udah bentuk snapshot buat disimpen di order.
+func (s *AddressService) ResolveShippingAddress(ctx context.Context, userId uuid.UUID, addressId *uuid.UUID) (*model.ShippingAddress, *common.ErrorResponse) {
+
+	var address model.UserAddress
+	var err error
+
+	if addressId != nil {
+		address, err = s.addressRepo.GetByID(ctx, userId, *addressId)
+	} else {
+		address, err = s.addressRepo.GetDefault(ctx, userId)
+	}
+
+	if err != nil {
+		if errors.Is(err, ErrAddressNotFound) {
+			if addressId == nil {
+				return nil, common.NewErrorResponse(400, "kamu belum punya alamat pengiriman! tambahkan alamat terlebih dahulu")
+			}
+			return nil, common.NewErrorResponse(404, err.Error())
+		}
+		return nil, common.NewErrorResponse(500, "gagal mengambil data alamat! "+err.Error())
+	}
+
+	return address.ToShippingAddress(), nil
+}
+
+func addressError(err error) *common.ErrorResponse {
+	if errors.Is(err, ErrAddressNotFound) {
+		return common.NewErrorResponse(404, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di database "+err.Error())
+}
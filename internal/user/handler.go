@@ -22,16 +22,18 @@ const (
 )
 
 type UserHandler struct {
-	UserService *UserService
-	Validator   *validator.Validate
-	decoder     *form.Decoder
+	UserService    *UserService
+	AddressService *AddressService
+	Validator      *validator.Validate
+	decoder        *form.Decoder
 }
 
-func NewUserHandler(svc *UserService, decode *form.Decoder, validator *validator.Validate) *UserHandler {
+func NewUserHandler(svc *UserService, addrSvc *AddressService, decode *form.Decoder, validator *validator.Validate) *UserHandler {
 	return &UserHandler{
-		UserService: svc,
-		Validator:   validator,
-		decoder:     decode,
+		UserService:    svc,
+		AddressService: addrSvc,
+		Validator:      validator,
+		decoder:        decode,
 	}
 }
 
@@ -87,6 +89,9 @@ func (h *UserHandler) UpdateCurrentUserHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// role cuma boleh diganti admin lewat /user/{id}
+	req.Role = nil
+
 	if len(r.MultipartForm.File) != 0 {
 		req.ProfilePicture = r.MultipartForm.File["profile_picture"][0]
 	}
@@ -124,6 +129,111 @@ func (h *UserHandler) DeleteCurrentUserHandler(w http.ResponseWriter, r *http.Re
 
 }
 
+// ==================== ADDRESS ENDPOINTS ====================
+
+// GetMyAddressesHandler - GET /user/addresses
+func (h *UserHandler) GetMyAddressesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r.Context())
+
+	data, err := h.AddressService.GetMyAddresses(r.Context(), userID)
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "Berhasil mengambil data alamat", data)
+}
+
+// AddAddressHandler - POST /user/addresses/add
+func (h *UserHandler) AddAddressHandler(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data alamat dengan benar!")
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+
+	data, err := h.AddressService.Create(r.Context(), userID, req)
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "Berhasil menambahkan alamat", data)
+}
+
+// UpdateAddressHandler - PUT /user/addresses/update/{id}
+func (h *UserHandler) UpdateAddressHandler(w http.ResponseWriter, r *http.Request) {
+	addressID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "ID tidak valid")
+		return
+	}
+
+	var req dto.UpdateAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data alamat dengan benar!")
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+
+	data, updateErr := h.AddressService.Update(r.Context(), userID, addressID, req)
+	if updateErr != nil {
+		pkg.JSONError(w, updateErr.Code, updateErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "Berhasil update alamat", data)
+}
+
+// DeleteAddressHandler - DELETE /user/addresses/delete/{id}
+func (h *UserHandler) DeleteAddressHandler(w http.ResponseWriter, r *http.Request) {
+	addressID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "ID tidak valid")
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+
+	if delErr := h.AddressService.Delete(r.Context(), userID, addressID); delErr != nil {
+		pkg.JSONError(w, delErr.Code, delErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "Berhasil menghapus alamat", nil)
+}
+
+// SetDefaultAddressHandler - PUT /user/addresses/set-default/{id}
+func (h *UserHandler) SetDefaultAddressHandler(w http.ResponseWriter, r *http.Request) {
+	addressID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "ID tidak valid")
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+
+	if setErr := h.AddressService.SetDefault(r.Context(), userID, addressID); setErr != nil {
+		pkg.JSONError(w, setErr.Code, setErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "Berhasil mengubah alamat utama", nil)
+}
+
 // ==================== ADMIN ENDPOINTS ====================
 
 // GetUserByIDHandler - GET /user/{id}
@@ -275,6 +385,12 @@ func (h *UserHandler) SetUpRoute(router chi.Router) {
 			r.Get("/profile/me", h.GetCurrentUserHandler)
 			r.Put("/profile", h.UpdateCurrentUserHandler)
 			r.Delete("/profile", h.DeleteCurrentUserHandler)
+
+			r.Get("/addresses", h.GetMyAddressesHandler)
+			r.Post("/addresses/add", h.AddAddressHandler)
+			r.Put("/addresses/update/{id}", h.UpdateAddressHandler)
+			r.Delete("/addresses/delete/{id}", h.DeleteAddressHandler)
+			r.Put("/addresses/set-default/{id}", h.SetDefaultAddressHandler)
 		})
 
 		// Admin endpoints (admin only)
@@ -0,0 +1,147 @@
+package voucher
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/middleware"
+	"backEnd-RingoTechLife/pkg"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type VoucherHandler struct {
+	voucherService *VoucherService
+	validator      *validator.Validate
+}
+
+func NewVoucherHandler(svc *VoucherService, vld *validator.Validate) *VoucherHandler {
+	return &VoucherHandler{
+		voucherService: svc,
+		validator:      vld,
+	}
+}
+
+func (vh *VoucherHandler) GetAllHandler(w http.ResponseWriter, r *http.Request) {
+
+	data, err := vh.voucherService.GetAll(r.Context())
+	if err != nil {
+		pkg.JSONError(w, err.Code, err.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (vh *VoucherHandler) GetByIdHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	data, getErr := vh.voucherService.GetById(r.Context(), id)
+	if getErr != nil {
+		pkg.JSONError(w, getErr.Code, getErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengambil data", data)
+}
+
+func (vh *VoucherHandler) AddHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.VoucherRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data voucher dengan benar!")
+		return
+	}
+
+	if err := vh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, addErr := vh.voucherService.Create(r.Context(), req)
+	if addErr != nil {
+		pkg.JSONError(w, addErr.Code, addErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menambahkan voucher", data)
+}
+
+func (vh *VoucherHandler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	var req dto.VoucherRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		pkg.JSONError(w, 400, "harap isi data voucher dengan benar!")
+		return
+	}
+
+	if err := vh.validator.Struct(req); err != nil {
+		pkg.JSONError(w, 400, pkg.ValidationErrorsToMap(err))
+		return
+	}
+
+	data, updateErr := vh.voucherService.Update(r.Context(), id, req)
+	if updateErr != nil {
+		pkg.JSONError(w, updateErr.Code, updateErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil mengupdate voucher", data)
+}
+
+func (vh *VoucherHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		pkg.JSONError(w, 400, "id tidak valid!")
+		return
+	}
+
+	if delErr := vh.voucherService.Delete(r.Context(), id); delErr != nil {
+		pkg.JSONError(w, delErr.Code, delErr.Message)
+		return
+	}
+
+	pkg.JSONSuccess(w, 200, "berhasil menghapus voucher", nil)
+}
+
+func (vh *VoucherHandler) SetUpRoute(router chi.Router) {
+
+	router.Route("/vouchers", func(r chi.Router) {
+		r.Use(httprate.Limit(
+			50,
+			time.Minute,
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				errorRes := common.NewErrorResponse(http.StatusTooManyRequests, "Terlalu banyak request, coba lagi nanti")
+				errorResJson, _ := json.Marshal(errorRes)
+				w.Write(errorResJson)
+			}),
+		))
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware(middleware.RoleAdmin))
+
+		r.Get("/get-all", vh.GetAllHandler)
+		r.Get("/id/{id}", vh.GetByIdHandler)
+		r.Post("/add", vh.AddHandler)
+		r.Put("/update/{id}", vh.UpdateHandler)
+		r.Delete("/delete/{id}", vh.DeleteHandler)
+	})
+}
@@ -0,0 +1,469 @@
+package voucher
+
+import (
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrVoucherInvalid induk semua error aturan voucher, jadi caller cukup
+// errors.Is(err, ErrVoucherInvalid) buat bedain sama error database
+var ErrVoucherInvalid = errors.New("voucher tidak dapat digunakan")
+
+type voucherRuleError struct {
+	msg string
+}
+
+func (e *voucherRuleError) Error() string {
+	return e.msg
+}
+
+func (e *voucherRuleError) Unwrap() error {
+	return ErrVoucherInvalid
+}
+
+var (
+	ErrVoucherNotFound      = errors.New("voucher tidak ditemukan!")
+	ErrVoucherCodeTaken     = errors.New("kode voucher sudah dipakai!")
+	ErrVoucherScopeNotFound = errors.New("produk atau kategori voucher tidak ditemukan!")
+	ErrVoucherInUse         = errors.New("voucher sudah pernah dipakai, nonaktifkan saja")
+
+	ErrVoucherUnknownCode    error = &voucherRuleError{"kode voucher tidak valid"}
+	ErrVoucherInactive       error = &voucherRuleError{"voucher sedang tidak aktif"}
+	ErrVoucherNotStarted     error = &voucherRuleError{"voucher belum bisa digunakan"}
+	ErrVoucherExpired        error = &voucherRuleError{"voucher sudah kadaluarsa"}
+	ErrVoucherQuotaExhausted error = &voucherRuleError{"kuota voucher sudah habis"}
+	ErrVoucherUserLimit      error = &voucherRuleError{"kamu sudah mencapai batas pemakaian voucher ini"}
+	ErrVoucherNotApplicable  error = &voucherRuleError{"voucher tidak berlaku untuk produk di pesanan ini"}
+	ErrVoucherMinSpend       error = &voucherRuleError{"belanjaan belum mencapai minimum pembelian voucher"}
+)
+
+type VoucherRepositoryInterface interface {
+	GetAll(ctx context.Context) ([]model.Voucher, error)
+	GetByID(ctx context.Context, id uuid.UUID) (model.Voucher, error)
+	Create(ctx context.Context, v *model.Voucher) (*model.Voucher, error)
+	Update(ctx context.Context, v *model.Voucher) (*model.Voucher, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Preview(ctx context.Context, code string, userID uuid.UUID, items []model.OrderItem) (model.AppliedVoucher, error)
+}
+
+type VoucherRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewVoucherRepository(pool *pgxpool.Pool) *VoucherRepositoryImpl {
+	return &VoucherRepositoryImpl{
+		db: pool,
+	}
+}
+
+const voucherSelectQuery = `
+	SELECT
+		v.id, v.code, v.description, v.discount_type, v.discount_value,
+		v.min_spend, v.max_discount, v.starts_at, v.ends_at,
+		v.usage_limit, v.per_user_limit, v.used_count, v.is_active,
+		COALESCE((SELECT array_agg(vp.product_id) FROM voucher_products vp WHERE vp.voucher_id = v.id), '{}') AS product_ids,
+		COALESCE((SELECT array_agg(vc.category_id) FROM voucher_categories vc WHERE vc.voucher_id = v.id), '{}') AS category_ids,
+		v.created_at, v.updated_at
+	FROM vouchers v
+`
+
+func (r *VoucherRepositoryImpl) GetAll(ctx context.Context) ([]model.Voucher, error) {
+	rows, err := r.db.Query(ctx, voucherSelectQuery+` ORDER BY v.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+
+	vouchers, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Voucher, error) {
+		return scanVoucher(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan voucher: %w", err)
+	}
+
+	return vouchers, nil
+}
+
+func (r *VoucherRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (model.Voucher, error) {
+	v, err := scanVoucher(r.db.QueryRow(ctx, voucherSelectQuery+` WHERE v.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Voucher{}, ErrVoucherNotFound
+		}
+		return model.Voucher{}, err
+	}
+	return v, nil
+}
+
+func (r *VoucherRepositoryImpl) Create(ctx context.Context, v *model.Voucher) (*model.Voucher, error) {
+	query := `
+		INSERT INTO vouchers
+			(code, description, discount_type, discount_value, min_spend, max_discount,
+			 starts_at, ends_at, usage_limit, per_user_limit, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, used_count, created_at, updated_at
+	`
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			v.Code,
+			v.Description,
+			v.DiscountType,
+			v.DiscountValue,
+			v.MinSpend,
+			v.MaxDiscount,
+			v.StartsAt,
+			v.EndsAt,
+			v.UsageLimit,
+			v.PerUserLimit,
+			v.IsActive,
+		).Scan(&v.ID, &v.UsedCount, &v.CreatedAt, &v.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		return replaceScopeTx(ctx, tx, v)
+	})
+
+	if err != nil {
+		return nil, voucherWriteError(err)
+	}
+
+	return v, nil
+}
+
+func (r *VoucherRepositoryImpl) Update(ctx context.Context, v *model.Voucher) (*model.Voucher, error) {
+	query := `
+		UPDATE vouchers
+		SET code = $1, description = $2, discount_type = $3, discount_value = $4,
+		    min_spend = $5, max_discount = $6, starts_at = $7, ends_at = $8,
+		    usage_limit = $9, per_user_limit = $10, is_active = $11, updated_at = NOW()
+		WHERE id = $12
+		RETURNING used_count, created_at, updated_at
+	`
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			v.Code,
+			v.Description,
+			v.DiscountType,
+			v.DiscountValue,
+			v.MinSpend,
+			v.MaxDiscount,
+			v.StartsAt,
+			v.EndsAt,
+			v.UsageLimit,
+			v.PerUserLimit,
+			v.IsActive,
+			v.ID,
+		).Scan(&v.UsedCount, &v.CreatedAt, &v.UpdatedAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrVoucherNotFound
+			}
+			return err
+		}
+
+		return replaceScopeTx(ctx, tx, v)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrVoucherNotFound) {
+			return nil, err
+		}
+		return nil, voucherWriteError(err)
+	}
+
+	return v, nil
+}
+
+func (r *VoucherRepositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.Exec(ctx, `DELETE FROM vouchers WHERE id = $1`, id)
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23503" {
+			return ErrVoucherInUse
+		}
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrVoucherNotFound
+	}
+	return nil
+}
+
+// Preview ngecek voucher tanpa make kuota, transaksinya selalu di rollback
+func (r *VoucherRepositoryImpl) Preview(ctx context.Context, code string, userID uuid.UUID, items []model.OrderItem) (model.AppliedVoucher, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.AppliedVoucher{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	return ApplyTx(ctx, tx, code, userID, items)
+}
+
+// ApplyTx ngunci voucher, ngecek semua aturan (aktif, masa berlaku, kuota
+// total & per user, scope produk/kategori, minimum belanja) terus ngitung
+// diskonnya. kuota baru kepake setelah RecordUsageTx dipanggil di transaksi
+// yang sama, jadi dua checkout barengan ga bisa sama-sama lolos di slot terakhir.
+func ApplyTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	code string,
+	userID uuid.UUID,
+	items []model.OrderItem,
+) (model.AppliedVoucher, error) {
+	lockQuery := `
+		SELECT id, code, discount_type, discount_value, min_spend, max_discount,
+		       starts_at, ends_at, usage_limit, per_user_limit, used_count, is_active
+		FROM vouchers
+		WHERE code = UPPER(TRIM($1))
+		FOR UPDATE
+	`
+
+	var v model.Voucher
+	err := tx.QueryRow(ctx, lockQuery, code).Scan(
+		&v.ID,
+		&v.Code,
+		&v.DiscountType,
+		&v.DiscountValue,
+		&v.MinSpend,
+		&v.MaxDiscount,
+		&v.StartsAt,
+		&v.EndsAt,
+		&v.UsageLimit,
+		&v.PerUserLimit,
+		&v.UsedCount,
+		&v.IsActive,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.AppliedVoucher{}, ErrVoucherUnknownCode
+		}
+		return model.AppliedVoucher{}, fmt.Errorf("failed to lock voucher: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case !v.IsActive:
+		return model.AppliedVoucher{}, ErrVoucherInactive
+	case now.Before(v.StartsAt):
+		return model.AppliedVoucher{}, ErrVoucherNotStarted
+	case now.After(v.EndsAt):
+		return model.AppliedVoucher{}, ErrVoucherExpired
+	case v.UsageLimit != nil && v.UsedCount >= *v.UsageLimit:
+		return model.AppliedVoucher{}, ErrVoucherQuotaExhausted
+	}
+
+	if v.PerUserLimit != nil {
+		var userUsage int
+		err := tx.QueryRow(ctx,
+			`SELECT COUNT(*) FROM voucher_usages WHERE voucher_id = $1 AND user_id = $2`,
+			v.ID, userID,
+		).Scan(&userUsage)
+		if err != nil {
+			return model.AppliedVoucher{}, fmt.Errorf("failed to count voucher usage: %w", err)
+		}
+		if userUsage >= *v.PerUserLimit {
+			return model.AppliedVoucher{}, ErrVoucherUserLimit
+		}
+	}
+
+	eligible, err := eligibleProductsTx(ctx, tx, v.ID, items)
+	if err != nil {
+		return model.AppliedVoucher{}, err
+	}
+
+	var eligibleSubtotal model.Money
+	for _, item := range items {
+		if eligible[item.ProductID] {
+			eligibleSubtotal += item.Subtotal
+		}
+	}
+
+	if eligibleSubtotal == 0 {
+		return model.AppliedVoucher{}, ErrVoucherNotApplicable
+	}
+
+	if eligibleSubtotal < v.MinSpend {
+		return model.AppliedVoucher{}, ErrVoucherMinSpend
+	}
+
+	return model.AppliedVoucher{
+		VoucherID:        v.ID,
+		Code:             v.Code,
+		EligibleSubtotal: eligibleSubtotal,
+		Discount:         calculateDiscount(v, eligibleSubtotal),
+	}, nil
+}
+
+// RecordUsageTx nyatet pemakaian voucher buat order yang baru dibuat
+func RecordUsageTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	applied model.AppliedVoucher,
+	userID uuid.UUID,
+	orderID uuid.UUID,
+) error {
+	usageQuery := `
+		INSERT INTO voucher_usages (voucher_id, user_id, order_id, discount_amount)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.Exec(ctx, usageQuery, applied.VoucherID, userID, orderID, applied.Discount); err != nil {
+		return fmt.Errorf("failed to record voucher usage: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE vouchers SET used_count = used_count + 1, updated_at = NOW() WHERE id = $1`, applied.VoucherID); err != nil {
+		return fmt.Errorf("failed to update voucher usage: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseTx balikin kuota voucher dari order yang dibatalin
+func ReleaseTx(ctx context.Context, tx pgx.Tx, orderID uuid.UUID) error {
+	var voucherID uuid.UUID
+	err := tx.QueryRow(ctx, `DELETE FROM voucher_usages WHERE order_id = $1 RETURNING voucher_id`, orderID).Scan(&voucherID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to release voucher usage: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE vouchers SET used_count = GREATEST(used_count - 1, 0), updated_at = NOW() WHERE id = $1`,
+		voucherID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update voucher usage: %w", err)
+	}
+	return nil
+}
+
+func eligibleProductsTx(ctx context.Context, tx pgx.Tx, voucherID uuid.UUID, items []model.OrderItem) (map[uuid.UUID]bool, error) {
+	productIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+
+	// voucher tanpa scope berlaku buat semua produk
+	query := `
+		SELECT p.id
+		FROM products p
+		WHERE p.id = ANY($1)
+		  AND (
+		      (NOT EXISTS (SELECT 1 FROM voucher_products WHERE voucher_id = $2)
+		       AND NOT EXISTS (SELECT 1 FROM voucher_categories WHERE voucher_id = $2))
+		      OR p.id IN (SELECT product_id FROM voucher_products WHERE voucher_id = $2)
+		      OR p.category_id IN (SELECT category_id FROM voucher_categories WHERE voucher_id = $2)
+		  )
+	`
+
+	rows, err := tx.Query(ctx, query, productIDs, voucherID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check voucher scope: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to check voucher scope: %w", err)
+	}
+
+	eligible := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		eligible[id] = true
+	}
+	return eligible, nil
+}
+
+func calculateDiscount(v model.Voucher, eligibleSubtotal model.Money) model.Money {
+	var discount model.Money
+	switch v.DiscountType {
+	case model.VoucherDiscountPercentage:
+		discount = eligibleSubtotal.Percent(v.DiscountValue)
+	default:
+		discount = model.Money(v.DiscountValue)
+	}
+
+	if v.MaxDiscount != nil && discount > *v.MaxDiscount {
+		discount = *v.MaxDiscount
+	}
+
+	// diskon ga boleh lebih gede dari harga barang yang kena voucher
+	return min(discount, eligibleSubtotal)
+}
+
+func replaceScopeTx(ctx context.Context, tx pgx.Tx, v *model.Voucher) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM voucher_products WHERE voucher_id = $1`, v.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM voucher_categories WHERE voucher_id = $1`, v.ID); err != nil {
+		return err
+	}
+
+	for _, productID := range v.ProductIDs {
+		if _, err := tx.Exec(ctx, `INSERT INTO voucher_products (voucher_id, product_id) VALUES ($1, $2)`, v.ID, productID); err != nil {
+			return err
+		}
+	}
+	for _, categoryID := range v.CategoryIDs {
+		if _, err := tx.Exec(ctx, `INSERT INTO voucher_categories (voucher_id, category_id) VALUES ($1, $2)`, v.ID, categoryID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func voucherWriteError(err error) error {
+	if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok {
+		switch pgErr.Code {
+		case "23505":
+			return ErrVoucherCodeTaken
+		case "23503":
+			return ErrVoucherScopeNotFound
+		}
+	}
+	return fmt.Errorf("failed to save voucher: %w", err)
+}
+
+type scannable interface {
+	Scan(dest ...any) error
+}
+
+func scanVoucher(row scannable) (model.Voucher, error) {
+	var v model.Voucher
+	err := row.Scan(
+		&v.ID,
+		&v.Code,
+		&v.Description,
+		&v.DiscountType,
+		&v.DiscountValue,
+		&v.MinSpend,
+		&v.MaxDiscount,
+		&v.StartsAt,
+		&v.EndsAt,
+		&v.UsageLimit,
+		&v.PerUserLimit,
+		&v.UsedCount,
+		&v.IsActive,
+		&v.ProductIDs,
+		&v.CategoryIDs,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+	)
+	return v, err
+}
+
+// NormalizeCode kode voucher disimpen & dicari dalam huruf kapital
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
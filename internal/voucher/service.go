@@ -0,0 +1,148 @@
+package voucher
+
+import (
+	"backEnd-RingoTechLife/internal/common"
+	"backEnd-RingoTechLife/internal/common/dto"
+	"backEnd-RingoTechLife/internal/common/model"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+type VoucherService struct {
+	voucherRepo VoucherRepositoryInterface
+}
+
+func NewVoucherService(repo *VoucherRepositoryImpl) *VoucherService {
+	return &VoucherService{
+		voucherRepo: repo,
+	}
+}
+
+func (s *VoucherService) GetAll(ctx context.Context) ([]model.Voucher, *common.ErrorResponse) {
+
+	data, err := s.voucherRepo.GetAll(ctx)
+	if err != nil {
+		return []model.Voucher{}, common.NewErrorResponse(500, "gagal mengambil data voucher! "+err.Error())
+	}
+	return data, nil
+}
+
+func (s *VoucherService) GetById(ctx context.Context, id uuid.UUID) (model.Voucher, *common.ErrorResponse) {
+
+	data, err := s.voucherRepo.GetByID(ctx, id)
+	if err != nil {
+		return model.Voucher{}, voucherError(err)
+	}
+	return data, nil
+}
+
+func (s *VoucherService) Create(ctx context.Context, req dto.VoucherRequest) (model.Voucher, *common.ErrorResponse) {
+
+	v, reqErr := requestToVoucher(req)
+	if reqErr != nil {
+		return model.Voucher{}, reqErr
+	}
+
+	data, err := s.voucherRepo.Create(ctx, &v)
+	if err != nil {
+		return model.Voucher{}, voucherError(err)
+	}
+	return *data, nil
+}
+
+func (s *VoucherService) Update(ctx context.Context, id uuid.UUID, req dto.VoucherRequest) (model.Voucher, *common.ErrorResponse) {
+
+	v, reqErr := requestToVoucher(req)
+	if reqErr != nil {
+		return model.Voucher{}, reqErr
+	}
+	v.ID = id
+
+	data, err := s.voucherRepo.Update(ctx, &v)
+	if err != nil {
+		return model.Voucher{}, voucherError(err)
+	}
+	return *data, nil
+}
+
+func (s *VoucherService) Delete(ctx context.Context, id uuid.UUID) *common.ErrorResponse {
+
+	if err := s.voucherRepo.Delete(ctx, id); err != nil {
+		return voucherError(err)
+	}
+	return nil
+}
+
+// Preview ngitung diskon voucher buat isi pesanan tanpa make kuota
+func (s *VoucherService) Preview(ctx context.Context, code string, userId uuid.UUID, items []model.OrderItem) (model.AppliedVoucher, *common.ErrorResponse) {
+
+	data, err := s.voucherRepo.Preview(ctx, code, userId, items)
+	if err != nil {
+		return model.AppliedVoucher{}, voucherError(err)
+	}
+	return data, nil
+}
+
+func requestToVoucher(req dto.VoucherRequest) (model.Voucher, *common.ErrorResponse) {
+
+	if req.DiscountType == string(model.VoucherDiscountPercentage) && req.DiscountValue > 100 {
+		return model.Voucher{}, common.NewErrorResponse(400, "diskon persentase tidak boleh lebih dari 100!")
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	productIds, err := parseUUIDs(req.ProductIds)
+	if err != nil {
+		return model.Voucher{}, common.NewErrorResponse(400, "product id tidak valid!")
+	}
+
+	categoryIds, err := parseUUIDs(req.CategoryIds)
+	if err != nil {
+		return model.Voucher{}, common.NewErrorResponse(400, "category id tidak valid!")
+	}
+
+	return model.Voucher{
+		Code:          NormalizeCode(req.Code),
+		Description:   req.Description,
+		DiscountType:  model.VoucherDiscountType(req.DiscountType),
+		DiscountValue: req.DiscountValue,
+		MinSpend:      req.MinSpend,
+		MaxDiscount:   req.MaxDiscount,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+		UsageLimit:    req.UsageLimit,
+		PerUserLimit:  req.PerUserLimit,
+		IsActive:      isActive,
+		ProductIDs:    productIds,
+		CategoryIDs:   categoryIds,
+	}, nil
+}
+
+func parseUUIDs(raw []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(raw))
+	for _, r := range raw {
+		id, err := uuid.Parse(r)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func voucherError(err error) *common.ErrorResponse {
+	switch {
+	case errors.Is(err, ErrVoucherNotFound), errors.Is(err, ErrVoucherScopeNotFound):
+		return common.NewErrorResponse(404, err.Error())
+	case errors.Is(err, ErrVoucherCodeTaken), errors.Is(err, ErrVoucherInUse):
+		return common.NewErrorResponse(409, err.Error())
+	case errors.Is(err, ErrVoucherInvalid):
+		return common.NewErrorResponse(422, err.Error())
+	}
+	return common.NewErrorResponse(500, "terjadi kesalahan di database "+err.Error())
+}
@@ -0,0 +1,4 @@
+-- dipakai sweeper buat nyari order pending yang udah lewat expires_at
+CREATE INDEX IF NOT EXISTS idx_orders_pending_expires_at
+    ON orders(expires_at)
+    WHERE status = 'pending';
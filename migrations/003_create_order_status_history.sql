@@ -0,0 +1,21 @@
+-- timeline perubahan status order, ditulis di transaksi yang sama dengan perubahan statusnya
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id     UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status  VARCHAR(50),
+    to_status    VARCHAR(50) NOT NULL,
+    actor_id     UUID REFERENCES users(id) ON DELETE SET NULL,
+    note         TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id
+    ON order_status_history(order_id, created_at);
+
+-- order lama dikasih satu baris awal biar timeline nya ga kosong
+INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, note, created_at)
+SELECT o.id, NULL, o.status, NULL, 'data sebelum riwayat status dicatat', o.updated_at
+FROM orders o
+WHERE NOT EXISTS (
+    SELECT 1 FROM order_status_history h WHERE h.order_id = o.id
+);
@@ -0,0 +1,16 @@
+-- data pengiriman order produk (kurir, resi, waktu kirim & sampai)
+CREATE TABLE IF NOT EXISTS shipments (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id         UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    courier          VARCHAR(100) NOT NULL,
+    tracking_number  VARCHAR(100) NOT NULL,
+    shipped_at       TIMESTAMPTZ,
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- dipake sweeper buat auto complete order delivered
+CREATE INDEX IF NOT EXISTS idx_shipments_delivered_at
+    ON shipments(delivered_at)
+    WHERE delivered_at IS NOT NULL;
@@ -0,0 +1,26 @@
+-- buku alamat user
+CREATE TABLE IF NOT EXISTS user_addresses (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id         UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label           VARCHAR(50),
+    recipient_name  VARCHAR(100) NOT NULL,
+    phone_number    VARCHAR(20) NOT NULL,
+    province        VARCHAR(100) NOT NULL,
+    city            VARCHAR(100) NOT NULL,
+    district        VARCHAR(100) NOT NULL,
+    postal_code     VARCHAR(10) NOT NULL,
+    address_line    VARCHAR(255) NOT NULL,
+    is_default      BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_addresses_user_id ON user_addresses(user_id);
+
+-- satu user cuma boleh punya satu alamat default
+CREATE UNIQUE INDEX IF NOT EXISTS uq_user_addresses_default
+    ON user_addresses(user_id)
+    WHERE is_default;
+
+-- salinan alamat pas checkout, order lama sebelum fitur ini tetep NULL
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_address JSONB;
@@ -0,0 +1,42 @@
+-- tarif ongkir berdasarkan zona wilayah x bracket berat (gram)
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE IF NOT EXISTS shipping_zones (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(100) NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- city NULL berarti berlaku untuk satu provinsi
+CREATE TABLE IF NOT EXISTS shipping_zone_regions (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    zone_id     UUID NOT NULL REFERENCES shipping_zones(id) ON DELETE CASCADE,
+    province    VARCHAR(100) NOT NULL,
+    city        VARCHAR(100),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- satu wilayah cuma boleh masuk satu zona
+CREATE UNIQUE INDEX IF NOT EXISTS uq_shipping_zone_regions_area
+    ON shipping_zone_regions (LOWER(TRIM(province)), COALESCE(LOWER(TRIM(city)), ''));
+
+-- max_weight NULL berarti bracket terakhir, kelebihan dihitung pake extra_per_kg
+CREATE TABLE IF NOT EXISTS shipping_rates (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    zone_id       UUID NOT NULL REFERENCES shipping_zones(id) ON DELETE CASCADE,
+    min_weight    INT NOT NULL CHECK (min_weight >= 0),
+    max_weight    INT CHECK (max_weight IS NULL OR max_weight > min_weight),
+    price         NUMERIC(12, 2) NOT NULL CHECK (price >= 0),
+    extra_per_kg  NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (extra_per_kg >= 0),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- bracket berat di satu zona ga boleh numpuk
+    CONSTRAINT shipping_rates_no_overlap EXCLUDE USING gist (
+        zone_id WITH =,
+        int4range(min_weight, max_weight, '[]') WITH &&
+    )
+);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_cost NUMERIC(12, 2) NOT NULL DEFAULT 0;
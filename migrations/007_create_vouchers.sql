@@ -0,0 +1,48 @@
+-- voucher / kode promo
+CREATE TABLE IF NOT EXISTS vouchers (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code            VARCHAR(50) NOT NULL UNIQUE,
+    description     VARCHAR(255),
+    discount_type   VARCHAR(20) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    discount_value  NUMERIC(12, 2) NOT NULL CHECK (discount_value > 0),
+    min_spend       NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    max_discount    NUMERIC(12, 2),
+    starts_at       TIMESTAMPTZ NOT NULL,
+    ends_at         TIMESTAMPTZ NOT NULL,
+    usage_limit     INT CHECK (usage_limit IS NULL OR usage_limit > 0),
+    per_user_limit  INT CHECK (per_user_limit IS NULL OR per_user_limit > 0),
+    used_count      INT NOT NULL DEFAULT 0,
+    is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (ends_at > starts_at)
+);
+
+-- scope voucher, kalau dua-duanya kosong voucher berlaku buat semua produk
+CREATE TABLE IF NOT EXISTS voucher_products (
+    voucher_id  UUID NOT NULL REFERENCES vouchers(id) ON DELETE CASCADE,
+    product_id  UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    PRIMARY KEY (voucher_id, product_id)
+);
+
+CREATE TABLE IF NOT EXISTS voucher_categories (
+    voucher_id   UUID NOT NULL REFERENCES vouchers(id) ON DELETE CASCADE,
+    category_id  UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (voucher_id, category_id)
+);
+
+-- satu order maksimal satu voucher. baris nya dihapus kalau order dibatalin
+CREATE TABLE IF NOT EXISTS voucher_usages (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    voucher_id       UUID NOT NULL REFERENCES vouchers(id) ON DELETE RESTRICT,
+    user_id          UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    order_id         UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    discount_amount  NUMERIC(12, 2) NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_voucher_usages_voucher_user ON voucher_usages(voucher_id, user_id);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(12, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS voucher_code VARCHAR(50);
@@ -0,0 +1,52 @@
+-- semua kolom nominal uang pindah dari NUMERIC ke BIGINT (rupiah utuh).
+-- di Go kolom ini dibaca pake model.Money (int64), jadi ga ada lagi float.
+--
+-- rencana migrasi:
+--   1. cek dulu ada nominal pecahan ato engga (query di bawah), rupiah ga punya sen
+--      jadi harusnya kosong. kalo ada, beresin manual dulu sebelum lanjut.
+--   2. ALTER kolom ke BIGINT pake ROUND() biar sisa ",00" ilang.
+--   3. deploy backend versi baru. model.Money masih bisa baca "15000.00" dari kolom
+--      NUMERIC lama, jadi urutan deploy vs migrasi ga bikin error scan.
+--
+-- cek nominal pecahan:
+--   SELECT 'products' AS tbl, id FROM products WHERE price <> TRUNC(price)
+--   UNION ALL SELECT 'orders', id FROM orders WHERE total_amount <> TRUNC(total_amount)
+--   UNION ALL SELECT 'order_items', id FROM order_items WHERE price_at_purchase <> TRUNC(price_at_purchase)
+--   UNION ALL SELECT 'payments', id FROM payments WHERE amount <> TRUNC(amount)
+--   UNION ALL SELECT 'service_requests', id FROM service_requests WHERE quoted_price <> TRUNC(quoted_price);
+
+BEGIN;
+
+ALTER TABLE products
+    ALTER COLUMN price TYPE BIGINT USING ROUND(price)::BIGINT;
+
+ALTER TABLE orders
+    ALTER COLUMN subtotal        TYPE BIGINT USING ROUND(subtotal)::BIGINT,
+    ALTER COLUMN discount_amount TYPE BIGINT USING ROUND(discount_amount)::BIGINT,
+    ALTER COLUMN shipping_cost   TYPE BIGINT USING ROUND(shipping_cost)::BIGINT,
+    ALTER COLUMN total_amount    TYPE BIGINT USING ROUND(total_amount)::BIGINT;
+
+ALTER TABLE order_items
+    ALTER COLUMN price_at_purchase TYPE BIGINT USING ROUND(price_at_purchase)::BIGINT,
+    ALTER COLUMN subtotal          TYPE BIGINT USING ROUND(subtotal)::BIGINT;
+
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount)::BIGINT;
+
+ALTER TABLE service_requests
+    ALTER COLUMN quoted_price TYPE BIGINT USING ROUND(quoted_price)::BIGINT;
+
+ALTER TABLE shipping_rates
+    ALTER COLUMN price        TYPE BIGINT USING ROUND(price)::BIGINT,
+    ALTER COLUMN extra_per_kg TYPE BIGINT USING ROUND(extra_per_kg)::BIGINT;
+
+-- discount_value buat tipe percentage isinya persen (1-100), buat fixed isinya rupiah
+ALTER TABLE vouchers
+    ALTER COLUMN discount_value TYPE BIGINT USING ROUND(discount_value)::BIGINT,
+    ALTER COLUMN min_spend      TYPE BIGINT USING ROUND(min_spend)::BIGINT,
+    ALTER COLUMN max_discount   TYPE BIGINT USING ROUND(max_discount)::BIGINT;
+
+ALTER TABLE voucher_usages
+    ALTER COLUMN discount_amount TYPE BIGINT USING ROUND(discount_amount)::BIGINT;
+
+COMMIT;
@@ -0,0 +1,12 @@
+-- kode unik transfer: nominal payment = total order + kode unik (1-999)
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS unique_code INT NOT NULL DEFAULT 0;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_unique_code_range;
+ALTER TABLE payments ADD CONSTRAINT payments_unique_code_range
+    CHECK (unique_code BETWEEN 0 AND 999);
+
+-- payment lama yang masih kebuka ga punya kode unik (0), jadi index nya
+-- cuma buat payment baru biar migrasi ga gagal gara-gara nominal kembar
+CREATE UNIQUE INDEX IF NOT EXISTS payments_open_amount_unique
+    ON payments (amount)
+    WHERE status IN ('unpaid', 'submitted') AND unique_code > 0;
@@ -0,0 +1,31 @@
+-- pembayaran lewat payment gateway (VA / QRIS)
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS method            VARCHAR(30) NOT NULL DEFAULT 'manual_transfer',
+    ADD COLUMN IF NOT EXISTS gateway_name      VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS gateway_charge_id VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS va_number         VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS qr_string         TEXT;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_method_check
+    CHECK (method IN ('manual_transfer', 'virtual_account', 'qris'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS payments_gateway_charge_unique
+    ON payments (gateway_name, gateway_charge_id)
+    WHERE gateway_charge_id IS NOT NULL;
+
+-- log callback gateway, (gateway_name, event_id) unik biar callback yang
+-- dikirim ulang ga diproses dua kali
+CREATE TABLE IF NOT EXISTS payment_gateway_events (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    gateway_name VARCHAR(50)  NOT NULL,
+    event_id     VARCHAR(150) NOT NULL,
+    charge_id    VARCHAR(100) NOT NULL,
+    status       VARCHAR(30)  NOT NULL,
+    payload      JSONB,
+    received_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    UNIQUE (gateway_name, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_gateway_events_charge
+    ON payment_gateway_events (gateway_name, charge_id);
@@ -0,0 +1,43 @@
+-- refund / pengembalian dana buat order yang udah dibayar
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_refunded_amount_check;
+ALTER TABLE payments ADD CONSTRAINT payments_refunded_amount_check
+    CHECK (refunded_amount >= 0 AND refunded_amount <= amount);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id     UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    payment_id   UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status       VARCHAR(20) NOT NULL DEFAULT 'requested'
+                 CHECK (status IN ('requested', 'approved', 'rejected', 'completed')),
+    is_full      BOOLEAN NOT NULL DEFAULT FALSE,
+    amount       BIGINT NOT NULL CHECK (amount > 0),
+    reason       TEXT NOT NULL,
+    admin_note   TEXT,
+    proof_image  VARCHAR(255),
+    reviewed_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reviewed_at  TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_refunds_payment ON refunds (payment_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_user ON refunds (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_refunds_status ON refunds (status, created_at);
+
+CREATE TABLE IF NOT EXISTS refund_items (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    refund_id     UUID NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    product_id    UUID NOT NULL,
+    product_name  VARCHAR(255) NOT NULL,
+    quantity      INT NOT NULL CHECK (quantity > 0),
+    amount        BIGINT NOT NULL CHECK (amount >= 0),
+    restock       BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (refund_id, order_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_refund_items_order_item ON refund_items (order_item_id);
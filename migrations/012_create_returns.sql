@@ -0,0 +1,49 @@
+-- retur (RMA) barang cacat dari order yang udah sampai
+CREATE TABLE IF NOT EXISTS returns (
+    id                          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id                    UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id                     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status                      VARCHAR(20) NOT NULL DEFAULT 'requested'
+                                CHECK (status IN ('requested', 'approved', 'rejected', 'cancelled',
+                                                  'shipped', 'received', 'inspected', 'resolved')),
+    reason                      TEXT NOT NULL,
+    photos                      TEXT[] NOT NULL DEFAULT '{}',
+    admin_note                  TEXT,
+    reviewed_by                 UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at                 TIMESTAMPTZ,
+    inbound_courier             VARCHAR(50),
+    inbound_tracking_number     VARCHAR(100),
+    shipped_at                  TIMESTAMPTZ,
+    received_at                 TIMESTAMPTZ,
+    inspection_result           VARCHAR(20)
+                                CHECK (inspection_result IN ('defect_confirmed', 'no_defect_found')),
+    inspection_note             TEXT,
+    inspected_by                UUID REFERENCES users(id) ON DELETE SET NULL,
+    inspected_at                TIMESTAMPTZ,
+    resolution                  VARCHAR(20)
+                                CHECK (resolution IN ('refund', 'replacement', 'repair')),
+    refund_id                   UUID REFERENCES refunds(id) ON DELETE SET NULL,
+    service_request_id          UUID REFERENCES service_requests(id) ON DELETE SET NULL,
+    replacement_courier         VARCHAR(50),
+    replacement_tracking_number VARCHAR(100),
+    resolved_by                 UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at                 TIMESTAMPTZ,
+    created_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_returns_order ON returns (order_id);
+CREATE INDEX IF NOT EXISTS idx_returns_user ON returns (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_returns_status ON returns (status, created_at);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    return_id     UUID NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    product_id    UUID NOT NULL,
+    product_name  VARCHAR(255) NOT NULL,
+    quantity      INT NOT NULL CHECK (quantity > 0),
+    UNIQUE (return_id, order_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_items_order_item ON return_items (order_item_id);
@@ -0,0 +1,20 @@
+-- invoice order, nomornya urut tanpa loncat per bulan (INV/YYYY/MM/00001).
+-- counter nya di-upsert di transaksi yang sama dengan konfirmasi order,
+-- jadi kalau transaksinya rollback nomornya ikut batal dan ga bolong.
+CREATE TABLE IF NOT EXISTS invoice_counters (
+    period      CHAR(7) PRIMARY KEY,
+    last_number INT NOT NULL CHECK (last_number > 0)
+);
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id       UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE RESTRICT,
+    invoice_number VARCHAR(30) NOT NULL UNIQUE,
+    period         CHAR(7) NOT NULL,
+    sequence       INT NOT NULL CHECK (sequence > 0),
+    revision       INT NOT NULL DEFAULT 1 CHECK (revision > 0),
+    snapshot       JSONB,
+    issued_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    generated_at   TIMESTAMPTZ,
+    UNIQUE (period, sequence)
+);
@@ -0,0 +1,41 @@
+-- ledger stok append-only, tiap perubahan products.stock wajib punya baris di sini
+-- (ditulis di transaksi yang sama), jadi stok bisa dihitung ulang dari SUM(delta)
+CREATE TABLE IF NOT EXISTS inventory_movements (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id      UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    delta           INTEGER NOT NULL CHECK (delta <> 0),
+    stock_after     INTEGER NOT NULL CHECK (stock_after >= 0),
+    reason          VARCHAR(20) NOT NULL
+                    CHECK (reason IN ('sale', 'cancel', 'restock', 'adjustment', 'return')),
+    reference_type  VARCHAR(20),
+    reference_id    UUID,
+    actor_id        UUID REFERENCES users(id) ON DELETE SET NULL,
+    note            TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_product
+    ON inventory_movements (product_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_reference
+    ON inventory_movements (reference_type, reference_id);
+
+-- saldo awal buat produk yang udah ada sebelum ledger dipake
+INSERT INTO inventory_movements (product_id, delta, stock_after, reason, note)
+SELECT id, stock, stock, 'adjustment', 'saldo awal ledger'
+FROM products
+WHERE stock <> 0
+  AND NOT EXISTS (SELECT 1 FROM inventory_movements m WHERE m.product_id = products.id);
+
+-- baris ledger ga boleh diubah, koreksi harus lewat movement baru.
+-- hapusnya cuma ikut cascade kalau produknya dihapus
+CREATE OR REPLACE FUNCTION inventory_movements_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'inventory_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_inventory_movements_append_only ON inventory_movements;
+CREATE TRIGGER trg_inventory_movements_append_only
+    BEFORE UPDATE ON inventory_movements
+    FOR EACH ROW EXECUTE FUNCTION inventory_movements_append_only();
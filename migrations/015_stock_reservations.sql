@@ -0,0 +1,17 @@
+-- stok on-hand dipisah sama stok yang lagi ditahan order belum dibayar.
+-- yang bisa dijual = stock - reserved_stock
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS reserved_stock INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS products_reserved_stock_check;
+ALTER TABLE products
+    ADD CONSTRAINT products_reserved_stock_check
+    CHECK (reserved_stock >= 0 AND reserved_stock <= stock);
+
+-- order lama udah motong stok pas dibuat, jadi dianggap udah di commit
+-- (kalau batal stoknya dibalikin kayak dulu). order baru mulai dari reservasi
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS stock_committed BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE orders
+    ALTER COLUMN stock_committed SET DEFAULT FALSE;
@@ -0,0 +1,28 @@
+-- unit fisik per produk (serial number / IMEI) buat garansi, cek barang curian & retur
+CREATE TABLE IF NOT EXISTS product_units (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id      UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    serial_number   VARCHAR(100) NOT NULL,
+    imei            VARCHAR(15),
+    condition_note  TEXT,
+    status          VARCHAR(20) NOT NULL DEFAULT 'in_stock'
+                    CHECK (status IN ('in_stock', 'reserved', 'sold', 'returned')),
+    order_item_id   UUID REFERENCES order_items(id) ON DELETE SET NULL,
+    reserved_at     TIMESTAMPTZ,
+    sold_at         TIMESTAMPTZ,
+    returned_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT product_units_serial_number_key UNIQUE (serial_number),
+    CONSTRAINT product_units_imei_key UNIQUE (imei),
+    -- unit yang lagi di reserve / udah kejual wajib nempel ke item order
+    CHECK (status NOT IN ('reserved', 'sold') OR order_item_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_units_available
+    ON product_units (product_id, created_at)
+    WHERE status = 'in_stock';
+
+CREATE INDEX IF NOT EXISTS idx_product_units_order_item
+    ON product_units (order_item_id);
@@ -0,0 +1,74 @@
+-- varian produk (kapasitas, warna, RAM). tiap produk punya tipe opsi + nilai nya,
+-- tiap varian = satu kombinasi nilai dengan sku, harga, stok & berat sendiri.
+-- produk yang punya varian: products.stock / reserved_stock = jumlah semua varian nya
+CREATE TABLE IF NOT EXISTS product_options (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id  UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name        VARCHAR(50) NOT NULL,
+    position    INT NOT NULL DEFAULT 0,
+    CONSTRAINT product_options_product_name_key UNIQUE (product_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS product_option_values (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    option_id   UUID NOT NULL REFERENCES product_options(id) ON DELETE CASCADE,
+    value       VARCHAR(100) NOT NULL,
+    position    INT NOT NULL DEFAULT 0,
+    CONSTRAINT product_option_values_option_value_key UNIQUE (option_id, value)
+);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id        UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku               VARCHAR(100) NOT NULL,
+    price             BIGINT NOT NULL CHECK (price >= 0),
+    stock             INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    reserved_stock    INTEGER NOT NULL DEFAULT 0,
+    weight            INTEGER CHECK (weight IS NULL OR weight >= 0),
+    image_id          UUID REFERENCES product_images(id) ON DELETE SET NULL,
+    is_active         BOOLEAN NOT NULL DEFAULT TRUE,
+    -- kombinasi opsi yang udah dinormalisasi (lowercase, urut nama opsi) biar ga dobel
+    option_signature  TEXT NOT NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT product_variants_sku_key UNIQUE (sku),
+    CONSTRAINT product_variants_signature_key UNIQUE (product_id, option_signature),
+    CONSTRAINT product_variants_reserved_stock_check
+        CHECK (reserved_stock >= 0 AND reserved_stock <= stock)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product
+    ON product_variants (product_id);
+
+CREATE TABLE IF NOT EXISTS product_variant_values (
+    variant_id       UUID NOT NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+    option_value_id  UUID NOT NULL REFERENCES product_option_values(id) ON DELETE CASCADE,
+    PRIMARY KEY (variant_id, option_value_id)
+);
+
+-- snapshot varian di item order (nama varian disimpen biar ga berubah kalau variannya diedit)
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES product_variants(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS variant_name VARCHAR(255);
+
+-- keranjang sekarang satu baris per produk + varian
+ALTER TABLE cart_items
+    ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE;
+ALTER TABLE cart_items
+    DROP CONSTRAINT IF EXISTS cart_items_user_id_product_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS cart_items_user_product_variant_key
+    ON cart_items (user_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid));
+
+-- ledger & unit fisik nyatet varian nya juga. ledger sengaja tanpa FK
+-- karena barisnya ga boleh di update (trigger append-only)
+ALTER TABLE inventory_movements
+    ADD COLUMN IF NOT EXISTS variant_id UUID,
+    ADD COLUMN IF NOT EXISTS variant_stock_after INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_variant
+    ON inventory_movements (variant_id, created_at DESC)
+    WHERE variant_id IS NOT NULL;
+
+ALTER TABLE product_units
+    ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES product_variants(id) ON DELETE SET NULL;
@@ -0,0 +1,44 @@
+-- produk bundle / paket (misal HP + charger + case). bundle itu produk biasa
+-- dengan harga sendiri, tapi stok nya ga disimpen: diturunin dari stok komponen nya.
+-- pas dipesan yang di reserve / dipotong stok tiap komponen
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS is_bundle BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS product_bundle_items (
+    id                    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    bundle_id             UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    -- komponen ga boleh dihapus selama masih dipake bundle
+    component_id          UUID NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    component_variant_id  UUID REFERENCES product_variants(id) ON DELETE RESTRICT,
+    quantity              INTEGER NOT NULL CHECK (quantity > 0),
+    position              INTEGER NOT NULL DEFAULT 0,
+
+    CONSTRAINT product_bundle_items_not_self CHECK (bundle_id <> component_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_bundle_items_component_key
+    ON product_bundle_items (bundle_id, component_id, COALESCE(component_variant_id, '00000000-0000-0000-0000-000000000000'::uuid));
+
+CREATE INDEX IF NOT EXISTS idx_product_bundle_items_component
+    ON product_bundle_items (component_id);
+
+-- stok bundle yang bisa dijual = komponen paling sedikit dibagi quantity nya.
+-- komponen yang ga aktif bikin bundle nya habis
+CREATE OR REPLACE FUNCTION bundle_available_stock(p_bundle_id UUID)
+RETURNS INTEGER AS $$
+    SELECT COALESCE(MIN(
+        CASE
+            WHEN p.status <> 'active' OR (v.id IS NOT NULL AND NOT v.is_active) THEN 0
+            ELSE COALESCE(v.stock - v.reserved_stock, p.stock - p.reserved_stock) / bi.quantity
+        END
+    ), 0)::int
+    FROM product_bundle_items bi
+    JOIN products p ON p.id = bi.component_id
+    LEFT JOIN product_variants v ON v.id = bi.component_variant_id
+    WHERE bi.bundle_id = p_bundle_id
+$$ LANGUAGE sql STABLE;
+
+-- snapshot isi bundle di item order: [{product_id, variant_id, product_name, variant_name, product_sku, quantity}]
+-- quantity per satu bundle. dipake juga buat ngelepas / motong / balikin stok komponen nya
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS bundle_components JSONB;
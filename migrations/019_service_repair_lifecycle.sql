@@ -0,0 +1,28 @@
+-- proses perbaikan setelah penawaran servis di acc user. status request nya
+-- tetep 'accepted', progres perbaikan nya disimpen di repair_status biar
+-- alur penawaran yang lama ga berubah
+ALTER TABLE service_requests
+    ADD COLUMN IF NOT EXISTS repair_status  VARCHAR(30)
+        CHECK (repair_status IN (
+            'device_received', 'diagnosing', 'waiting_parts', 'in_repair',
+            'quality_check', 'ready_for_pickup', 'picked_up', 'returned'
+        )),
+    ADD COLUMN IF NOT EXISTS received_at    TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS ready_at       TIMESTAMPTZ,
+    -- diisi pas perangkat diambil (picked_up) atau dibalikin tanpa diperbaiki (returned)
+    ADD COLUMN IF NOT EXISTS picked_up_at   TIMESTAMPTZ;
+
+-- timeline perbaikan, satu baris tiap perpindahan status plus catatan teknisi nya.
+-- ditulis di transaksi yang sama dengan perubahan repair_status
+CREATE TABLE IF NOT EXISTS service_request_status_history (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_request_id  UUID NOT NULL REFERENCES service_requests(id) ON DELETE CASCADE,
+    from_status         VARCHAR(30),
+    to_status           VARCHAR(30) NOT NULL,
+    actor_id            UUID REFERENCES users(id) ON DELETE SET NULL,
+    note                TEXT,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_request_status_history_request
+    ON service_request_status_history(service_request_id, created_at);
@@ -0,0 +1,67 @@
+-- penawaran servis dipecah per baris (jasa, sparepart, biaya diagnosa) dan
+-- bisa direvisi. tiap revisi nambah version, revisi yang masih nunggu keputusan
+-- user cuma boleh satu; revisi baru otomatis bikin revisi pending lama superseded
+CREATE TABLE IF NOT EXISTS service_quotes (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_request_id  UUID NOT NULL REFERENCES service_requests(id) ON DELETE CASCADE,
+    version             INTEGER NOT NULL CHECK (version > 0),
+    status              VARCHAR(20) NOT NULL DEFAULT 'pending'
+                        CHECK (status IN ('pending', 'accepted', 'rejected', 'superseded')),
+    subtotal            BIGINT NOT NULL CHECK (subtotal >= 0),
+    estimated_duration  INTEGER NOT NULL CHECK (estimated_duration > 0),
+    note                TEXT,
+    created_by          UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    decided_at          TIMESTAMPTZ,
+
+    CONSTRAINT service_quotes_version_key UNIQUE (service_request_id, version)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS service_quotes_one_pending
+    ON service_quotes (service_request_id)
+    WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS service_quote_items (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    quote_id     UUID NOT NULL REFERENCES service_quotes(id) ON DELETE CASCADE,
+    item_type    VARCHAR(20) NOT NULL CHECK (item_type IN ('labour', 'part', 'diagnostic')),
+    -- sparepart boleh nyambung ke produk toko, cuma buat referensi nama / harga
+    product_id   UUID REFERENCES products(id) ON DELETE SET NULL,
+    description  VARCHAR(255) NOT NULL,
+    quantity     INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price   BIGINT NOT NULL CHECK (unit_price >= 0),
+    subtotal     BIGINT NOT NULL CHECK (subtotal >= 0),
+    position     INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_quote_items_quote
+    ON service_quote_items (quote_id, position);
+
+-- penawaran lama (satu harga doang) dijadiin revisi 1 dengan satu baris jasa
+INSERT INTO service_quotes (service_request_id, version, status, subtotal, estimated_duration, note, created_by, created_at, decided_at)
+SELECT sr.id, 1,
+       CASE sr.status
+           WHEN 'quoted' THEN 'pending'
+           WHEN 'accepted' THEN 'accepted'
+           ELSE 'rejected'
+       END,
+       sr.quoted_price, COALESCE(sr.estimated_duration, 1), sr.admin_note, sr.quoted_by,
+       COALESCE(sr.quoted_at, sr.updated_at), sr.decided_at
+FROM service_requests sr
+WHERE sr.quoted_price IS NOT NULL
+  AND sr.status IN ('quoted', 'accepted', 'rejected_by_user')
+  AND NOT EXISTS (SELECT 1 FROM service_quotes q WHERE q.service_request_id = sr.id);
+
+INSERT INTO service_quote_items (quote_id, item_type, description, quantity, unit_price, subtotal)
+SELECT q.id, 'labour', 'Biaya servis', 1, q.subtotal, q.subtotal
+FROM service_quotes q
+WHERE NOT EXISTS (SELECT 1 FROM service_quote_items i WHERE i.quote_id = q.id);
+
+-- baris penawaran yang di acc ikut masuk ke order sebagai item.
+-- item non produk ga nyentuh stok / unit sama sekali
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS item_type VARCHAR(20) NOT NULL DEFAULT 'product'
+        CHECK (item_type IN ('product', 'labour', 'part', 'diagnostic'));
+
+ALTER TABLE order_items
+    ALTER COLUMN product_id DROP NOT NULL;
@@ -0,0 +1,45 @@
+-- sparepart dari stok toko yang dipake buat perbaikan. tiap baris part di
+-- revisi penawaran yang nyambung ke produk nge-reserve stok produk nya:
+--   waiting   -> stok kurang pas mau di reserve, perbaikan nunggu sparepart
+--   reserved  -> stok ditahan (reserved_stock produk naik)
+--   committed -> perbaikan selesai, stok dipotong & dicatat di ledger
+--   released  -> penawaran ditolak / diganti revisi lain / perangkat dibalikin
+CREATE TABLE IF NOT EXISTS service_request_parts (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_request_id  UUID NOT NULL REFERENCES service_requests(id) ON DELETE CASCADE,
+    quote_id            UUID NOT NULL REFERENCES service_quotes(id) ON DELETE CASCADE,
+    quote_item_id       UUID REFERENCES service_quote_items(id) ON DELETE SET NULL,
+    product_id          UUID NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    quantity            INTEGER NOT NULL CHECK (quantity > 0),
+    status              VARCHAR(20) NOT NULL DEFAULT 'waiting'
+                        CHECK (status IN ('waiting', 'reserved', 'committed', 'released')),
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    committed_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_request_parts_request
+    ON service_request_parts (service_request_id);
+
+CREATE INDEX IF NOT EXISTS idx_service_request_parts_open
+    ON service_request_parts (product_id)
+    WHERE status IN ('waiting', 'reserved');
+
+-- sparepart dari penawaran yang udah ada sebelum tabel ini dicatat waiting,
+-- stok nya baru di reserve pas perbaikan nya dimulai (in_repair)
+INSERT INTO service_request_parts (service_request_id, quote_id, quote_item_id, product_id, quantity, status)
+SELECT q.service_request_id, q.id, i.id, i.product_id, i.quantity, 'waiting'
+FROM service_quote_items i
+JOIN service_quotes q ON q.id = i.quote_id
+JOIN service_requests sr ON sr.id = q.service_request_id
+WHERE i.item_type = 'part'
+  AND i.product_id IS NOT NULL
+  AND (
+      q.status = 'pending'
+      OR (q.status = 'accepted' AND q.version = (
+          SELECT MAX(version) FROM service_quotes
+          WHERE service_request_id = q.service_request_id AND status = 'accepted'
+      ))
+  )
+  AND (sr.repair_status IS NULL OR sr.repair_status NOT IN ('ready_for_pickup', 'picked_up', 'returned'))
+  AND NOT EXISTS (SELECT 1 FROM service_request_parts p WHERE p.quote_item_id = i.id);
@@ -0,0 +1,36 @@
+-- role teknisi. kalau kolom users.role nya enum, value nya ditambahin dulu
+DO $$
+DECLARE
+    role_type TEXT;
+BEGIN
+    SELECT udt_name INTO role_type
+    FROM information_schema.columns
+    WHERE table_name = 'users' AND column_name = 'role' AND data_type = 'USER-DEFINED';
+
+    IF role_type IS NOT NULL THEN
+        EXECUTE format('ALTER TYPE %I ADD VALUE IF NOT EXISTS %L', role_type, 'TECHNICIAN');
+    END IF;
+END $$;
+
+-- teknisi yang lagi megang request servis. teknisi cuma bisa liat & ngerjain
+-- request yang di assign ke dia
+ALTER TABLE service_requests
+    ADD COLUMN IF NOT EXISTS assigned_to  UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS assigned_at  TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_service_requests_assigned_to
+    ON service_requests (assigned_to, created_at);
+
+-- riwayat assign / pindah teknisi. from_technician_id NULL = assign pertama
+CREATE TABLE IF NOT EXISTS service_request_assignments (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_request_id  UUID NOT NULL REFERENCES service_requests(id) ON DELETE CASCADE,
+    from_technician_id  UUID REFERENCES users(id) ON DELETE SET NULL,
+    to_technician_id    UUID REFERENCES users(id) ON DELETE SET NULL,
+    assigned_by         UUID REFERENCES users(id) ON DELETE SET NULL,
+    note                TEXT,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_request_assignments_request
+    ON service_request_assignments (service_request_id, created_at);
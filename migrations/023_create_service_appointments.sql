@@ -0,0 +1,67 @@
+-- booking jadwal antar perangkat ke toko. pengaturan nya cuma satu baris,
+-- jam nya disimpen sebagai jam lokal toko (utc_offset_minutes, default WIB)
+CREATE TABLE IF NOT EXISTS service_appointment_settings (
+    id                  BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    slot_minutes        INTEGER NOT NULL DEFAULT 60 CHECK (slot_minutes BETWEEN 15 AND 480),
+    capacity_per_slot   INTEGER NOT NULL DEFAULT 2 CHECK (capacity_per_slot > 0),
+    booking_days_ahead  INTEGER NOT NULL DEFAULT 14 CHECK (booking_days_ahead BETWEEN 1 AND 90),
+    utc_offset_minutes  INTEGER NOT NULL DEFAULT 420 CHECK (utc_offset_minutes BETWEEN -720 AND 840),
+    updated_by          UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO service_appointment_settings (id) VALUES (TRUE) ON CONFLICT DO NOTHING;
+
+-- jam buka per hari (0 = minggu). hari yang ga ada baris nya berarti tutup
+CREATE TABLE IF NOT EXISTS service_opening_hours (
+    weekday     SMALLINT PRIMARY KEY CHECK (weekday BETWEEN 0 AND 6),
+    open_time   TIME NOT NULL,
+    close_time  TIME NOT NULL,
+
+    CONSTRAINT service_opening_hours_range CHECK (close_time > open_time)
+);
+
+INSERT INTO service_opening_hours (weekday, open_time, close_time)
+SELECT d, '09:00', '17:00' FROM generate_series(1, 6) AS d
+WHERE NOT EXISTS (SELECT 1 FROM service_opening_hours);
+
+CREATE TABLE IF NOT EXISTS service_holidays (
+    date        DATE PRIMARY KEY,
+    note        VARCHAR(255),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- jumlah booking aktif per slot. booking nambah booked pakai UPDATE ... WHERE
+-- booked < kapasitas, jadi dua booking barengan ga bisa ngelewatin kapasitas
+CREATE TABLE IF NOT EXISTS service_appointment_slots (
+    slot_start  TIMESTAMPTZ PRIMARY KEY,
+    booked      INTEGER NOT NULL DEFAULT 0 CHECK (booked >= 0)
+);
+
+--   booked    -> slot nya kepake
+--   completed -> perangkat udah diterima toko
+--   cancelled -> dibatalin user / admin, atau request nya ditolak
+--   no_show   -> customer ga dateng
+-- selain booked slot nya dilepas
+CREATE TABLE IF NOT EXISTS service_appointments (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_request_id  UUID NOT NULL REFERENCES service_requests(id) ON DELETE CASCADE,
+    user_id             UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    slot_start          TIMESTAMPTZ NOT NULL,
+    slot_end            TIMESTAMPTZ NOT NULL,
+    status              VARCHAR(20) NOT NULL DEFAULT 'booked'
+                        CHECK (status IN ('booked', 'completed', 'cancelled', 'no_show')),
+    note                TEXT,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT service_appointments_range CHECK (slot_end > slot_start)
+);
+
+-- satu request cuma boleh punya satu booking aktif
+CREATE UNIQUE INDEX IF NOT EXISTS service_appointments_one_booked
+    ON service_appointments (service_request_id)
+    WHERE status = 'booked';
+
+CREATE INDEX IF NOT EXISTS idx_service_appointments_slot
+    ON service_appointments (slot_start);
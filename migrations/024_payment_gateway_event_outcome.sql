@@ -0,0 +1,19 @@
+-- hasil proses tiap callback gateway. callback lunas yang ga bisa diproses
+-- otomatis (nominal beda, order nya udah batal / kadaluarsa, charge ga dikenal)
+-- tetep dicatet biar gateway berhenti retry, terus dicek manual sama admin
+ALTER TABLE payment_gateway_events
+    ADD COLUMN IF NOT EXISTS payment_id      UUID REFERENCES payments(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS amount          BIGINT,
+    ADD COLUMN IF NOT EXISTS outcome         VARCHAR(30) NOT NULL DEFAULT 'processed',
+    ADD COLUMN IF NOT EXISTS detail          TEXT,
+    ADD COLUMN IF NOT EXISTS resolved_by     UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS resolved_at     TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS resolution_note TEXT;
+
+ALTER TABLE payment_gateway_events DROP CONSTRAINT IF EXISTS payment_gateway_events_outcome_check;
+ALTER TABLE payment_gateway_events ADD CONSTRAINT payment_gateway_events_outcome_check
+    CHECK (outcome IN ('processed', 'ignored', 'amount_mismatch', 'order_not_open', 'unknown_charge'));
+
+CREATE INDEX IF NOT EXISTS idx_payment_gateway_events_review
+    ON payment_gateway_events (received_at)
+    WHERE outcome IN ('amount_mismatch', 'order_not_open', 'unknown_charge') AND resolved_at IS NULL;
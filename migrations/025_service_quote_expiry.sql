@@ -0,0 +1,17 @@
+-- penawaran yang nunggu keputusan user ada batas waktu nya, sparepart yang
+-- di reserve buat penawaran itu dilepas sweeper pas udah lewat expires_at
+ALTER TABLE service_quotes
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+ALTER TABLE service_quotes DROP CONSTRAINT IF EXISTS service_quotes_status_check;
+ALTER TABLE service_quotes ADD CONSTRAINT service_quotes_status_check
+    CHECK (status IN ('pending', 'accepted', 'rejected', 'superseded', 'expired'));
+
+-- penawaran pending yang udah ada dikasih waktu minimal sehari lagi
+UPDATE service_quotes
+SET expires_at = GREATEST(created_at + INTERVAL '3 days', NOW() + INTERVAL '1 day')
+WHERE status = 'pending' AND expires_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_service_quotes_pending_expiry
+    ON service_quotes (expires_at)
+    WHERE status = 'pending';
@@ -21,6 +21,8 @@ func validationMessage(e validator.FieldError) string {
 		return "terlalu panjang"
 	case "numeric":
 		return "harus berupa angka"
+	case "imei":
+		return "IMEI tidak valid (15 digit, checksum Luhn)"
 	default:
 		return "tidak valid"
 	}
@@ -50,3 +52,33 @@ func SlugValidator(fl validator.FieldLevel) bool {
 	slug := fl.Field().String()
 	return slugRegex.MatchString(slug)
 }
+
+// IMEIValidator IMEI harus 15 digit angka dan lolos checksum Luhn
+func IMEIValidator(fl validator.FieldLevel) bool {
+	return ValidIMEI(fl.Field().String())
+}
+
+func ValidIMEI(imei string) bool {
+	if len(imei) != 15 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < len(imei); i++ {
+		c := imei[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+
+		d := int(c - '0')
+		// digit ke 2, 4, 6, ... dari kiri (posisi ganjil dari index 0) dikali 2
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}